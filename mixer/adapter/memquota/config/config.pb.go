@@ -7,10 +7,10 @@
 	The `memquota` adapter can be used to support Istio's quota management
 	system. Although functional, this adapter is not intended for production
 	use and is suited for local testing only. The reason for this limitation
-	is that this adapter can only be used in meshes where there is a single
-	instance of Mixer running for the whole mesh (i.e. non-HA configuration)
-	and if that single instance crashes, all outstanding quota values will
-	be lost.
+	is that if a Mixer instance crashes, all its outstanding quota values will
+	be lost. Unless peer sync is enabled, this adapter can only be used in meshes
+	where there is a single instance of Mixer running for the whole mesh (i.e.
+	non-HA configuration).
 
 	It is generated from these files:
 		mixer/adapter/memquota/config/config.proto
@@ -54,6 +54,23 @@ type Params struct {
 	Quotas []Params_Quota `protobuf:"bytes,1,rep,name=quotas" json:"quotas"`
 	// Minimum number of seconds that deduplication is possible for a given operation.
 	MinDeduplicationDuration time.Duration `protobuf:"bytes,2,opt,name=min_deduplication_duration,json=minDeduplicationDuration,stdduration" json:"min_deduplication_duration"`
+	// Address, in host:port form, on which the adapter receives the quota allocations
+	// made by its peers, the memquota adapters of the other Mixer replicas. When set,
+	// the allocations made by each replica are periodically sent to the others, so
+	// that the limits apply to the replicas as a whole rather than to each of them.
+	// Only the allocations sent from the IP addresses of the peers are accepted, and
+	// their limits are taken from the local configuration. Peer sync is disabled if empty.
+	PeerSyncAddress string `protobuf:"bytes,3,opt,name=peer_sync_address,json=peerSyncAddress,proto3" json:"peer_sync_address,omitempty"`
+	// Addresses, in host:port form, of the peers.
+	Peers []string `protobuf:"bytes,4,rep,name=peers" json:"peers,omitempty"`
+	// DNS name resolving to the IP addresses of the peers, such as the name of
+	// a Kubernetes headless service. The peers are expected to listen on the port of
+	// `peer_sync_address`. The name is resolved again every 30 seconds.
+	PeerDnsName string `protobuf:"bytes,5,opt,name=peer_dns_name,json=peerDnsName,proto3" json:"peer_dns_name,omitempty"`
+	// How often the allocations are sent to the peers. Peers converge after about
+	// this amount of time, during which the limits may be exceeded.
+	// Default value is 200 milliseconds.
+	PeerSyncInterval time.Duration `protobuf:"bytes,6,opt,name=peer_sync_interval,json=peerSyncInterval,stdduration" json:"peer_sync_interval"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -186,6 +203,41 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		return 0, err
 	}
 	i += n1
+	if len(m.PeerSyncAddress) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.PeerSyncAddress)))
+		i += copy(dAtA[i:], m.PeerSyncAddress)
+	}
+	if len(m.Peers) > 0 {
+		for _, s := range m.Peers {
+			dAtA[i] = 0x22
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.PeerDnsName) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.PeerDnsName)))
+		i += copy(dAtA[i:], m.PeerDnsName)
+	}
+	dAtA[i] = 0x32
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.PeerSyncInterval)))
+	n2, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.PeerSyncInterval, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n2
 	return i, nil
 }
 
@@ -306,6 +358,22 @@ func (m *Params) Size() (n int) {
 	}
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.MinDeduplicationDuration)
 	n += 1 + l + sovConfig(uint64(l))
+	l = len(m.PeerSyncAddress)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	if len(m.Peers) > 0 {
+		for _, s := range m.Peers {
+			l = len(s)
+			n += 1 + l + sovConfig(uint64(l))
+		}
+	}
+	l = len(m.PeerDnsName)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.PeerSyncInterval)
+	n += 1 + l + sovConfig(uint64(l))
 	return n
 }
 
@@ -369,6 +437,10 @@ func (this *Params) String() string {
 	s := strings.Join([]string{`&Params{`,
 		`Quotas:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Quotas), "Params_Quota", "Params_Quota", 1), `&`, ``, 1) + `,`,
 		`MinDeduplicationDuration:` + strings.Replace(strings.Replace(this.MinDeduplicationDuration.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`PeerSyncAddress:` + fmt.Sprintf("%v", this.PeerSyncAddress) + `,`,
+		`Peers:` + fmt.Sprintf("%v", this.Peers) + `,`,
+		`PeerDnsName:` + fmt.Sprintf("%v", this.PeerDnsName) + `,`,
+		`PeerSyncInterval:` + strings.Replace(strings.Replace(this.PeerSyncInterval.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -506,6 +578,123 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PeerSyncAddress", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PeerSyncAddress = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Peers", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Peers = append(m.Peers, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PeerDnsName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PeerDnsName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PeerSyncInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.PeerSyncInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -1011,34 +1200,36 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/memquota/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 450 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x91, 0x3f, 0x8b, 0x14, 0x31,
-	0x18, 0xc6, 0x93, 0xfd, 0xc7, 0xed, 0x7b, 0xf8, 0x87, 0x70, 0xe0, 0x38, 0x60, 0x76, 0x11, 0x84,
-	0xc5, 0x22, 0x03, 0x67, 0xb3, 0x1c, 0x58, 0xb8, 0xae, 0x8d, 0x08, 0xea, 0x54, 0x62, 0xb3, 0xe4,
-	0x6e, 0x72, 0x43, 0x70, 0x93, 0xac, 0xd9, 0x99, 0x65, 0xaf, 0xb3, 0xb4, 0xb4, 0xb0, 0xb0, 0xb4,
-	0xb0, 0xf0, 0xa3, 0x6c, 0x79, 0xa5, 0x58, 0xa8, 0x33, 0x36, 0x96, 0xf7, 0x11, 0x64, 0x92, 0x19,
-	0xef, 0x10, 0xc4, 0xad, 0xae, 0x9a, 0x77, 0x92, 0xe7, 0x79, 0xde, 0x1f, 0x4f, 0xe0, 0xae, 0x92,
-	0x6b, 0x61, 0x23, 0x9e, 0xf0, 0x45, 0x26, 0x6c, 0xa4, 0x84, 0x7a, 0x9d, 0x9b, 0x8c, 0x47, 0x47,
-	0x46, 0x1f, 0xcb, 0xb4, 0xfe, 0xb0, 0x85, 0x35, 0x99, 0x21, 0x37, 0x6a, 0x15, 0x6b, 0x54, 0xcc,
-	0x5f, 0x87, 0x34, 0x35, 0x26, 0x9d, 0x8b, 0xc8, 0xc9, 0x0e, 0xf3, 0xe3, 0x28, 0xc9, 0x2d, 0xcf,
-	0xa4, 0xd1, 0xde, 0x18, 0xee, 0xa5, 0x26, 0x35, 0x6e, 0x8c, 0xaa, 0xc9, 0x9f, 0xde, 0xfe, 0xd4,
-	0x85, 0xde, 0x33, 0x6e, 0xb9, 0x5a, 0x92, 0x87, 0xd0, 0x73, 0x81, 0xcb, 0x00, 0x0f, 0xdb, 0xa3,
-	0xdd, 0xfd, 0x3b, 0xec, 0x1f, 0xab, 0x98, 0x37, 0xb0, 0xe7, 0xd5, 0xd9, 0xa4, 0xb3, 0xf9, 0x36,
-	0x40, 0x71, 0x6d, 0x25, 0x1c, 0x42, 0x25, 0xf5, 0x2c, 0x11, 0x49, 0xbe, 0x98, 0xcb, 0x23, 0x07,
-	0x30, 0x6b, 0x48, 0x82, 0xd6, 0x10, 0x8f, 0x76, 0xf7, 0x6f, 0x32, 0x8f, 0xca, 0x1a, 0x54, 0x36,
-	0xad, 0x05, 0x93, 0x9d, 0x2a, 0xec, 0xc3, 0xf7, 0x01, 0x8e, 0x03, 0x25, 0xf5, 0xf4, 0x62, 0x4a,
-	0xa3, 0x09, 0xbf, 0x62, 0xe8, 0xba, 0xd5, 0x84, 0x40, 0x47, 0x73, 0x25, 0x02, 0x3c, 0xc4, 0xa3,
-	0x7e, 0xec, 0x66, 0x72, 0x0b, 0x40, 0xf1, 0xf5, 0x8c, 0x2b, 0x93, 0xeb, 0xcc, 0x2d, 0x6c, 0xc7,
-	0x7d, 0xc5, 0xd7, 0x0f, 0xdc, 0x01, 0x79, 0x0c, 0x57, 0x57, 0x7c, 0x2e, 0x93, 0x73, 0xa6, 0xf6,
-	0xf6, 0x4c, 0x57, 0x9c, 0xb5, 0xb9, 0x20, 0x4f, 0xa0, 0x6f, 0x56, 0xc2, 0x5a, 0x99, 0x88, 0x65,
-	0xd0, 0x71, 0x9d, 0x8d, 0xfe, 0xd7, 0xd9, 0xd3, 0xda, 0x50, 0xd7, 0x76, 0x1e, 0x70, 0xd0, 0x79,
-	0xfb, 0x71, 0x80, 0xc3, 0xf7, 0x2d, 0xd8, 0x69, 0x34, 0xe4, 0x05, 0x40, 0x22, 0x95, 0xd0, 0x4b,
-	0x69, 0x74, 0xf3, 0x2a, 0xe3, 0x6d, 0x37, 0xb0, 0xe9, 0x1f, 0xeb, 0x23, 0x9d, 0xd9, 0x93, 0xf8,
-	0x42, 0xd6, 0x25, 0xb6, 0x14, 0xde, 0x87, 0x6b, 0x7f, 0x91, 0x90, 0xeb, 0xd0, 0x7e, 0x25, 0x4e,
-	0xea, 0x67, 0xab, 0x46, 0xb2, 0x07, 0xdd, 0x15, 0x9f, 0xe7, 0xc2, 0xa1, 0xf4, 0x63, 0xff, 0x73,
-	0xd0, 0x1a, 0x63, 0x5f, 0xcb, 0x64, 0xbc, 0x29, 0x28, 0x3a, 0x2d, 0x28, 0xfa, 0x52, 0x50, 0x74,
-	0x56, 0x50, 0xf4, 0xa6, 0xa4, 0xf8, 0x73, 0x49, 0xd1, 0xa6, 0xa4, 0xf8, 0xb4, 0xa4, 0xf8, 0x47,
-	0x49, 0xf1, 0xaf, 0x92, 0xa2, 0xb3, 0x92, 0xe2, 0x77, 0x3f, 0x29, 0x7a, 0xd9, 0xf3, 0xad, 0x1c,
-	0xf6, 0x1c, 0xea, 0xbd, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xb0, 0x4b, 0x3f, 0x47, 0x64, 0x03,
-	0x00, 0x00,
+	// 496 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x52, 0xc1, 0x6e, 0xd3, 0x4a,
+	0x14, 0x8d, 0xe3, 0xc4, 0xaa, 0x6f, 0xd4, 0xd7, 0xbe, 0x51, 0x24, 0x8c, 0x25, 0x92, 0xa8, 0x12,
+	0x92, 0xd5, 0xc5, 0x58, 0x0a, 0x9b, 0xaa, 0x12, 0x8b, 0x86, 0xb0, 0x00, 0x21, 0xa0, 0x66, 0x83,
+	0xd8, 0x58, 0xd3, 0xcc, 0xd4, 0x1a, 0xe1, 0x99, 0x09, 0x63, 0x3b, 0x4a, 0xfe, 0x80, 0x0f, 0x60,
+	0xc1, 0x92, 0x25, 0x9f, 0xd2, 0x6f, 0x40, 0x02, 0x54, 0xbe, 0x80, 0x4f, 0x40, 0x9e, 0xb1, 0x9b,
+	0x0a, 0x09, 0x91, 0x15, 0x2b, 0x5f, 0xdf, 0x39, 0xe7, 0xdc, 0x33, 0xe7, 0x0e, 0x1c, 0x0b, 0xbe,
+	0x66, 0x3a, 0x26, 0x94, 0x2c, 0x4b, 0xa6, 0x63, 0xc1, 0xc4, 0xbb, 0x4a, 0x95, 0x24, 0x5e, 0x28,
+	0x79, 0xc9, 0xb3, 0xe6, 0x83, 0x97, 0x5a, 0x95, 0x0a, 0xdd, 0x69, 0x50, 0xb8, 0x45, 0x61, 0x7b,
+	0x1c, 0x8e, 0x32, 0xa5, 0xb2, 0x9c, 0xc5, 0x06, 0x76, 0x51, 0x5d, 0xc6, 0xb4, 0xd2, 0xa4, 0xe4,
+	0x4a, 0x5a, 0x62, 0x38, 0xcc, 0x54, 0xa6, 0x4c, 0x19, 0xd7, 0x95, 0xed, 0x1e, 0x7d, 0xf5, 0xc0,
+	0x7b, 0x49, 0x34, 0x11, 0x05, 0x7a, 0x04, 0x9e, 0x11, 0x2c, 0x02, 0x67, 0xe2, 0x46, 0x83, 0xe9,
+	0x7d, 0xfc, 0x87, 0x51, 0xd8, 0x12, 0xf0, 0x79, 0xdd, 0x9b, 0xf5, 0xae, 0xbe, 0x8d, 0x3b, 0x49,
+	0x43, 0x45, 0x04, 0x42, 0xc1, 0x65, 0x4a, 0x19, 0xad, 0x96, 0x39, 0x5f, 0x18, 0x03, 0x69, 0xeb,
+	0x24, 0xe8, 0x4e, 0x9c, 0x68, 0x30, 0xbd, 0x8b, 0xad, 0x55, 0xdc, 0x5a, 0xc5, 0xf3, 0x06, 0x30,
+	0xdb, 0xab, 0xc5, 0x3e, 0x7e, 0x1f, 0x3b, 0x49, 0x20, 0xb8, 0x9c, 0xdf, 0x56, 0x69, 0x31, 0xe8,
+	0x18, 0xfe, 0x5f, 0x32, 0xa6, 0xd3, 0x62, 0x23, 0x17, 0x29, 0xa1, 0x54, 0xb3, 0xa2, 0x08, 0xdc,
+	0x89, 0x13, 0xf9, 0xc9, 0x41, 0x7d, 0xf0, 0x6a, 0x23, 0x17, 0x67, 0xb6, 0x8d, 0x86, 0xd0, 0xaf,
+	0x5b, 0x45, 0xd0, 0x9b, 0xb8, 0x91, 0x9f, 0xd8, 0x1f, 0x74, 0x04, 0xfb, 0x46, 0x81, 0xca, 0x22,
+	0x95, 0x44, 0xb0, 0xa0, 0x6f, 0xd8, 0x83, 0xba, 0x39, 0x97, 0xc5, 0x73, 0x22, 0x18, 0x3a, 0x07,
+	0xb4, 0x9d, 0xc2, 0x65, 0xc9, 0xf4, 0x8a, 0xe4, 0x81, 0xb7, 0xfb, 0x05, 0x0e, 0x5b, 0x2f, 0x4f,
+	0x1a, 0x72, 0xf8, 0xc5, 0x81, 0xbe, 0xc9, 0x0c, 0x21, 0xe8, 0x99, 0xb9, 0x8e, 0x99, 0x6b, 0x6a,
+	0x74, 0x0f, 0x40, 0x90, 0x75, 0x4a, 0x84, 0xaa, 0x64, 0x69, 0x92, 0x72, 0x13, 0x5f, 0x90, 0xf5,
+	0x99, 0x69, 0xa0, 0xa7, 0xf0, 0xdf, 0x8a, 0xe4, 0x9c, 0x6e, 0xc3, 0x74, 0x77, 0xf7, 0xb2, 0x6f,
+	0xa8, 0x37, 0x09, 0x3e, 0x03, 0x5f, 0xad, 0x98, 0xd6, 0x9c, 0x32, 0x9b, 0xcc, 0x60, 0x1a, 0xfd,
+	0x6d, 0xd9, 0x2f, 0x1a, 0x42, 0xb3, 0xef, 0xad, 0xc0, 0x69, 0xef, 0xfd, 0xa7, 0xb1, 0x13, 0x7e,
+	0xe8, 0xc2, 0x5e, 0x8b, 0x41, 0xaf, 0x01, 0x28, 0x17, 0x4c, 0x16, 0x5c, 0xc9, 0xf6, 0x39, 0x9d,
+	0xec, 0x3a, 0x01, 0xcf, 0x6f, 0xa8, 0x8f, 0x65, 0xa9, 0x37, 0xc9, 0x2d, 0xad, 0x7f, 0x98, 0x52,
+	0xf8, 0x10, 0x0e, 0x7e, 0x73, 0x82, 0x0e, 0xc1, 0x7d, 0xcb, 0x36, 0xcd, 0xda, 0xea, 0xb2, 0x7e,
+	0x60, 0x2b, 0x92, 0x57, 0xcc, 0x58, 0xf1, 0x13, 0xfb, 0x73, 0xda, 0x3d, 0x71, 0x6c, 0x2c, 0xb3,
+	0xe1, 0xd5, 0xf5, 0xa8, 0xf3, 0xf3, 0x7a, 0xd4, 0xf9, 0xfc, 0x63, 0xd4, 0x79, 0xe3, 0xd9, 0x1b,
+	0x5f, 0x78, 0xc6, 0xc6, 0x83, 0x5f, 0x03, 0x00, 0x88, 0x07, 0xb6, 0xc2, 0xf9, 0x03, 0x00, 0x00,
 }
@@ -23,14 +23,19 @@
 // This means this isn't good for allocation quotas although
 // it works well enough for rate limits quotas.
 //
-// - Since the data is all memory-resident and there isn't any cross-node
-// synchronization, this adapter can't be used in an Istio mixer where
-// a single service can be handled by different mixer instances.
+// - Since the data is all memory-resident, this adapter can't be used in an
+// Istio mixer where a single service can be handled by different mixer
+// instances, unless peer sync is enabled. The mixer instances then
+// periodically exchange their allocations, so that the limits apply
+// to the instances as a whole, give or take the allocations made
+// between exchanges.
 package memquota
 
 import (
 	"context"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"istio.io/istio/mixer/adapter/memquota/config"
@@ -54,6 +59,12 @@ type handler struct {
 
 	// logger provided by the framework
 	logger adapter.Logger
+
+	// shares the allocations with the other mixer instances, nil if peer sync is disabled
+	peers *peerSync
+
+	// the amounts allocated by peers that they haven't released yet, protected by lock
+	peerAllocated map[string]int64
 }
 
 // Limit is implemented by Quota and Override messages.
@@ -120,17 +131,20 @@ func (h *handler) alloc(instance *quota.Instance, args adapter.QuotaArgs, q Limi
 					return 0, time.Time{}, 0
 				}
 
-				// grab as much as we can
+				// grab as much as we can, peers may have allocated more than the limit
 				result = q.GetMaxAmount() - inUse
+				if result < 0 {
+					result = 0
+				}
 			}
 			h.cells[key] = inUse + result
+			h.recordForPeers(key, instance, result)
 			return result, time.Time{}, 0
 		}
 
 		window, ok := h.windows[key]
 		if !ok {
-			seconds := int32((q.GetValidDuration() + time.Second - 1) / time.Second)
-			window = newRollingWindow(q.GetMaxAmount(), int64(seconds)*ticksPerSecond)
+			window = newRollingWindow(q.GetMaxAmount(), ticksInWindow(q.GetValidDuration()))
 			h.windows[key] = window
 		}
 
@@ -144,6 +158,7 @@ func (h *handler) alloc(instance *quota.Instance, args adapter.QuotaArgs, q Limi
 			_ = window.alloc(result, currentTick)
 		}
 
+		h.recordForPeers(key, instance, result)
 		return result, currentTime.Add(q.GetValidDuration()), q.GetValidDuration()
 	})
 
@@ -169,10 +184,12 @@ func (h *handler) free(instance *quota.Instance, args adapter.QuotaArgs, q Limit
 			if result >= inUse {
 				// delete the cell since it contains no useful state
 				delete(h.cells, key)
+				h.recordForPeers(key, instance, -inUse)
 				return inUse, time.Time{}, 0
 			}
 
 			h.cells[key] = inUse - result
+			h.recordForPeers(key, instance, -result)
 			return result, time.Time{}, 0
 		}
 
@@ -186,6 +203,7 @@ func (h *handler) free(instance *quota.Instance, args adapter.QuotaArgs, q Limit
 		}
 
 		result = window.release(result, currentTick)
+		h.recordForPeers(key, instance, -result)
 
 		if window.available() == q.GetMaxAmount() {
 			// delete the cell since it contains no useful state
@@ -201,7 +219,107 @@ func (h *handler) free(instance *quota.Instance, args adapter.QuotaArgs, q Limit
 	}, err
 }
 
+// recordForPeers records an amount allocated, or released if negative, so that it is sent to
+// the peers. It must be called with the dedup lock held.
+func (h *handler) recordForPeers(key string, instance *quota.Instance, amount int64) {
+	if h.peers != nil {
+		h.peers.record(key, instance, amount)
+	}
+}
+
+// applyPeerDeltas applies the amounts allocated and released by a peer. The limits of the
+// quotas are looked up in the local configuration, and the deltas that don't match it are dropped.
+func (h *handler) applyPeerDeltas(deltas []syncDelta) {
+	h.common.Lock()
+	defer h.common.Unlock()
+
+	currentTick := h.common.getTime().UnixNano() / nanosPerTick
+
+	for _, d := range deltas {
+		cfg, ok := h.limits[d.Name]
+		if !ok || (d.Key != d.Name && !strings.HasPrefix(d.Key, d.Name+";")) {
+			h.logger.Warningf("dropping peer allocation of %d for unknown quota %s", d.Amount, d.Key)
+			continue
+		}
+
+		dimensions := make(map[string]interface{}, len(d.Dimensions))
+		for k, v := range d.Dimensions {
+			dimensions[k] = v
+		}
+		q := limit(cfg, &quota.Instance{Name: d.Name, Dimensions: dimensions}, h.logger)
+
+		amount := d.Amount
+		if amount > q.GetMaxAmount() {
+			h.logger.Warningf("dropping peer allocation of %d for quota %s, over its limit of %d", amount, d.Key, q.GetMaxAmount())
+			continue
+		}
+		if amount < 0 {
+			// peers can only release what they allocated
+			if -amount > h.peerAllocated[d.Key] {
+				amount = -h.peerAllocated[d.Key]
+			}
+			if amount == 0 {
+				continue
+			}
+		}
+		if allocated := h.peerAllocated[d.Key] + amount; allocated > 0 {
+			h.peerAllocated[d.Key] = allocated
+		} else {
+			delete(h.peerAllocated, d.Key)
+		}
+
+		if q.GetValidDuration() == 0 {
+			inUse := h.cells[d.Key] + amount
+			if inUse <= 0 {
+				delete(h.cells, d.Key)
+			} else {
+				h.cells[d.Key] = inUse
+			}
+			continue
+		}
+
+		window, ok := h.windows[d.Key]
+		if !ok {
+			if amount <= 0 {
+				// nothing to release
+				continue
+			}
+			window = newRollingWindow(q.GetMaxAmount(), ticksInWindow(q.GetValidDuration()))
+			h.windows[d.Key] = window
+		}
+
+		if amount > 0 {
+			window.charge(amount, currentTick)
+		} else {
+			window.release(-amount, currentTick)
+		}
+	}
+}
+
+// reapPeerAllocated forgets the amounts allocated by peers for the keys that are no longer
+// tracked. It must be called with the dedup lock held.
+func (h *handler) reapPeerAllocated() {
+	for key := range h.peerAllocated {
+		if _, ok := h.cells[key]; ok {
+			continue
+		}
+		if _, ok := h.windows[key]; ok {
+			continue
+		}
+		delete(h.peerAllocated, key)
+	}
+}
+
+// ticksInWindow returns the number of ticks in the rolling window of a quota, rounded up to the second.
+func ticksInWindow(validDuration time.Duration) int64 {
+	seconds := int32((validDuration + time.Second - 1) / time.Second)
+	return int64(seconds) * ticksPerSecond
+}
+
 func (h *handler) Close() error {
+	if h.peers != nil {
+		h.peers.close()
+	}
 	h.common.ticker.Stop()
 	return nil
 }
@@ -219,6 +337,7 @@ func GetInfo() adapter.Info {
 		},
 		DefaultConfig: &config.Params{
 			MinDeduplicationDuration: 1 * time.Second,
+			PeerSyncInterval:         200 * time.Millisecond,
 		},
 
 		NewBuilder: func() adapter.HandlerBuilder { return &builder{} },
@@ -239,6 +358,31 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 	if ac.MinDeduplicationDuration <= 0 {
 		ce = ce.Appendf("minDeduplicationDuration", "deduplication window of %v is invalid, must be > 0", ac.MinDeduplicationDuration)
 	}
+
+	if ac.PeerSyncAddress == "" {
+		if len(ac.Peers) > 0 || ac.PeerDnsName != "" {
+			ce = ce.Appendf("peerSyncAddress", "peer sync address must be set to sync with peers")
+		}
+		return
+	}
+
+	if _, _, err := net.SplitHostPort(ac.PeerSyncAddress); err != nil {
+		ce = ce.Appendf("peerSyncAddress", "peer sync address %s is invalid: %v", ac.PeerSyncAddress, err)
+	}
+
+	if len(ac.Peers) == 0 && ac.PeerDnsName == "" {
+		ce = ce.Appendf("peers", "peers or peerDnsName must be set to sync with peers")
+	}
+
+	for _, peer := range ac.Peers {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			ce = ce.Appendf("peers", "peer address %s is invalid: %v", peer, err)
+		}
+	}
+
+	if ac.PeerSyncInterval <= 0 {
+		ce = ce.Appendf("peerSyncInterval", "peer sync interval of %v is invalid, must be > 0", ac.PeerSyncInterval)
+	}
 	return
 }
 
@@ -270,16 +414,27 @@ func (b *builder) buildWithDedup(_ context.Context, env adapter.Env, ticker *tim
 			getTime:     time.Now,
 			logger:      env.Logger(),
 		},
-		cells:   make(map[string]int64),
-		windows: make(map[string]*rollingWindow),
-		limits:  limits,
-		logger:  env.Logger(),
+		cells:         make(map[string]int64),
+		windows:       make(map[string]*rollingWindow),
+		limits:        limits,
+		logger:        env.Logger(),
+		peerAllocated: make(map[string]int64),
+	}
+
+	if ac.PeerSyncAddress != "" {
+		p, err := newPeerSync(ac, env, h.applyPeerDeltas)
+		if err != nil {
+			ticker.Stop()
+			return nil, fmt.Errorf("unable to sync with peers on %s: %v", ac.PeerSyncAddress, err)
+		}
+		h.peers = p
 	}
 
 	env.ScheduleDaemon(func() {
 		for range h.common.ticker.C {
 			h.common.Lock()
 			h.common.reapDedup()
+			h.reapPeerAllocated()
 			h.common.Unlock()
 		}
 	})
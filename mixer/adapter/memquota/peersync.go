@@ -0,0 +1,473 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"istio.io/istio/mixer/adapter/memquota/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/template/quota"
+)
+
+// Peer sync shares the quota allocations of a handler with the handlers of the other Mixer
+// replicas, its peers. Every handler periodically sends the net amounts it allocated for each
+// quota key since the previous sync to all of its peers, which apply them to their own cells
+// and rolling windows. Allocations are not coordinated, so the limits can be exceeded by the
+// amounts allocated during a sync interval, and the amounts that can't be delivered to a peer
+// are not sent again.
+//
+// Only the messages sent from the addresses of the configured peers are applied. The limits
+// of the quotas always come from the local configuration, and peers can't release more than
+// they allocated.
+
+const (
+	// syncPath is the HTTP path on which the peers post their allocations.
+	syncPath = "/memquota/sync"
+
+	// dnsRefreshInterval determines how often the DNS name of the peers is resolved.
+	dnsRefreshInterval = 30 * time.Second
+
+	// maxSyncMessageSize is the maximum size of the messages accepted from peers.
+	maxSyncMessageSize = 16 << 20
+)
+
+type (
+	// syncMessage holds the allocations a handler sends to its peers.
+	syncMessage struct {
+		// Node identifies the sender, so that messages sent to self are ignored.
+		Node   string      `json:"node"`
+		Deltas []syncDelta `json:"deltas"`
+	}
+
+	// syncDelta is the net amount allocated for a quota key since the previous sync.
+	syncDelta struct {
+		Key    string `json:"key"`
+		Amount int64  `json:"amount"`
+
+		// quota instance of the key, for peers to look up its limit in their configuration
+		Name       string            `json:"name"`
+		Dimensions map[string]string `json:"dimensions,omitempty"`
+	}
+
+	// peerSync sends the allocations of a handler to its peers, and applies theirs.
+	peerSync struct {
+		server *syncServer
+
+		staticPeers []string
+		dnsName     string
+		dnsPort     string
+
+		client *http.Client
+		logger adapter.Logger
+
+		// applies the allocations of peers to the handler
+		apply func([]syncDelta)
+
+		// indirection to support tests
+		lookupHost func(host string) ([]string, error)
+
+		// allocations waiting to be sent, protected by lock
+		lock    sync.Mutex
+		pending map[string]*syncDelta
+
+		// the peers resolved through DNS, and when they were
+		dnsPeers   []string
+		lastLookup time.Time
+
+		// IP addresses of the peers, from which messages are accepted, protected by hostsLock
+		hostsLock sync.RWMutex
+		hosts     map[string]bool
+
+		// peers that the last sync failed to reach
+		failing map[string]bool
+
+		ticker  *time.Ticker
+		closing chan struct{}
+		done    chan struct{}
+	}
+
+	// syncServer receives the messages sent to an address by the peers. It is shared by the
+	// handlers configured with the same address, as the handler built for a new configuration
+	// starts before the handler it replaces is closed.
+	syncServer struct {
+		address  string
+		node     string
+		listener net.Listener
+		server   *http.Server
+
+		// number of handlers using the server, protected by syncServersLock
+		refs int
+
+		lock      sync.RWMutex
+		receivers map[*peerSync]struct{}
+	}
+)
+
+var (
+	syncServersLock sync.Mutex
+	syncServers     = make(map[string]*syncServer)
+)
+
+// acquireSyncServer returns the server for the address, starting it if necessary.
+func acquireSyncServer(address string, env adapter.Env) (*syncServer, error) {
+	syncServersLock.Lock()
+	defer syncServersLock.Unlock()
+
+	if s, ok := syncServers[address]; ok {
+		s.refs++
+		return s, nil
+	}
+
+	node := make([]byte, 8)
+	if _, err := rand.Read(node); err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &syncServer{
+		address:   address,
+		node:      hex.EncodeToString(node),
+		listener:  l,
+		refs:      1,
+		receivers: make(map[*peerSync]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(syncPath, s.handle)
+	s.server = &http.Server{Handler: mux}
+
+	env.ScheduleDaemon(func() {
+		// returns once the server is closed
+		_ = s.server.Serve(l)
+	})
+
+	syncServers[address] = s
+	return s, nil
+}
+
+// release stops the server once no handler uses it anymore.
+func (s *syncServer) release() {
+	syncServersLock.Lock()
+	defer syncServersLock.Unlock()
+
+	s.refs--
+	if s.refs == 0 {
+		delete(syncServers, s.address)
+		_ = s.server.Close()
+	}
+}
+
+func (s *syncServer) addReceiver(p *peerSync) {
+	s.lock.Lock()
+	s.receivers[p] = struct{}{}
+	s.lock.Unlock()
+}
+
+func (s *syncServer) removeReceiver(p *peerSync) {
+	s.lock.Lock()
+	delete(s.receivers, p)
+	s.lock.Unlock()
+}
+
+// handle applies the allocations sent by a peer to the handlers using the server.
+func (s *syncServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var msg syncMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxSyncMessageSize)).Decode(&msg); err != nil {
+		http.Error(w, fmt.Sprintf("invalid sync message: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid remote address %s", r.RemoteAddr), http.StatusBadRequest)
+		return
+	}
+
+	accepted := msg.Node == s.node
+	if !accepted {
+		s.lock.RLock()
+		for p := range s.receivers {
+			if p.isPeer(host) {
+				p.apply(msg.Deltas)
+				accepted = true
+			}
+		}
+		s.lock.RUnlock()
+	}
+
+	if !accepted {
+		http.Error(w, fmt.Sprintf("%s is not a peer", host), http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newPeerSync starts to share the allocations of a handler with its peers.
+func newPeerSync(ac *config.Params, env adapter.Env, apply func([]syncDelta)) (*peerSync, error) {
+	_, port, err := net.SplitHostPort(ac.PeerSyncAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := acquireSyncServer(ac.PeerSyncAddress, env)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &peerSync{
+		server:      server,
+		staticPeers: ac.Peers,
+		dnsName:     ac.PeerDnsName,
+		dnsPort:     port,
+		client:      &http.Client{Timeout: ac.PeerSyncInterval},
+		logger:      env.Logger(),
+		apply:       apply,
+		lookupHost:  net.LookupHost,
+		pending:     make(map[string]*syncDelta),
+		hosts:       make(map[string]bool),
+		failing:     make(map[string]bool),
+		ticker:      time.NewTicker(ac.PeerSyncInterval),
+		closing:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	server.addReceiver(p)
+	env.ScheduleDaemon(p.run)
+
+	return p, nil
+}
+
+// record adds an amount allocated for a quota key to the allocations waiting to be sent.
+// Released amounts are negative.
+func (p *peerSync) record(key string, instance *quota.Instance, amount int64) {
+	if amount == 0 {
+		return
+	}
+
+	p.lock.Lock()
+	d, ok := p.pending[key]
+	if !ok {
+		d = &syncDelta{
+			Key:        key,
+			Name:       instance.Name,
+			Dimensions: dimensionStrings(instance.Dimensions),
+		}
+		p.pending[key] = d
+	}
+	d.Amount += amount
+	p.lock.Unlock()
+}
+
+func (p *peerSync) run() {
+	defer close(p.done)
+
+	// resolve the peers to accept their messages before the first sync
+	_ = p.peers()
+
+	for {
+		select {
+		case <-p.ticker.C:
+			p.sync()
+		case <-p.closing:
+			return
+		}
+	}
+}
+
+// sync sends the pending allocations to the peers.
+func (p *peerSync) sync() {
+	p.lock.Lock()
+	pending := p.pending
+	p.pending = make(map[string]*syncDelta, len(pending))
+	p.lock.Unlock()
+
+	msg := syncMessage{
+		Node:   p.server.node,
+		Deltas: make([]syncDelta, 0, len(pending)),
+	}
+	for _, d := range pending {
+		if d.Amount != 0 {
+			msg.Deltas = append(msg.Deltas, *d)
+		}
+	}
+
+	peers := p.peers()
+	if len(msg.Deltas) == 0 || len(peers) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		_ = p.logger.Errorf("unable to encode quota allocations for peers: %v", err)
+		return
+	}
+
+	for _, peer := range peers {
+		err := p.send(peer, body)
+		if err != nil && !p.failing[peer] {
+			p.logger.Warningf("unable to send quota allocations to peer %s: %v", peer, err)
+		} else if err == nil && p.failing[peer] {
+			p.logger.Infof("sending quota allocations to peer %s again", peer)
+		}
+		p.failing[peer] = err != nil
+	}
+}
+
+func (p *peerSync) send(peer string, body []byte) error {
+	resp, err := p.client.Post("http://"+peer+syncPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// peers returns the addresses of the peers, resolving their names if it is time to.
+func (p *peerSync) peers() []string {
+	now := time.Now()
+	if p.lastLookup.IsZero() || now.Sub(p.lastLookup) >= dnsRefreshInterval {
+		p.lastLookup = now
+
+		if p.dnsName != "" {
+			hosts, err := p.lookupHost(p.dnsName)
+			if err != nil {
+				p.logger.Warningf("unable to resolve peers %s: %v", p.dnsName, err)
+			} else {
+				peers := make([]string, 0, len(p.staticPeers)+len(hosts))
+				peers = append(peers, p.staticPeers...)
+				for _, host := range hosts {
+					peers = append(peers, net.JoinHostPort(host, p.dnsPort))
+				}
+				p.dnsPeers = peers
+			}
+		}
+
+		p.updateHosts()
+	}
+
+	if p.dnsPeers == nil {
+		return p.staticPeers
+	}
+	return p.dnsPeers
+}
+
+// updateHosts updates the IP addresses from which messages are accepted. The addresses of the
+// peers that can't be resolved are kept from the previous update.
+func (p *peerSync) updateHosts() {
+	peers := p.dnsPeers
+	if peers == nil {
+		peers = p.staticPeers
+	}
+
+	p.hostsLock.RLock()
+	previous := p.hosts
+	p.hostsLock.RUnlock()
+
+	hosts := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		host, _, err := net.SplitHostPort(peer)
+		if err != nil {
+			continue
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			hosts[ip.String()] = true
+			continue
+		}
+
+		addrs, err := p.lookupHost(host)
+		if err != nil {
+			p.logger.Warningf("unable to resolve peer %s: %v", host, err)
+			for h := range previous {
+				hosts[h] = true
+			}
+			continue
+		}
+		for _, addr := range addrs {
+			if ip := net.ParseIP(addr); ip != nil {
+				hosts[ip.String()] = true
+			}
+		}
+	}
+
+	p.hostsLock.Lock()
+	p.hosts = hosts
+	p.hostsLock.Unlock()
+}
+
+// isPeer returns whether the host is the IP address of a peer.
+func (p *peerSync) isPeer(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	p.hostsLock.RLock()
+	defer p.hostsLock.RUnlock()
+	return p.hosts[ip.String()]
+}
+
+// dimensionStrings returns the string form of the dimensions of a quota instance, as they are
+// matched against the dimensions of the quota overrides.
+func dimensionStrings(dimensions map[string]interface{}) map[string]string {
+	if len(dimensions) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(dimensions))
+	for k, v := range dimensions {
+		switch vv := v.(type) {
+		case time.Time:
+			out[k] = vv.Format(time.RFC3339)
+		default:
+			out[k] = fmt.Sprint(vv)
+		}
+	}
+	return out
+}
+
+// close stops sharing the allocations of the handler.
+func (p *peerSync) close() {
+	close(p.closing)
+	<-p.done
+	p.ticker.Stop()
+
+	p.server.removeReceiver(p)
+	p.server.release()
+}
@@ -0,0 +1,407 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/adapter/memquota/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/test"
+	"istio.io/istio/mixer/template/quota"
+)
+
+// freeAddress returns a local address with a port that is not in use.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	return addr
+}
+
+// buildPeers builds n handlers that sync with each other, including with themselves.
+func buildPeers(t *testing.T, n int) []*handler {
+	t.Helper()
+
+	addresses := make([]string, n)
+	for i := range addresses {
+		addresses[i] = freeAddress(t)
+	}
+
+	handlers := make([]*handler, n)
+	for i := range handlers {
+		cfg := &config.Params{
+			Quotas: []config.Params_Quota{
+				{Name: "rate", MaxAmount: 30, ValidDuration: time.Minute},
+				{Name: "alloc", MaxAmount: 30},
+			},
+			MinDeduplicationDuration: time.Hour,
+			PeerSyncAddress:          addresses[i],
+			Peers:                    addresses,
+			PeerSyncInterval:         10 * time.Millisecond,
+		}
+
+		b := GetInfo().NewBuilder()
+		b.SetAdapterConfig(cfg)
+		if err := b.Validate(); err != nil {
+			t.Fatalf("Got error %v, expecting success", err)
+		}
+
+		h, err := b.Build(context.Background(), test.NewEnv(t))
+		if err != nil {
+			t.Fatalf("Got error %v, expecting success", err)
+		}
+		handlers[i] = h.(*handler)
+	}
+
+	return handlers
+}
+
+var dedupID int
+
+func allocate(t *testing.T, h *handler, name string, amount int64, bestEffort bool) int64 {
+	t.Helper()
+
+	dedupID++
+	args := adapter.QuotaArgs{
+		DeduplicationID: strconv.Itoa(dedupID),
+		QuotaAmount:     amount,
+		BestEffort:      bestEffort,
+	}
+
+	result, err := h.HandleQuota(context.Background(), &quota.Instance{Name: name}, args)
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	return result.Amount
+}
+
+// inUse returns the amount in use for a quota, as seen by each handler.
+func inUse(handlers []*handler, name string) []int64 {
+	key := makeKey(name, nil)
+
+	amounts := make([]int64, len(handlers))
+	for i, h := range handlers {
+		h.common.Lock()
+		if h.limits[name].ValidDuration == 0 {
+			amounts[i] = h.cells[key]
+		} else if w, ok := h.windows[key]; ok {
+			amounts[i] = h.limits[name].MaxAmount - w.available()
+		}
+		h.common.Unlock()
+	}
+	return amounts
+}
+
+// waitForConvergence waits until every handler sees the given amount in use for a quota.
+func waitForConvergence(t *testing.T, handlers []*handler, name string, amount int64) {
+	t.Helper()
+
+	want := make([]int64, len(handlers))
+	for i := range want {
+		want[i] = amount
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got := inUse(handlers, name)
+		if reflect.DeepEqual(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Handlers did not converge on quota %s: got %v in use, want %d", name, got, amount)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func closeAll(t *testing.T, handlers []*handler) {
+	for _, h := range handlers {
+		if err := h.Close(); err != nil {
+			t.Errorf("Unable to close handler: %v", err)
+		}
+	}
+}
+
+func TestPeerSync_RateLimit(t *testing.T) {
+	handlers := buildPeers(t, 3)
+	defer closeAll(t, handlers)
+
+	for _, h := range handlers {
+		if got := allocate(t, h, "rate", 5, false); got != 5 {
+			t.Fatalf("Got %d, expecting 5", got)
+		}
+	}
+	waitForConvergence(t, handlers, "rate", 15)
+
+	// the allocations of the peers count against the limit
+	if got := allocate(t, handlers[0], "rate", 20, false); got != 0 {
+		t.Errorf("Got %d, expecting 0", got)
+	}
+	if got := allocate(t, handlers[0], "rate", 20, true); got != 15 {
+		t.Errorf("Got %d, expecting 15", got)
+	}
+	waitForConvergence(t, handlers, "rate", 30)
+
+	for _, h := range handlers {
+		if got := allocate(t, h, "rate", 1, true); got != 0 {
+			t.Errorf("Got %d, expecting 0", got)
+		}
+	}
+}
+
+func TestPeerSync_Allocation(t *testing.T) {
+	handlers := buildPeers(t, 3)
+	defer closeAll(t, handlers)
+
+	for _, h := range handlers {
+		if got := allocate(t, h, "alloc", 10, false); got != 10 {
+			t.Fatalf("Got %d, expecting 10", got)
+		}
+	}
+	waitForConvergence(t, handlers, "alloc", 30)
+
+	if got := allocate(t, handlers[2], "alloc", 1, false); got != 0 {
+		t.Errorf("Got %d, expecting 0", got)
+	}
+
+	// releases are synced as well
+	if got := allocate(t, handlers[1], "alloc", -10, false); got != 10 {
+		t.Errorf("Got %d, expecting 10", got)
+	}
+	waitForConvergence(t, handlers, "alloc", 20)
+
+	if got := allocate(t, handlers[2], "alloc", 10, false); got != 10 {
+		t.Errorf("Got %d, expecting 10", got)
+	}
+	waitForConvergence(t, handlers, "alloc", 30)
+}
+
+func TestPeerSync_SharedServer(t *testing.T) {
+	addr := freeAddress(t)
+	cfg := &config.Params{
+		MinDeduplicationDuration: time.Hour,
+		PeerSyncAddress:          addr,
+		Peers:                    []string{addr},
+		PeerSyncInterval:         10 * time.Millisecond,
+	}
+
+	b := GetInfo().NewBuilder()
+	b.SetAdapterConfig(cfg)
+
+	// a handler replacing another one shares its server
+	h1, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	h2, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	if h1.(*handler).peers.server != h2.(*handler).peers.server {
+		t.Errorf("Expecting the handlers to share their server")
+	}
+
+	_ = h1.Close()
+	if _, err = net.Dial("tcp", addr); err != nil {
+		t.Errorf("Expecting the server to be up: %v", err)
+	}
+
+	_ = h2.Close()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Expecting the server to be closed: %v", err)
+	}
+	_ = l.Close()
+}
+
+func TestPeerSync_UnknownPeer(t *testing.T) {
+	addr := freeAddress(t)
+	cfg := &config.Params{
+		Quotas:                   []config.Params_Quota{{Name: "alloc", MaxAmount: 30}},
+		MinDeduplicationDuration: time.Hour,
+		PeerSyncAddress:          addr,
+		Peers:                    []string{"10.0.0.1:9000"},
+		PeerSyncInterval:         10 * time.Millisecond,
+	}
+
+	b := GetInfo().NewBuilder()
+	b.SetAdapterConfig(cfg)
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	handlers := []*handler{h.(*handler)}
+	defer closeAll(t, handlers)
+
+	body, err := json.Marshal(&syncMessage{
+		Node:   "intruder",
+		Deltas: []syncDelta{{Key: "alloc", Amount: 30, Name: "alloc"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post("http://"+addr+syncPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Got status %s, expecting %d", resp.Status, http.StatusForbidden)
+	}
+	if got := inUse(handlers, "alloc"); got[0] != 0 {
+		t.Errorf("Got %d in use, expecting 0", got[0])
+	}
+}
+
+func TestPeerSync_InvalidDeltas(t *testing.T) {
+	handlers := buildPeers(t, 1)
+	defer closeAll(t, handlers)
+	h := handlers[0]
+
+	h.applyPeerDeltas([]syncDelta{
+		// unknown quota
+		{Key: "unknown", Amount: 10, Name: "unknown"},
+		// key of another quota
+		{Key: "rate", Amount: 10, Name: "alloc"},
+		// over the limit
+		{Key: "alloc", Amount: 31, Name: "alloc"},
+		// release of an amount that wasn't allocated by peers
+		{Key: "alloc", Amount: -10, Name: "alloc"},
+	})
+	if got := inUse(handlers, "alloc"); got[0] != 0 {
+		t.Errorf("Got %d in use, expecting 0", got[0])
+	}
+	if got := inUse(handlers, "rate"); got[0] != 0 {
+		t.Errorf("Got %d in use, expecting 0", got[0])
+	}
+
+	// peers can't release the allocations of the handler
+	if got := allocate(t, h, "alloc", 10, false); got != 10 {
+		t.Fatalf("Got %d, expecting 10", got)
+	}
+	h.applyPeerDeltas([]syncDelta{
+		{Key: "alloc", Amount: 5, Name: "alloc"},
+		{Key: "alloc", Amount: -15, Name: "alloc"},
+	})
+	if got := inUse(handlers, "alloc"); got[0] != 10 {
+		t.Errorf("Got %d in use, expecting 10", got[0])
+	}
+
+	// the limit comes from the local configuration
+	h.applyPeerDeltas([]syncDelta{{Key: "rate", Amount: 10, Name: "rate"}})
+	h.common.Lock()
+	w := h.windows["rate"]
+	h.common.Unlock()
+	if w == nil || w.available() != 20 {
+		t.Errorf("Got window %v, expecting 20 available", w)
+	}
+}
+
+func TestPeerSync_DNS(t *testing.T) {
+	lookups := 0
+	p := &peerSync{
+		staticPeers: []string{"10.0.0.1:9000"},
+		dnsName:     "mixer-peers",
+		dnsPort:     "9094",
+		logger:      test.NewEnv(t),
+		lookupHost: func(host string) ([]string, error) {
+			lookups++
+			if host != "mixer-peers" {
+				return nil, fmt.Errorf("unexpected lookup of %s", host)
+			}
+			if lookups > 1 {
+				return nil, fmt.Errorf("lookup failed")
+			}
+			return []string{"10.0.0.2", "fd00::3"}, nil
+		},
+	}
+
+	want := []string{"10.0.0.1:9000", "10.0.0.2:9094", "[fd00::3]:9094"}
+	if got := p.peers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got peers %v, expecting %v", got, want)
+	}
+
+	// the name is only resolved every dnsRefreshInterval
+	_ = p.peers()
+	if lookups != 1 {
+		t.Errorf("Got %d lookups, expecting 1", lookups)
+	}
+
+	// the peers are kept when the name can't be resolved
+	p.lastLookup = p.lastLookup.Add(-dnsRefreshInterval)
+	if got := p.peers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got peers %v, expecting %v", got, want)
+	}
+	if lookups != 2 {
+		t.Errorf("Got %d lookups, expecting 2", lookups)
+	}
+
+	// only the messages of the peers are accepted
+	for host, want := range map[string]bool{"10.0.0.1": true, "10.0.0.2": true, "fd00:0::3": true, "10.0.0.4": false} {
+		if got := p.isPeer(host); got != want {
+			t.Errorf("Got isPeer(%s) %v, expecting %v", host, got, want)
+		}
+	}
+}
+
+func TestPeerSync_Validate(t *testing.T) {
+	cases := []struct {
+		address  string
+		peers    []string
+		dnsName  string
+		interval time.Duration
+		valid    bool
+	}{
+		{"", nil, "", 0, true},
+		{":9094", []string{"mixer-1:9094"}, "", time.Second, true},
+		{":9094", nil, "mixer-peers", time.Second, true},
+		{"", []string{"mixer-1:9094"}, "", time.Second, false},
+		{"", nil, "mixer-peers", time.Second, false},
+		{"9094", []string{"mixer-1:9094"}, "", time.Second, false},
+		{":9094", nil, "", time.Second, false},
+		{":9094", []string{"mixer-1"}, "", time.Second, false},
+		{":9094", []string{"mixer-1:9094"}, "", 0, false},
+	}
+
+	for _, c := range cases {
+		cfg := GetInfo().DefaultConfig.(*config.Params)
+		cfg.PeerSyncAddress = c.address
+		cfg.Peers = c.peers
+		cfg.PeerDnsName = c.dnsName
+		cfg.PeerSyncInterval = c.interval
+
+		b := GetInfo().NewBuilder()
+		b.SetAdapterConfig(cfg)
+		if err := b.Validate(); (err == nil) != c.valid {
+			t.Errorf("Got %v, expecting valid: %v for case %v", err, c.valid, c)
+		}
+	}
+}
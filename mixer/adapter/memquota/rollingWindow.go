@@ -61,6 +61,14 @@ func (w *rollingWindow) alloc(amount int64, currentTick int64) bool {
 	return true
 }
 
+// charge records an amount allocated elsewhere, even if it exceeds the available units.
+func (w *rollingWindow) charge(amount int64, currentTick int64) {
+	w.roll(currentTick)
+
+	w.slots[w.currentSlot] += amount
+	w.avail -= amount
+}
+
 func (w *rollingWindow) release(amount int64, currentTick int64) int64 {
 	w.roll(currentTick)
 
@@ -110,5 +118,9 @@ func (w *rollingWindow) roll(currentTick int64) {
 }
 
 func (w *rollingWindow) available() int64 {
+	if w.avail < 0 {
+		// more was charged than the limit
+		return 0
+	}
 	return w.avail
 }
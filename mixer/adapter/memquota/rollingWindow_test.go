@@ -91,3 +91,34 @@ func TestRelease(t *testing.T) {
 		}
 	}
 }
+
+func TestCharge(t *testing.T) {
+	w := newRollingWindow(5, 3)
+
+	if ok := w.alloc(3, 1); !ok {
+		t.Errorf("Expecting to succeed")
+	}
+
+	// charges beyond the limit are recorded
+	w.charge(4, 2)
+	if w.available() != 0 {
+		t.Errorf("Expecting 0 available, got %d", w.available())
+	}
+	if ok := w.alloc(1, 2); ok {
+		t.Errorf("Expecting to fail")
+	}
+
+	// the allocation of tick 1 is reclaimed, but not the charge of tick 2
+	if ok := w.alloc(1, 4); !ok {
+		t.Errorf("Expecting to succeed")
+	}
+	if w.available() != 0 {
+		t.Errorf("Expecting 0 available, got %d", w.available())
+	}
+
+	// the charge is reclaimed like an allocation
+	w.roll(5)
+	if w.available() != 4 {
+		t.Errorf("Expecting 4 available, got %d", w.available())
+	}
+}
@@ -58,6 +58,32 @@ const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 // spec:
 //   config_store_url: "fs:///tmp/testdata/config"
 // ```
+//
+// ServiceRoles support two annotations:
+// * `rbac.istio.io/includes` is a comma separated list of ServiceRoles whose
+//   rules are included in the annotated ServiceRole. Included ServiceRoles are
+//   looked up in the namespace of the annotated ServiceRole, then in the
+//   cluster namespace.
+// * `rbac.istio.io/effect` is either `allow`, the default, or `deny`. The
+//   subjects of a `deny` ServiceRole are denied access to the services matched
+//   by its rules, regardless of the `allow` ServiceRoles bound to them.
+//
+// For example, the following ServiceRole grants the permissions of the
+// `products-viewer` ServiceRole, plus the use of POST and PUT.
+//
+// ```yaml
+// apiVersion: "config.istio.io/v1alpha2"
+// kind: ServiceRole
+// metadata:
+//   name: products-editor
+//   namespace: default
+//   annotations:
+//     rbac.istio.io/includes: products-viewer
+// spec:
+//   rules:
+//   - services: ["products.svc.cluster.local"]
+//     methods: ["POST", "PUT"]
+// ```
 type Params struct {
 	// URL for the config store. It is used to initiate a new Store instance.
 	// Following are some examples of the config store URL:
@@ -66,6 +92,23 @@ type Params struct {
 	ConfigStoreUrl string `protobuf:"bytes,1,opt,name=config_store_url,json=configStoreUrl,proto3" json:"config_store_url,omitempty"`
 	// The duration for which authorization results may be cached.
 	CacheDuration time.Duration `protobuf:"bytes,2,opt,name=cache_duration,json=cacheDuration,stdduration" json:"cache_duration"`
+	// If true, requests are never denied. The requests that would be denied are
+	// logged along with the rule that denies them, so that policies can be
+	// evaluated before they are enforced.
+	DryRun bool `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// Namespace whose ServiceRoles and ServiceRoleBindings apply to the services
+	// of every namespace, in addition to the ones of the service namespace.
+	// The ServiceRoles of this namespace can also be included by the ServiceRoles
+	// of other namespaces. Cluster-wide policies are disabled if empty.
+	ClusterNamespace string `protobuf:"bytes,4,opt,name=cluster_namespace,json=clusterNamespace,proto3" json:"cluster_namespace,omitempty"`
+	// Address, in host:port form, of an HTTP endpoint explaining authorization
+	// decisions, for policies to be checked before they are rolled out. A GET of
+	// `/debug/rbac/explain` with the `namespace`, `service`, `path`, `method`,
+	// `user` and `groups` query parameters of a request returns whether it is
+	// allowed, and the ServiceRole, rule and ServiceRoleBinding deciding it.
+	// Action and subject properties are passed as `action.<name>` and
+	// `subject.<name>` parameters. The endpoint is disabled if empty.
+	DebugAddress string `protobuf:"bytes,5,opt,name=debug_address,json=debugAddress,proto3" json:"debug_address,omitempty"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -104,6 +147,28 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		return 0, err
 	}
 	i += n1
+	if m.DryRun {
+		dAtA[i] = 0x18
+		i++
+		if m.DryRun {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.ClusterNamespace) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.ClusterNamespace)))
+		i += copy(dAtA[i:], m.ClusterNamespace)
+	}
+	if len(m.DebugAddress) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.DebugAddress)))
+		i += copy(dAtA[i:], m.DebugAddress)
+	}
 	return i, nil
 }
 
@@ -125,6 +190,17 @@ func (m *Params) Size() (n int) {
 	}
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.CacheDuration)
 	n += 1 + l + sovConfig(uint64(l))
+	if m.DryRun {
+		n += 2
+	}
+	l = len(m.ClusterNamespace)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = len(m.DebugAddress)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
 	return n
 }
 
@@ -148,6 +224,9 @@ func (this *Params) String() string {
 	s := strings.Join([]string{`&Params{`,
 		`ConfigStoreUrl:` + fmt.Sprintf("%v", this.ConfigStoreUrl) + `,`,
 		`CacheDuration:` + strings.Replace(strings.Replace(this.CacheDuration.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`DryRun:` + fmt.Sprintf("%v", this.DryRun) + `,`,
+		`ClusterNamespace:` + fmt.Sprintf("%v", this.ClusterNamespace) + `,`,
+		`DebugAddress:` + fmt.Sprintf("%v", this.DebugAddress) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -248,6 +327,84 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DryRun", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DryRun = bool(v != 0)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClusterNamespace", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ClusterNamespace = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DebugAddress", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DebugAddress = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -377,21 +534,24 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/rbac/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 256 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0xcb, 0xcd, 0xac, 0x48,
-	0x2d, 0xd2, 0x4f, 0x4c, 0x49, 0x2c, 0x28, 0x49, 0x2d, 0xd2, 0x2f, 0x4a, 0x4a, 0x4c, 0xd6, 0x4f,
-	0xce, 0xcf, 0x4b, 0xcb, 0x4c, 0x87, 0x52, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0xc2, 0x50,
-	0x15, 0x7a, 0x20, 0x15, 0x7a, 0x10, 0x29, 0x29, 0xb9, 0xf4, 0xfc, 0xfc, 0xf4, 0x9c, 0x54, 0x7d,
-	0xb0, 0x92, 0xa4, 0xd2, 0x34, 0xfd, 0x94, 0xd2, 0xa2, 0xc4, 0x92, 0xcc, 0xfc, 0x3c, 0x88, 0x26,
-	0x29, 0x91, 0xf4, 0xfc, 0xf4, 0x7c, 0x30, 0x53, 0x1f, 0xc4, 0x82, 0x88, 0x2a, 0xd5, 0x71, 0xb1,
-	0x05, 0x24, 0x16, 0x25, 0xe6, 0x16, 0x0b, 0x69, 0x70, 0x09, 0x40, 0x4c, 0x8a, 0x2f, 0x2e, 0xc9,
-	0x2f, 0x4a, 0x8d, 0x2f, 0x2d, 0xca, 0x91, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0xe2, 0x83, 0x88,
-	0x07, 0x83, 0x84, 0x43, 0x8b, 0x72, 0x84, 0xbc, 0xb8, 0xf8, 0x92, 0x13, 0x93, 0x33, 0x52, 0xe3,
-	0x61, 0x36, 0x48, 0x30, 0x29, 0x30, 0x6a, 0x70, 0x1b, 0x49, 0xea, 0x41, 0x9c, 0xa0, 0x07, 0x73,
-	0x82, 0x9e, 0x0b, 0x54, 0x81, 0x13, 0xc7, 0x89, 0x7b, 0xf2, 0x0c, 0x33, 0xee, 0xcb, 0x33, 0x06,
-	0xf1, 0x82, 0xb5, 0xc2, 0x25, 0x2c, 0x4e, 0x3c, 0x94, 0x63, 0xb8, 0xf0, 0x50, 0x8e, 0xe1, 0xc6,
-	0x43, 0x39, 0x86, 0x0f, 0x0f, 0xe5, 0x18, 0x1a, 0x1e, 0xc9, 0x31, 0xae, 0x78, 0x24, 0xc7, 0x70,
-	0xe2, 0x91, 0x1c, 0xe3, 0x85, 0x47, 0x72, 0x8c, 0x0f, 0x1e, 0xc9, 0x31, 0xbe, 0x78, 0x24, 0xc7,
-	0xf0, 0xe1, 0x91, 0x1c, 0xe3, 0x84, 0xc7, 0x72, 0x0c, 0x51, 0x6c, 0x10, 0xd7, 0x24, 0xb1, 0x81,
-	0x6d, 0x31, 0x06, 0x04, 0x00, 0x00, 0xff, 0xff, 0x7c, 0xf7, 0x13, 0xb7, 0x35, 0x01, 0x00, 0x00,
+	// 294 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x34, 0x8f, 0xcf, 0x4a, 0xc3, 0x30,
+	0x1c, 0xc7, 0x5b, 0xff, 0xd4, 0x19, 0xdd, 0x98, 0x75, 0x60, 0xdd, 0xa1, 0x1b, 0x0a, 0x52, 0x10,
+	0x12, 0xd0, 0x27, 0x70, 0x78, 0xf2, 0x20, 0x52, 0xf1, 0xe2, 0x25, 0xa4, 0x49, 0x16, 0x07, 0x5d,
+	0x33, 0x7e, 0x69, 0xc0, 0xbd, 0x89, 0x8f, 0xe0, 0xa3, 0xec, 0x29, 0x94, 0x79, 0xf3, 0xe6, 0x23,
+	0x48, 0x93, 0xf6, 0x94, 0x1f, 0x9f, 0xef, 0xe7, 0x97, 0x7c, 0x83, 0xae, 0x96, 0x8b, 0x77, 0x09,
+	0x84, 0x09, 0xb6, 0xaa, 0x25, 0x10, 0x28, 0x18, 0x27, 0x5c, 0x57, 0xf3, 0x85, 0x6a, 0x0f, 0xbc,
+	0x02, 0x5d, 0xeb, 0xf8, 0xb4, 0x35, 0x70, 0x63, 0x60, 0x1f, 0x8d, 0x53, 0xa5, 0xb5, 0x2a, 0x25,
+	0x71, 0x4a, 0x61, 0xe7, 0x44, 0x58, 0x60, 0xf5, 0x42, 0x57, 0x7e, 0x69, 0x3c, 0x52, 0x5a, 0x69,
+	0x37, 0x92, 0x66, 0xf2, 0xf4, 0xe2, 0x37, 0x44, 0xd1, 0x13, 0x03, 0xb6, 0x34, 0x71, 0x86, 0x86,
+	0xfe, 0x2a, 0x6a, 0x6a, 0x0d, 0x92, 0x5a, 0x28, 0x93, 0x70, 0x1a, 0x66, 0x87, 0xf9, 0xc0, 0xf3,
+	0xe7, 0x06, 0xbf, 0x40, 0x19, 0x3f, 0xa0, 0x01, 0x67, 0xfc, 0x4d, 0xd2, 0xee, 0x89, 0x64, 0x67,
+	0x1a, 0x66, 0x47, 0x37, 0xe7, 0xd8, 0x77, 0xc0, 0x5d, 0x07, 0x7c, 0xdf, 0x0a, 0xb3, 0xde, 0xe6,
+	0x6b, 0x12, 0x7c, 0x7c, 0x4f, 0xc2, 0xbc, 0xef, 0x56, 0xbb, 0x20, 0x3e, 0x43, 0x07, 0x02, 0xd6,
+	0x14, 0x6c, 0x95, 0xec, 0x4e, 0xc3, 0xac, 0x97, 0x47, 0x02, 0xd6, 0xb9, 0xad, 0xe2, 0x6b, 0x74,
+	0xc2, 0x4b, 0x6b, 0x6a, 0x09, 0xb4, 0x62, 0x4b, 0x69, 0x56, 0x8c, 0xcb, 0x64, 0xcf, 0xf5, 0x19,
+	0xb6, 0xc1, 0x63, 0xc7, 0xe3, 0x4b, 0xd4, 0x17, 0xb2, 0xb0, 0x8a, 0x32, 0x21, 0x40, 0x1a, 0x93,
+	0xec, 0x3b, 0xf1, 0xd8, 0xc1, 0x3b, 0xcf, 0x66, 0xa3, 0xcd, 0x36, 0x0d, 0xfe, 0xb6, 0x69, 0xf0,
+	0xf9, 0x93, 0x06, 0xaf, 0x91, 0xff, 0x54, 0x11, 0xb9, 0xb2, 0xb7, 0xff, 0x03, 0x00, 0x04, 0x3a,
+	0xd0, 0xf1, 0x7d, 0x01, 0x00, 0x00,
 }
@@ -55,7 +55,12 @@ func (c *controller) processRBACRoles(env adapter.Env) {
 				rn = make(rolesByName)
 				roles[k.Namespace] = rn
 			}
-			rn[k.Name] = newRoleInfo(roleSpec)
+			role := newRoleInfo(roleSpec)
+			if err := role.setAnnotations(obj.Metadata.Annotations); err != nil {
+				_ = env.Logger().Errorf("Role %s in namespace %s is ignored: %v", k.Name, k.Namespace, err)
+				continue
+			}
+			rn[k.Name] = role
 			env.Logger().Infof("Role namespace: %s, name: %s, spec: %v", k.Namespace, k.Name, roleSpec)
 		}
 	}
@@ -90,5 +95,54 @@ func (c *controller) processRBACRoles(env adapter.Env) {
 		}
 	}
 
+	c.includeRoles(roles, env)
 	c.rbacStore.changeRoles(roles)
 }
+
+// includeRoles adds the rules of the roles included by each role to the rules of the role.
+func (c *controller) includeRoles(roles rolesMapByNamespace, env adapter.Env) {
+	for ns, rn := range roles {
+		for name, role := range rn {
+			if len(role.includes) > 0 {
+				included := map[*roleInfo]bool{role: true}
+				role.rules = append(role.rules, c.includedRules(roles, ns, name, role, included, env)...)
+			}
+		}
+	}
+}
+
+// includedRules returns the rules of the roles included by a role, and by the roles they include.
+// Roles that are already included are skipped, so that cycles are broken.
+func (c *controller) includedRules(roles rolesMapByNamespace, namespace string, name string, role *roleInfo,
+	included map[*roleInfo]bool, env adapter.Env) []*ruleInfo {
+
+	var rules []*ruleInfo
+	for _, includeName := range role.includes {
+		includeNamespace, include := c.lookupRole(roles, namespace, includeName)
+		if include == nil {
+			_ = env.Logger().Errorf("Role %s in namespace %s includes a role that does not exist %s", name, namespace, includeName)
+			continue
+		}
+		if included[include] {
+			continue
+		}
+		included[include] = true
+
+		rules = append(rules, roleRules(include.info, includeNamespace+"/"+includeName)...)
+		rules = append(rules, c.includedRules(roles, includeNamespace, includeName, include, included, env)...)
+	}
+	return rules
+}
+
+// lookupRole finds the role with the given name in a namespace, or in the cluster namespace.
+func (c *controller) lookupRole(roles rolesMapByNamespace, namespace string, name string) (string, *roleInfo) {
+	if role := roles[namespace][name]; role != nil {
+		return namespace, role
+	}
+	if cluster := c.rbacStore.clusterNamespace; cluster != "" {
+		if role := roles[cluster][name]; role != nil {
+			return cluster, role
+		}
+	}
+	return "", nil
+}
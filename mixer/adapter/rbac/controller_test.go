@@ -118,3 +118,59 @@ func TestController_processRBACRoles(t *testing.T) {
 		t.Fatalf("Got %v, Want %v", binding, wantRoleBinding)
 	}
 }
+
+func TestController_includeRoles(t *testing.T) {
+	role := func(service string, annotations map[string]string) *store.Resource {
+		return &store.Resource{
+			Metadata: store.ResourceMeta{Annotations: annotations},
+			Spec: &rbacproto.ServiceRole{
+				Rules: []*rbacproto.AccessRule{
+					{Services: []string{service}, Methods: []string{"GET"}},
+				},
+			},
+		}
+	}
+
+	configState := map[store.Key]*store.Resource{
+		{serviceRoleKind, "istio-system", "viewer"}: role("cluster", nil),
+		{serviceRoleKind, "ns1", "reader"}:          role("reader", map[string]string{includesAnnotation: "writer"}),
+		{serviceRoleKind, "ns1", "writer"}:          role("writer", map[string]string{includesAnnotation: "reader,viewer,missing"}),
+		{serviceRoleKind, "ns1", "admin"}:           role("admin", map[string]string{includesAnnotation: "writer"}),
+		{serviceRoleKind, "ns1", "blocked"}:         role("blocked", map[string]string{effectAnnotation: "deny"}),
+		{serviceRoleKind, "ns1", "broken"}:          role("broken", map[string]string{effectAnnotation: "maybe"}),
+	}
+
+	r := &configStore{clusterNamespace: "istio-system"}
+	c := &controller{
+		configState: configState,
+		rbacStore:   r,
+	}
+
+	c.processRBACRoles(test.NewEnv(t))
+
+	services := func(role *roleInfo) []string {
+		var s []string
+		for _, rule := range role.rules {
+			s = append(s, rule.rule.Services[0]+"@"+rule.includedFrom)
+		}
+		return s
+	}
+
+	roles := r.roles["ns1"]
+	for name, want := range map[string][]string{
+		"admin":  {"admin@", "writer@ns1/writer", "reader@ns1/reader", "cluster@istio-system/viewer"},
+		"writer": {"writer@", "reader@ns1/reader", "cluster@istio-system/viewer"},
+		"reader": {"reader@", "writer@ns1/writer", "cluster@istio-system/viewer"},
+	} {
+		if got := services(roles[name]); !reflect.DeepEqual(got, want) {
+			t.Errorf("Got rules %v, want %v for role %s", got, want, name)
+		}
+	}
+
+	if !roles["blocked"].deny {
+		t.Errorf("Role blocked should deny access")
+	}
+	if roles["broken"] != nil {
+		t.Errorf("Role broken with an invalid effect should be ignored")
+	}
+}
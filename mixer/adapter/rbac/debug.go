@@ -0,0 +1,142 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+// explainPath is the HTTP path of the endpoint explaining authorization decisions.
+const explainPath = "/debug/rbac/explain"
+
+// Prefixes of the query parameters of the explain endpoint carrying request properties.
+const (
+	actionPropertyPrefix  = "action."
+	subjectPropertyPrefix = "subject."
+)
+
+// debugServer serves the explain endpoint on an address. It is shared by the handlers configured
+// with the same address, as the handler built for a new configuration starts before the handler
+// it replaces is closed. Decisions are explained with the roles of the latest handler.
+type debugServer struct {
+	address string
+	server  *http.Server
+
+	// number of handlers using the server, protected by debugServersLock
+	refs int
+
+	lock  sync.RWMutex
+	store *configStore
+	env   adapter.Env
+}
+
+var (
+	debugServersLock sync.Mutex
+	debugServers     = make(map[string]*debugServer)
+)
+
+// acquireDebugServer returns the server for the address, starting it if necessary, and has it
+// explain decisions with the roles of the given store.
+func acquireDebugServer(address string, store *configStore, env adapter.Env) (*debugServer, error) {
+	debugServersLock.Lock()
+	defer debugServersLock.Unlock()
+
+	s, ok := debugServers[address]
+	if !ok {
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+
+		s = &debugServer{address: address}
+		mux := http.NewServeMux()
+		mux.HandleFunc(explainPath, s.explain)
+		s.server = &http.Server{Handler: mux}
+
+		env.ScheduleDaemon(func() {
+			// returns once the server is closed
+			_ = s.server.Serve(l)
+		})
+		debugServers[address] = s
+	}
+
+	s.refs++
+	s.lock.Lock()
+	s.store = store
+	s.env = env
+	s.lock.Unlock()
+	return s, nil
+}
+
+// release stops the server once no handler uses it anymore.
+func (s *debugServer) release() {
+	debugServersLock.Lock()
+	defer debugServersLock.Unlock()
+
+	s.refs--
+	if s.refs == 0 {
+		delete(debugServers, s.address)
+		_ = s.server.Close()
+	}
+}
+
+// explain returns the decision for the request described by the query parameters, and the
+// ServiceRole, rule and ServiceRoleBinding deciding it.
+func (s *debugServer) explain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	for _, param := range []string{"namespace", "service", "path", "method"} {
+		if query.Get(param) == "" {
+			http.Error(w, fmt.Sprintf("missing %s", param), http.StatusBadRequest)
+			return
+		}
+	}
+
+	req := &request{
+		service:           query.Get("service"),
+		path:              query.Get("path"),
+		method:            query.Get("method"),
+		user:              query.Get("user"),
+		groups:            query.Get("groups"),
+		actionProperties:  make(map[string]interface{}),
+		subjectProperties: make(map[string]interface{}),
+	}
+	for param, values := range query {
+		switch {
+		case strings.HasPrefix(param, actionPropertyPrefix):
+			req.actionProperties[strings.TrimPrefix(param, actionPropertyPrefix)] = values[0]
+		case strings.HasPrefix(param, subjectPropertyPrefix):
+			req.subjectProperties[strings.TrimPrefix(param, subjectPropertyPrefix)] = values[0]
+		}
+	}
+
+	s.lock.RLock()
+	d := s.store.evaluate(query.Get("namespace"), req, s.env)
+	s.lock.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(d)
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/mixer/pkg/adapter/test"
+)
+
+func TestDebugServer_Explain(t *testing.T) {
+	s := &debugServer{store: setupRBACStore(), env: test.NewEnv(t)}
+
+	cases := []struct {
+		query    string
+		status   int
+		expected decision
+	}{
+		{"namespace=ns1&service=bookstore&path=/books&method=GET&subject.namespace=acme", http.StatusOK,
+			decision{
+				Allowed: true,
+				Role:    "ns1/role1",
+				Rule:    "rule 0 of ServiceRole ns1/role1",
+				Binding: "ns1/binding1",
+				Reason:  "allowed by rule 0 of ServiceRole ns1/role1, bound by ServiceRoleBinding ns1/binding1",
+			}},
+		{"namespace=ns1&service=bookstore&path=/books&method=GET&subject.namespace=other", http.StatusOK,
+			decision{Reason: "denied as no ServiceRole allows it"}},
+		{"namespace=ns1&service=bookstore&path=/books", http.StatusBadRequest, decision{}},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		s.explain(w, httptest.NewRequest(http.MethodGet, explainPath+"?"+c.query, nil))
+		if w.Code != c.status {
+			t.Errorf("Got status %d, want %d for query %s", w.Code, c.status, c.query)
+			continue
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+
+		var got decision
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Unable to decode the explanation: %v", err)
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("Got %+v, want %+v for query %s", got, c.expected, c.query)
+		}
+	}
+}
@@ -21,13 +21,19 @@
 // The RBAC policies are specified in ServiceRole and ServiceRoleBinding CRD objects.
 // You can define a ServiceRole that contains a set of permissions for service/method level
 // access. You can then assign a ServiceRole to a set of subjects using ServiceRoleBinding specification.
-// ServiceRole and the corresponding ServiceRoleBindings should be in the same namespace.
+// ServiceRole and the corresponding ServiceRoleBindings should be in the same namespace, unless
+// they are in the cluster namespace of the handler, in which case they apply to every namespace.
+// A ServiceRole can include the rules of other ServiceRoles, and deny access to its subjects
+// rather than allow it, see the "rbac.istio.io/includes" and "rbac.istio.io/effect" annotations.
+// When a debug address is configured, the handler explains which ServiceRole and ServiceRoleBinding
+// decide a request on the "/debug/rbac/explain" HTTP endpoint.
 // Please see "istio.io/istio/mixer/testdata/config/rbac.yaml" for an example of RBAC handler, plus ServieRole
 // ServiceRoleBinding specifications.
 package rbac
 
 import (
 	"context"
+	"net"
 	"net/url"
 	"time"
 
@@ -53,6 +59,9 @@ type (
 		store         store.Store
 		closing       chan bool
 		done          chan bool
+
+		// explains the authorization decisions, nil if the debug endpoint is disabled
+		debug *debugServer
 	}
 )
 
@@ -78,6 +87,12 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 	if ac.CacheDuration < 0 {
 		ce = ce.Appendf("cachingDuration", "caching interval must be >= 0, it is %v", ac.CacheDuration)
 	}
+
+	if ac.DebugAddress != "" {
+		if _, _, err := net.SplitHostPort(ac.DebugAddress); err != nil {
+			ce = ce.Appendf("debugAddress", "debug address %s is invalid: %v", ac.DebugAddress, err)
+		}
+	}
 	return
 }
 
@@ -89,7 +104,10 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 	if err != nil {
 		return nil, env.Logger().Errorf("Unable to connect to the configuration server: %v", err)
 	}
-	r := &configStore{}
+	r := &configStore{
+		clusterNamespace: b.adapterConfig.ClusterNamespace,
+		dryRun:           b.adapterConfig.DryRun,
+	}
 	h := &handler{
 		rbac:          r,
 		env:           env,
@@ -102,6 +120,13 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 		return nil, env.Logger().Errorf("Unable to start controller: %v", err)
 	}
 
+	if b.adapterConfig.DebugAddress != "" {
+		if h.debug, err = acquireDebugServer(b.adapterConfig.DebugAddress, r, env); err != nil {
+			_ = h.Close()
+			return nil, env.Logger().Errorf("Unable to serve the RBAC debug endpoint on %s: %v", b.adapterConfig.DebugAddress, err)
+		}
+	}
+
 	return h, nil
 }
 
@@ -198,6 +223,9 @@ func (h *handler) Close() error {
 
 	<-h.done
 	h.store.Stop()
+	if h.debug != nil {
+		h.debug.release()
+	}
 	return nil
 }
 
@@ -15,6 +15,7 @@
 package rbac
 
 import (
+	"fmt"
 	"strings"
 
 	rbacproto "istio.io/api/rbac/v1alpha1"
@@ -27,15 +28,73 @@ type authorizer interface {
 	CheckPermission(inst *authorization.Instance, env adapter.Env) (bool, error)
 }
 
+// Annotations of ServiceRoles
+const (
+	// includesAnnotation is a comma separated list of roles whose rules are included in a role.
+	includesAnnotation = "rbac.istio.io/includes"
+
+	// effectAnnotation tells whether the rules of a role allow or deny access to its subjects.
+	effectAnnotation = "rbac.istio.io/effect"
+
+	allowEffect = "allow"
+	denyEffect  = "deny"
+)
+
 // Information about a ServiceRole and associted ServiceRoleBindings
 type roleInfo struct {
 	// ServiceRole proto definition
 	info *rbacproto.ServiceRole
 
+	// If true, the subjects of the role are denied access by its rules.
+	deny bool
+
+	// Names of the roles whose rules are included in this role.
+	includes []string
+
+	// The rules of the role, followed by the rules of the roles it includes.
+	rules []*ruleInfo
+
 	// A set of ServiceRoleBindings that refer to this role.
 	bindings map[string]*rbacproto.ServiceRoleBinding
 }
 
+// Information about a rule of a role, used to explain authorization decisions.
+type ruleInfo struct {
+	rule *rbacproto.AccessRule
+
+	// Index of the rule in the ServiceRole that defines it.
+	index int
+
+	// Namespace and name of the ServiceRole that defines the rule, if it is an included rule.
+	includedFrom string
+}
+
+// The attributes of a request that are matched against the rules and subjects of roles.
+type request struct {
+	service string
+	path    string
+	method  string
+	user    string
+	groups  string
+
+	actionProperties  map[string]interface{}
+	subjectProperties map[string]interface{}
+}
+
+// The outcome of the evaluation of a request, and the policy that decides it.
+type decision struct {
+	Allowed bool `json:"allowed"`
+
+	// Namespace and name of the ServiceRole and ServiceRoleBinding deciding the request, and the
+	// rule of the role matching it. Empty if no role applies to the request.
+	Role    string `json:"role,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Binding string `json:"binding,omitempty"`
+
+	// Explanation of the decision, as logged.
+	Reason string `json:"reason"`
+}
+
 // maps role name to role info
 type rolesByName map[string]*roleInfo
 
@@ -47,13 +106,47 @@ type rolesMapByNamespace map[string]rolesByName
 type configStore struct {
 	// All the roles organized per namespace.
 	roles rolesMapByNamespace
+
+	// Namespace whose roles apply to the services of every namespace. None if empty.
+	clusterNamespace string
+
+	// If true, the requests that would be denied are logged and allowed.
+	dryRun bool
 }
 
 // Create a RoleInfo object.
 func newRoleInfo(spec *rbacproto.ServiceRole) *roleInfo {
 	return &roleInfo{
-		info: spec,
+		info:  spec,
+		rules: roleRules(spec, ""),
+	}
+}
+
+// Create the ruleInfo objects for the rules of a ServiceRole.
+func roleRules(spec *rbacproto.ServiceRole, includedFrom string) []*ruleInfo {
+	rules := make([]*ruleInfo, 0, len(spec.GetRules()))
+	for i, rule := range spec.GetRules() {
+		rules = append(rules, &ruleInfo{rule: rule, index: i, includedFrom: includedFrom})
+	}
+	return rules
+}
+
+// Set the effect and included roles of a ServiceRole from its annotations.
+func (ri *roleInfo) setAnnotations(annotations map[string]string) error {
+	switch effect := annotations[effectAnnotation]; effect {
+	case "", allowEffect:
+	case denyEffect:
+		ri.deny = true
+	default:
+		return fmt.Errorf("invalid %s annotation '%s', expecting '%s' or '%s'", effectAnnotation, effect, allowEffect, denyEffect)
+	}
+
+	for _, name := range strings.Split(annotations[includesAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ri.includes = append(ri.includes, name)
+		}
 	}
+	return nil
 }
 
 // Set a binding for a given Service role.
@@ -95,65 +188,131 @@ func (rs *configStore) CheckPermission(inst *authorization.Instance, env adapter
 		return false, env.Logger().Errorf("Missing method")
 	}
 
-	extraAct := inst.Action.Properties
-
-	user := inst.Subject.User
-	groups := inst.Subject.Groups
+	req := &request{
+		service:           serviceName,
+		path:              path,
+		method:            method,
+		user:              inst.Subject.User,
+		groups:            inst.Subject.Groups,
+		actionProperties:  inst.Action.Properties,
+		subjectProperties: inst.Subject.Properties,
+	}
 
-	instSub := inst.Subject.Properties
+	d := rs.evaluate(namespace, req, env)
+	desc := fmt.Sprintf("%s %s on service %s in namespace %s by user '%s'", method, path, serviceName, namespace, req.user)
+	switch {
+	case d.Allowed:
+		if env.Logger().VerbosityLevel(4) {
+			env.Logger().Infof("RBAC: %s is %s", desc, d.Reason)
+		}
+	case rs.dryRun:
+		env.Logger().Warningf("RBAC dry run: %s would be %s", desc, d.Reason)
+		return true, nil
+	default:
+		env.Logger().Infof("RBAC: %s is %s", desc, d.Reason)
+	}
+	return d.Allowed, nil
+}
 
-	rn := rs.roles[namespace]
-	if rn == nil {
-		return false, nil
+// Namespaces whose roles apply to the services of a given namespace.
+func (rs *configStore) namespaces(namespace string) []string {
+	if rs.clusterNamespace == "" || rs.clusterNamespace == namespace {
+		return []string{namespace}
 	}
+	return []string{namespace, rs.clusterNamespace}
+}
 
-	for rolename, roleInfo := range rn {
-		eligibleRole := false
-		env.Logger().Infof("Checking role: %s", rolename)
-		rules := roleInfo.info.GetRules()
-		for _, rule := range rules {
-			if matchRule(serviceName, path, method, extraAct, rule, env) {
-				eligibleRole = true
-				break
-			}
-		}
-		if !eligibleRole {
-			env.Logger().Infof("role %s is not eligible", rolename)
-			continue
-		}
-		env.Logger().Infof("role %s is eligible", rolename)
-		bindings := roleInfo.bindings
-		for _, binding := range bindings {
-			subjects := binding.GetSubjects()
-			for _, subject := range subjects {
-				foundMatch := false
-				if subject.GetUser() != "" {
-					if subject.GetUser() == "*" || subject.GetUser() == user {
-						foundMatch = true
-					} else {
-						// Found a mismatch, try next subject.
-						continue
-					}
+// Decide whether a request to a service in the given namespace is allowed, and explain why.
+// Roles that deny access take precedence over the roles that allow it.
+func (rs *configStore) evaluate(namespace string, req *request, env adapter.Env) *decision {
+	namespaces := rs.namespaces(namespace)
+
+	for _, deny := range []bool{true, false} {
+		for _, ns := range namespaces {
+			for rolename, roleInfo := range rs.roles[ns] {
+				if roleInfo.deny != deny {
+					continue
 				}
-				if subject.GetGroup() != "" {
-					if subject.GetGroup() == "*" || subject.GetGroup() == groups {
-						foundMatch = true
-					} else {
-						// Found a mismatch, try next subject.
-						continue
-					}
+				env.Logger().Infof("Checking role: %s/%s", ns, rolename)
+				rule := roleInfo.matchRules(req, env)
+				if rule == nil {
+					env.Logger().Infof("role %s/%s is not eligible", ns, rolename)
+					continue
 				}
-				subProp := subject.GetProperties()
-				if len(subProp) != 0 && checkSubject(instSub, subProp) {
-					foundMatch = true
+				env.Logger().Infof("role %s/%s is eligible", ns, rolename)
+				binding := roleInfo.matchBindings(req)
+				if binding == "" {
+					continue
 				}
-				if foundMatch {
-					return true, nil
+
+				verdict := "allowed"
+				if deny {
+					verdict = "denied"
+				}
+				return &decision{
+					Allowed: !deny,
+					Role:    ns + "/" + rolename,
+					Rule:    rule.describe(ns, rolename),
+					Binding: ns + "/" + binding,
+					Reason:  fmt.Sprintf("%s by %s, bound by ServiceRoleBinding %s/%s", verdict, rule.describe(ns, rolename), ns, binding),
 				}
 			}
 		}
 	}
-	return false, nil
+	return &decision{Reason: "denied as no ServiceRole allows it"}
+}
+
+// Return the first rule of a role that matches the request, nil if none does.
+func (ri *roleInfo) matchRules(req *request, env adapter.Env) *ruleInfo {
+	for _, rule := range ri.rules {
+		if matchRule(req.service, req.path, req.method, req.actionProperties, rule.rule, env) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Return the name of the first binding of a role with a subject that matches the request,
+// empty if none does.
+func (ri *roleInfo) matchBindings(req *request) string {
+	for name, binding := range ri.bindings {
+		for _, subject := range binding.GetSubjects() {
+			if matchSubject(req, subject) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// Describe where a rule comes from, given the role it applies through.
+func (r *ruleInfo) describe(namespace string, role string) string {
+	if r.includedFrom == "" {
+		return fmt.Sprintf("rule %d of ServiceRole %s/%s", r.index, namespace, role)
+	}
+	return fmt.Sprintf("rule %d of ServiceRole %s included by ServiceRole %s/%s", r.index, r.includedFrom, namespace, role)
+}
+
+// Helper function to check whether or not a request matches a subject of a ServiceRoleBinding.
+func matchSubject(req *request, subject *rbacproto.Subject) bool {
+	foundMatch := false
+	if subject.GetUser() != "" {
+		if subject.GetUser() != "*" && subject.GetUser() != req.user {
+			return false
+		}
+		foundMatch = true
+	}
+	if subject.GetGroup() != "" {
+		if subject.GetGroup() != "*" && subject.GetGroup() != req.groups {
+			return false
+		}
+		foundMatch = true
+	}
+	subProp := subject.GetProperties()
+	if len(subProp) != 0 && checkSubject(req.subjectProperties, subProp) {
+		foundMatch = true
+	}
+	return foundMatch
 }
 
 // Helper function to check whether or not a request matches a rule in a ServiceRole specification.
@@ -15,6 +15,7 @@
 package rbac
 
 import (
+	"reflect"
 	"testing"
 
 	rbacproto "istio.io/api/rbac/v1alpha1"
@@ -228,3 +229,142 @@ func TestRBACStore_CheckPermission(t *testing.T) {
 		}
 	}
 }
+
+func newInstance(namespace, service, method, user string) *authorization.Instance {
+	return &authorization.Instance{
+		Subject: &authorization.Subject{
+			User:       user,
+			Properties: map[string]interface{}{},
+		},
+		Action: &authorization.Action{
+			Namespace:  namespace,
+			Service:    service,
+			Path:       "/index",
+			Method:     method,
+			Properties: map[string]interface{}{},
+		},
+	}
+}
+
+func bindUser(role *roleInfo, name string, user string) *roleInfo {
+	role.setBinding(name, &rbacproto.ServiceRoleBinding{
+		Subjects: []*rbacproto.Subject{{User: user}},
+		RoleRef:  &rbacproto.RoleRef{Kind: "ServiceRole", Name: name},
+	})
+	return role
+}
+
+func TestRBACStore_DenyRolesAndClusterNamespace(t *testing.T) {
+	readAll := newRoleInfo(&rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{
+			{Services: []string{"*"}, Methods: []string{"GET"}},
+		},
+	})
+	denyBilling := newRoleInfo(&rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{
+			{Services: []string{"products"}, Methods: []string{"GET"}},
+			{Services: []string{"billing"}, Methods: []string{"*"}},
+		},
+	})
+	denyBilling.deny = true
+	writeProducts := newRoleInfo(&rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{
+			{Services: []string{"products"}, Methods: []string{"POST"}},
+		},
+	})
+
+	s := &configStore{
+		clusterNamespace: "istio-system",
+		roles: rolesMapByNamespace{
+			"istio-system": rolesByName{
+				"read-all":     bindUser(readAll, "read-all", "*"),
+				"deny-billing": bindUser(denyBilling, "deny-billing", "mallory"),
+			},
+			"ns1": rolesByName{
+				"write-products": bindUser(writeProducts, "write-products", "alice"),
+			},
+		},
+	}
+
+	cases := []struct {
+		namespace string
+		service   string
+		method    string
+		user      string
+		expected  bool
+		reason    string
+	}{
+		{"ns1", "products", "GET", "alice", true,
+			"allowed by rule 0 of ServiceRole istio-system/read-all, bound by ServiceRoleBinding istio-system/read-all"},
+		{"ns1", "products", "POST", "alice", true,
+			"allowed by rule 0 of ServiceRole ns1/write-products, bound by ServiceRoleBinding ns1/write-products"},
+		{"ns2", "products", "POST", "alice", false,
+			"denied as no ServiceRole allows it"},
+		{"ns1", "billing", "GET", "mallory", false,
+			"denied by rule 1 of ServiceRole istio-system/deny-billing, bound by ServiceRoleBinding istio-system/deny-billing"},
+		{"ns2", "reviews", "GET", "mallory", true,
+			"allowed by rule 0 of ServiceRole istio-system/read-all, bound by ServiceRoleBinding istio-system/read-all"},
+	}
+
+	for _, c := range cases {
+		inst := newInstance(c.namespace, c.service, c.method, c.user)
+		result, err := s.CheckPermission(inst, test.NewEnv(t))
+		if err != nil || result != c.expected {
+			t.Errorf("Got %v, %v, want %v for case %v", result, err, c.expected, c)
+		}
+
+		req := &request{service: c.service, path: "/index", method: c.method, user: c.user}
+		if d := s.evaluate(c.namespace, req, test.NewEnv(t)); d.Reason != c.reason {
+			t.Errorf("Got reason %q, want %q for case %v", d.Reason, c.reason, c)
+		}
+	}
+
+	// Without a cluster namespace, the roles of istio-system only apply to its own services.
+	s.clusterNamespace = ""
+	if result, _ := s.CheckPermission(newInstance("ns1", "products", "GET", "alice"), test.NewEnv(t)); result {
+		t.Errorf("Got allowed, want denied without cluster namespace")
+	}
+	if result, _ := s.CheckPermission(newInstance("istio-system", "products", "GET", "alice"), test.NewEnv(t)); !result {
+		t.Errorf("Got denied, want allowed in the namespace of the role")
+	}
+}
+
+func TestRBACStore_DryRun(t *testing.T) {
+	s := setupRBACStore()
+	s.dryRun = true
+
+	if result, err := s.CheckPermission(newInstance("ns1", "products", "POST", "bob@yahoo.com"), test.NewEnv(t)); !result || err != nil {
+		t.Errorf("Got %v, %v, want the request to be allowed in dry run", result, err)
+	}
+
+	// Invalid requests are still rejected.
+	if result, _ := s.CheckPermission(newInstance("", "products", "GET", "bob@yahoo.com"), test.NewEnv(t)); result {
+		t.Errorf("Got allowed, want an invalid request to be denied in dry run")
+	}
+}
+
+func TestRoleInfo_SetAnnotations(t *testing.T) {
+	cases := []struct {
+		annotations map[string]string
+		deny        bool
+		includes    []string
+		valid       bool
+	}{
+		{nil, false, nil, true},
+		{map[string]string{effectAnnotation: "allow"}, false, nil, true},
+		{map[string]string{effectAnnotation: "deny", includesAnnotation: "a, b,,c "}, true, []string{"a", "b", "c"}, true},
+		{map[string]string{effectAnnotation: "block"}, false, nil, false},
+	}
+
+	for _, c := range cases {
+		role := newRoleInfo(&rbacproto.ServiceRole{})
+		err := role.setAnnotations(c.annotations)
+		if (err == nil) != c.valid {
+			t.Errorf("Got error %v for case %v", err, c)
+			continue
+		}
+		if role.deny != c.deny || !reflect.DeepEqual(role.includes, c.includes) {
+			t.Errorf("Got deny %v, includes %v for case %v", role.deny, role.includes, c)
+		}
+	}
+}
@@ -53,23 +53,59 @@ const (
 	FIXED_WINDOW Params_QuotaAlgorithm = 0
 	// ROLLING_WINDOW The rolling window algorithm's additional precision comes at the cost of increased redis resource usage.
 	ROLLING_WINDOW Params_QuotaAlgorithm = 1
+	// TOKEN_BUCKET The token bucket algorithm refills max_amount tokens every valid_duration, continuously,
+	// and lets a quota consume up to burst_amount tokens at once.
+	TOKEN_BUCKET Params_QuotaAlgorithm = 2
 )
 
 var Params_QuotaAlgorithm_name = map[int32]string{
 	0: "FIXED_WINDOW",
 	1: "ROLLING_WINDOW",
+	2: "TOKEN_BUCKET",
 }
 var Params_QuotaAlgorithm_value = map[string]int32{
 	"FIXED_WINDOW":   0,
 	"ROLLING_WINDOW": 1,
+	"TOKEN_BUCKET":   2,
 }
 
 func (Params_QuotaAlgorithm) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptorConfig, []int{0, 0}
 }
 
-// redisquota adapter supports the rate limit quota using either fixed or
-// rolling window algorithm. And it is using Redis as a shared data storage.
+// Topologies of the Redis deployment backing the quotas:
+type Params_DeploymentType int32
+
+const (
+	// SINGLE_NODE A single Redis server, at redis_server_url.
+	SINGLE_NODE Params_DeploymentType = 0
+	// CLUSTER A Redis Cluster, discovered from the seed nodes in redis_server_urls. Quota keys are hashed
+	// onto the cluster slots so that the quotas are spread across the cluster nodes.
+	CLUSTER Params_DeploymentType = 1
+	// SENTINEL A Redis master monitored by the Redis Sentinel nodes in redis_server_urls. The adapter
+	// follows the master when Sentinel fails over to a replica.
+	SENTINEL Params_DeploymentType = 2
+)
+
+var Params_DeploymentType_name = map[int32]string{
+	0: "SINGLE_NODE",
+	1: "CLUSTER",
+	2: "SENTINEL",
+}
+var Params_DeploymentType_value = map[string]int32{
+	"SINGLE_NODE": 0,
+	"CLUSTER":     1,
+	"SENTINEL":    2,
+}
+
+func (Params_DeploymentType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptorConfig, []int{0, 1}
+}
+
+// redisquota adapter supports the rate limit quota using a fixed window,
+// rolling window or token bucket algorithm. And it is using Redis as a shared
+// data storage, which can be a single server, a Redis Cluster or a master
+// monitored by Redis Sentinel.
 //
 // Example configuration:
 //
@@ -91,15 +127,42 @@ func (Params_QuotaAlgorithm) EnumDescriptor() ([]byte, []int) {
 //           destination: reviews
 //         maxAmount: 5
 // ```
+//
+// Token bucket quotas spread `maxAmount` evenly over `validDuration` and
+// allow bursts of up to `burstAmount`. With a Redis Cluster, every quota key
+// is hashed onto a single slot, so quotas are sharded across the nodes:
+//
+// ```yaml
+// deploymentType: CLUSTER
+// redisServerUrls:
+//   - redis-cluster-0.redis:6379
+//   - redis-cluster-1.redis:6379
+//   - redis-cluster-2.redis:6379
+// quotas:
+//   - name: requestCount.quota.istio-system
+//     maxAmount: 100
+//     validDuration: 1s
+//     burstAmount: 500
+//     rateLimitAlgorithm: TOKEN_BUCKET
+// ```
 type Params struct {
 	// The set of known quotas. At least one quota configuration is required
 	Quotas []Params_Quota `protobuf:"bytes,1,rep,name=quotas" json:"quotas"`
 	// Redis connection string <hostname>:<port number>
 	// ex) localhost:6379
+	// redis_server_url is only used when deployment_type is SINGLE_NODE
 	RedisServerUrl string `protobuf:"bytes,2,opt,name=redis_server_url,json=redisServerUrl,proto3" json:"redis_server_url,omitempty"`
 	// Maximum number of idle connections to redis
 	// Default is 10 connections per every CPU as reported by runtime.NumCPU.
 	ConnectionPoolSize int64 `protobuf:"varint,3,opt,name=connection_pool_size,json=connectionPoolSize,proto3" json:"connection_pool_size,omitempty"`
+	// Topology of the Redis deployment. The default value is SINGLE_NODE
+	DeploymentType Params_DeploymentType `protobuf:"varint,4,opt,name=deployment_type,json=deploymentType,proto3,enum=adapter.redisquota.config.Params_DeploymentType" json:"deployment_type,omitempty"`
+	// Redis connection strings <hostname>:<port number> of the cluster seed nodes when
+	// deployment_type is CLUSTER, or of the sentinels when deployment_type is SENTINEL.
+	RedisServerUrls []string `protobuf:"bytes,5,rep,name=redis_server_urls,json=redisServerUrls" json:"redis_server_urls,omitempty"`
+	// Name of the master monitored by the sentinels.
+	// sentinel_master_name is required when deployment_type is SENTINEL
+	SentinelMasterName string `protobuf:"bytes,6,opt,name=sentinel_master_name,json=sentinelMasterName,proto3" json:"sentinel_master_name,omitempty"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -151,6 +214,10 @@ type Params_Quota struct {
 	// Overrides associated with this quota.
 	// The first matching override is applied.
 	Overrides []*Params_Override `protobuf:"bytes,6,rep,name=overrides" json:"overrides,omitempty"`
+	// The maximum number of tokens the bucket holds, that is the largest burst allowed.
+	// burst_amount will be ignored unless rate_limit_algorithm is TOKEN_BUCKET
+	// The default value is the max_amount of the quota, or of the matching override.
+	BurstAmount int64 `protobuf:"varint,7,opt,name=burst_amount,json=burstAmount,proto3" json:"burst_amount,omitempty"`
 }
 
 func (m *Params_Quota) Reset()                    { *m = Params_Quota{} }
@@ -199,11 +266,19 @@ func (m *Params_Quota) GetOverrides() []*Params_Override {
 	return nil
 }
 
+func (m *Params_Quota) GetBurstAmount() int64 {
+	if m != nil {
+		return m.BurstAmount
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Params)(nil), "adapter.redisquota.config.Params")
 	proto.RegisterType((*Params_Override)(nil), "adapter.redisquota.config.Params.Override")
 	proto.RegisterType((*Params_Quota)(nil), "adapter.redisquota.config.Params.Quota")
 	proto.RegisterEnum("adapter.redisquota.config.Params_QuotaAlgorithm", Params_QuotaAlgorithm_name, Params_QuotaAlgorithm_value)
+	proto.RegisterEnum("adapter.redisquota.config.Params_DeploymentType", Params_DeploymentType_name, Params_DeploymentType_value)
 }
 func (x Params_QuotaAlgorithm) String() string {
 	s, ok := Params_QuotaAlgorithm_name[int32(x)]
@@ -212,6 +287,13 @@ func (x Params_QuotaAlgorithm) String() string {
 	}
 	return strconv.Itoa(int(x))
 }
+func (x Params_DeploymentType) String() string {
+	s, ok := Params_DeploymentType_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
 func (m *Params) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -250,6 +332,32 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintConfig(dAtA, i, uint64(m.ConnectionPoolSize))
 	}
+	if m.DeploymentType != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.DeploymentType))
+	}
+	if len(m.RedisServerUrls) > 0 {
+		for _, s := range m.RedisServerUrls {
+			dAtA[i] = 0x2a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.SentinelMasterName) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.SentinelMasterName)))
+		i += copy(dAtA[i:], m.SentinelMasterName)
+	}
 	return i, nil
 }
 
@@ -352,6 +460,11 @@ func (m *Params_Quota) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if m.BurstAmount != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.BurstAmount))
+	}
 	return i, nil
 }
 
@@ -380,6 +493,19 @@ func (m *Params) Size() (n int) {
 	if m.ConnectionPoolSize != 0 {
 		n += 1 + sovConfig(uint64(m.ConnectionPoolSize))
 	}
+	if m.DeploymentType != 0 {
+		n += 1 + sovConfig(uint64(m.DeploymentType))
+	}
+	if len(m.RedisServerUrls) > 0 {
+		for _, s := range m.RedisServerUrls {
+			l = len(s)
+			n += 1 + l + sovConfig(uint64(l))
+		}
+	}
+	l = len(m.SentinelMasterName)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
 	return n
 }
 
@@ -423,6 +549,9 @@ func (m *Params_Quota) Size() (n int) {
 			n += 1 + l + sovConfig(uint64(l))
 		}
 	}
+	if m.BurstAmount != 0 {
+		n += 1 + sovConfig(uint64(m.BurstAmount))
+	}
 	return n
 }
 
@@ -447,6 +576,9 @@ func (this *Params) String() string {
 		`Quotas:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Quotas), "Params_Quota", "Params_Quota", 1), `&`, ``, 1) + `,`,
 		`RedisServerUrl:` + fmt.Sprintf("%v", this.RedisServerUrl) + `,`,
 		`ConnectionPoolSize:` + fmt.Sprintf("%v", this.ConnectionPoolSize) + `,`,
+		`DeploymentType:` + fmt.Sprintf("%v", this.DeploymentType) + `,`,
+		`RedisServerUrls:` + fmt.Sprintf("%v", this.RedisServerUrls) + `,`,
+		`SentinelMasterName:` + fmt.Sprintf("%v", this.SentinelMasterName) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -483,6 +615,7 @@ func (this *Params_Quota) String() string {
 		`BucketDuration:` + strings.Replace(strings.Replace(this.BucketDuration.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
 		`RateLimitAlgorithm:` + fmt.Sprintf("%v", this.RateLimitAlgorithm) + `,`,
 		`Overrides:` + strings.Replace(fmt.Sprintf("%v", this.Overrides), "Params_Override", "Params_Override", 1) + `,`,
+		`BurstAmount:` + fmt.Sprintf("%v", this.BurstAmount) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -603,6 +736,83 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeploymentType", wireType)
+			}
+			m.DeploymentType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeploymentType |= (Params_DeploymentType(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RedisServerUrls", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RedisServerUrls = append(m.RedisServerUrls, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SentinelMasterName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SentinelMasterName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -998,6 +1208,25 @@ func (m *Params_Quota) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BurstAmount", wireType)
+			}
+			m.BurstAmount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BurstAmount |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -1127,41 +1356,48 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/redisquota/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 570 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0xb1, 0x6f, 0xd3, 0x4e,
-	0x18, 0xf5, 0xd5, 0xa9, 0xd5, 0x5e, 0x7f, 0x3f, 0x37, 0x3a, 0x65, 0x70, 0x23, 0x71, 0x8d, 0xba,
-	0x10, 0x21, 0x64, 0x57, 0x45, 0x42, 0x55, 0x25, 0x86, 0x56, 0x09, 0x10, 0x14, 0x35, 0xc5, 0x15,
-	0x2a, 0x62, 0x31, 0x97, 0xf8, 0x6a, 0x4e, 0xb5, 0x7d, 0xe1, 0x6c, 0x47, 0x69, 0x27, 0xc6, 0x8e,
-	0x8c, 0x8c, 0x8c, 0xfc, 0x1b, 0x6c, 0x19, 0x33, 0x32, 0x01, 0x31, 0x0b, 0x63, 0xff, 0x04, 0xe4,
-	0xb3, 0x9d, 0x00, 0x12, 0x6a, 0x26, 0x7f, 0x7e, 0xf7, 0xde, 0xbb, 0xef, 0xfb, 0xde, 0xc1, 0xfb,
-	0x01, 0x1b, 0x53, 0x61, 0x11, 0x97, 0x0c, 0x63, 0x2a, 0x2c, 0x41, 0x5d, 0x16, 0xbd, 0x4d, 0x78,
-	0x4c, 0xac, 0x01, 0x0f, 0xcf, 0x99, 0x57, 0x7c, 0xcc, 0xa1, 0xe0, 0x31, 0x47, 0x5b, 0x05, 0xcf,
-	0x5c, 0xf0, 0xcc, 0x9c, 0x50, 0xc7, 0x1e, 0xe7, 0x9e, 0x4f, 0x2d, 0x49, 0xec, 0x27, 0xe7, 0x96,
-	0x9b, 0x08, 0x12, 0x33, 0x1e, 0xe6, 0xd2, 0x7a, 0xcd, 0xe3, 0x1e, 0x97, 0xa5, 0x95, 0x55, 0x39,
-	0xba, 0xf3, 0x59, 0x83, 0xda, 0x09, 0x11, 0x24, 0x88, 0x50, 0x1b, 0x6a, 0xd2, 0x30, 0x32, 0x40,
-	0x43, 0x6d, 0x6e, 0xec, 0xdd, 0x35, 0xff, 0x79, 0x99, 0x99, 0x4b, 0xcc, 0xe7, 0x19, 0x76, 0x54,
-	0x99, 0x7c, 0xdd, 0x56, 0xec, 0x42, 0x8c, 0x9a, 0xb0, 0x2a, 0xf9, 0x4e, 0x44, 0xc5, 0x88, 0x0a,
-	0x27, 0x11, 0xbe, 0xb1, 0xd2, 0x00, 0xcd, 0x75, 0x5b, 0x97, 0xf8, 0xa9, 0x84, 0x5f, 0x08, 0x1f,
-	0xed, 0xc2, 0xda, 0x80, 0x87, 0x21, 0x1d, 0x64, 0x5d, 0x3a, 0x43, 0xce, 0x7d, 0x27, 0x62, 0x57,
-	0xd4, 0x50, 0x1b, 0xa0, 0xa9, 0xda, 0x68, 0x71, 0x76, 0xc2, 0xb9, 0x7f, 0xca, 0xae, 0x68, 0x7d,
-	0x0a, 0xe0, 0x5a, 0x6f, 0x44, 0x85, 0x60, 0x2e, 0x45, 0xaf, 0x21, 0x74, 0x59, 0x40, 0xc3, 0x88,
-	0xf1, 0xb0, 0xec, 0xf9, 0xe0, 0xf6, 0x9e, 0x4b, 0xbd, 0xd9, 0x9a, 0x8b, 0xdb, 0x61, 0x2c, 0x2e,
-	0x8b, 0x31, 0x7e, 0xf3, 0x44, 0x77, 0x20, 0x0c, 0xc8, 0xd8, 0x21, 0x01, 0x4f, 0xc2, 0x58, 0x0e,
-	0xa1, 0xda, 0xeb, 0x01, 0x19, 0x1f, 0x4a, 0xa0, 0xfe, 0x08, 0x6e, 0xfe, 0xe5, 0x81, 0xaa, 0x50,
-	0xbd, 0xa0, 0x97, 0x06, 0x90, 0xf3, 0x66, 0x25, 0xaa, 0xc1, 0xd5, 0x11, 0xf1, 0x13, 0x5a, 0xec,
-	0x20, 0xff, 0x39, 0x58, 0xd9, 0x07, 0x07, 0x95, 0xeb, 0x8f, 0xdb, 0xa0, 0x7e, 0xad, 0xc2, 0x55,
-	0xb9, 0x46, 0x84, 0x60, 0x25, 0x24, 0x01, 0x2d, 0xc4, 0xb2, 0xbe, 0xa5, 0x03, 0xf4, 0x0c, 0xea,
-	0x23, 0xe2, 0x33, 0xd7, 0x29, 0xb3, 0x96, 0xbb, 0xdb, 0xd8, 0xdb, 0x32, 0xf3, 0xc7, 0x60, 0x96,
-	0x8f, 0xc1, 0x6c, 0x15, 0x84, 0xa3, 0xb5, 0x6c, 0xca, 0x0f, 0xdf, 0xb6, 0x81, 0xfd, 0xbf, 0x94,
-	0x96, 0x07, 0xa8, 0x0b, 0x37, 0xfb, 0xc9, 0xe0, 0x82, 0xc6, 0x0b, 0xb3, 0xca, 0xf2, 0x66, 0x7a,
-	0xae, 0x9d, 0xbb, 0xf5, 0x61, 0x4d, 0x90, 0x98, 0x3a, 0x3e, 0x0b, 0x58, 0xec, 0x10, 0xdf, 0xe3,
-	0x82, 0xc5, 0x6f, 0x02, 0x63, 0xb5, 0x01, 0x9a, 0xfa, 0xde, 0xee, 0x92, 0x4f, 0xeb, 0xb0, 0xd4,
-	0xd9, 0x28, 0x73, 0xeb, 0x66, 0x66, 0x73, 0x0c, 0x3d, 0x85, 0xeb, 0xbc, 0x08, 0x33, 0x32, 0x34,
-	0x99, 0xff, 0xbd, 0xe5, 0xf3, 0xb7, 0x17, 0xe2, 0x3c, 0x8a, 0x9d, 0x87, 0x50, 0xff, 0xf3, 0x56,
-	0x54, 0x85, 0xff, 0x3d, 0xee, 0xbc, 0x6c, 0xb7, 0x9c, 0xb3, 0xce, 0x71, 0xab, 0x77, 0x56, 0x55,
-	0x10, 0x82, 0xba, 0xdd, 0xeb, 0x76, 0x3b, 0xc7, 0x4f, 0x4a, 0x0c, 0x1c, 0xed, 0x4f, 0x66, 0x58,
-	0x99, 0xce, 0xb0, 0xf2, 0x65, 0x86, 0x95, 0x9b, 0x19, 0x56, 0xde, 0xa5, 0x18, 0x7c, 0x4a, 0xb1,
-	0x32, 0x49, 0x31, 0x98, 0xa6, 0x18, 0x7c, 0x4f, 0x31, 0xf8, 0x99, 0x62, 0xe5, 0x26, 0xc5, 0xe0,
-	0xfd, 0x0f, 0xac, 0xbc, 0xd2, 0xf2, 0x96, 0xfa, 0x9a, 0x5c, 0xe9, 0x83, 0x5f, 0x01, 0x00, 0x00,
-	0xff, 0xff, 0x82, 0x98, 0x9b, 0xbf, 0x05, 0x04, 0x00, 0x00,
+	// 684 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xcd, 0x6e, 0xda, 0x4a,
+	0x14, 0xc6, 0xfc, 0x05, 0x0e, 0xb9, 0xc6, 0x77, 0xc4, 0xc2, 0x41, 0xba, 0x84, 0x9b, 0x4d, 0x51,
+	0x54, 0x99, 0x28, 0xdd, 0x54, 0x51, 0xbb, 0x08, 0xc1, 0x4d, 0x68, 0xa8, 0x49, 0x0d, 0x51, 0xda,
+	0x6e, 0xdc, 0x01, 0x4f, 0xa8, 0x15, 0xdb, 0x43, 0xc7, 0x63, 0x14, 0xf2, 0x04, 0x7d, 0x84, 0x2e,
+	0xbb, 0xac, 0xd4, 0x17, 0xc9, 0xb2, 0x4f, 0xd0, 0x2a, 0xdd, 0x57, 0xea, 0x23, 0x54, 0x1e, 0x63,
+	0x28, 0x91, 0xaa, 0x64, 0xc5, 0x99, 0xef, 0x9c, 0xef, 0xe3, 0x9c, 0xf3, 0x1d, 0xc3, 0x43, 0xcf,
+	0xb9, 0x24, 0xac, 0x89, 0x6d, 0x3c, 0xe1, 0x84, 0x35, 0x19, 0xb1, 0x9d, 0xe0, 0x7d, 0x48, 0x39,
+	0x6e, 0x8e, 0xa8, 0x7f, 0xee, 0x8c, 0xe7, 0x3f, 0xda, 0x84, 0x51, 0x4e, 0xd1, 0xc6, 0xbc, 0x4e,
+	0x5b, 0xd6, 0x69, 0x71, 0x41, 0xb5, 0x36, 0xa6, 0x74, 0xec, 0x92, 0xa6, 0x28, 0x1c, 0x86, 0xe7,
+	0x4d, 0x3b, 0x64, 0x98, 0x3b, 0xd4, 0x8f, 0xa9, 0xd5, 0xca, 0x98, 0x8e, 0xa9, 0x08, 0x9b, 0x51,
+	0x14, 0xa3, 0x5b, 0x3f, 0x0b, 0x90, 0x3f, 0xc1, 0x0c, 0x7b, 0x01, 0xd2, 0x21, 0x2f, 0x04, 0x03,
+	0x55, 0xaa, 0x67, 0x1a, 0xa5, 0xdd, 0x07, 0xda, 0x5f, 0xff, 0x4c, 0x8b, 0x29, 0xda, 0xcb, 0x08,
+	0x6b, 0x65, 0xaf, 0xbf, 0x6d, 0xa6, 0xcc, 0x39, 0x19, 0x35, 0x40, 0x11, 0xf5, 0x56, 0x40, 0xd8,
+	0x94, 0x30, 0x2b, 0x64, 0xae, 0x9a, 0xae, 0x4b, 0x8d, 0xa2, 0x29, 0x0b, 0xbc, 0x2f, 0xe0, 0x53,
+	0xe6, 0xa2, 0x1d, 0xa8, 0x8c, 0xa8, 0xef, 0x93, 0x51, 0xd4, 0xa5, 0x35, 0xa1, 0xd4, 0xb5, 0x02,
+	0xe7, 0x8a, 0xa8, 0x99, 0xba, 0xd4, 0xc8, 0x98, 0x68, 0x99, 0x3b, 0xa1, 0xd4, 0xed, 0x3b, 0x57,
+	0x04, 0xbd, 0x86, 0xb2, 0x4d, 0x26, 0x2e, 0x9d, 0x79, 0xc4, 0xe7, 0x16, 0x9f, 0x4d, 0x88, 0x9a,
+	0xad, 0x4b, 0x0d, 0x79, 0x77, 0xe7, 0xee, 0x5e, 0xdb, 0x0b, 0xe2, 0x60, 0x36, 0x21, 0xa6, 0x6c,
+	0xaf, 0xbc, 0xd1, 0x36, 0xfc, 0x7b, 0xbb, 0xed, 0x40, 0xcd, 0xd5, 0x33, 0x8d, 0xa2, 0x59, 0x5e,
+	0xed, 0x3b, 0x88, 0x1a, 0x0f, 0x88, 0xcf, 0x1d, 0x9f, 0xb8, 0x96, 0x87, 0x03, 0x4e, 0x98, 0xe5,
+	0x63, 0x8f, 0xa8, 0x79, 0x31, 0x26, 0x4a, 0x72, 0x2f, 0x44, 0xca, 0xc0, 0x1e, 0xa9, 0x7e, 0x95,
+	0xa0, 0xd0, 0x9b, 0x12, 0xc6, 0x1c, 0x9b, 0xa0, 0xb7, 0x00, 0xb6, 0xe3, 0x11, 0x3f, 0x70, 0xa8,
+	0x9f, 0x2c, 0x7b, 0xef, 0xee, 0x01, 0x12, 0xbe, 0xd6, 0x5e, 0x90, 0x75, 0x9f, 0xb3, 0xd9, 0x7c,
+	0xff, 0x7f, 0x68, 0xa2, 0xff, 0x00, 0x3c, 0x7c, 0x69, 0x61, 0x8f, 0x86, 0x3e, 0x17, 0xdb, 0xcf,
+	0x98, 0x45, 0x0f, 0x5f, 0xee, 0x0b, 0xa0, 0xfa, 0x14, 0xca, 0xb7, 0x34, 0x90, 0x02, 0x99, 0x0b,
+	0x32, 0x53, 0x25, 0x31, 0x41, 0x14, 0xa2, 0x0a, 0xe4, 0xa6, 0xd8, 0x0d, 0xc9, 0xdc, 0xbc, 0xf8,
+	0xb1, 0x97, 0x7e, 0x2c, 0xed, 0x65, 0x3f, 0x7c, 0xda, 0x94, 0xaa, 0x5f, 0x32, 0x90, 0x13, 0xfe,
+	0x23, 0x04, 0x59, 0x31, 0x7e, 0x4c, 0x16, 0xf1, 0x1d, 0x1d, 0xa0, 0xe7, 0x20, 0x4f, 0xb1, 0xeb,
+	0xd8, 0x56, 0x72, 0xa4, 0xc2, 0xf4, 0xd2, 0xee, 0x86, 0x16, 0x5f, 0xb1, 0x96, 0x5c, 0xb1, 0xd6,
+	0x9e, 0x17, 0xb4, 0x0a, 0xd1, 0x94, 0x1f, 0xbf, 0x6f, 0x4a, 0xe6, 0x3f, 0x82, 0x9a, 0x24, 0x50,
+	0x17, 0xca, 0xc3, 0x70, 0x74, 0x41, 0xf8, 0x52, 0x2c, 0x7b, 0x7f, 0x31, 0x39, 0xe6, 0x2e, 0xd4,
+	0x86, 0x50, 0x61, 0x98, 0x13, 0xcb, 0x75, 0x3c, 0x87, 0x5b, 0xd8, 0x1d, 0x53, 0xe6, 0xf0, 0x77,
+	0x9e, 0x9a, 0xbb, 0xef, 0x9d, 0x89, 0x9d, 0xec, 0x27, 0x3c, 0x13, 0x45, 0x6a, 0xdd, 0x48, 0x6c,
+	0x81, 0xa1, 0x23, 0x28, 0xd2, 0xb9, 0x99, 0x81, 0x9a, 0x17, 0xfe, 0x6f, 0xdf, 0xdf, 0x7f, 0x73,
+	0x49, 0x46, 0xff, 0xc3, 0xfa, 0x30, 0x64, 0x01, 0x4f, 0x16, 0xbd, 0x26, 0x16, 0x5d, 0x12, 0x58,
+	0xbc, 0xea, 0xd8, 0xad, 0xad, 0x23, 0x90, 0x57, 0x1b, 0x43, 0x0a, 0xac, 0x3f, 0xeb, 0xbc, 0xd2,
+	0xdb, 0xd6, 0x59, 0xc7, 0x68, 0xf7, 0xce, 0x94, 0x14, 0x42, 0x20, 0x9b, 0xbd, 0x6e, 0xb7, 0x63,
+	0x1c, 0x26, 0x98, 0x14, 0x55, 0x0d, 0x7a, 0xc7, 0xba, 0x61, 0xb5, 0x4e, 0x0f, 0x8e, 0xf5, 0x81,
+	0x92, 0xde, 0x7a, 0x02, 0xf2, 0xea, 0xa7, 0x84, 0xca, 0x50, 0xea, 0x77, 0x8c, 0xc3, 0xae, 0x6e,
+	0x19, 0xbd, 0xb6, 0xae, 0xa4, 0x50, 0x09, 0xd6, 0x0e, 0xba, 0xa7, 0xfd, 0x81, 0x6e, 0x2a, 0x12,
+	0x5a, 0x87, 0x42, 0x5f, 0x37, 0x06, 0x1d, 0x43, 0xef, 0x2a, 0xe9, 0x56, 0xe5, 0xfa, 0xa6, 0x96,
+	0xfa, 0x75, 0x53, 0x4b, 0x7d, 0xfe, 0x51, 0x4b, 0xbd, 0xc9, 0xc7, 0x13, 0x0e, 0xf3, 0xc2, 0xa1,
+	0x47, 0xbf, 0x07, 0x00, 0x78, 0xad, 0xd2, 0xf5, 0x0d, 0x05, 0x00, 0x00,
 }
@@ -40,6 +40,7 @@ var (
 	rateLimitingLUAScripts = map[config.Params_QuotaAlgorithm]string{
 		config.FIXED_WINDOW:   luaFixedWindow,
 		config.ROLLING_WINDOW: luaRollingWindow,
+		config.TOKEN_BUCKET:   luaTokenBucket,
 	}
 )
 
@@ -51,8 +52,8 @@ type (
 
 	handler struct {
 		// go-redis client
-		// connection pool with redis
-		client *redis.Client
+		// connection pool with redis, or with the nodes of a redis cluster
+		client redis.UniversalClient
 
 		// the limits we know about
 		limits map[string]*config.Params_Quota
@@ -63,6 +64,9 @@ type (
 		// list of algorithm LUA scripts
 		scripts map[config.Params_QuotaAlgorithm]*redis.Script
 
+		// if true, the keys of a quota are hash tagged to map to a single redis cluster slot
+		hashTags bool
+
 		// indirection to support fast deterministic tests
 		getTime func() time.Time
 
@@ -104,6 +108,23 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 			continue
 		}
 
+		if _, ok := rateLimitingLUAScripts[quotas.RateLimitAlgorithm]; !ok {
+			ce = ce.Appendf("rate_limit_algorithm", "quotas.rate_limit_algorithm %v is not supported", quotas.RateLimitAlgorithm)
+			continue
+		}
+
+		if quotas.RateLimitAlgorithm == config.TOKEN_BUCKET {
+			if quotas.MaxAmount <= 0 {
+				ce = ce.Appendf("max_amount", "quotas.max_amount should be > 0 for TOKEN_BUCKET algorithm")
+				continue
+			}
+
+			if quotas.BurstAmount < 0 {
+				ce = ce.Appendf("burst_amount", "quotas.burst_amount should be >= 0 for TOKEN_BUCKET algorithm")
+				continue
+			}
+		}
+
 		if quotas.RateLimitAlgorithm == config.ROLLING_WINDOW {
 			if quotas.BucketDuration == 0 {
 				ce = ce.Appendf("bucket_duration", "quotas.bucket_duration should be > 0 for ROLLING_WINDOW algorithm")
@@ -143,27 +164,36 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 			b.adapterConfig.ConnectionPoolSize)
 	}
 
-	if len(b.adapterConfig.RedisServerUrl) == 0 {
-		ce = ce.Appendf("redis_server_url", "redis_server_url should not be empty")
-	}
-
-	// test redis connection
-	option := redis.Options{
-		Addr: b.adapterConfig.RedisServerUrl,
-	}
+	switch b.adapterConfig.DeploymentType {
+	case config.SINGLE_NODE:
+		if len(b.adapterConfig.RedisServerUrl) == 0 {
+			ce = ce.Appendf("redis_server_url", "redis_server_url should not be empty")
+		}
+	case config.CLUSTER, config.SENTINEL:
+		if len(b.adapterConfig.RedisServerUrls) == 0 {
+			ce = ce.Appendf("redis_server_urls", "redis_server_urls should not be empty for %v deployment",
+				b.adapterConfig.DeploymentType)
+			return
+		}
 
-	if b.adapterConfig.ConnectionPoolSize > 0 {
-		option.PoolSize = int(b.adapterConfig.ConnectionPoolSize)
+		if b.adapterConfig.DeploymentType == config.SENTINEL && len(b.adapterConfig.SentinelMasterName) == 0 {
+			ce = ce.Appendf("sentinel_master_name", "sentinel_master_name should not be empty for SENTINEL deployment")
+			return
+		}
+	default:
+		ce = ce.Appendf("deployment_type", "deployment_type %v is not supported", b.adapterConfig.DeploymentType)
+		return
 	}
 
-	client := redis.NewClient(&option)
+	// test redis connection
+	client := newClient(b.adapterConfig)
 	if _, err := client.Ping().Result(); err != nil {
 		ce = ce.Appendf(info.Name, "could not create a connection to redis server: %v", err)
 		return
 	}
 
 	// check scripts loading to redis
-	scripts := make(map[config.Params_QuotaAlgorithm]*redis.Script, 2)
+	scripts := make(map[config.Params_QuotaAlgorithm]*redis.Script, len(rateLimitingLUAScripts))
 	for algorithm, script := range rateLimitingLUAScripts {
 		scripts[algorithm] = redis.NewScript(script)
 		if _, err := scripts[algorithm].Load(client).Result(); err != nil {
@@ -176,6 +206,33 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 	return
 }
 
+// newClient returns a client for the redis deployment described by the configuration.
+func newClient(cfg *config.Params) redis.UniversalClient {
+	poolSize := 0
+	if cfg.ConnectionPoolSize > 0 {
+		poolSize = int(cfg.ConnectionPoolSize)
+	}
+
+	switch cfg.DeploymentType {
+	case config.CLUSTER:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    cfg.RedisServerUrls,
+			PoolSize: poolSize,
+		})
+	case config.SENTINEL:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    cfg.SentinelMasterName,
+			SentinelAddrs: cfg.RedisServerUrls,
+			PoolSize:      poolSize,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisServerUrl,
+			PoolSize: poolSize,
+		})
+	}
+}
+
 // getOverrideHash returns hash key of the given dimension in sorted by key
 func getDimensionHash(dimensions map[string]string) string {
 	var keys []string
@@ -207,21 +264,13 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 	}
 
 	// initialize redis client
-	option := redis.Options{
-		Addr: b.adapterConfig.RedisServerUrl,
-	}
-
-	if b.adapterConfig.ConnectionPoolSize > 0 {
-		option.PoolSize = int(b.adapterConfig.ConnectionPoolSize)
-	}
-
-	client := redis.NewClient(&option)
+	client := newClient(b.adapterConfig)
 	if _, err := client.Ping().Result(); err != nil {
 		return nil, fmt.Errorf("could not create a connection to redis server: %v", err)
 	}
 
 	// load scripts into redis
-	scripts := make(map[config.Params_QuotaAlgorithm]*redis.Script, 2)
+	scripts := make(map[config.Params_QuotaAlgorithm]*redis.Script, len(rateLimitingLUAScripts))
 	for algorithm, script := range rateLimitingLUAScripts {
 		scripts[algorithm] = redis.NewScript(script)
 	}
@@ -233,6 +282,7 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 		logger:        env.Logger(),
 		getTime:       time.Now,
 		dimensionHash: dimensionHash,
+		hashTags:      b.adapterConfig.DeploymentType == config.CLUSTER,
 	}
 
 	return h, nil
@@ -322,20 +372,23 @@ func (h *handler) HandleQuota(context context.Context, instance *quota.Instance,
 				h.logger.Infof("key: %v maxAmount: %v", key, maxAmount)
 			}
 
+			burstAmount := maxAmount
+			if limit.BurstAmount > 0 {
+				burstAmount = limit.BurstAmount
+			}
+
 			// execute lua algorithm script
 			result, err := script.Run(
 				h.client,
-				[]string{
-					key + ".meta", // KEY[1]
-					key + ".data", // KEY[2]
-				},
-				maxAmount, // ARGV[1] credit
+				h.scriptKeys(key),                       // KEY[1] meta, KEY[2] data
+				maxAmount,                               // ARGV[1] credit
 				limit.GetValidDuration().Nanoseconds(),  // ARGV[2] window length
 				limit.GetBucketDuration().Nanoseconds(), // ARGV[3] bucket length
 				args.BestEffort,                         // ARGV[4] best effort
 				args.QuotaAmount,                        // ARGV[5] token
 				now.UnixNano(),                          // ARGV[6] timestamp
-				args.DeduplicationID,                    // ARGS[8] deduplication id
+				args.DeduplicationID,                    // ARGV[7] deduplication id
+				burstAmount,                             // ARGV[8] bucket capacity
 			).Result()
 
 			if err != nil {
@@ -364,6 +417,17 @@ func (h *handler) HandleQuota(context context.Context, instance *quota.Instance,
 	return adapter.QuotaResult{}, nil
 }
 
+// scriptKeys returns the redis keys of a quota key. In a redis cluster, the key is used as a
+// hash tag so that all the keys of a quota, including the deduplication keys derived from it by
+// the scripts, map to the same slot, as scripts can only access the keys of a single slot. The
+// other deployments keep the keys of the previous releases, so that the counters survive upgrades.
+func (h *handler) scriptKeys(key string) []string {
+	if h.hashTags {
+		key = "{" + key + "}"
+	}
+	return []string{key + ".meta", key + ".data"}
+}
+
 func (h handler) Close() error {
 	return h.client.Close()
 }
@@ -19,6 +19,8 @@ package redisquota
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -50,6 +52,14 @@ func TestBuilderValidate(t *testing.T) {
 	}
 	defer mockRedis.Close()
 
+	validQuotas := []config.Params_Quota{
+		{
+			Name:          "fixed-window",
+			MaxAmount:     10,
+			ValidDuration: time.Minute,
+		},
+	}
+
 	cases := map[string]struct {
 		quotaTypes map[string]*quota.Type
 		config     *config.Params
@@ -240,6 +250,120 @@ func TestBuilderValidate(t *testing.T) {
 				"dimensions: quotas.overrides.dimensions is empty",
 			},
 		},
+		"Unsupported quota.rate_limit_algorithm": {
+			quotaTypes: map[string]*quota.Type{
+				"unknown": {},
+			},
+			config: &config.Params{
+				RedisServerUrl: mockRedis.Addr(),
+				Quotas: []config.Params_Quota{
+					{
+						Name:               "unknown",
+						MaxAmount:          10,
+						ValidDuration:      time.Minute,
+						RateLimitAlgorithm: config.Params_QuotaAlgorithm(10),
+					},
+				},
+			},
+			errMsg: []string{
+				"rate_limit_algorithm: quotas.rate_limit_algorithm 10 is not supported",
+			},
+		},
+		"Token bucket invalid quota.max_amount": {
+			quotaTypes: map[string]*quota.Type{
+				"token-bucket": {},
+			},
+			config: &config.Params{
+				RedisServerUrl: mockRedis.Addr(),
+				Quotas: []config.Params_Quota{
+					{
+						Name:               "token-bucket",
+						ValidDuration:      time.Minute,
+						RateLimitAlgorithm: config.TOKEN_BUCKET,
+					},
+				},
+			},
+			errMsg: []string{
+				"max_amount: quotas.max_amount should be > 0 for TOKEN_BUCKET algorithm",
+			},
+		},
+		"Token bucket invalid quota.burst_amount": {
+			quotaTypes: map[string]*quota.Type{
+				"token-bucket": {},
+			},
+			config: &config.Params{
+				RedisServerUrl: mockRedis.Addr(),
+				Quotas: []config.Params_Quota{
+					{
+						Name:               "token-bucket",
+						MaxAmount:          10,
+						ValidDuration:      time.Minute,
+						BurstAmount:        -1,
+						RateLimitAlgorithm: config.TOKEN_BUCKET,
+					},
+				},
+			},
+			errMsg: []string{
+				"burst_amount: quotas.burst_amount should be >= 0 for TOKEN_BUCKET algorithm",
+			},
+		},
+		"Valid token bucket configuration": {
+			quotaTypes: map[string]*quota.Type{
+				"token-bucket": {},
+			},
+			config: &config.Params{
+				RedisServerUrl: mockRedis.Addr(),
+				Quotas: []config.Params_Quota{
+					{
+						Name:               "token-bucket",
+						MaxAmount:          10,
+						ValidDuration:      time.Minute,
+						BurstAmount:        20,
+						RateLimitAlgorithm: config.TOKEN_BUCKET,
+					},
+				},
+			},
+			errMsg: []string{},
+		},
+		"Empty cluster redis server urls": {
+			config: &config.Params{
+				DeploymentType: config.CLUSTER,
+				Quotas:         validQuotas,
+			},
+			errMsg: []string{
+				"redis_server_urls: redis_server_urls should not be empty for CLUSTER deployment",
+			},
+		},
+		"Empty sentinel redis server urls": {
+			config: &config.Params{
+				DeploymentType:     config.SENTINEL,
+				SentinelMasterName: "mymaster",
+				Quotas:             validQuotas,
+			},
+			errMsg: []string{
+				"redis_server_urls: redis_server_urls should not be empty for SENTINEL deployment",
+			},
+		},
+		"Empty sentinel master name": {
+			config: &config.Params{
+				DeploymentType:  config.SENTINEL,
+				RedisServerUrls: []string{mockRedis.Addr()},
+				Quotas:          validQuotas,
+			},
+			errMsg: []string{
+				"sentinel_master_name: sentinel_master_name should not be empty for SENTINEL deployment",
+			},
+		},
+		"Unsupported deployment type": {
+			config: &config.Params{
+				DeploymentType: config.Params_DeploymentType(10),
+				RedisServerUrl: mockRedis.Addr(),
+				Quotas:         validQuotas,
+			},
+			errMsg: []string{
+				"deployment_type: deployment_type 10 is not supported",
+			},
+		},
 	}
 
 	info := GetInfo()
@@ -426,6 +550,61 @@ func TestHandleQuota(t *testing.T) {
 				{false, 7, 200, 7, 100, ""},
 			},
 		},
+		"algorithm = token bucket, best effort = true": {
+			quotaType: map[string]*quota.Type{
+				"token_bucket_best_effort": {},
+			},
+			quotaConfig: []config.Params_Quota{
+				{
+					Name:               "token_bucket_best_effort",
+					MaxAmount:          10,
+					ValidDuration:      time.Second * time.Duration(10),
+					BurstAmount:        5,
+					RateLimitAlgorithm: config.TOKEN_BUCKET,
+				},
+			},
+			instance: quota.Instance{
+				Name:       "token_bucket_best_effort",
+				Dimensions: map[string]interface{}{},
+			},
+			request: []RequestInfo{
+				{true, 3, 0, 3, 3, ""},
+				{true, 3, 0, 2, 2, ""},
+				{true, 3, 0, 0, 0, ""},
+				{true, 3, 1, 1, 1, "test"}, // record current response
+				{true, 3, 1, 1, 1, "test"}, // from the previous response
+				{true, 3, 2, 1, 1, ""},
+				{true, 6, 30, 5, 5, ""}, // refill is capped by the burst amount
+				{true, 1, 30, 0, 0, ""},
+			},
+		},
+		"algorithm = token bucket, best effort = false": {
+			quotaType: map[string]*quota.Type{
+				"token_bucket": {},
+			},
+			quotaConfig: []config.Params_Quota{
+				{
+					Name:               "token_bucket",
+					MaxAmount:          10,
+					ValidDuration:      time.Second * time.Duration(10),
+					RateLimitAlgorithm: config.TOKEN_BUCKET,
+				},
+			},
+			instance: quota.Instance{
+				Name:       "token_bucket",
+				Dimensions: map[string]interface{}{},
+			},
+			request: []RequestInfo{
+				{false, 8, 0, 8, 8, ""},
+				{false, 3, 0, 0, 0, ""},
+				{false, 2, 0, 2, 2, ""},
+				{false, 1, 0, 0, 0, ""},
+				{false, 3, 3, 3, 3, ""},
+				{false, 1, 3, 0, 0, ""},
+				{false, 11, 100, 0, 0, ""}, // bucket holds max_amount tokens by default
+				{false, 10, 100, 10, 10, ""},
+			},
+		},
 		"algorithm = rolling window, best effort = false, limit override": {
 			quotaType: map[string]*quota.Type{
 				"rolling_window_override": {},
@@ -655,6 +834,152 @@ func TestHandleQuotaErrorMsg(t *testing.T) {
 	}
 }
 
+// newFakeClusterNode returns a redis cluster node that assigns all the slots to the given redis server.
+func newFakeClusterNode(t *testing.T, redis *miniredis.Miniredis) *server.Server {
+	s, err := server.NewServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(redis.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Register("CLUSTER", func(c *server.Peer, cmd string, args []string) {
+		c.WriteLen(1)
+		c.WriteLen(3)
+		c.WriteInt(0)
+		c.WriteInt(16383)
+		c.WriteLen(2)
+		c.WriteBulk(redis.Host())
+		c.WriteInt(port)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// newFakeSentinel returns a redis sentinel that reports the given redis server as the master.
+func newFakeSentinel(t *testing.T, redis *miniredis.Miniredis) *server.Server {
+	s, err := server.NewServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Register("SENTINEL", func(c *server.Peer, cmd string, args []string) {
+		if len(args) == 2 && strings.ToLower(args[0]) == "get-master-addr-by-name" && args[1] == "mymaster" {
+			c.WriteLen(2)
+			c.WriteBulk(redis.Host())
+			c.WriteBulk(redis.Port())
+			return
+		}
+		c.WriteLen(0)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Register("SUBSCRIBE", func(c *server.Peer, cmd string, args []string) {
+		for idx, channel := range args {
+			c.WriteLen(3)
+			c.WriteBulk("subscribe")
+			c.WriteBulk(channel)
+			c.WriteInt(idx + 1)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestDeploymentType(t *testing.T) {
+	mockRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Unable to start mock redis server: %v", err)
+	}
+	defer mockRedis.Close()
+
+	clusterNode := newFakeClusterNode(t, mockRedis)
+	defer clusterNode.Close()
+
+	sentinel := newFakeSentinel(t, mockRedis)
+	defer sentinel.Close()
+
+	cases := map[string]*config.Params{
+		"single node": {
+			RedisServerUrl: mockRedis.Addr(),
+		},
+		"cluster": {
+			DeploymentType:  config.CLUSTER,
+			RedisServerUrls: []string{clusterNode.Addr().String()},
+		},
+		"sentinel": {
+			DeploymentType:     config.SENTINEL,
+			RedisServerUrls:    []string{sentinel.Addr().String()},
+			SentinelMasterName: "mymaster",
+		},
+	}
+
+	info := GetInfo()
+
+	for id, cfg := range cases {
+		mockRedis.FlushAll()
+
+		cfg.ConnectionPoolSize = 1
+		cfg.Quotas = []config.Params_Quota{
+			{
+				Name:               "token_bucket",
+				MaxAmount:          10,
+				ValidDuration:      time.Second * time.Duration(10),
+				RateLimitAlgorithm: config.TOKEN_BUCKET,
+			},
+		}
+
+		b := info.NewBuilder().(*builder)
+		b.SetAdapterConfig(cfg)
+		b.SetQuotaTypes(map[string]*quota.Type{"token_bucket": {}})
+
+		if ce := b.Validate(); ce != nil {
+			t.Errorf("%v: Validate() failed: %v", id, ce)
+			continue
+		}
+
+		adapterHandler, err := b.Build(context.Background(), test.NewEnv(t))
+		if err != nil {
+			t.Errorf("%v: Build() failed: %v", id, err)
+			continue
+		}
+
+		qr, err := adapterHandler.(*handler).HandleQuota(context.Background(), &quota.Instance{Name: "token_bucket"}, adapter.QuotaArgs{
+			QuotaAmount:     4,
+			DeduplicationID: "test",
+		})
+		if err != nil {
+			t.Errorf("%v: Unexpected error %v", id, err)
+		}
+
+		if qr.Amount != 4 {
+			t.Errorf("%v: Expecting token 4, got %d", id, qr.Amount)
+		}
+
+		// in a cluster, all keys of a quota share a hash tag, and thus a slot; other
+		// deployments keep the keys of the previous releases
+		keys := []string{"token_bucket.meta", "test-token_bucket.meta"}
+		if cfg.DeploymentType == config.CLUSTER {
+			keys = []string{"{token_bucket}.meta", "test-{token_bucket}.meta"}
+		}
+		for _, key := range keys {
+			if !mockRedis.Exists(key) {
+				t.Errorf("%v: Expecting key %s, got %v", id, key, mockRedis.Keys())
+			}
+		}
+
+		_ = adapterHandler.Close()
+	}
+}
+
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -0,0 +1,96 @@
+// Copyright 2018 Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisquota
+
+const (
+	// LUA script for token bucket
+	luaTokenBucket = `
+local key_meta = KEYS[1]
+local key_data = KEYS[2]
+
+local credit = tonumber(ARGV[1])
+local windowLength = tonumber(ARGV[2])
+local bucketLength = tonumber(ARGV[3])
+local bestEffort = tonumber(ARGV[4])
+local token = tonumber(ARGV[5])
+local timestamp = tonumber(ARGV[6])
+local deduplicationid = ARGV[7]
+local burst = tonumber(ARGV[8])
+
+-- lookup previous response for the deduplicationid and returns if it is still valid
+--------------------------------------------------------------------------------
+if (deduplicationid or '') ~= '' then
+	local previous_token = tonumber(redis.call("HGET", deduplicationid .. "-" .. key_meta, "token"))
+	local previous_expire = tonumber(redis.call("HGET", deduplicationid .. "-" .. key_meta, "expire"))
+
+	if previous_token and previous_expire then
+		if timestamp < previous_expire then
+			return {previous_token, previous_expire - timestamp}
+		end
+	end
+end
+
+-- read or initialize meta information, a new bucket starts full
+--------------------------------------------------------------------------------
+local info_token = tonumber(redis.call("HGET", key_meta, "token"))
+local info_timestamp = tonumber(redis.call("HGET", key_meta, "timestamp"))
+
+if not info_token or not info_timestamp then
+  info_token = burst
+  info_timestamp = timestamp
+end
+
+-- refill the bucket with the tokens accrued since the last update
+--------------------------------------------------------------------------------
+if timestamp > info_timestamp then
+  info_token = math.min(burst, info_token + (timestamp - info_timestamp) * credit / windowLength)
+  info_timestamp = timestamp
+end
+
+-- take tokens from the bucket
+--------------------------------------------------------------------------------
+local allocated = 0
+if info_token >= token then
+  allocated = token
+elseif bestEffort == 1 then
+  allocated = math.floor(info_token)
+end
+
+info_token = info_token - allocated
+redis.call("HMSET", key_meta, "token", info_token, "timestamp", info_timestamp)
+
+-- a full bucket is the same as a missing one, set the expiration time for
+-- automatic cleanup once the bucket is refilled
+--------------------------------------------------------------------------------
+redis.call("PEXPIRE", key_meta, math.ceil((burst - info_token) * windowLength / credit / 1000000) + 1)
+
+if allocated <= 0 then
+  -- not enough token
+  return {0, 0}
+end
+
+-- allocated token are replenished after valid_duration
+--------------------------------------------------------------------------------
+local valid_duration = math.ceil(allocated * windowLength / credit)
+
+-- save current request and set expiration time for auto cleanup
+if (deduplicationid or '') ~= '' then
+	redis.call("HMSET", deduplicationid .. "-" .. key_meta, "token", allocated, "expire", timestamp + valid_duration)
+	redis.call("PEXPIRE", deduplicationid .. "-" .. key_meta, math.ceil(valid_duration / 1000000))
+end
+
+return {allocated, valid_duration}
+`
+)
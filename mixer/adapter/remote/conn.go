@@ -0,0 +1,100 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync/atomic"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"istio.io/istio/mixer/adapter/remote/config"
+)
+
+// connPool is a fixed set of client connections to a single adapter
+// endpoint. Calls are spread over the connections in a round-robin fashion.
+type connPool struct {
+	conns []*grpc.ClientConn
+	next  uint32
+}
+
+// newConnPool dials size connections to address. Dialing does not block, so
+// the pool can be created while the adapter is still unreachable.
+func newConnPool(address string, size int, opts ...grpc.DialOption) (*connPool, error) {
+	p := &connPool{conns: make([]*grpc.ClientConn, 0, size)}
+	for i := 0; i < size; i++ {
+		conn, err := grpc.Dial(address, opts...)
+		if err != nil {
+			_ = p.Close()
+			return nil, fmt.Errorf("unable to connect to adapter at %s: %v", address, err)
+		}
+		p.conns = append(p.conns, conn)
+	}
+	return p, nil
+}
+
+// get returns the next connection to use.
+func (p *connPool) get() *grpc.ClientConn {
+	n := atomic.AddUint32(&p.next, 1)
+	return p.conns[n%uint32(len(p.conns))]
+}
+
+// Close closes every connection of the pool.
+func (p *connPool) Close() error {
+	var result *multierror.Error
+	for _, conn := range p.conns {
+		if err := conn.Close(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// transportOption returns the dial option that secures connections as described
+// by cfg. A nil cfg yields plaintext connections.
+func transportOption(cfg *config.Params_TLS) (grpc.DialOption, error) {
+	if cfg == nil {
+		return grpc.WithInsecure(), nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.ServerName}
+
+	if cfg.CaCertificates != "" {
+		pem, err := ioutil.ReadFile(cfg.CaCertificates)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificates: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid CA certificates found in %s", cfg.CaCertificates)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCertificate != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertificate, cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	model "istio.io/api/mixer/adapter/model/v1beta1"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/template/apikey"
+	"istio.io/istio/mixer/template/authorization"
+	"istio.io/istio/mixer/template/checknothing"
+	"istio.io/istio/mixer/template/listentry"
+	"istio.io/istio/mixer/template/logentry"
+	"istio.io/istio/mixer/template/metric"
+	"istio.io/istio/mixer/template/quota"
+	"istio.io/istio/mixer/template/reportnothing"
+)
+
+// This file converts the instances Mixer hands to the adapter into the
+// InstanceMsg types of the per-template gRPC services.
+
+// encodeValue converts a dynamically typed instance field into a Value message.
+// A nil input produces a nil Value.
+func encodeValue(v interface{}) (*model.Value, error) {
+	switch vv := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &model.Value{Value: &model.Value_StringValue{StringValue: vv}}, nil
+	case int64:
+		return &model.Value{Value: &model.Value_Int64Value{Int64Value: vv}}, nil
+	case float64:
+		return &model.Value{Value: &model.Value_DoubleValue{DoubleValue: vv}}, nil
+	case bool:
+		return &model.Value{Value: &model.Value_BoolValue{BoolValue: vv}}, nil
+	case net.IP:
+		return &model.Value{Value: &model.Value_IpAddressValue{IpAddressValue: &model.IPAddress{Value: vv}}}, nil
+	case []byte:
+		return &model.Value{Value: &model.Value_IpAddressValue{IpAddressValue: &model.IPAddress{Value: vv}}}, nil
+	case time.Time:
+		ts, err := encodeTimestamp(vv)
+		if err != nil {
+			return nil, err
+		}
+		return &model.Value{Value: &model.Value_TimestampValue{TimestampValue: ts}}, nil
+	case time.Duration:
+		return &model.Value{Value: &model.Value_DurationValue{DurationValue: &model.Duration{Value: types.DurationProto(vv)}}}, nil
+	case adapter.EmailAddress:
+		return &model.Value{Value: &model.Value_EmailAddressValue{EmailAddressValue: &model.EmailAddress{Value: string(vv)}}}, nil
+	case adapter.DNSName:
+		return &model.Value{Value: &model.Value_DnsNameValue{DnsNameValue: &model.DNSName{Value: string(vv)}}}, nil
+	case adapter.URI:
+		return &model.Value{Value: &model.Value_UriValue{UriValue: &model.Uri{Value: string(vv)}}}, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// encodeValueMap converts a map of dynamically typed instance fields. Entries
+// holding nil are dropped.
+func encodeValueMap(m map[string]interface{}) (map[string]*model.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]*model.Value, len(m))
+	for k, v := range m {
+		if v == nil {
+			continue
+		}
+		val, err := encodeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		result[k] = val
+	}
+	return result, nil
+}
+
+func encodeTimestamp(t time.Time) (*model.TimeStamp, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	ts, err := types.TimestampProto(t)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TimeStamp{Value: ts}, nil
+}
+
+func encodeAPIKey(inst *apikey.Instance) (*apikey.InstanceMsg, error) {
+	ts, err := encodeTimestamp(inst.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: timestamp: %v", inst.Name, err)
+	}
+	return &apikey.InstanceMsg{
+		Name:         inst.Name,
+		Api:          inst.Api,
+		ApiVersion:   inst.ApiVersion,
+		ApiOperation: inst.ApiOperation,
+		ApiKey:       inst.ApiKey,
+		Timestamp:    ts,
+	}, nil
+}
+
+func encodeAuthorization(inst *authorization.Instance) (*authorization.InstanceMsg, error) {
+	msg := &authorization.InstanceMsg{Name: inst.Name}
+	if s := inst.Subject; s != nil {
+		props, err := encodeValueMap(s.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s: subject properties: %v", inst.Name, err)
+		}
+		msg.Subject = &authorization.SubjectMsg{
+			User:       s.User,
+			Groups:     s.Groups,
+			Properties: props,
+		}
+	}
+	if a := inst.Action; a != nil {
+		props, err := encodeValueMap(a.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s: action properties: %v", inst.Name, err)
+		}
+		msg.Action = &authorization.ActionMsg{
+			Namespace:  a.Namespace,
+			Service:    a.Service,
+			Method:     a.Method,
+			Path:       a.Path,
+			Properties: props,
+		}
+	}
+	return msg, nil
+}
+
+func encodeCheckNothing(inst *checknothing.Instance) *checknothing.InstanceMsg {
+	return &checknothing.InstanceMsg{Name: inst.Name}
+}
+
+func encodeListEntry(inst *listentry.Instance) *listentry.InstanceMsg {
+	return &listentry.InstanceMsg{Name: inst.Name, Value: inst.Value}
+}
+
+func encodeLogEntry(inst *logentry.Instance) (*logentry.InstanceMsg, error) {
+	vars, err := encodeValueMap(inst.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: variables: %v", inst.Name, err)
+	}
+	ts, err := encodeTimestamp(inst.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: timestamp: %v", inst.Name, err)
+	}
+	dims, err := encodeValueMap(inst.MonitoredResourceDimensions)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: monitored resource dimensions: %v", inst.Name, err)
+	}
+	return &logentry.InstanceMsg{
+		Name:                        inst.Name,
+		Variables:                   vars,
+		Timestamp:                   ts,
+		Severity:                    inst.Severity,
+		MonitoredResourceType:       inst.MonitoredResourceType,
+		MonitoredResourceDimensions: dims,
+	}, nil
+}
+
+func encodeMetric(inst *metric.Instance) (*metric.InstanceMsg, error) {
+	val, err := encodeValue(inst.Value)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: value: %v", inst.Name, err)
+	}
+	dims, err := encodeValueMap(inst.Dimensions)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: dimensions: %v", inst.Name, err)
+	}
+	mrDims, err := encodeValueMap(inst.MonitoredResourceDimensions)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: monitored resource dimensions: %v", inst.Name, err)
+	}
+	return &metric.InstanceMsg{
+		Name:                        inst.Name,
+		Value:                       val,
+		Dimensions:                  dims,
+		MonitoredResourceType:       inst.MonitoredResourceType,
+		MonitoredResourceDimensions: mrDims,
+	}, nil
+}
+
+func encodeQuota(inst *quota.Instance) (*quota.InstanceMsg, error) {
+	dims, err := encodeValueMap(inst.Dimensions)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: dimensions: %v", inst.Name, err)
+	}
+	return &quota.InstanceMsg{Name: inst.Name, Dimensions: dims}, nil
+}
+
+func encodeReportNothing(inst *reportnothing.Instance) *reportnothing.InstanceMsg {
+	return &reportnothing.InstanceMsg{Name: inst.Name}
+}
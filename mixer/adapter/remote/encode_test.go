@@ -0,0 +1,84 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	model "istio.io/api/mixer/adapter/model/v1beta1"
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+func TestEncodeValue(t *testing.T) {
+	ts := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	tsProto, _ := types.TimestampProto(ts)
+
+	cases := []struct {
+		in   interface{}
+		want *model.Value
+	}{
+		{nil, nil},
+		{"abc", &model.Value{Value: &model.Value_StringValue{StringValue: "abc"}}},
+		{int64(42), &model.Value{Value: &model.Value_Int64Value{Int64Value: 42}}},
+		{1.5, &model.Value{Value: &model.Value_DoubleValue{DoubleValue: 1.5}}},
+		{true, &model.Value{Value: &model.Value_BoolValue{BoolValue: true}}},
+		{net.ParseIP("10.0.0.1"), &model.Value{Value: &model.Value_IpAddressValue{IpAddressValue: &model.IPAddress{Value: net.ParseIP("10.0.0.1")}}}},
+		{[]byte{10, 0, 0, 1}, &model.Value{Value: &model.Value_IpAddressValue{IpAddressValue: &model.IPAddress{Value: []byte{10, 0, 0, 1}}}}},
+		{ts, &model.Value{Value: &model.Value_TimestampValue{TimestampValue: &model.TimeStamp{Value: tsProto}}}},
+		{time.Second, &model.Value{Value: &model.Value_DurationValue{DurationValue: &model.Duration{Value: types.DurationProto(time.Second)}}}},
+		{adapter.EmailAddress("a@b.com"), &model.Value{Value: &model.Value_EmailAddressValue{EmailAddressValue: &model.EmailAddress{Value: "a@b.com"}}}},
+		{adapter.DNSName("b.com"), &model.Value{Value: &model.Value_DnsNameValue{DnsNameValue: &model.DNSName{Value: "b.com"}}}},
+		{adapter.URI("http://b.com"), &model.Value{Value: &model.Value_UriValue{UriValue: &model.Uri{Value: "http://b.com"}}}},
+	}
+
+	for _, c := range cases {
+		got, err := encodeValue(c.in)
+		if err != nil {
+			t.Errorf("encodeValue(%v): got error %v, expecting success", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("encodeValue(%v): got %v, expected %v", c.in, got, c.want)
+		}
+	}
+
+	if _, err := encodeValue(map[string]string{}); err == nil {
+		t.Error("encodeValue(map): got success, expecting error")
+	}
+}
+
+func TestEncodeValueMap(t *testing.T) {
+	got, err := encodeValueMap(map[string]interface{}{"a": "b", "nil": nil})
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	want := map[string]*model.Value{"a": {Value: &model.Value_StringValue{StringValue: "b"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, expected %v", got, want)
+	}
+
+	if got, err = encodeValueMap(nil); got != nil || err != nil {
+		t.Errorf("Got %v, %v, expecting nil", got, err)
+	}
+
+	if _, err = encodeValueMap(map[string]interface{}{"bad": struct{}{}}); err == nil {
+		t.Error("Got success, expecting error")
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f mixer/adapter/remote/config/config.proto
+
+// Package remote provides an adapter that dispatches instances to an
+// out-of-process adapter through the gRPC services generated for each
+// template (e.g. HandleMetricService).
+package remote // import "istio.io/istio/mixer/adapter/remote"
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	model "istio.io/api/mixer/adapter/model/v1beta1"
+	"istio.io/istio/mixer/adapter/remote/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/template/apikey"
+	"istio.io/istio/mixer/template/authorization"
+	"istio.io/istio/mixer/template/checknothing"
+	"istio.io/istio/mixer/template/listentry"
+	"istio.io/istio/mixer/template/logentry"
+	"istio.io/istio/mixer/template/metric"
+	"istio.io/istio/mixer/template/quota"
+	"istio.io/istio/mixer/template/reportnothing"
+)
+
+const (
+	defaultTimeout  = time.Second
+	defaultPoolSize = 1
+)
+
+type (
+	builder struct {
+		adapterConfig *config.Params
+	}
+
+	handler struct {
+		conns   *connPool
+		timeout time.Duration
+
+		// adapterConfig is sent along with every request.
+		adapterConfig *types.Any
+	}
+)
+
+///////////////// Configuration-time Methods ///////////////
+
+// adapter.HandlerBuilder#Build
+func (b *builder) Build(_ context.Context, env adapter.Env) (adapter.Handler, error) {
+	ac := b.adapterConfig
+
+	opt, err := transportOption(ac.Tls)
+	if err != nil {
+		return nil, env.Logger().Errorf("Unable to configure transport security for %s: %v", ac.Address, err)
+	}
+
+	adapterConfig, err := types.MarshalAny(ac)
+	if err != nil {
+		return nil, env.Logger().Errorf("Unable to encode adapter configuration: %v", err)
+	}
+
+	size := defaultPoolSize
+	if ac.ConnectionPoolSize > 0 {
+		size = int(ac.ConnectionPoolSize)
+	}
+	conns, err := newConnPool(ac.Address, size, opt)
+	if err != nil {
+		return nil, env.Logger().Errorf("%v", err)
+	}
+
+	timeout := defaultTimeout
+	if ac.Timeout > 0 {
+		timeout = ac.Timeout
+	}
+
+	return &handler{
+		conns:         conns,
+		timeout:       timeout,
+		adapterConfig: adapterConfig,
+	}, nil
+}
+
+// adapter.HandlerBuilder#SetAdapterConfig
+func (b *builder) SetAdapterConfig(cfg adapter.Config) { b.adapterConfig = cfg.(*config.Params) }
+
+// adapter.HandlerBuilder#Validate
+func (b *builder) Validate() (ce *adapter.ConfigErrors) {
+	ac := b.adapterConfig
+
+	if ac.Address == "" {
+		ce = ce.Appendf("address", "address is empty")
+	} else if _, _, err := net.SplitHostPort(ac.Address); err != nil {
+		ce = ce.Appendf("address", "address is malformed: %v", err)
+	}
+
+	if ac.Timeout < 0 {
+		ce = ce.Appendf("timeout", "timeout must be >= 0")
+	}
+
+	if ac.ConnectionPoolSize < 0 {
+		ce = ce.Appendf("connectionPoolSize", "connection pool size must be >= 0")
+	}
+
+	if t := ac.Tls; t != nil {
+		if (t.ClientCertificate == "") != (t.PrivateKey == "") {
+			ce = ce.Appendf("tls", "clientCertificate and privateKey must be specified together")
+		}
+	}
+
+	return ce
+}
+
+// The instance types are not needed up front: instances are sent to the
+// adapter as they come and it is up to the adapter to interpret them.
+
+// apikey.HandlerBuilder#SetApiKeyTypes
+func (*builder) SetApiKeyTypes(map[string]*apikey.Type) {}
+
+// authorization.HandlerBuilder#SetAuthorizationTypes
+func (*builder) SetAuthorizationTypes(map[string]*authorization.Type) {}
+
+// checknothing.HandlerBuilder#SetCheckNothingTypes
+func (*builder) SetCheckNothingTypes(map[string]*checknothing.Type) {}
+
+// listentry.HandlerBuilder#SetListEntryTypes
+func (*builder) SetListEntryTypes(map[string]*listentry.Type) {}
+
+// logentry.HandlerBuilder#SetLogEntryTypes
+func (*builder) SetLogEntryTypes(map[string]*logentry.Type) {}
+
+// metric.HandlerBuilder#SetMetricTypes
+func (*builder) SetMetricTypes(map[string]*metric.Type) {}
+
+// quota.HandlerBuilder#SetQuotaTypes
+func (*builder) SetQuotaTypes(map[string]*quota.Type) {}
+
+// reportnothing.HandlerBuilder#SetReportNothingTypes
+func (*builder) SetReportNothingTypes(map[string]*reportnothing.Type) {}
+
+////////////////// Request-time Methods //////////////////////////
+
+// apikey.Handler#HandleApiKey
+func (h *handler) HandleApiKey(ctx context.Context, inst *apikey.Instance) (adapter.CheckResult, error) {
+	msg, err := encodeAPIKey(inst)
+	if err != nil {
+		return adapter.CheckResult{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	return checkResult(apikey.NewHandleApiKeyServiceClient(h.conns.get()).HandleApiKey(ctx,
+		&apikey.HandleApiKeyRequest{Instance: msg, AdapterConfig: h.adapterConfig}))
+}
+
+// authorization.Handler#HandleAuthorization
+func (h *handler) HandleAuthorization(ctx context.Context, inst *authorization.Instance) (adapter.CheckResult, error) {
+	msg, err := encodeAuthorization(inst)
+	if err != nil {
+		return adapter.CheckResult{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	return checkResult(authorization.NewHandleAuthorizationServiceClient(h.conns.get()).HandleAuthorization(ctx,
+		&authorization.HandleAuthorizationRequest{Instance: msg, AdapterConfig: h.adapterConfig}))
+}
+
+// checknothing.Handler#HandleCheckNothing
+func (h *handler) HandleCheckNothing(ctx context.Context, inst *checknothing.Instance) (adapter.CheckResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	return checkResult(checknothing.NewHandleCheckNothingServiceClient(h.conns.get()).HandleCheckNothing(ctx,
+		&checknothing.HandleCheckNothingRequest{Instance: encodeCheckNothing(inst), AdapterConfig: h.adapterConfig}))
+}
+
+// listentry.Handler#HandleListEntry
+func (h *handler) HandleListEntry(ctx context.Context, inst *listentry.Instance) (adapter.CheckResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	return checkResult(listentry.NewHandleListEntryServiceClient(h.conns.get()).HandleListEntry(ctx,
+		&listentry.HandleListEntryRequest{Instance: encodeListEntry(inst), AdapterConfig: h.adapterConfig}))
+}
+
+// logentry.Handler#HandleLogEntry
+func (h *handler) HandleLogEntry(ctx context.Context, insts []*logentry.Instance) error {
+	if len(insts) == 0 {
+		return nil
+	}
+	msgs := make([]*logentry.InstanceMsg, 0, len(insts))
+	for _, inst := range insts {
+		msg, err := encodeLogEntry(inst)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	_, err := logentry.NewHandleLogEntryServiceClient(h.conns.get()).HandleLogEntry(ctx,
+		&logentry.HandleLogEntryRequest{Instances: msgs, AdapterConfig: h.adapterConfig})
+	return err
+}
+
+// metric.Handler#HandleMetric
+func (h *handler) HandleMetric(ctx context.Context, insts []*metric.Instance) error {
+	if len(insts) == 0 {
+		return nil
+	}
+	msgs := make([]*metric.InstanceMsg, 0, len(insts))
+	for _, inst := range insts {
+		msg, err := encodeMetric(inst)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	_, err := metric.NewHandleMetricServiceClient(h.conns.get()).HandleMetric(ctx,
+		&metric.HandleMetricRequest{Instances: msgs, AdapterConfig: h.adapterConfig})
+	return err
+}
+
+// quota.Handler#HandleQuota
+func (h *handler) HandleQuota(ctx context.Context, inst *quota.Instance, args adapter.QuotaArgs) (adapter.QuotaResult, error) {
+	msg, err := encodeQuota(inst)
+	if err != nil {
+		return adapter.QuotaResult{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	res, err := quota.NewHandleQuotaServiceClient(h.conns.get()).HandleQuota(ctx, &quota.HandleQuotaRequest{
+		Instance:      msg,
+		AdapterConfig: h.adapterConfig,
+		DedupId:       args.DeduplicationID,
+		QuotaRequest: &model.QuotaRequest{
+			Quotas: map[string]model.QuotaRequest_QuotaParams{
+				inst.Name: {Amount: args.QuotaAmount, BestEffort: args.BestEffort},
+			},
+		},
+	})
+	if err != nil {
+		return adapter.QuotaResult{}, err
+	}
+	r, found := res.Quotas[inst.Name]
+	if !found {
+		return adapter.QuotaResult{}, fmt.Errorf("adapter returned no result for quota %s", inst.Name)
+	}
+	return adapter.QuotaResult{
+		ValidDuration: r.ValidDuration,
+		Amount:        r.GrantedAmount,
+	}, nil
+}
+
+// reportnothing.Handler#HandleReportNothing
+func (h *handler) HandleReportNothing(ctx context.Context, insts []*reportnothing.Instance) error {
+	if len(insts) == 0 {
+		return nil
+	}
+	msgs := make([]*reportnothing.InstanceMsg, 0, len(insts))
+	for _, inst := range insts {
+		msgs = append(msgs, encodeReportNothing(inst))
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	_, err := reportnothing.NewHandleReportNothingServiceClient(h.conns.get()).HandleReportNothing(ctx,
+		&reportnothing.HandleReportNothingRequest{Instances: msgs, AdapterConfig: h.adapterConfig})
+	return err
+}
+
+// adapter.Handler#Close
+func (h *handler) Close() error { return h.conns.Close() }
+
+// checkResult converts the outcome of a check call into the result handed back to Mixer.
+func checkResult(res *model.CheckResult, err error) (adapter.CheckResult, error) {
+	if err != nil {
+		return adapter.CheckResult{}, err
+	}
+	return adapter.CheckResult{
+		Status:        res.Status,
+		ValidDuration: res.ValidDuration,
+		ValidUseCount: res.ValidUseCount,
+	}, nil
+}
+
+////////////////// Bootstrap //////////////////////////
+
+// GetInfo returns the adapter.Info specific to this adapter.
+func GetInfo() adapter.Info {
+	return adapter.Info{
+		Name:        "remote",
+		Impl:        "istio.io/istio/mixer/adapter/remote",
+		Description: "Dispatches instances to an out-of-process adapter over gRPC",
+		SupportedTemplates: []string{
+			apikey.TemplateName,
+			authorization.TemplateName,
+			checknothing.TemplateName,
+			listentry.TemplateName,
+			logentry.TemplateName,
+			metric.TemplateName,
+			quota.TemplateName,
+			reportnothing.TemplateName,
+		},
+		NewBuilder: func() adapter.HandlerBuilder { return &builder{} },
+		DefaultConfig: &config.Params{
+			Timeout:            defaultTimeout,
+			ConnectionPoolSize: defaultPoolSize,
+		},
+	}
+}
@@ -0,0 +1,644 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rpc "github.com/gogo/googleapis/google/rpc"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	model "istio.io/api/mixer/adapter/model/v1beta1"
+	"istio.io/istio/mixer/adapter/remote/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/test"
+	"istio.io/istio/mixer/template/apikey"
+	"istio.io/istio/mixer/template/authorization"
+	"istio.io/istio/mixer/template/checknothing"
+	"istio.io/istio/mixer/template/listentry"
+	"istio.io/istio/mixer/template/logentry"
+	"istio.io/istio/mixer/template/metric"
+	"istio.io/istio/mixer/template/quota"
+	"istio.io/istio/mixer/template/reportnothing"
+)
+
+// fakeAdapter is an out-of-process adapter serving every template service
+// supported by the remote adapter. It records the requests it receives.
+type fakeAdapter struct {
+	mu       sync.Mutex
+	requests []interface{}
+
+	delay       time.Duration
+	checkResult *model.CheckResult
+	granted     int64
+
+	srv  *grpc.Server
+	addr string
+}
+
+func newFakeAdapter(t *testing.T, opts ...grpc.ServerOption) *fakeAdapter {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+
+	f := &fakeAdapter{
+		checkResult: &model.CheckResult{
+			Status:        rpc.Status{Code: int32(rpc.PERMISSION_DENIED), Message: "denied"},
+			ValidDuration: 10 * time.Second,
+			ValidUseCount: 42,
+		},
+		granted: 7,
+		srv:     grpc.NewServer(opts...),
+		addr:    l.Addr().String(),
+	}
+	apikey.RegisterHandleApiKeyServiceServer(f.srv, f)
+	authorization.RegisterHandleAuthorizationServiceServer(f.srv, f)
+	checknothing.RegisterHandleCheckNothingServiceServer(f.srv, f)
+	listentry.RegisterHandleListEntryServiceServer(f.srv, f)
+	logentry.RegisterHandleLogEntryServiceServer(f.srv, f)
+	metric.RegisterHandleMetricServiceServer(f.srv, f)
+	quota.RegisterHandleQuotaServiceServer(f.srv, f)
+	reportnothing.RegisterHandleReportNothingServiceServer(f.srv, f)
+
+	go func() { _ = f.srv.Serve(l) }()
+	return f
+}
+
+func (f *fakeAdapter) record(ctx context.Context, req interface{}) error {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	delay := f.delay
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (f *fakeAdapter) last() interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		return nil
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+func (f *fakeAdapter) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+func (f *fakeAdapter) check(ctx context.Context, req interface{}) (*model.CheckResult, error) {
+	if err := f.record(ctx, req); err != nil {
+		return nil, err
+	}
+	return f.checkResult, nil
+}
+
+func (f *fakeAdapter) report(ctx context.Context, req interface{}) (*model.ReportResult, error) {
+	if err := f.record(ctx, req); err != nil {
+		return nil, err
+	}
+	return &model.ReportResult{}, nil
+}
+
+func (f *fakeAdapter) HandleApiKey(ctx context.Context, req *apikey.HandleApiKeyRequest) (*model.CheckResult, error) {
+	return f.check(ctx, req)
+}
+
+func (f *fakeAdapter) HandleAuthorization(ctx context.Context, req *authorization.HandleAuthorizationRequest) (*model.CheckResult, error) {
+	return f.check(ctx, req)
+}
+
+func (f *fakeAdapter) HandleCheckNothing(ctx context.Context, req *checknothing.HandleCheckNothingRequest) (*model.CheckResult, error) {
+	return f.check(ctx, req)
+}
+
+func (f *fakeAdapter) HandleListEntry(ctx context.Context, req *listentry.HandleListEntryRequest) (*model.CheckResult, error) {
+	return f.check(ctx, req)
+}
+
+func (f *fakeAdapter) HandleLogEntry(ctx context.Context, req *logentry.HandleLogEntryRequest) (*model.ReportResult, error) {
+	return f.report(ctx, req)
+}
+
+func (f *fakeAdapter) HandleMetric(ctx context.Context, req *metric.HandleMetricRequest) (*model.ReportResult, error) {
+	return f.report(ctx, req)
+}
+
+func (f *fakeAdapter) HandleReportNothing(ctx context.Context, req *reportnothing.HandleReportNothingRequest) (*model.ReportResult, error) {
+	return f.report(ctx, req)
+}
+
+func (f *fakeAdapter) HandleQuota(ctx context.Context, req *quota.HandleQuotaRequest) (*model.QuotaResult, error) {
+	if err := f.record(ctx, req); err != nil {
+		return nil, err
+	}
+	res := &model.QuotaResult{Quotas: make(map[string]model.QuotaResult_Result)}
+	for name, params := range req.QuotaRequest.Quotas {
+		granted := f.granted
+		if granted > params.Amount {
+			granted = params.Amount
+		}
+		res.Quotas[name] = model.QuotaResult_Result{ValidDuration: time.Minute, GrantedAmount: granted}
+	}
+	return res, nil
+}
+
+func buildHandler(t *testing.T, cfg *config.Params) adapter.Handler {
+	t.Helper()
+
+	info := GetInfo()
+	b := info.NewBuilder().(*builder)
+	b.SetApiKeyTypes(nil)
+	b.SetAuthorizationTypes(nil)
+	b.SetCheckNothingTypes(nil)
+	b.SetListEntryTypes(nil)
+	b.SetLogEntryTypes(nil)
+	b.SetMetricTypes(nil)
+	b.SetQuotaTypes(nil)
+	b.SetReportNothingTypes(nil)
+	b.SetAdapterConfig(cfg)
+
+	if err := b.Validate(); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	return h
+}
+
+func TestBasic(t *testing.T) {
+	info := GetInfo()
+
+	for _, tmpl := range []string{
+		apikey.TemplateName,
+		authorization.TemplateName,
+		checknothing.TemplateName,
+		listentry.TemplateName,
+		logentry.TemplateName,
+		metric.TemplateName,
+		quota.TemplateName,
+		reportnothing.TemplateName,
+	} {
+		if !contains(info.SupportedTemplates, tmpl) {
+			t.Errorf("Template %s not supported", tmpl)
+		}
+	}
+
+	cfg := info.DefaultConfig.(*config.Params)
+	if cfg.Timeout != defaultTimeout || cfg.ConnectionPoolSize != defaultPoolSize {
+		t.Errorf("Unexpected default config %v", cfg)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	f := newFakeAdapter(t)
+	defer f.srv.Stop()
+
+	cfg := &config.Params{Address: f.addr, Params: map[string]string{"policy": "strict"}}
+	h := buildHandler(t, cfg)
+	defer func() {
+		if err := h.Close(); err != nil {
+			t.Errorf("Got error %v, expecting success", err)
+		}
+	}()
+
+	want := adapter.CheckResult{
+		Status:        rpc.Status{Code: int32(rpc.PERMISSION_DENIED), Message: "denied"},
+		ValidDuration: 10 * time.Second,
+		ValidUseCount: 42,
+	}
+
+	ts := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	res, err := h.(apikey.Handler).HandleApiKey(context.Background(), &apikey.Instance{
+		Name:      "key.apikey.istio-system",
+		Api:       "bookstore",
+		ApiKey:    "secret",
+		Timestamp: ts,
+	})
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("Got %v, expected %v", res, want)
+	}
+	akReq := f.last().(*apikey.HandleApiKeyRequest)
+	if akReq.Instance.Name != "key.apikey.istio-system" || akReq.Instance.Api != "bookstore" || akReq.Instance.ApiKey != "secret" {
+		t.Errorf("Unexpected instance %v", akReq.Instance)
+	}
+	if got, _ := types.TimestampFromProto(akReq.Instance.Timestamp.Value); !got.Equal(ts) {
+		t.Errorf("Got timestamp %v, expected %v", got, ts)
+	}
+
+	// the adapter configuration is forwarded with every request
+	var got config.Params
+	if err = types.UnmarshalAny(akReq.AdapterConfig, &got); err != nil {
+		t.Fatalf("Unable to decode adapter config: %v", err)
+	}
+	if got.Params["policy"] != "strict" {
+		t.Errorf("Got adapter params %v, expected policy=strict", got.Params)
+	}
+
+	res, err = h.(authorization.Handler).HandleAuthorization(context.Background(), &authorization.Instance{
+		Name:    "authz.authorization.istio-system",
+		Subject: &authorization.Subject{User: "alice", Properties: map[string]interface{}{"version": "v1"}},
+		Action:  &authorization.Action{Namespace: "default", Service: "reviews", Method: "GET", Path: "/"},
+	})
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("Got %v, expected %v", res, want)
+	}
+	azReq := f.last().(*authorization.HandleAuthorizationRequest)
+	if azReq.Instance.Subject.User != "alice" || azReq.Instance.Action.Service != "reviews" {
+		t.Errorf("Unexpected instance %v", azReq.Instance)
+	}
+	if v := azReq.Instance.Subject.Properties["version"]; !reflect.DeepEqual(v, stringValue("v1")) {
+		t.Errorf("Got subject property %v, expected v1", v)
+	}
+
+	if _, err = h.(checknothing.Handler).HandleCheckNothing(context.Background(), &checknothing.Instance{Name: "cn"}); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	if name := f.last().(*checknothing.HandleCheckNothingRequest).Instance.Name; name != "cn" {
+		t.Errorf("Got instance %s, expected cn", name)
+	}
+
+	if _, err = h.(listentry.Handler).HandleListEntry(context.Background(), &listentry.Instance{Name: "le", Value: "10.0.0.1"}); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	if v := f.last().(*listentry.HandleListEntryRequest).Instance.Value; v != "10.0.0.1" {
+		t.Errorf("Got value %s, expected 10.0.0.1", v)
+	}
+}
+
+func TestReport(t *testing.T) {
+	f := newFakeAdapter(t)
+	defer f.srv.Stop()
+
+	h := buildHandler(t, &config.Params{Address: f.addr})
+	defer func() { _ = h.Close() }()
+
+	err := h.(metric.Handler).HandleMetric(context.Background(), []*metric.Instance{
+		{Name: "requests", Value: int64(1), Dimensions: map[string]interface{}{"code": int64(200), "ip": net.ParseIP("10.0.0.1")}},
+		{Name: "latency", Value: 15 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	mReq := f.last().(*metric.HandleMetricRequest)
+	if len(mReq.Instances) != 2 {
+		t.Fatalf("Got %d instances, expected 2", len(mReq.Instances))
+	}
+	wantMsgs := []*metric.InstanceMsg{
+		{
+			Name:  "requests",
+			Value: &model.Value{Value: &model.Value_Int64Value{Int64Value: 1}},
+			Dimensions: map[string]*model.Value{
+				"code": {Value: &model.Value_Int64Value{Int64Value: 200}},
+				"ip":   {Value: &model.Value_IpAddressValue{IpAddressValue: &model.IPAddress{Value: net.ParseIP("10.0.0.1")}}},
+			},
+		},
+		{
+			Name:  "latency",
+			Value: &model.Value{Value: &model.Value_DurationValue{DurationValue: &model.Duration{Value: types.DurationProto(15 * time.Millisecond)}}},
+		},
+	}
+	if !reflect.DeepEqual(mReq.Instances, wantMsgs) {
+		t.Errorf("Got %v, expected %v", mReq.Instances, wantMsgs)
+	}
+
+	err = h.(logentry.Handler).HandleLogEntry(context.Background(), []*logentry.Instance{
+		{Name: "access", Severity: "INFO", Variables: map[string]interface{}{"user": "alice", "missing": nil}},
+	})
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	lReq := f.last().(*logentry.HandleLogEntryRequest)
+	if lReq.Instances[0].Severity != "INFO" || !reflect.DeepEqual(lReq.Instances[0].Variables["user"], stringValue("alice")) {
+		t.Errorf("Unexpected instance %v", lReq.Instances[0])
+	}
+	if _, found := lReq.Instances[0].Variables["missing"]; found {
+		t.Error("nil variable was sent to the adapter")
+	}
+
+	if err = h.(reportnothing.Handler).HandleReportNothing(context.Background(), []*reportnothing.Instance{{Name: "rn"}}); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	if n := len(f.last().(*reportnothing.HandleReportNothingRequest).Instances); n != 1 {
+		t.Errorf("Got %d instances, expected 1", n)
+	}
+
+	// empty batches don't reach the adapter
+	calls := f.count()
+	if err = h.(metric.Handler).HandleMetric(context.Background(), nil); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if f.count() != calls {
+		t.Error("Empty batch was sent to the adapter")
+	}
+
+	// values that can't be encoded are reported
+	err = h.(metric.Handler).HandleMetric(context.Background(), []*metric.Instance{{Name: "bad", Value: struct{}{}}})
+	if err == nil || !strings.Contains(err.Error(), "unsupported value type") {
+		t.Errorf("Got error %v, expecting unsupported value type", err)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	f := newFakeAdapter(t)
+	defer f.srv.Stop()
+
+	h := buildHandler(t, &config.Params{Address: f.addr})
+	defer func() { _ = h.Close() }()
+
+	res, err := h.(quota.Handler).HandleQuota(context.Background(),
+		&quota.Instance{Name: "rq", Dimensions: map[string]interface{}{"source": "a"}},
+		adapter.QuotaArgs{DeduplicationID: "dedup", QuotaAmount: 10, BestEffort: true})
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	want := adapter.QuotaResult{ValidDuration: time.Minute, Amount: 7}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("Got %v, expected %v", res, want)
+	}
+
+	req := f.last().(*quota.HandleQuotaRequest)
+	if req.DedupId != "dedup" {
+		t.Errorf("Got dedup id %s, expected dedup", req.DedupId)
+	}
+	if p := req.QuotaRequest.Quotas["rq"]; p.Amount != 10 || !p.BestEffort {
+		t.Errorf("Unexpected quota params %v", p)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	f := newFakeAdapter(t)
+	defer f.srv.Stop()
+	f.delay = time.Second
+
+	h := buildHandler(t, &config.Params{Address: f.addr, Timeout: 10 * time.Millisecond})
+	defer func() { _ = h.Close() }()
+
+	_, err := h.(checknothing.Handler).HandleCheckNothing(context.Background(), &checknothing.Instance{Name: "cn"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Got error %v, expecting deadline exceeded", err)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	// building a handler doesn't require the adapter to be up
+	h := buildHandler(t, &config.Params{Address: "127.0.0.1:1", Timeout: 100 * time.Millisecond})
+	defer func() { _ = h.Close() }()
+
+	if err := h.(reportnothing.Handler).HandleReportNothing(context.Background(), []*reportnothing.Instance{{Name: "rn"}}); err == nil {
+		t.Error("Got success, expecting error")
+	}
+}
+
+func TestConnectionPool(t *testing.T) {
+	f := newFakeAdapter(t)
+	defer f.srv.Stop()
+
+	h := buildHandler(t, &config.Params{Address: f.addr, ConnectionPoolSize: 3})
+	conns := h.(*handler).conns
+	if len(conns.conns) != 3 {
+		t.Fatalf("Got %d connections, expected 3", len(conns.conns))
+	}
+
+	seen := make(map[*grpc.ClientConn]bool)
+	for i := 0; i < 3; i++ {
+		seen[conns.get()] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Got %d distinct connections, expected 3", len(seen))
+	}
+
+	for i := 0; i < 6; i++ {
+		if _, err := h.(checknothing.Handler).HandleCheckNothing(context.Background(), &checknothing.Instance{Name: "cn"}); err != nil {
+			t.Fatalf("Got error %v, expecting success", err)
+		}
+	}
+
+	if err := h.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// A single self-signed certificate serves as CA, server and client certificate.
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	cert := writeSelfSignedCert(t, certFile, keyFile)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+
+	f := newFakeAdapter(t, grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	defer f.srv.Stop()
+
+	h := buildHandler(t, &config.Params{
+		Address: f.addr,
+		Tls: &config.Params_TLS{
+			CaCertificates:    certFile,
+			ClientCertificate: certFile,
+			PrivateKey:        keyFile,
+			ServerName:        "adapter.istio-system",
+		},
+	})
+	defer func() { _ = h.Close() }()
+
+	if _, err = h.(checknothing.Handler).HandleCheckNothing(context.Background(), &checknothing.Instance{Name: "cn"}); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+
+	// without a client certificate the adapter refuses the connection
+	h2 := buildHandler(t, &config.Params{
+		Address: f.addr,
+		Timeout: 500 * time.Millisecond,
+		Tls: &config.Params_TLS{
+			CaCertificates: certFile,
+			ServerName:     "adapter.istio-system",
+		},
+	})
+	defer func() { _ = h2.Close() }()
+
+	if _, err = h2.(checknothing.Handler).HandleCheckNothing(context.Background(), &checknothing.Instance{Name: "cn"}); err == nil {
+		t.Error("Got success, expecting error")
+	}
+}
+
+func writeSelfSignedCert(t *testing.T, certFile, keyFile string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "adapter.istio-system"},
+		DNSNames:              []string{"adapter.istio-system"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err = ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		cfg    *config.Params
+		fields []string
+	}{
+		{"valid", &config.Params{Address: "adapter:9070"}, nil},
+		{"no address", &config.Params{}, []string{"address"}},
+		{"bad address", &config.Params{Address: "adapter"}, []string{"address"}},
+		{"negative timeout", &config.Params{Address: "adapter:9070", Timeout: -time.Second}, []string{"timeout"}},
+		{"negative pool", &config.Params{Address: "adapter:9070", ConnectionPoolSize: -1}, []string{"connectionPoolSize"}},
+		{"cert without key", &config.Params{
+			Address: "adapter:9070",
+			Tls:     &config.Params_TLS{ClientCertificate: "cert.pem"},
+		}, []string{"tls"}},
+		{"valid tls", &config.Params{
+			Address: "adapter:9070",
+			Tls:     &config.Params_TLS{ClientCertificate: "cert.pem", PrivateKey: "key.pem"},
+		}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := &builder{}
+			b.SetAdapterConfig(c.cfg)
+			ce := b.Validate()
+
+			var fields []string
+			if ce != nil {
+				for _, e := range ce.Multi.Errors {
+					fields = append(fields, e.(adapter.ConfigError).Field)
+				}
+			}
+			if !reflect.DeepEqual(fields, c.fields) {
+				t.Errorf("Got errors for %v, expected %v: %v", fields, c.fields, ce)
+			}
+		})
+	}
+}
+
+func TestTransportOption(t *testing.T) {
+	if _, err := transportOption(nil); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if _, err := transportOption(&config.Params_TLS{ServerName: "adapter"}); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if _, err := transportOption(&config.Params_TLS{CaCertificates: "/does/not/exist"}); err == nil {
+		t.Error("Got success, expecting error")
+	}
+	if _, err := transportOption(&config.Params_TLS{ClientCertificate: "/does/not/exist", PrivateKey: "/does/not/exist"}); err == nil {
+		t.Error("Got success, expecting error")
+	}
+
+	// a bad transport configuration fails the build
+	b := &builder{}
+	b.SetAdapterConfig(&config.Params{Address: "adapter:9070", Tls: &config.Params_TLS{CaCertificates: "/does/not/exist"}})
+	if _, err := b.Build(context.Background(), test.NewEnv(t)); err == nil {
+		t.Error("Got success, expecting error")
+	}
+}
+
+func stringValue(s string) *model.Value {
+	return &model.Value{Value: &model.Value_StringValue{StringValue: s}}
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
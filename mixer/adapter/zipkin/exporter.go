@@ -0,0 +1,154 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+// exporter batches spans and posts them to a collector from a single
+// background goroutine. Spans are buffered in a bounded queue so that a slow
+// or unavailable collector never blocks request processing.
+type exporter struct {
+	// dropped counts the spans discarded since the last report, accessed
+	// atomically. It is kept first to guarantee its 64-bit alignment.
+	dropped uint64
+
+	url         string
+	contentType string
+	encode      func([]*span) ([][]byte, error)
+	client      *http.Client
+	log         adapter.Logger
+
+	batchSize int
+	interval  time.Duration
+
+	queue chan *span
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// enqueue adds a span to the queue, dropping it if the queue is full.
+func (e *exporter) enqueue(s *span) bool {
+	select {
+	case e.queue <- s:
+		return true
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+		return false
+	}
+}
+
+// run sends queued spans until the exporter is closed. A batch is sent as
+// soon as it is full, and at the latest one flush interval after it started.
+func (e *exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]*span, 0, e.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.send(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+			e.reportDropped()
+
+		case <-e.stop:
+			// send whatever is left before shutting down
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+					if len(batch) >= e.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					e.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+// close stops the exporter once the spans still queued have been sent.
+func (e *exporter) close() {
+	close(e.stop)
+	<-e.done
+}
+
+func (e *exporter) send(spans []*span) {
+	payloads, err := e.encode(spans)
+	if err != nil {
+		_ = e.log.Errorf("Unable to encode %d spans: %v", len(spans), err)
+		return
+	}
+
+	for _, p := range payloads {
+		if err := e.post(p); err != nil {
+			_ = e.log.Errorf("Unable to send spans to %s: %v", e.url, err)
+		}
+	}
+}
+
+func (e *exporter) post(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", e.contentType)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// drain the body so that the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e.log.Warningf("Collector %s responded with %s", e.url, resp.Status)
+	}
+	return nil
+}
+
+func (e *exporter) reportDropped() {
+	if n := atomic.SwapUint64(&e.dropped, 0); n > 0 {
+		e.log.Warningf("Dropped %d spans because the export queue is full", n)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/template/tracespan"
+)
+
+type (
+	// span is the encoding-neutral form of a tracespan instance.
+	span struct {
+		traceIDHigh uint64
+		traceIDLow  uint64
+		id          uint64
+		parentID    uint64 // 0 for root spans
+
+		name        string
+		serviceName string
+		start       time.Time
+		duration    time.Duration
+
+		// tags holds the exported tags, keyed by their exported name.
+		tags map[string]interface{}
+	}
+
+	// converter turns tracespan instances into spans.
+	converter struct {
+		serviceNameTag     string
+		defaultServiceName string
+		tagMapping         map[string]string
+		dropUnmappedTags   bool
+	}
+
+	// zipkinSpan is a span in the Zipkin v2 JSON format.
+	zipkinSpan struct {
+		TraceID       string            `json:"traceId"`
+		ID            string            `json:"id"`
+		ParentID      string            `json:"parentId,omitempty"`
+		Name          string            `json:"name,omitempty"`
+		Timestamp     int64             `json:"timestamp,omitempty"`
+		Duration      int64             `json:"duration,omitempty"`
+		LocalEndpoint *zipkinEndpoint   `json:"localEndpoint,omitempty"`
+		Tags          map[string]string `json:"tags,omitempty"`
+	}
+
+	zipkinEndpoint struct {
+		ServiceName string `json:"serviceName,omitempty"`
+	}
+)
+
+func (c *converter) convert(inst *tracespan.Instance) (*span, error) {
+	s := &span{
+		name:        inst.SpanName,
+		serviceName: c.defaultServiceName,
+		start:       inst.StartTime,
+	}
+
+	var err error
+	if s.traceIDHigh, s.traceIDLow, err = parseTraceID(inst.TraceId); err != nil {
+		return nil, fmt.Errorf("instance %s has an invalid trace ID: %v", inst.Name, err)
+	}
+
+	if inst.SpanId == "" {
+		s.id = newID()
+	} else if s.id, err = parseID(inst.SpanId); err != nil {
+		return nil, fmt.Errorf("instance %s has an invalid span ID: %v", inst.Name, err)
+	}
+
+	if inst.ParentSpanId != "" {
+		if s.parentID, err = parseID(inst.ParentSpanId); err != nil {
+			return nil, fmt.Errorf("instance %s has an invalid parent span ID: %v", inst.Name, err)
+		}
+	}
+
+	if inst.EndTime.After(inst.StartTime) {
+		s.duration = inst.EndTime.Sub(inst.StartTime)
+	}
+
+	s.tags = make(map[string]interface{}, len(inst.SpanTags))
+	for k, v := range inst.SpanTags {
+		if v == nil {
+			continue
+		}
+
+		if k == c.serviceNameTag {
+			if name := adapter.Stringify(v); name != "" {
+				s.serviceName = name
+			}
+			continue
+		}
+
+		if mapped, ok := c.tagMapping[k]; ok {
+			k = mapped
+		} else if c.dropUnmappedTags {
+			continue
+		}
+		s.tags[k] = v
+	}
+
+	return s, nil
+}
+
+// parseTraceID parses a 64 or 128-bit hex encoded trace ID.
+func parseTraceID(id string) (high, low uint64, err error) {
+	if id == "" {
+		return 0, 0, fmt.Errorf("trace ID is empty")
+	}
+
+	if len(id) > 32 {
+		return 0, 0, fmt.Errorf("trace ID %q is longer than 32 characters", id)
+	}
+
+	if len(id) > 16 {
+		if high, err = strconv.ParseUint(id[:len(id)-16], 16, 64); err != nil {
+			return 0, 0, err
+		}
+		id = id[len(id)-16:]
+	}
+
+	if low, err = strconv.ParseUint(id, 16, 64); err != nil {
+		return 0, 0, err
+	}
+
+	return high, low, nil
+}
+
+// parseID parses a 64-bit hex encoded span ID.
+func parseID(id string) (uint64, error) {
+	if len(id) > 16 {
+		return 0, fmt.Errorf("span ID %q is longer than 16 characters", id)
+	}
+	return strconv.ParseUint(id, 16, 64)
+}
+
+// newID returns a random non-zero span ID.
+func newID() uint64 {
+	for {
+		if id := uint64(rand.Int63()); id != 0 {
+			return id
+		}
+	}
+}
+
+func encodeZipkinJSON(spans []*span) ([][]byte, error) {
+	out := make([]*zipkinSpan, 0, len(spans))
+	for _, s := range spans {
+		zs := &zipkinSpan{
+			ID:            fmt.Sprintf("%016x", s.id),
+			Name:          s.name,
+			Duration:      int64(s.duration / time.Microsecond),
+			LocalEndpoint: &zipkinEndpoint{ServiceName: s.serviceName},
+		}
+
+		if s.traceIDHigh != 0 {
+			zs.TraceID = fmt.Sprintf("%016x%016x", s.traceIDHigh, s.traceIDLow)
+		} else {
+			zs.TraceID = fmt.Sprintf("%016x", s.traceIDLow)
+		}
+
+		if s.parentID != 0 {
+			zs.ParentID = fmt.Sprintf("%016x", s.parentID)
+		}
+
+		if !s.start.IsZero() {
+			zs.Timestamp = s.start.UnixNano() / int64(time.Microsecond)
+		}
+
+		if len(s.tags) > 0 {
+			zs.Tags = make(map[string]string, len(s.tags))
+			for k, v := range s.tags {
+				zs.Tags[k] = adapter.Stringify(v)
+			}
+		}
+
+		out = append(out, zs)
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{b}, nil
+}
+
+// encodeJaegerThrift encodes spans as Jaeger Thrift batches. A batch describes
+// a single process, so one batch is produced for each service.
+func encodeJaegerThrift(spans []*span) ([][]byte, error) {
+	batches := make(map[string]*jaeger.Batch)
+	for _, s := range spans {
+		b, ok := batches[s.serviceName]
+		if !ok {
+			b = &jaeger.Batch{Process: &jaeger.Process{ServiceName: s.serviceName}}
+			batches[s.serviceName] = b
+		}
+
+		js := &jaeger.Span{
+			TraceIdLow:    int64(s.traceIDLow),
+			TraceIdHigh:   int64(s.traceIDHigh),
+			SpanId:        int64(s.id),
+			ParentSpanId:  int64(s.parentID),
+			OperationName: s.name,
+			Flags:         1, // sampled
+			Duration:      int64(s.duration / time.Microsecond),
+			Tags:          jaegerTags(s.tags),
+		}
+		if !s.start.IsZero() {
+			js.StartTime = s.start.UnixNano() / int64(time.Microsecond)
+		}
+
+		b.Spans = append(b.Spans, js)
+	}
+
+	services := make([]string, 0, len(batches))
+	for name := range batches {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	out := make([][]byte, 0, len(batches))
+	for _, name := range services {
+		buf := thrift.NewTMemoryBuffer()
+		if err := batches[name].Write(thrift.NewTBinaryProtocolTransport(buf)); err != nil {
+			return nil, err
+		}
+		out = append(out, buf.Bytes())
+	}
+	return out, nil
+}
+
+func jaegerTags(tags map[string]interface{}) []*jaeger.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]*jaeger.Tag, 0, len(tags))
+	for _, k := range keys {
+		t := &jaeger.Tag{Key: k}
+		switch v := tags[k].(type) {
+		case int64:
+			t.VType = jaeger.TagType_LONG
+			t.VLong = &v
+		case float64:
+			t.VType = jaeger.TagType_DOUBLE
+			t.VDouble = &v
+		case bool:
+			t.VType = jaeger.TagType_BOOL
+			t.VBool = &v
+		default:
+			s := adapter.Stringify(v)
+			t.VType = jaeger.TagType_STRING
+			t.VStr = &s
+		}
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/template/tracespan"
+)
+
+func TestParseTraceID(t *testing.T) {
+	cases := []struct {
+		id        string
+		high, low uint64
+		err       bool
+	}{
+		{"1", 0, 1, false},
+		{"48485a3953bb6124", 0, 0x48485a3953bb6124, false},
+		{"463ac35c9f6413ad48485a3953bb6124", 0x463ac35c9f6413ad, 0x48485a3953bb6124, false},
+		{"1000000000000000f", 1, 0xf, false},
+		{"", 0, 0, true},
+		{"xyz", 0, 0, true},
+		{"x63ac35c9f6413ad48485a3953bb6124", 0, 0, true},
+		{"1463ac35c9f6413ad48485a3953bb6124", 0, 0, true},
+	}
+
+	for _, c := range cases {
+		high, low, err := parseTraceID(c.id)
+		if (err != nil) != c.err {
+			t.Errorf("parseTraceID(%q) returned error %v, want error %v", c.id, err, c.err)
+			continue
+		}
+		if high != c.high || low != c.low {
+			t.Errorf("parseTraceID(%q) = %x, %x, want %x, %x", c.id, high, low, c.high, c.low)
+		}
+	}
+}
+
+func TestParseID(t *testing.T) {
+	if id, err := parseID("a2fb4a1d1a96d312"); err != nil || id != 0xa2fb4a1d1a96d312 {
+		t.Errorf("parseID() = %x, %v", id, err)
+	}
+	if _, err := parseID("a2fb4a1d1a96d3120"); err == nil {
+		t.Error("parseID() accepted an ID longer than 16 characters")
+	}
+	if _, err := parseID("-1"); err == nil {
+		t.Error("parseID() accepted a negative ID")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	start := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	c := converter{
+		serviceNameTag:     "source.service",
+		defaultServiceName: "istio-mesh",
+		tagMapping:         map[string]string{"http.method": "method"},
+		dropUnmappedTags:   true,
+	}
+
+	s, err := c.convert(&tracespan.Instance{
+		TraceId:   "1",
+		SpanName:  "/ratings",
+		StartTime: start,
+		EndTime:   start.Add(-time.Second),
+		SpanTags: map[string]interface{}{
+			"source.service": "",
+			"http.method":    "GET",
+			"http.url":       "/ratings",
+		},
+	})
+	if err != nil {
+		t.Fatalf("convert() failed: %v", err)
+	}
+
+	if s.id == 0 {
+		t.Error("convert() did not generate a span ID")
+	}
+	if s.serviceName != "istio-mesh" {
+		t.Errorf("got service name %q, want the default one", s.serviceName)
+	}
+	if s.duration != 0 {
+		t.Errorf("got duration %v for a span ending before it starts, want 0", s.duration)
+	}
+	if want := map[string]interface{}{"method": "GET"}; !reflect.DeepEqual(s.tags, want) {
+		t.Errorf("got tags %v, want %v", s.tags, want)
+	}
+
+	for _, inst := range []*tracespan.Instance{
+		{TraceId: "1", SpanId: "xyz"},
+		{TraceId: "1", ParentSpanId: "xyz"},
+	} {
+		if _, err := c.convert(inst); err == nil {
+			t.Errorf("convert(%v) succeeded, want an error", inst)
+		}
+	}
+}
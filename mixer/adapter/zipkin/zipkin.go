@@ -0,0 +1,207 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f mixer/adapter/zipkin/config/config.proto
+
+// Package zipkin provides an adapter that exports tracespan instances to a
+// Zipkin or Jaeger collector.
+package zipkin // import "istio.io/istio/mixer/adapter/zipkin"
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/mixer/adapter/zipkin/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/template/tracespan"
+)
+
+type (
+	builder struct {
+		adapterConfig *config.Params
+	}
+
+	handler struct {
+		log      adapter.Logger
+		exporter *exporter
+		conv     converter
+
+		// Traces whose low 64 bits of ID are below threshold are exported,
+		// unless sampleAll is set.
+		sampleAll bool
+		threshold uint64
+	}
+)
+
+///////////////// Configuration-time Methods ///////////////
+
+// adapter.HandlerBuilder#Build
+func (b *builder) Build(_ context.Context, env adapter.Env) (adapter.Handler, error) {
+	ac := b.adapterConfig
+
+	e := &exporter{
+		url:       ac.CollectorUrl,
+		client:    &http.Client{Timeout: ac.Timeout},
+		log:       env.Logger(),
+		batchSize: int(ac.MaxBatchSize),
+		interval:  ac.FlushInterval,
+		queue:     make(chan *span, ac.QueueSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	switch ac.Encoding {
+	case config.JAEGER_THRIFT:
+		e.contentType = "application/x-thrift"
+		e.encode = encodeJaegerThrift
+	default:
+		e.contentType = "application/json"
+		e.encode = encodeZipkinJSON
+	}
+
+	env.ScheduleDaemon(e.run)
+
+	h := &handler{
+		log:      env.Logger(),
+		exporter: e,
+		conv: converter{
+			serviceNameTag:     ac.ServiceNameTag,
+			defaultServiceName: ac.DefaultServiceName,
+			tagMapping:         ac.TagMapping,
+			dropUnmappedTags:   ac.DropUnmappedTags,
+		},
+	}
+
+	if ac.SampleProbability >= 1 {
+		h.sampleAll = true
+	} else {
+		h.threshold = uint64(ac.SampleProbability * math.Exp2(64))
+	}
+
+	return h, nil
+}
+
+// adapter.HandlerBuilder#SetAdapterConfig
+func (b *builder) SetAdapterConfig(cfg adapter.Config) { b.adapterConfig = cfg.(*config.Params) }
+
+// adapter.HandlerBuilder#Validate
+func (b *builder) Validate() (ce *adapter.ConfigErrors) {
+	ac := b.adapterConfig
+
+	if ac.CollectorUrl == "" {
+		ce = ce.Appendf("collectorUrl", "collector URL is empty")
+	} else if u, err := url.ParseRequestURI(ac.CollectorUrl); err != nil {
+		ce = ce.Appendf("collectorUrl", "collector URL is malformed: %v", err)
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		ce = ce.Appendf("collectorUrl", "collector URL scheme must be http or https, it is %q", u.Scheme)
+	}
+
+	if _, ok := config.Params_Encoding_name[int32(ac.Encoding)]; !ok {
+		ce = ce.Appendf("encoding", "unknown encoding %v", ac.Encoding)
+	}
+
+	if ac.SampleProbability < 0 || ac.SampleProbability > 1 {
+		ce = ce.Appendf("sampleProbability", "sample probability must be between 0 and 1, it is %v", ac.SampleProbability)
+	}
+
+	if ac.MaxBatchSize <= 0 {
+		ce = ce.Appendf("maxBatchSize", "max batch size must be > 0, it is %d", ac.MaxBatchSize)
+	}
+
+	if ac.QueueSize <= 0 {
+		ce = ce.Appendf("queueSize", "queue size must be > 0, it is %d", ac.QueueSize)
+	}
+
+	if ac.FlushInterval <= 0 {
+		ce = ce.Appendf("flushInterval", "flush interval must be > 0, it is %v", ac.FlushInterval)
+	}
+
+	if ac.Timeout < 0 {
+		ce = ce.Appendf("timeout", "timeout must be >= 0, it is %v", ac.Timeout)
+	}
+
+	if ac.ServiceNameTag == "" && ac.DefaultServiceName == "" {
+		ce = ce.Appendf("defaultServiceName", "default service name must be set when no service name tag is configured")
+	}
+
+	return ce
+}
+
+// tracespan.HandlerBuilder#SetTraceSpanTypes
+func (*builder) SetTraceSpanTypes(map[string]*tracespan.Type) {}
+
+////////////////// Request-time Methods //////////////////////////
+
+// tracespan.Handler#HandleTraceSpan
+func (h *handler) HandleTraceSpan(_ context.Context, insts []*tracespan.Instance) error {
+	var result *multierror.Error
+
+	for _, inst := range insts {
+		s, err := h.conv.convert(inst)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		if !h.sampled(s) {
+			continue
+		}
+
+		h.exporter.enqueue(s)
+	}
+
+	return result.ErrorOrNil()
+}
+
+// sampled determines whether the trace a span belongs to is exported. The
+// decision only depends on the trace ID so that all the spans of a trace,
+// including those reported by other Mixer instances, are treated the same.
+func (h *handler) sampled(s *span) bool {
+	return h.sampleAll || s.traceIDLow < h.threshold
+}
+
+// adapter.Handler#Close
+func (h *handler) Close() error {
+	h.exporter.close()
+	return nil
+}
+
+////////////////// Bootstrap //////////////////////////
+
+// GetInfo returns the adapter.Info specific to this adapter.
+func GetInfo() adapter.Info {
+	return adapter.Info{
+		Name:               "zipkin",
+		Impl:               "istio.io/istio/mixer/adapter/zipkin",
+		Description:        "Exports trace spans to a Zipkin or Jaeger collector",
+		SupportedTemplates: []string{tracespan.TemplateName},
+		NewBuilder:         func() adapter.HandlerBuilder { return &builder{} },
+		DefaultConfig: &config.Params{
+			CollectorUrl:       "http://zipkin:9411/api/v2/spans",
+			Encoding:           config.ZIPKIN_V2_JSON,
+			SampleProbability:  1,
+			MaxBatchSize:       100,
+			QueueSize:          1000,
+			FlushInterval:      time.Second,
+			Timeout:            5 * time.Second,
+			ServiceNameTag:     "source.service",
+			DefaultServiceName: "istio-mesh",
+		},
+	}
+}
@@ -0,0 +1,523 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/gogo/protobuf/proto"
+	"github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+
+	"istio.io/istio/mixer/adapter/zipkin/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/test"
+	"istio.io/istio/mixer/template/tracespan"
+)
+
+// collector records the requests posted to it.
+type collector struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	types    []string
+	payloads [][]byte
+	status   int
+}
+
+func newCollector() *collector {
+	c := &collector{status: http.StatusAccepted}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		c.lock.Lock()
+		c.types = append(c.types, r.Header.Get("Content-Type"))
+		c.payloads = append(c.payloads, b)
+		status := c.status
+		c.lock.Unlock()
+		w.WriteHeader(status)
+	}))
+	return c
+}
+
+func (c *collector) requests() ([]string, [][]byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string{}, c.types...), append([][]byte{}, c.payloads...)
+}
+
+func (c *collector) zipkinSpans(t *testing.T) []zipkinSpan {
+	t.Helper()
+	_, payloads := c.requests()
+	var all []zipkinSpan
+	for _, p := range payloads {
+		var spans []zipkinSpan
+		if err := json.Unmarshal(p, &spans); err != nil {
+			t.Fatalf("Unable to decode %q: %v", p, err)
+		}
+		all = append(all, spans...)
+	}
+	return all
+}
+
+func buildHandler(t *testing.T, env adapter.Env, url string, mod func(*config.Params)) *handler {
+	t.Helper()
+	info := GetInfo()
+	cfg := proto.Clone(info.DefaultConfig).(*config.Params)
+	cfg.CollectorUrl = url
+	if mod != nil {
+		mod(cfg)
+	}
+
+	b := info.NewBuilder().(*builder)
+	b.SetAdapterConfig(cfg)
+	b.SetTraceSpanTypes(nil)
+	if err := b.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	h, err := b.Build(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	return h.(*handler)
+}
+
+func newInstance(traceID, spanID, parentID string, tags map[string]interface{}) *tracespan.Instance {
+	start := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	return &tracespan.Instance{
+		Name:         "span",
+		TraceId:      traceID,
+		SpanId:       spanID,
+		ParentSpanId: parentID,
+		SpanName:     "/reviews",
+		StartTime:    start,
+		EndTime:      start.Add(25 * time.Millisecond),
+		SpanTags:     tags,
+	}
+}
+
+func TestBasic(t *testing.T) {
+	info := GetInfo()
+
+	if !contains(info.SupportedTemplates, tracespan.TemplateName) {
+		t.Errorf("SupportedTemplates = %v, want %s", info.SupportedTemplates, tracespan.TemplateName)
+	}
+
+	b := info.NewBuilder().(*builder)
+	b.SetAdapterConfig(info.DefaultConfig)
+	if err := b.Validate(); err != nil {
+		t.Errorf("Validate() of the default config failed: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		mod   func(*config.Params)
+		field string
+	}{
+		{"empty url", func(c *config.Params) { c.CollectorUrl = "" }, "collectorUrl"},
+		{"relative url", func(c *config.Params) { c.CollectorUrl = "zipkin/api/v2/spans" }, "collectorUrl"},
+		{"bad scheme", func(c *config.Params) { c.CollectorUrl = "ftp://zipkin/api/v2/spans" }, "collectorUrl"},
+		{"bad encoding", func(c *config.Params) { c.Encoding = 42 }, "encoding"},
+		{"negative probability", func(c *config.Params) { c.SampleProbability = -0.1 }, "sampleProbability"},
+		{"probability above 1", func(c *config.Params) { c.SampleProbability = 1.5 }, "sampleProbability"},
+		{"zero batch size", func(c *config.Params) { c.MaxBatchSize = 0 }, "maxBatchSize"},
+		{"zero queue size", func(c *config.Params) { c.QueueSize = 0 }, "queueSize"},
+		{"zero flush interval", func(c *config.Params) { c.FlushInterval = 0 }, "flushInterval"},
+		{"negative timeout", func(c *config.Params) { c.Timeout = -time.Second }, "timeout"},
+		{"no service name", func(c *config.Params) { c.ServiceNameTag = ""; c.DefaultServiceName = "" }, "defaultServiceName"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := proto.Clone(GetInfo().DefaultConfig).(*config.Params)
+			c.mod(cfg)
+
+			b := &builder{}
+			b.SetAdapterConfig(cfg)
+			ce := b.Validate()
+			if ce == nil {
+				t.Fatalf("Validate() succeeded, want an error for %s", c.field)
+			}
+			if len(ce.Multi.Errors) != 1 {
+				t.Fatalf("Validate() returned %d errors, want 1: %v", len(ce.Multi.Errors), ce)
+			}
+			if got := ce.Multi.Errors[0].(adapter.ConfigError).Field; got != c.field {
+				t.Errorf("Validate() reported field %s, want %s", got, c.field)
+			}
+		})
+	}
+}
+
+func TestZipkinJSON(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+
+	env := test.NewEnv(t)
+	h := buildHandler(t, env, c.URL, func(cfg *config.Params) {
+		cfg.TagMapping = map[string]string{"http.method": "method"}
+	})
+
+	insts := []*tracespan.Instance{
+		newInstance("463ac35c9f6413ad48485a3953bb6124", "a2fb4a1d1a96d312", "0020000000000001", map[string]interface{}{
+			"source.service":   "reviews.default",
+			"http.method":      "GET",
+			"http.status_code": int64(200),
+			"missing":          nil,
+		}),
+		newInstance("48485a3953bb6124", "0020000000000001", "", nil),
+	}
+
+	if err := h.HandleTraceSpan(context.Background(), insts); err != nil {
+		t.Fatalf("HandleTraceSpan() failed: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	types, _ := c.requests()
+	if len(types) != 1 || types[0] != "application/json" {
+		t.Errorf("got requests with content types %v, want a single application/json request", types)
+	}
+
+	got := c.zipkinSpans(t)
+	want := []zipkinSpan{
+		{
+			TraceID:       "463ac35c9f6413ad48485a3953bb6124",
+			ID:            "a2fb4a1d1a96d312",
+			ParentID:      "0020000000000001",
+			Name:          "/reviews",
+			Timestamp:     1519898400000000,
+			Duration:      25000,
+			LocalEndpoint: &zipkinEndpoint{ServiceName: "reviews.default"},
+			Tags:          map[string]string{"method": "GET", "http.status_code": "200"},
+		},
+		{
+			TraceID:       "48485a3953bb6124",
+			ID:            "0020000000000001",
+			Name:          "/reviews",
+			Timestamp:     1519898400000000,
+			Duration:      25000,
+			LocalEndpoint: &zipkinEndpoint{ServiceName: "istio-mesh"},
+		},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d spans, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, _ := json.Marshal(got[i])
+		w, _ := json.Marshal(want[i])
+		if string(g) != string(w) {
+			t.Errorf("span %d = %s, want %s", i, g, w)
+		}
+	}
+}
+
+func TestJaegerThrift(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+
+	env := test.NewEnv(t)
+	h := buildHandler(t, env, c.URL, func(cfg *config.Params) {
+		cfg.Encoding = config.JAEGER_THRIFT
+	})
+
+	insts := []*tracespan.Instance{
+		newInstance("463ac35c9f6413ad48485a3953bb6124", "a2fb4a1d1a96d312", "", map[string]interface{}{
+			"source.service":   "reviews.default",
+			"http.status_code": int64(200),
+			"error":            false,
+			"ratio":            0.5,
+			"http.url":         "/reviews",
+		}),
+		newInstance("48485a3953bb6124", "0020000000000001", "a2fb4a1d1a96d312", map[string]interface{}{
+			"source.service": "ratings.default",
+		}),
+	}
+
+	if err := h.HandleTraceSpan(context.Background(), insts); err != nil {
+		t.Fatalf("HandleTraceSpan() failed: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	types, payloads := c.requests()
+	if len(payloads) != 2 {
+		t.Fatalf("got %d requests, want one per service", len(payloads))
+	}
+
+	var batches []*jaeger.Batch
+	for i, p := range payloads {
+		if types[i] != "application/x-thrift" {
+			t.Errorf("request %d has content type %s, want application/x-thrift", i, types[i])
+		}
+
+		buf := thrift.NewTMemoryBuffer()
+		_, _ = buf.Write(p)
+		b := &jaeger.Batch{}
+		if err := b.Read(thrift.NewTBinaryProtocolTransport(buf)); err != nil {
+			t.Fatalf("Unable to decode batch %d: %v", i, err)
+		}
+		batches = append(batches, b)
+	}
+
+	// batches are sent in service name order
+	if got := batches[0].Process.ServiceName; got != "ratings.default" {
+		t.Errorf("first batch is for %s, want ratings.default", got)
+	}
+	if got := batches[1].Process.ServiceName; got != "reviews.default" {
+		t.Errorf("second batch is for %s, want reviews.default", got)
+	}
+
+	ratings := batches[0].Spans[0]
+	if ratings.TraceIdHigh != 0 || ratings.TraceIdLow != 0x48485a3953bb6124 {
+		t.Errorf("got trace ID %x%x, want 48485a3953bb6124", ratings.TraceIdHigh, ratings.TraceIdLow)
+	}
+	if uint64(ratings.ParentSpanId) != 0xa2fb4a1d1a96d312 {
+		t.Errorf("got parent span ID %x, want a2fb4a1d1a96d312", uint64(ratings.ParentSpanId))
+	}
+
+	reviews := batches[1].Spans[0]
+	if reviews.TraceIdHigh != 0x463ac35c9f6413ad || reviews.TraceIdLow != 0x48485a3953bb6124 {
+		t.Errorf("got trace ID %x%x, want 463ac35c9f6413ad48485a3953bb6124", reviews.TraceIdHigh, reviews.TraceIdLow)
+	}
+	if reviews.OperationName != "/reviews" || reviews.StartTime != 1519898400000000 || reviews.Duration != 25000 {
+		t.Errorf("got span %v", reviews)
+	}
+
+	tags := make(map[string]*jaeger.Tag)
+	for _, tag := range reviews.Tags {
+		tags[tag.Key] = tag
+	}
+	if len(tags) != 4 {
+		t.Errorf("got tags %v, want 4 tags", reviews.Tags)
+	}
+	if tag := tags["http.status_code"]; tag == nil || tag.VType != jaeger.TagType_LONG || tag.GetVLong() != 200 {
+		t.Errorf("http.status_code = %v, want LONG 200", tag)
+	}
+	if tag := tags["error"]; tag == nil || tag.VType != jaeger.TagType_BOOL || tag.GetVBool() {
+		t.Errorf("error = %v, want BOOL false", tag)
+	}
+	if tag := tags["ratio"]; tag == nil || tag.VType != jaeger.TagType_DOUBLE || tag.GetVDouble() != 0.5 {
+		t.Errorf("ratio = %v, want DOUBLE 0.5", tag)
+	}
+	if tag := tags["http.url"]; tag == nil || tag.VType != jaeger.TagType_STRING || tag.GetVStr() != "/reviews" {
+		t.Errorf("http.url = %v, want STRING /reviews", tag)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	cases := []struct {
+		probability float64
+		traceIDs    []string
+		want        []string
+	}{
+		{0, []string{"1", "7fffffffffffffff"}, nil},
+		{1, []string{"1", "ffffffffffffffff"}, []string{"0000000000000001", "ffffffffffffffff"}},
+		{0.5, []string{"1", "7ffffffffffffff0", "8000000000000001", "ffffffffffffffff0000000000000002"},
+			[]string{"0000000000000001", "7ffffffffffffff0", "ffffffffffffffff0000000000000002"}},
+	}
+
+	for _, c := range cases {
+		col := newCollector()
+
+		h := buildHandler(t, test.NewEnv(t), col.URL, func(cfg *config.Params) {
+			cfg.SampleProbability = c.probability
+		})
+
+		var insts []*tracespan.Instance
+		for _, id := range c.traceIDs {
+			insts = append(insts, newInstance(id, "", "", nil))
+		}
+		if err := h.HandleTraceSpan(context.Background(), insts); err != nil {
+			t.Fatalf("HandleTraceSpan() failed: %v", err)
+		}
+		_ = h.Close()
+
+		var got []string
+		for _, s := range col.zipkinSpans(t) {
+			got = append(got, s.TraceID)
+		}
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("probability %v: got traces %v, want %v", c.probability, got, c.want)
+		}
+
+		col.Close()
+	}
+}
+
+func TestBatching(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+
+	h := buildHandler(t, test.NewEnv(t), c.URL, func(cfg *config.Params) {
+		cfg.MaxBatchSize = 2
+		cfg.FlushInterval = time.Hour
+	})
+
+	var insts []*tracespan.Instance
+	for i := 0; i < 5; i++ {
+		insts = append(insts, newInstance("1", "", "", nil))
+	}
+	if err := h.HandleTraceSpan(context.Background(), insts); err != nil {
+		t.Fatalf("HandleTraceSpan() failed: %v", err)
+	}
+
+	// full batches are sent right away
+	waitFor(t, func() bool {
+		_, payloads := c.requests()
+		return len(payloads) == 2
+	})
+
+	// the remainder is sent on close
+	_ = h.Close()
+
+	_, payloads := c.requests()
+	if len(payloads) != 3 {
+		t.Fatalf("got %d requests, want 3", len(payloads))
+	}
+	if got := len(c.zipkinSpans(t)); got != 5 {
+		t.Errorf("got %d spans, want 5", got)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+
+	h := buildHandler(t, test.NewEnv(t), c.URL, func(cfg *config.Params) {
+		cfg.FlushInterval = 10 * time.Millisecond
+	})
+	defer func() { _ = h.Close() }()
+
+	if err := h.HandleTraceSpan(context.Background(), []*tracespan.Instance{newInstance("1", "", "", nil)}); err != nil {
+		t.Fatalf("HandleTraceSpan() failed: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		_, payloads := c.requests()
+		return len(payloads) == 1
+	})
+}
+
+func TestQueueOverflow(t *testing.T) {
+	env := test.NewEnv(t)
+	e := &exporter{
+		log:   env,
+		queue: make(chan *span, 1),
+	}
+
+	if !e.enqueue(&span{}) {
+		t.Error("enqueue() dropped a span while the queue has room")
+	}
+	if e.enqueue(&span{}) {
+		t.Error("enqueue() accepted a span while the queue is full")
+	}
+
+	e.reportDropped()
+	if !containsLog(env, "Dropped 1 spans") {
+		t.Errorf("no drop was reported, logs: %v", env.GetLogs())
+	}
+}
+
+func TestInvalidInstances(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+
+	h := buildHandler(t, test.NewEnv(t), c.URL, nil)
+
+	insts := []*tracespan.Instance{
+		newInstance("", "", "", nil),
+		newInstance("xyz", "", "", nil),
+		newInstance("1", "", "", nil),
+	}
+	err := h.HandleTraceSpan(context.Background(), insts)
+	if err == nil || !strings.Contains(err.Error(), "2 errors") {
+		t.Errorf("HandleTraceSpan() returned %v, want 2 errors", err)
+	}
+	_ = h.Close()
+
+	if got := len(c.zipkinSpans(t)); got != 1 {
+		t.Errorf("got %d spans, want the valid one only", got)
+	}
+}
+
+func TestCollectorErrors(t *testing.T) {
+	c := newCollector()
+	c.status = http.StatusInternalServerError
+	defer c.Close()
+
+	env := test.NewEnv(t)
+	h := buildHandler(t, env, c.URL, nil)
+	if err := h.HandleTraceSpan(context.Background(), []*tracespan.Instance{newInstance("1", "", "", nil)}); err != nil {
+		t.Fatalf("HandleTraceSpan() failed: %v", err)
+	}
+	_ = h.Close()
+
+	if !containsLog(env, "responded with 500") {
+		t.Errorf("collector error was not logged, logs: %v", env.GetLogs())
+	}
+
+	env = test.NewEnv(t)
+	h = buildHandler(t, env, "http://127.0.0.1:1/api/v2/spans", nil)
+	if err := h.HandleTraceSpan(context.Background(), []*tracespan.Instance{newInstance("1", "", "", nil)}); err != nil {
+		t.Fatalf("HandleTraceSpan() failed: %v", err)
+	}
+	_ = h.Close()
+
+	if !containsLog(env, "Unable to send spans") {
+		t.Errorf("connection error was not logged, logs: %v", env.GetLogs())
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met in time")
+}
+
+func containsLog(env *test.Env, s string) bool {
+	for _, l := range env.GetLogs() {
+		if strings.Contains(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
@@ -62,6 +62,21 @@ type rootArgs struct {
 	repeat int
 
 	tracingOptions *tracing.Options
+
+	tls tlsArgs
+}
+
+// tlsArgs holds the settings used to connect to Mixer over TLS.
+type tlsArgs struct {
+	// caCertFile is the file holding the CA certificates used to verify Mixer. TLS is only used if this is set.
+	caCertFile string
+
+	// certFile and keyFile hold the client certificate presented to Mixer, if any.
+	certFile string
+	keyFile  string
+
+	// serverName overrides the name expected in Mixer's certificate.
+	serverName string
 }
 
 func addAttributeFlags(cmd *cobra.Command, rootArgs *rootArgs) {
@@ -70,6 +85,15 @@ func addAttributeFlags(cmd *cobra.Command, rootArgs *rootArgs) {
 	cmd.PersistentFlags().IntVarP(&rootArgs.repeat, "repeat", "r", 1,
 		"Sends the specified number of requests in quick succession")
 
+	cmd.PersistentFlags().StringVarP(&rootArgs.tls.caCertFile, "tls_ca_cert", "", "",
+		"File containing the CA certificates used to verify Mixer. If set, Mixer is called over TLS")
+	cmd.PersistentFlags().StringVarP(&rootArgs.tls.certFile, "tls_cert", "", "",
+		"File containing the client certificate presented to Mixer")
+	cmd.PersistentFlags().StringVarP(&rootArgs.tls.keyFile, "tls_key", "", "",
+		"File containing the private key of the client certificate")
+	cmd.PersistentFlags().StringVarP(&rootArgs.tls.serverName, "tls_server_name", "", "",
+		"Name expected in Mixer's certificate, defaults to the host in the Mixer address")
+
 	cmd.PersistentFlags().StringVarP(&rootArgs.attributes, "attributes", "a", "",
 		"List of name/value auto-sensed attributes specified as name1=value1,name2=value2,...")
 	cmd.PersistentFlags().StringVarP(&rootArgs.stringAttributes, "string_attributes", "s", "",
@@ -16,7 +16,10 @@ package cmd
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"sort"
 	"strconv"
 	"strings"
@@ -27,6 +30,7 @@ import (
 	otgrpc "github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
 	ot "github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 
 	mixerpb "istio.io/api/mixer/v1"
@@ -40,11 +44,19 @@ type clientState struct {
 	connection *grpc.ClientConn
 }
 
-func createAPIClient(port string, tracingOptions *tracing.Options) (*clientState, error) {
+func createAPIClient(port string, tracingOptions *tracing.Options, tlsArgs *tlsArgs) (*clientState, error) {
 	cs := clientState{}
 
 	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithInsecure())
+	if tlsArgs.caCertFile == "" {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		config, err := clientTLSConfig(tlsArgs)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	}
 
 	if tracingOptions.TracingEnabled() {
 		_, err := tracing.Configure("mixer-client", tracingOptions)
@@ -64,6 +76,31 @@ func createAPIClient(port string, tracingOptions *tracing.Options) (*clientState
 	return &cs, nil
 }
 
+func clientTLSConfig(tlsArgs *tlsArgs) (*tls.Config, error) {
+	pem, err := ioutil.ReadFile(tlsArgs.caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA certificates: %v", err)
+	}
+
+	config := &tls.Config{
+		RootCAs:    x509.NewCertPool(),
+		ServerName: tlsArgs.serverName,
+	}
+	if !config.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid certificates found in %s", tlsArgs.caCertFile)
+	}
+
+	if tlsArgs.certFile != "" || tlsArgs.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsArgs.certFile, tlsArgs.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
 func deleteAPIClient(cs *clientState) {
 	_ = cs.connection.Close()
 	cs.client = nil
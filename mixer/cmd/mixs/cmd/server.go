@@ -55,7 +55,8 @@ func serverCmd(info map[string]template.Info, adapters []adapter.InfoFn, printf,
 		"If true, each request to Mixer will be executed in a single go routine (useful for debugging)")
 
 	serverCmd.PersistentFlags().StringVarP(&sa.ConfigStoreURL, "configStoreURL", "", sa.ConfigStoreURL,
-		"URL of the config store. Use k8s://path_to_kubeconfig or fs:// for file system. If path_to_kubeconfig is empty, in-cluster kubeconfig is used.")
+		"URL of the config store. Use k8s://path_to_kubeconfig, fs:// for file system or ads://host:port for an aggregated discovery "+
+			"server. If path_to_kubeconfig is empty, in-cluster kubeconfig is used.")
 
 	serverCmd.PersistentFlags().StringVarP(&sa.ConfigDefaultNamespace, "configDefaultNamespace", "", sa.ConfigDefaultNamespace,
 		"Namespace used to store mesh wide configuration.")
@@ -84,6 +85,28 @@ func serverCmd(info map[string]template.Info, adapters []adapter.InfoFn, printf,
 	serverCmd.PersistentFlags().BoolVar(&sa.EnableProfiling, "profile", sa.EnableProfiling,
 		"Enable profiling via web interface host:port/debug/pprof")
 
+	serverCmd.PersistentFlags().StringVar(&sa.TLSCertFile, "tlsCertFile", sa.TLSCertFile,
+		"File containing the certificate used to serve Mixer's gRPC API over TLS. If empty, the API is served in plaintext.")
+	serverCmd.PersistentFlags().StringVar(&sa.TLSKeyFile, "tlsKeyFile", sa.TLSKeyFile,
+		"File containing the private key of the certificate in tlsCertFile.")
+	serverCmd.PersistentFlags().StringVar(&sa.TLSClientCAFile, "tlsClientCAFile", sa.TLSClientCAFile,
+		"File containing the CA certificates used to verify client certificates. If empty, client certificates are not requested.")
+	serverCmd.PersistentFlags().BoolVar(&sa.TLSRequireClientCert, "tlsRequireClientCert", sa.TLSRequireClientCert,
+		"If true, reject clients that do not present a certificate signed by a CA in tlsClientCAFile.")
+	serverCmd.PersistentFlags().DurationVar(&sa.TLSReloadInterval, "tlsReloadInterval", sa.TLSReloadInterval,
+		"Interval at which the TLS files are checked for rotated certificates.")
+
+	serverCmd.PersistentFlags().BoolVar(&sa.AsyncReports, "asyncReports", sa.AsyncReports,
+		"If true, reports are acknowledged once queued for the handlers, and dispatched to them in batches")
+	serverCmd.PersistentFlags().IntVar(&sa.ReportQueueSize, "reportQueueSize", sa.ReportQueueSize,
+		"Max number of report instances queued for each handler when asyncReports is enabled")
+	serverCmd.PersistentFlags().IntVar(&sa.ReportBatchSize, "reportBatchSize", sa.ReportBatchSize,
+		"Max number of report instances dispatched to a handler at once when asyncReports is enabled")
+	serverCmd.PersistentFlags().DurationVar(&sa.ReportFlushInterval, "reportFlushInterval", sa.ReportFlushInterval,
+		"Max amount of time report instances are queued before being dispatched when asyncReports is enabled")
+	serverCmd.PersistentFlags().StringVar(&sa.ReportOverflowPolicy, "reportOverflowPolicy", sa.ReportOverflowPolicy,
+		"What happens to report instances when the queue of their handler is full: drop-newest, drop-oldest or block")
+
 	sa.LoggingOptions.AttachCobraFlags(serverCmd)
 	sa.TracingOptions.AttachCobraFlags(serverCmd)
 
@@ -83,11 +83,20 @@ func (s *grpcServer) Check(legacyCtx legacyContext.Context, req *mixerpb.CheckRe
 	protoBag := attribute.NewProtoBag(&req.Attributes, s.globalDict, s.globalWordList)
 	defer protoBag.Done()
 
+	// the identity of an authenticated peer takes precedence over the attributes it sent
+	var requestBag attribute.Bag = protoBag
+	if id := peerIdentity(legacyCtx); id != "" {
+		peerBag := attribute.GetMutableBag(protoBag)
+		peerBag.Set(PeerIdentityAttribute, id)
+		defer peerBag.Done()
+		requestBag = peerBag
+	}
+
 	// This holds the output state of preprocess operations
-	checkBag := attribute.GetMutableBag(protoBag)
+	checkBag := attribute.GetMutableBag(requestBag)
 	defer checkBag.Done()
 
-	if err := s.dispatcher.Preprocess(legacyCtx, protoBag, checkBag); err != nil {
+	if err := s.dispatcher.Preprocess(legacyCtx, requestBag, checkBag); err != nil {
 		err = fmt.Errorf("preprocessing attributes failed: %v", err)
 		log.Errora("Check failed:", err.Error())
 		return nil, grpc.Errorf(codes.Internal, err.Error())
@@ -202,6 +211,8 @@ func (s *grpcServer) Report(legacyCtx legacyContext.Context, req *mixerpb.Report
 	// This holds the output state of preprocess operations, which ends up as a delta over the current accumBag.
 	reportBag := attribute.GetMutableBag(accumBag)
 
+	peerID := peerIdentity(legacyCtx)
+
 	var err error
 	for i := 0; i < len(req.Attributes); i++ {
 		span, newctx := opentracing.StartSpanFromContext(legacyCtx, fmt.Sprintf("Attributes %d", i))
@@ -218,6 +229,11 @@ func (s *grpcServer) Report(legacyCtx legacyContext.Context, req *mixerpb.Report
 			}
 		}
 
+		// the identity of an authenticated peer takes precedence over the attributes it sent
+		if peerID != "" {
+			accumBag.Set(PeerIdentityAttribute, peerID)
+		}
+
 		log.Debug("Dispatching Preprocess")
 
 		if err = s.dispatcher.Preprocess(newctx, accumBag, reportBag); err != nil {
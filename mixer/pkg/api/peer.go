@@ -0,0 +1,61 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// PeerIdentityAttribute is the attribute holding the SPIFFE identity of the peer that sent the
+// request, taken from its verified client certificate. It overrides any value sent by the peer.
+const PeerIdentityAttribute = "source.principal"
+
+// peerIdentity returns the SPIFFE identity found in the verified certificate of the peer, or an
+// empty string if the peer did not present a verified certificate with such an identity.
+func peerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	san := util.ExtractSANExtension(tlsInfo.State.VerifiedChains[0][0].Extensions)
+	if san == nil {
+		return ""
+	}
+
+	ids, err := util.ExtractIDsFromSAN(san)
+	if err != nil {
+		return ""
+	}
+
+	for _, id := range ids {
+		if id.Type == util.TypeURI && strings.HasPrefix(string(id.Value), util.URIScheme+"://") {
+			return string(id.Value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func peerContext(t *testing.T, verified bool, hosts string) context.Context {
+	cert := &x509.Certificate{}
+	if hosts != "" {
+		san, err := util.BuildSubjectAltNameExtension(hosts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert.Extensions = []pkix.Extension{*san}
+	}
+
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if verified {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+func TestPeerIdentity(t *testing.T) {
+	const id = "spiffe://cluster.local/ns/default/sa/bookinfo-productpage"
+
+	for _, c := range []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"no peer", context.Background(), ""},
+		{"plaintext", peer.NewContext(context.Background(), &peer.Peer{}), ""},
+		{"unverified", peerContext(t, false, id), ""},
+		{"no SAN", peerContext(t, true, ""), ""},
+		{"no SPIFFE identity", peerContext(t, true, "mixer.istio-system,https://example.com"), ""},
+		{"SPIFFE identity", peerContext(t, true, "mixer.istio-system,"+id), id},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := peerIdentity(c.ctx); got != c.want {
+				t.Errorf("peerIdentity() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
@@ -95,6 +95,11 @@ func (s StringMap) Get(key string) (string, bool) {
 	return str, found
 }
 
+// Len returns the number of entries in the stringmap
+func (s StringMap) Len() int {
+	return len(s.entries)
+}
+
 // Get returns an attribute value.
 func (pb *ProtoBag) Get(name string) (interface{}, bool) {
 	// find the dictionary index for the given string
@@ -0,0 +1,218 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ads
+
+import (
+	"net"
+	"strconv"
+	"sync"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/mixer/pkg/config/store"
+)
+
+// fakeServer is an in-process aggregated discovery server, which serves the resources
+// set by the test to each stream, for the subscribed type URLs.
+type fakeServer struct {
+	server   *grpc.Server
+	listener net.Listener
+
+	mu        sync.Mutex
+	resources map[store.Key]*store.BackEndResource
+	version   int
+	// changed is closed when the resources are updated
+	changed chan struct{}
+	// broken is closed to break the current streams
+	broken chan struct{}
+	// streams is the number of streams opened so far
+	streams int
+	// acks and nacks are the numbers of responses accepted and rejected by the clients
+	acks  int
+	nacks int
+}
+
+func newFakeServer(resources ...*store.BackEndResource) (*fakeServer, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	f := &fakeServer{
+		server:    grpc.NewServer(),
+		listener:  l,
+		resources: map[store.Key]*store.BackEndResource{},
+		changed:   make(chan struct{}),
+		broken:    make(chan struct{}),
+	}
+	for _, r := range resources {
+		f.resources[r.Key()] = r
+	}
+	ads.RegisterAggregatedDiscoveryServiceServer(f.server, f)
+	go func() { _ = f.server.Serve(l) }()
+	return f, nil
+}
+
+// Addr returns the address the server listens on.
+func (f *fakeServer) Addr() string {
+	return f.listener.Addr().String()
+}
+
+// Close stops the server.
+func (f *fakeServer) Close() {
+	f.server.Stop()
+}
+
+// Set adds or replaces resources, and pushes them to the streams.
+func (f *fakeServer) Set(resources ...*store.BackEndResource) {
+	f.Update(func(m map[store.Key]*store.BackEndResource) {
+		for _, r := range resources {
+			m[r.Key()] = r
+		}
+	})
+}
+
+// Delete removes resources, and pushes the change to the streams.
+func (f *fakeServer) Delete(keys ...store.Key) {
+	f.Update(func(m map[store.Key]*store.BackEndResource) {
+		for _, k := range keys {
+			delete(m, k)
+		}
+	})
+}
+
+// Update changes the resources and pushes them to the streams.
+func (f *fakeServer) Update(fn func(map[store.Key]*store.BackEndResource)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f.resources)
+	f.version++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Disconnect breaks the current streams after changing the resources. The change is not
+// pushed to the broken streams, so that the clients only get it from a resync.
+func (f *fakeServer) Disconnect(fn func(map[store.Key]*store.BackEndResource)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f.resources)
+	f.version++
+	close(f.broken)
+	f.broken = make(chan struct{})
+}
+
+// Stats returns the number of streams, acks and nacks so far.
+func (f *fakeServer) Stats() (streams, acks, nacks int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.streams, f.acks, f.nacks
+}
+
+// response builds the response with all the resources of a type URL.
+func (f *fakeServer) response(typeURL string) (*xdsapi.DiscoveryResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := &xdsapi.DiscoveryResponse{
+		TypeUrl:     typeURL,
+		VersionInfo: strconv.Itoa(f.version),
+		Nonce:       strconv.Itoa(f.version),
+		Resources:   []types.Any{},
+	}
+	for _, r := range f.resources {
+		if TypeURL(r.Kind) != typeURL {
+			continue
+		}
+		a, err := MarshalResource(r)
+		if err != nil {
+			return nil, err
+		}
+		out.Resources = append(out.Resources, *a)
+	}
+	return out, nil
+}
+
+// StreamAggregatedResources implements the aggregated discovery service.
+func (f *fakeServer) StreamAggregatedResources(stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	f.mu.Lock()
+	f.streams++
+	broken := f.broken
+	changed := f.changed
+	f.mu.Unlock()
+
+	donec := make(chan struct{})
+	defer close(donec)
+	reqc := make(chan *xdsapi.DiscoveryRequest)
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case reqc <- req:
+			case <-donec:
+				return
+			}
+		}
+	}()
+
+	subscribed := map[string]bool{}
+	for {
+		var typeURLs []string
+		select {
+		case req := <-reqc:
+			if req.ResponseNonce != "" {
+				f.mu.Lock()
+				if req.VersionInfo == req.ResponseNonce {
+					f.acks++
+				} else {
+					f.nacks++
+				}
+				f.mu.Unlock()
+				continue
+			}
+			subscribed[req.TypeUrl] = true
+			typeURLs = []string{req.TypeUrl}
+		case <-changed:
+			f.mu.Lock()
+			changed = f.changed
+			f.mu.Unlock()
+			for typeURL := range subscribed {
+				typeURLs = append(typeURLs, typeURL)
+			}
+		case <-broken:
+			return status.Error(codes.Unavailable, "disconnected by the server")
+		case err := <-errc:
+			return err
+		}
+
+		for _, typeURL := range typeURLs {
+			resp, err := f.response(typeURL)
+			if err != nil {
+				return err
+			}
+			if err = stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ads
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/probe"
+)
+
+const (
+	// Scheme is the URL scheme of the config store, like ads://pilot:15010
+	Scheme = "ads"
+
+	// defaultNodeID is the node identifier sent to the server. It can be customized
+	// through the "node" query parameter in the config URL, like ads://pilot:15010?node=mixer-1
+	defaultNodeID = "mixer"
+
+	// defaultRetryTimeout is the default duration Init waits for the initial data of all kinds.
+	// The timeout can be customized through the "retry-timeout" query parameter in the config URL,
+	// like ads://pilot:15010?retry-timeout=1m
+	defaultRetryTimeout = time.Second * 30
+
+	// initialBackoff and maxBackoff bound the delay between two connection attempts.
+	initialBackoff = time.Second / 10
+	maxBackoff     = time.Second * 30
+)
+
+// NewStore creates a new Store instance.
+func NewStore(u *url.URL) (store.Backend, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing server address in the config URL %s", u)
+	}
+	retryTimeout := defaultRetryTimeout
+	if param := u.Query().Get("retry-timeout"); param != "" {
+		if timeout, err := time.ParseDuration(param); err == nil {
+			retryTimeout = timeout
+		} else {
+			log.Errorf("Failed to parse retry-timeout flag, using the default timeout %v: %v", defaultRetryTimeout, err)
+		}
+	}
+	nodeID := u.Query().Get("node")
+	if nodeID == "" {
+		nodeID = defaultNodeID
+	}
+	return &Store{
+		address:        u.Host,
+		nodeID:         nodeID,
+		retryTimeout:   retryTimeout,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		donec:          make(chan struct{}),
+		Probe:          probe.NewProbe(),
+	}, nil
+}
+
+// Register registers this module as a StoreBackend.
+// Do not use 'init()' for automatic registration; linker will drop
+// the whole module because it looks unused.
+func Register(builders map[string]store.Builder) {
+	builders[Scheme] = NewStore
+}
@@ -0,0 +1,334 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ads provides the config store backend which receives the config resources from
+// a server of the envoy aggregated discovery service (ADS), like pilot.
+//
+// The store subscribes to the type URL of each kind on a single stream. Each response
+// carries the full set of resources of a kind, encoded as google.protobuf.Struct, and
+// replaces the previous set. When the stream breaks, the store reconnects with an
+// exponential backoff and subscribes again, which resyncs all the kinds.
+package ads
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/probe"
+)
+
+// typeURLPrefix is the prefix of the type URL of each kind, as for the CRDs of the kinds.
+const typeURLPrefix = "config.istio.io/v1alpha2/"
+
+// TypeURL returns the type URL the resources of a kind are subscribed with.
+func TypeURL(kind string) string {
+	return typeURLPrefix + kind
+}
+
+// MarshalResource encodes a resource for a discovery response.
+func MarshalResource(r *store.BackEndResource) (*types.Any, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"kind": r.Kind,
+		"metadata": map[string]interface{}{
+			"name":        r.Metadata.Name,
+			"namespace":   r.Metadata.Namespace,
+			"labels":      r.Metadata.Labels,
+			"annotations": r.Metadata.Annotations,
+			"revision":    r.Metadata.Revision,
+		},
+		"spec": r.Spec,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pb := &types.Struct{}
+	if err = jsonpb.Unmarshal(bytes.NewReader(data), pb); err != nil {
+		return nil, err
+	}
+	return types.MarshalAny(pb)
+}
+
+// UnmarshalResource decodes a resource of a discovery response.
+func UnmarshalResource(a *types.Any) (*store.BackEndResource, error) {
+	pb := &types.Struct{}
+	if err := types.UnmarshalAny(a, pb); err != nil {
+		return nil, err
+	}
+	data, err := (&jsonpb.Marshaler{}).MarshalToString(pb)
+	if err != nil {
+		return nil, err
+	}
+	r := &store.BackEndResource{}
+	if err = json.Unmarshal([]byte(data), r); err != nil {
+		return nil, err
+	}
+	if r.Kind == "" || r.Metadata.Namespace == "" || r.Metadata.Name == "" {
+		return nil, fmt.Errorf("key elements are empty. Extracted as %s from %s", r.Key(), data)
+	}
+	return r, nil
+}
+
+// Store offers store.Backend interface through the aggregated discovery service.
+type Store struct {
+	address      string
+	nodeID       string
+	retryTimeout time.Duration
+	donec        chan struct{}
+
+	// The delays between the connection attempts. They are not const to allow
+	// changing the values for unittests.
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	kinds map[string]bool
+
+	mu   sync.RWMutex
+	data map[store.Key]*store.BackEndResource
+	// synced has the kinds received at least once. syncedc is closed when all the kinds are.
+	synced  map[string]bool
+	syncedc chan struct{}
+
+	watchMutex sync.RWMutex
+	watchCh    chan store.BackendEvent
+
+	*probe.Probe
+}
+
+var _ store.Backend = new(Store)
+var _ probe.SupportsProbe = new(Store)
+
+// Stop implements store.Backend interface.
+func (s *Store) Stop() {
+	close(s.donec)
+}
+
+// Init implements store.Backend interface. It waits for the initial data of all the kinds
+// until the retry timeout, and keeps receiving them asynchronously afterwards.
+func (s *Store) Init(kinds []string) error {
+	s.kinds = make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		s.kinds[k] = true
+	}
+	s.data = map[store.Key]*store.BackEndResource{}
+	s.synced = map[string]bool{}
+	s.syncedc = make(chan struct{})
+	if len(kinds) == 0 {
+		close(s.syncedc)
+	}
+
+	go s.run()
+
+	select {
+	case <-s.syncedc:
+	case <-s.donec:
+	case <-time.After(s.retryTimeout):
+		log.Warnf("Config from %s is not yet ready after %v, waiting asynchronously", s.address, s.retryTimeout)
+	}
+	return nil
+}
+
+// run maintains the stream to the server until the store is stopped.
+func (s *Store) run() {
+	backoff := s.initialBackoff
+	for {
+		received, err := s.stream()
+		select {
+		case <-s.donec:
+			return
+		default:
+		}
+		if received {
+			backoff = s.initialBackoff
+		}
+		s.SetAvailable(err)
+		log.Warnf("Config stream to %s is broken, reconnecting in %v: %v", s.address, backoff, err)
+
+		select {
+		case <-s.donec:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// stream subscribes to all the kinds and applies the responses until the stream breaks.
+// It returns whether a response was received, and the error which broke the stream.
+func (s *Store) stream() (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.donec:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	conn, err := grpc.Dial(s.address, grpc.WithInsecure())
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	stream, err := ads.NewAggregatedDiscoveryServiceClient(conn).StreamAggregatedResources(ctx)
+	if err != nil {
+		return false, err
+	}
+	node := &core.Node{Id: s.nodeID}
+	for kind := range s.kinds {
+		if err = stream.Send(&xdsapi.DiscoveryRequest{Node: node, TypeUrl: TypeURL(kind)}); err != nil {
+			return false, err
+		}
+	}
+
+	// versions has the last version applied for each type URL, sent back to reject a response.
+	versions := map[string]string{}
+	received := false
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
+
+		version := resp.VersionInfo
+		if err = s.apply(resp); err != nil {
+			log.Errorf("Rejected config version %q of %s: %v", resp.VersionInfo, resp.TypeUrl, err)
+			version = versions[resp.TypeUrl]
+		} else {
+			versions[resp.TypeUrl] = version
+			s.SetAvailable(nil)
+		}
+
+		err = stream.Send(&xdsapi.DiscoveryRequest{
+			Node:          node,
+			TypeUrl:       resp.TypeUrl,
+			VersionInfo:   version,
+			ResponseNonce: resp.Nonce,
+		})
+		if err != nil {
+			return received, err
+		}
+	}
+}
+
+// apply replaces the resources of a kind with the ones of the response, and dispatches
+// the changes to the watcher.
+func (s *Store) apply(resp *xdsapi.DiscoveryResponse) error {
+	kind := strings.TrimPrefix(resp.TypeUrl, typeURLPrefix)
+	if kind == resp.TypeUrl || !s.kinds[kind] {
+		return fmt.Errorf("unexpected type %s", resp.TypeUrl)
+	}
+
+	resources := make(map[store.Key]*store.BackEndResource, len(resp.Resources))
+	for i := range resp.Resources {
+		r, err := UnmarshalResource(&resp.Resources[i])
+		if err != nil {
+			return err
+		}
+		if r.Kind != kind {
+			return fmt.Errorf("resource %s does not match the type %s", r.Key(), resp.TypeUrl)
+		}
+		resources[r.Key()] = r
+	}
+
+	evs := []store.BackendEvent{}
+	s.mu.Lock()
+	for k, r := range resources {
+		if old, ok := s.data[k]; !ok || !reflect.DeepEqual(old, r) {
+			evs = append(evs, store.BackendEvent{Type: store.Update, Key: k, Value: r})
+		}
+		s.data[k] = r
+	}
+	for k := range s.data {
+		if _, ok := resources[k]; !ok && k.Kind == kind {
+			evs = append(evs, store.BackendEvent{Type: store.Delete, Key: k})
+			delete(s.data, k)
+		}
+	}
+	if !s.synced[kind] {
+		s.synced[kind] = true
+		if len(s.synced) == len(s.kinds) {
+			close(s.syncedc)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, ev := range evs {
+		s.dispatch(ev)
+	}
+	return nil
+}
+
+func (s *Store) dispatch(ev store.BackendEvent) {
+	s.watchMutex.RLock()
+	defer s.watchMutex.RUnlock()
+	if s.watchCh == nil {
+		return
+	}
+	select {
+	case <-s.donec:
+	case s.watchCh <- ev:
+	}
+}
+
+// Watch implements store.Backend interface.
+func (s *Store) Watch() (<-chan store.BackendEvent, error) {
+	ch := make(chan store.BackendEvent)
+	s.watchMutex.Lock()
+	s.watchCh = ch
+	s.watchMutex.Unlock()
+	return ch, nil
+}
+
+// Get implements store.Backend interface.
+func (s *Store) Get(key store.Key) (*store.BackEndResource, error) {
+	s.mu.RLock()
+	r, ok := s.data[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return r, nil
+}
+
+// List implements store.Backend interface.
+func (s *Store) List() map[store.Key]*store.BackEndResource {
+	s.mu.RLock()
+	result := make(map[store.Key]*store.BackEndResource, len(s.data))
+	for k, r := range s.data {
+		result[k] = r
+	}
+	s.mu.RUnlock()
+	return result
+}
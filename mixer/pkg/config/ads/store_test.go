@@ -0,0 +1,210 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ads
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/pkg/config/store"
+)
+
+const testTimeout = 5 * time.Second
+
+func resource(kind, name, value string) *store.BackEndResource {
+	return &store.BackEndResource{
+		Kind: kind,
+		Metadata: store.ResourceMeta{
+			Name:      name,
+			Namespace: "ns",
+			Labels:    map[string]string{"app": name},
+			Revision:  value,
+		},
+		Spec: map[string]interface{}{"value": value, "count": float64(1)},
+	}
+}
+
+func newTestStore(t *testing.T, f *fakeServer, kinds ...string) *Store {
+	u, err := url.Parse("ads://" + f.Addr() + "?retry-timeout=" + testTimeout.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewStore(u)
+	if err != nil {
+		t.Fatalf("NewStore() => unexpected error %v", err)
+	}
+	s := b.(*Store)
+	s.initialBackoff = time.Millisecond
+	s.maxBackoff = 10 * time.Millisecond
+	if err = s.Init(kinds); err != nil {
+		t.Fatalf("Init() => unexpected error %v", err)
+	}
+	return s
+}
+
+func waitFor(t *testing.T, ch <-chan store.BackendEvent, want store.BackendEvent) {
+	select {
+	case ev := <-ch:
+		if !reflect.DeepEqual(ev, want) {
+			t.Errorf("got event %+v, want %+v", ev, want)
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out waiting for event %+v", want)
+	}
+}
+
+func TestMarshalResource(t *testing.T) {
+	r := resource("Handler", "h1", "v1")
+	a, err := MarshalResource(r)
+	if err != nil {
+		t.Fatalf("MarshalResource() => unexpected error %v", err)
+	}
+	got, err := UnmarshalResource(a)
+	if err != nil {
+		t.Fatalf("UnmarshalResource() => unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(got, r) {
+		t.Errorf("UnmarshalResource() => %+v, want %+v", got, r)
+	}
+
+	a, _ = MarshalResource(&store.BackEndResource{Kind: "Handler"})
+	if _, err = UnmarshalResource(a); err == nil {
+		t.Error("UnmarshalResource() => want error for a resource without name")
+	}
+}
+
+func TestNewStoreBadURL(t *testing.T) {
+	if _, err := store.NewRegistry(Register).NewStore("ads://"); err == nil {
+		t.Error("NewStore() => want error for a URL without address")
+	}
+	if _, err := store.NewRegistry(Register).NewStore("ads://localhost:15010?node=mixer-1"); err != nil {
+		t.Errorf("NewStore() => unexpected error %v", err)
+	}
+}
+
+func TestStoreInitAndList(t *testing.T) {
+	h1 := resource("Handler", "h1", "v1")
+	r1 := resource("Rule", "r1", "v1")
+	f, err := newFakeServer(h1, r1, resource("Unknown", "u1", "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := newTestStore(t, f, "Handler", "Rule")
+	defer s.Stop()
+
+	want := map[store.Key]*store.BackEndResource{h1.Key(): h1, r1.Key(): r1}
+	if got := s.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() => %+v, want %+v", got, want)
+	}
+	if got, err := s.Get(h1.Key()); err != nil || !reflect.DeepEqual(got, h1) {
+		t.Errorf("Get(%s) => %+v, %v, want %+v", h1.Key(), got, err, h1)
+	}
+	if _, err := s.Get(store.Key{Kind: "Handler", Namespace: "ns", Name: "missing"}); err != store.ErrNotFound {
+		t.Errorf("Get() => %v, want %v", err, store.ErrNotFound)
+	}
+}
+
+func TestStoreWatch(t *testing.T) {
+	h1 := resource("Handler", "h1", "v1")
+	f, err := newFakeServer(h1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := newTestStore(t, f, "Handler", "Rule")
+	defer s.Stop()
+	ch, err := s.Watch()
+	if err != nil {
+		t.Fatalf("Watch() => unexpected error %v", err)
+	}
+
+	r1 := resource("Rule", "r1", "v1")
+	f.Set(r1)
+	waitFor(t, ch, store.BackendEvent{Type: store.Update, Key: r1.Key(), Value: r1})
+
+	h1v2 := resource("Handler", "h1", "v2")
+	f.Set(h1v2)
+	waitFor(t, ch, store.BackendEvent{Type: store.Update, Key: h1.Key(), Value: h1v2})
+
+	f.Delete(r1.Key())
+	waitFor(t, ch, store.BackendEvent{Type: store.Delete, Key: r1.Key()})
+
+	if _, acks, nacks := f.Stats(); acks == 0 || nacks != 0 {
+		t.Errorf("got %d acks and %d nacks, want acks and no nacks", acks, nacks)
+	}
+}
+
+func TestStoreResync(t *testing.T) {
+	h1 := resource("Handler", "h1", "v1")
+	h2 := resource("Handler", "h2", "v1")
+	f, err := newFakeServer(h1, h2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := newTestStore(t, f, "Handler")
+	defer s.Stop()
+	ch, err := s.Watch()
+	if err != nil {
+		t.Fatalf("Watch() => unexpected error %v", err)
+	}
+
+	// the deletion is only seen by resyncing after the reconnection
+	f.Disconnect(func(m map[store.Key]*store.BackEndResource) {
+		delete(m, h2.Key())
+	})
+	waitFor(t, ch, store.BackendEvent{Type: store.Delete, Key: h2.Key()})
+
+	if streams, _, _ := f.Stats(); streams < 2 {
+		t.Errorf("got %d streams, want a reconnection", streams)
+	}
+	want := map[store.Key]*store.BackEndResource{h1.Key(): h1}
+	if got := s.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() => %+v, want %+v", got, want)
+	}
+}
+
+func TestStoreInitTimeout(t *testing.T) {
+	f, err := newFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := f.Addr()
+	f.Close()
+
+	u, _ := url.Parse("ads://" + addr + "?retry-timeout=10ms")
+	b, err := NewStore(u)
+	if err != nil {
+		t.Fatalf("NewStore() => unexpected error %v", err)
+	}
+	defer b.Stop()
+
+	start := time.Now()
+	if err = b.Init([]string{"Handler"}); err != nil {
+		t.Errorf("Init() => unexpected error %v", err)
+	}
+	if d := time.Since(start); d > testTimeout {
+		t.Errorf("Init() took %v, want the retry timeout", d)
+	}
+	if got := b.List(); len(got) != 0 {
+		t.Errorf("List() => %+v, want empty", got)
+	}
+}
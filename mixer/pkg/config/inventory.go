@@ -15,6 +15,7 @@
 package config
 
 import (
+	"istio.io/istio/mixer/pkg/config/ads"
 	"istio.io/istio/mixer/pkg/config/crd"
 	"istio.io/istio/mixer/pkg/config/store"
 )
@@ -23,5 +24,6 @@ import (
 func StoreInventory() []store.RegisterFunc {
 	return []store.RegisterFunc{
 		crd.Register,
+		ads.Register,
 	}
 }
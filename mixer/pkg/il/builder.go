@@ -182,6 +182,86 @@ func (f *Builder) AEQDouble(v float64) {
 	f.op2(AEqD, a1, a2)
 }
 
+// AddInteger appends the "add_i" instruction to the byte code.
+func (f *Builder) AddInteger() {
+	f.op0(AddI)
+}
+
+// AddDouble appends the "add_d" instruction to the byte code.
+func (f *Builder) AddDouble() {
+	f.op0(AddD)
+}
+
+// SubInteger appends the "sub_i" instruction to the byte code.
+func (f *Builder) SubInteger() {
+	f.op0(SubI)
+}
+
+// SubDouble appends the "sub_d" instruction to the byte code.
+func (f *Builder) SubDouble() {
+	f.op0(SubD)
+}
+
+// MulInteger appends the "mul_i" instruction to the byte code.
+func (f *Builder) MulInteger() {
+	f.op0(MulI)
+}
+
+// MulDouble appends the "mul_d" instruction to the byte code.
+func (f *Builder) MulDouble() {
+	f.op0(MulD)
+}
+
+// DivInteger appends the "div_i" instruction to the byte code.
+func (f *Builder) DivInteger() {
+	f.op0(DivI)
+}
+
+// DivDouble appends the "div_d" instruction to the byte code.
+func (f *Builder) DivDouble() {
+	f.op0(DivD)
+}
+
+// LTInteger appends the "lt_i" instruction to the byte code.
+func (f *Builder) LTInteger() {
+	f.op0(LtI)
+}
+
+// LTDouble appends the "lt_d" instruction to the byte code.
+func (f *Builder) LTDouble() {
+	f.op0(LtD)
+}
+
+// LEInteger appends the "le_i" instruction to the byte code.
+func (f *Builder) LEInteger() {
+	f.op0(LeI)
+}
+
+// LEDouble appends the "le_d" instruction to the byte code.
+func (f *Builder) LEDouble() {
+	f.op0(LeD)
+}
+
+// GTInteger appends the "gt_i" instruction to the byte code.
+func (f *Builder) GTInteger() {
+	f.op0(GtI)
+}
+
+// GTDouble appends the "gt_d" instruction to the byte code.
+func (f *Builder) GTDouble() {
+	f.op0(GtD)
+}
+
+// GEInteger appends the "ge_i" instruction to the byte code.
+func (f *Builder) GEInteger() {
+	f.op0(GeI)
+}
+
+// GEDouble appends the "ge_d" instruction to the byte code.
+func (f *Builder) GEDouble() {
+	f.op0(GeD)
+}
+
 // Not appends the "not" instruction to the byte code.
 func (f *Builder) Not() {
 	f.op0(Not)
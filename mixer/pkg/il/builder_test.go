@@ -139,6 +139,150 @@ var builderTests = []builderTest{
 			1076117241,
 		},
 	},
+	{
+		n: "addinteger",
+		i: func(b *Builder) {
+			b.AddInteger()
+		},
+		e: []uint32{
+			uint32(AddI),
+		},
+	},
+	{
+		n: "adddouble",
+		i: func(b *Builder) {
+			b.AddDouble()
+		},
+		e: []uint32{
+			uint32(AddD),
+		},
+	},
+	{
+		n: "subinteger",
+		i: func(b *Builder) {
+			b.SubInteger()
+		},
+		e: []uint32{
+			uint32(SubI),
+		},
+	},
+	{
+		n: "subdouble",
+		i: func(b *Builder) {
+			b.SubDouble()
+		},
+		e: []uint32{
+			uint32(SubD),
+		},
+	},
+	{
+		n: "mulinteger",
+		i: func(b *Builder) {
+			b.MulInteger()
+		},
+		e: []uint32{
+			uint32(MulI),
+		},
+	},
+	{
+		n: "muldouble",
+		i: func(b *Builder) {
+			b.MulDouble()
+		},
+		e: []uint32{
+			uint32(MulD),
+		},
+	},
+	{
+		n: "divinteger",
+		i: func(b *Builder) {
+			b.DivInteger()
+		},
+		e: []uint32{
+			uint32(DivI),
+		},
+	},
+	{
+		n: "divdouble",
+		i: func(b *Builder) {
+			b.DivDouble()
+		},
+		e: []uint32{
+			uint32(DivD),
+		},
+	},
+	{
+		n: "ltinteger",
+		i: func(b *Builder) {
+			b.LTInteger()
+		},
+		e: []uint32{
+			uint32(LtI),
+		},
+	},
+	{
+		n: "ltdouble",
+		i: func(b *Builder) {
+			b.LTDouble()
+		},
+		e: []uint32{
+			uint32(LtD),
+		},
+	},
+	{
+		n: "leinteger",
+		i: func(b *Builder) {
+			b.LEInteger()
+		},
+		e: []uint32{
+			uint32(LeI),
+		},
+	},
+	{
+		n: "ledouble",
+		i: func(b *Builder) {
+			b.LEDouble()
+		},
+		e: []uint32{
+			uint32(LeD),
+		},
+	},
+	{
+		n: "gtinteger",
+		i: func(b *Builder) {
+			b.GTInteger()
+		},
+		e: []uint32{
+			uint32(GtI),
+		},
+	},
+	{
+		n: "gtdouble",
+		i: func(b *Builder) {
+			b.GTDouble()
+		},
+		e: []uint32{
+			uint32(GtD),
+		},
+	},
+	{
+		n: "geinteger",
+		i: func(b *Builder) {
+			b.GEInteger()
+		},
+		e: []uint32{
+			uint32(GeI),
+		},
+	},
+	{
+		n: "gedouble",
+		i: func(b *Builder) {
+			b.GEDouble()
+		},
+		e: []uint32{
+			uint32(GeD),
+		},
+	},
 	{
 		n: "ret",
 		i: func(b *Builder) {
@@ -29,6 +29,9 @@ type Extern struct {
 	returnType il.Type
 
 	v reflect.Value
+
+	// fn is the function itself, used for invoking the common signatures without reflection.
+	fn interface{}
 }
 
 // ExternFromFn creates a new, reflection based Extern, based on the given function. It panics if the
@@ -91,6 +94,7 @@ func ExternFromFn(name string, fn interface{}) Extern {
 		paramTypes: paramTypes,
 		returnType: returnType,
 		v:          v,
+		fn:         fn,
 	}
 }
 
@@ -122,8 +126,11 @@ func ilType(t reflect.Type) il.Type {
 			return il.Interface
 		}
 	case reflect.Interface:
-		switch t.Name() {
-		case "StringMap":
+		switch {
+		case t.Name() == "StringMap":
+			return il.Interface
+		case t.NumMethod() == 0:
+			// Any value kept on the heap, like strings, string maps and IP addresses.
 			return il.Interface
 		}
 	}
@@ -141,11 +148,15 @@ func ilType(t reflect.Type) il.Type {
 // the stack first).
 func (e Extern) invoke(s *il.StringTable, heap []interface{}, hp *uint32, stack []uint32, sp uint32) (uint32, uint32, error) {
 
-	// Convert the parameters on stack to reflect.Values.
-	ins := make([]reflect.Value, len(e.paramTypes))
-
 	// ap is the index to the beginning of the arguments.
 	ap := sp - typesStackAllocSize(e.paramTypes)
+
+	if o1, o2, ok, err := e.invokeDirect(heap, hp, stack, ap); ok {
+		return o1, o2, err
+	}
+
+	// Convert the parameters on stack to reflect.Values.
+	ins := make([]reflect.Value, len(e.paramTypes))
 	for i := 0; i < len(e.paramTypes); i++ {
 
 		switch e.paramTypes[i] {
@@ -243,3 +254,81 @@ func (e Extern) invoke(s *il.StringTable, heap []interface{}, hp *uint32, stack
 		panic("interpreter.Extern.invoke: unrecognized return type")
 	}
 }
+
+// invokeDirect calls the functions with the common signatures without going through reflection, which
+// avoids allocating the parameter and return values on each call. It returns false as the third value
+// if the signature of the function is not one of them.
+func (e Extern) invokeDirect(heap []interface{}, hp *uint32, stack []uint32, ap uint32) (uint32, uint32, bool, error) {
+	switch fn := e.fn.(type) {
+	case func(string) string:
+		return pushString(heap, hp, fn(heap[stack[ap]].(string)), nil)
+
+	case func(string) (string, error):
+		str, err := fn(heap[stack[ap]].(string))
+		return pushString(heap, hp, str, err)
+
+	case func(string, string) string:
+		return pushString(heap, hp, fn(heap[stack[ap]].(string), heap[stack[ap+1]].(string)), nil)
+
+	case func(string, string) bool:
+		return pushBool(fn(heap[stack[ap]].(string), heap[stack[ap+1]].(string)), nil)
+
+	case func(string, string) (bool, error):
+		return pushBool(fn(heap[stack[ap]].(string), heap[stack[ap+1]].(string)))
+
+	case func(string, string, string) string:
+		return pushString(heap, hp, fn(heap[stack[ap]].(string), heap[stack[ap+1]].(string), heap[stack[ap+2]].(string)), nil)
+
+	case func(string, string, string) (string, error):
+		str, err := fn(heap[stack[ap]].(string), heap[stack[ap+1]].(string), heap[stack[ap+2]].(string))
+		return pushString(heap, hp, str, err)
+
+	case func(bool, string, string) string:
+		return pushString(heap, hp, fn(stack[ap] != 0, heap[stack[ap+1]].(string), heap[stack[ap+2]].(string)), nil)
+
+	case func(string, int64, int64) (string, error):
+		begin := il.ByteCodeToInteger(stack[ap+2], stack[ap+1])
+		end := il.ByteCodeToInteger(stack[ap+4], stack[ap+3])
+		str, err := fn(heap[stack[ap]].(string), begin, end)
+		return pushString(heap, hp, str, err)
+
+	case func(string) (time.Duration, error):
+		d, err := fn(heap[stack[ap]].(string))
+		if err != nil {
+			return 0, 0, true, err
+		}
+		o1, o2 := il.IntegerToByteCode(int64(d))
+		return o2, o1, true, nil
+
+	case func(time.Time, string) string:
+		return pushString(heap, hp, fn(heap[stack[ap]].(time.Time), heap[stack[ap+1]].(string)), nil)
+
+	case func([]byte, string) (bool, error):
+		// IP addresses can also be held as net.IP, which is left to the reflection based conversion.
+		if b, ok := heap[stack[ap]].([]byte); ok {
+			return pushBool(fn(b, heap[stack[ap+1]].(string)))
+		}
+
+	case func(interface{}) int64:
+		o1, o2 := il.IntegerToByteCode(fn(heap[stack[ap]]))
+		return o2, o1, true, nil
+	}
+
+	return 0, 0, false, nil
+}
+
+func pushString(heap []interface{}, hp *uint32, str string, err error) (uint32, uint32, bool, error) {
+	if err != nil {
+		return 0, 0, true, err
+	}
+	heap[*hp] = str
+	*hp++
+	return *hp - 1, 0, true, nil
+}
+
+func pushBool(b bool, err error) (uint32, uint32, bool, error) {
+	if err != nil || !b {
+		return 0, 0, true, err
+	}
+	return 1, 0, true, nil
+}
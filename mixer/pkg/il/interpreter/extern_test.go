@@ -15,7 +15,12 @@
 package interpreter
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
 	"istio.io/istio/mixer/pkg/il"
 )
@@ -105,3 +110,103 @@ func TestExternFromFn_UnrecognizedReturnType(t *testing.T) {
 	hp := uint32(0)
 	_, _, _ = e.invoke(p.Strings(), heap, &hp, stack, sp)
 }
+
+func TestExternFromFn_Direct(t *testing.T) {
+	errFoo := errors.New("foo")
+	ts := time.Date(2018, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		fn     interface{}
+		params []interface{}
+	}{
+		{"string", strings.ToUpper, []interface{}{"abc"}},
+		{"string/error", func(s string) (string, error) { return s + "!", nil }, []interface{}{"abc"}},
+		{"string/error/err", func(s string) (string, error) { return "", errFoo }, []interface{}{"abc"}},
+		{"2 strings", func(a, b string) string { return a + b }, []interface{}{"abc", "def"}},
+		{"2 strings/bool", strings.HasPrefix, []interface{}{"abc", "ab"}},
+		{"2 strings/bool/false", strings.HasPrefix, []interface{}{"abc", "bc"}},
+		{"2 strings/bool/error", func(a, b string) (bool, error) { return a == b, nil }, []interface{}{"abc", "abc"}},
+		{"2 strings/bool/error/err", func(a, b string) (bool, error) { return false, errFoo }, []interface{}{"abc", "abc"}},
+		{"3 strings", func(a, b, c string) string { return a + b + c }, []interface{}{"a", "b", "c"}},
+		{"3 strings/error", func(a, b, c string) (string, error) { return a + b + c, nil }, []interface{}{"a", "b", "c"}},
+		{"bool/2 strings", func(c bool, a, b string) string {
+			if c {
+				return a
+			}
+			return b
+		}, []interface{}{false, "a", "b"}},
+		{"string/2 integers", func(s string, b, e int64) (string, error) { return s[b:e], nil }, []interface{}{"abcdef", int64(1), int64(3)}},
+		{"interface", func(v interface{}) int64 { return int64(len(v.(string))) - 5 }, []interface{}{"abc"}},
+		{"duration", time.ParseDuration, []interface{}{"-3h"}},
+		{"duration/err", time.ParseDuration, []interface{}{"3x"}},
+		{"time/string", func(t time.Time, l string) string { return t.Format(l) }, []interface{}{ts, time.RFC3339}},
+		{"bytes/string", func(ip []byte, s string) (bool, error) { return net.IP(ip).String() == s, nil }, []interface{}{[]byte(net.ParseIP("1.2.3.4")), "1.2.3.4"}},
+		{"net.IP/string", func(ip []byte, s string) (bool, error) { return net.IP(ip).String() == s, nil }, []interface{}{net.ParseIP("1.2.3.4"), "1.2.3.4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			direct := ExternFromFn("foo", tt.fn)
+			reflected := direct
+			reflected.fn = nil
+
+			p := il.NewProgram()
+			invoke := func(e Extern) (interface{}, error) {
+				heap := make([]interface{}, heapSize)
+				stack := make([]uint32, opStackSize)
+				hp := uint32(0)
+				sp := uint32(0)
+				for _, param := range tt.params {
+					switch v := param.(type) {
+					case bool:
+						if v {
+							stack[sp] = 1
+						}
+						sp++
+					case int64:
+						o1, o2 := il.IntegerToByteCode(v)
+						stack[sp], stack[sp+1] = o2, o1
+						sp += 2
+					default:
+						heap[hp] = v
+						stack[sp] = hp
+						hp++
+						sp++
+					}
+				}
+				o1, o2, err := e.invoke(p.Strings(), heap, &hp, stack, sp)
+				switch e.returnType {
+				case il.String:
+					return heap[o1], err
+				case il.Bool:
+					return o1 != 0, err
+				default:
+					return il.ByteCodeToInteger(o2, o1), err
+				}
+			}
+
+			want, wantErr := invoke(reflected)
+			got, gotErr := invoke(direct)
+			if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) || (wantErr == nil && got != want) {
+				t.Errorf("invoke() => %v, %v; want %v, %v", got, gotErr, want, wantErr)
+			}
+		})
+	}
+}
+
+func TestExternFromFn_DirectAllocations(t *testing.T) {
+	e := ExternFromFn("foo", strings.HasPrefix)
+
+	p := il.NewProgram()
+	heap := []interface{}{"abc", "ab"}
+	stack := []uint32{0, 1}
+	hp := uint32(2)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _, _ = e.invoke(p.Strings(), heap, &hp, stack, 2)
+	})
+	if allocs != 0 {
+		t.Errorf("invoke() => %v allocations, want 0", allocs)
+	}
+}
@@ -843,6 +843,228 @@ func (in *Interpreter) run(fn *il.Function, bag attribute.Bag, step bool) (Resul
 			opstack[sp+1] = uint32(tu64 & 0xFFFFFFFF)
 			sp = sp + 2
 
+		case il.MulI:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 = int64(t1) + int64(t2)<<32
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 *= int64(t1) + int64(t2)<<32
+			opstack[sp] = uint32(ti64 >> 32)
+			opstack[sp+1] = uint32(ti64 & 0xFFFFFFFF)
+			sp = sp + 2
+
+		case il.MulD:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 = math.Float64frombits(uint64(t1) + uint64(t2)<<32)
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 *= math.Float64frombits(uint64(t1) + uint64(t2)<<32)
+			tu64 = math.Float64bits(tf64)
+			opstack[sp] = uint32(tu64 >> 32)
+			opstack[sp+1] = uint32(tu64 & 0xFFFFFFFF)
+			sp = sp + 2
+
+		case il.DivI:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 = int64(t1) + int64(t2)<<32
+			if ti64 == 0 {
+				tErr = errors.New("division by zero")
+				goto RETURN_ERR
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 = (int64(t1) + int64(t2)<<32) / ti64
+			opstack[sp] = uint32(ti64 >> 32)
+			opstack[sp+1] = uint32(ti64 & 0xFFFFFFFF)
+			sp = sp + 2
+
+		case il.DivD:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 = math.Float64frombits(uint64(t1) + uint64(t2)<<32)
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 = math.Float64frombits(uint64(t1)+uint64(t2)<<32) / tf64
+			tu64 = math.Float64bits(tf64)
+			opstack[sp] = uint32(tu64 >> 32)
+			opstack[sp+1] = uint32(tu64 & 0xFFFFFFFF)
+			sp = sp + 2
+
+		case il.LtI:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 = int64(t1) + int64(t2)<<32
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if int64(t1)+int64(t2)<<32 < ti64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
+		case il.LtD:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 = math.Float64frombits(uint64(t1) + uint64(t2)<<32)
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if math.Float64frombits(uint64(t1)+uint64(t2)<<32) < tf64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
+		case il.LeI:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 = int64(t1) + int64(t2)<<32
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if int64(t1)+int64(t2)<<32 <= ti64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
+		case il.LeD:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 = math.Float64frombits(uint64(t1) + uint64(t2)<<32)
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if math.Float64frombits(uint64(t1)+uint64(t2)<<32) <= tf64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
+		case il.GtI:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 = int64(t1) + int64(t2)<<32
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if int64(t1)+int64(t2)<<32 > ti64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
+		case il.GtD:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 = math.Float64frombits(uint64(t1) + uint64(t2)<<32)
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if math.Float64frombits(uint64(t1)+uint64(t2)<<32) > tf64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
+		case il.GeI:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			ti64 = int64(t1) + int64(t2)<<32
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if int64(t1)+int64(t2)<<32 >= ti64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
+		case il.GeD:
+			if sp < 4 {
+				goto STACK_UNDERFLOW
+			}
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			tf64 = math.Float64frombits(uint64(t1) + uint64(t2)<<32)
+			t1 = opstack[sp-1]
+			t2 = opstack[sp-2]
+			sp = sp - 2
+			if math.Float64frombits(uint64(t1)+uint64(t2)<<32) >= tf64 {
+				opstack[sp] = 1
+				sp++
+			} else {
+				opstack[sp] = 0
+				sp++
+			}
+
 		case il.Jmp:
 			t1 = body[ip]
 			ip++
@@ -17,6 +17,7 @@ package interpreter
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"strings"
 	"testing"
@@ -1397,6 +1398,226 @@ func TestInterpreter_Eval(t *testing.T) {
 		  	end`,
 			expected: float64(456.456) - float64(-123.123),
 		},
+		"mul_i": {
+			code: `
+			fn main() integer
+			  apush_i -12
+			  apush_i 34
+			  mul_i
+			  ret
+		  	end`,
+			expected: int64(-408),
+		},
+		"mul_d": {
+			code: `
+			fn main() double
+			  apush_d 1.5
+			  apush_d -2.25
+			  mul_d
+			  ret
+		  	end`,
+			expected: float64(1.5) * float64(-2.25),
+		},
+		"div_i": {
+			code: `
+			fn main() integer
+			  apush_i -457
+			  apush_i 12
+			  div_i
+			  ret
+		  	end`,
+			expected: int64(-38),
+		},
+		"div_d": {
+			code: `
+			fn main() double
+			  apush_d 1.5
+			  apush_d -0.25
+			  div_d
+			  ret
+		  	end`,
+			expected: float64(1.5) / float64(-0.25),
+		},
+		"div_d/zero": {
+			code: `
+			fn main() double
+			  apush_d 1.5
+			  apush_d 0
+			  div_d
+			  ret
+		  	end`,
+			expected: math.Inf(1),
+		},
+		"lt_i": {
+			code: `
+			fn main() bool
+			  apush_i -1
+			  apush_i 2
+			  lt_i
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"lt_i/equal": {
+			code: `
+			fn main() bool
+			  apush_i 2
+			  apush_i 2
+			  lt_i
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"le_i": {
+			code: `
+			fn main() bool
+			  apush_i 2
+			  apush_i 2
+			  le_i
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"le_i/false": {
+			code: `
+			fn main() bool
+			  apush_i 3
+			  apush_i 2
+			  le_i
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"gt_i": {
+			code: `
+			fn main() bool
+			  apush_i 3
+			  apush_i -2
+			  gt_i
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"gt_i/equal": {
+			code: `
+			fn main() bool
+			  apush_i 2
+			  apush_i 2
+			  gt_i
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"ge_i": {
+			code: `
+			fn main() bool
+			  apush_i 2
+			  apush_i 2
+			  ge_i
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"ge_i/false": {
+			code: `
+			fn main() bool
+			  apush_i -3
+			  apush_i 2
+			  ge_i
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"lt_d": {
+			code: `
+			fn main() bool
+			  apush_d -1.5
+			  apush_d 2.5
+			  lt_d
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"lt_d/equal": {
+			code: `
+			fn main() bool
+			  apush_d 2.5
+			  apush_d 2.5
+			  lt_d
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"le_d": {
+			code: `
+			fn main() bool
+			  apush_d 2.5
+			  apush_d 2.5
+			  le_d
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"le_d/false": {
+			code: `
+			fn main() bool
+			  apush_d 3.5
+			  apush_d 2.5
+			  le_d
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"gt_d": {
+			code: `
+			fn main() bool
+			  apush_d 3.5
+			  apush_d -2.5
+			  gt_d
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"gt_d/equal": {
+			code: `
+			fn main() bool
+			  apush_d 2.5
+			  apush_d 2.5
+			  gt_d
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"ge_d": {
+			code: `
+			fn main() bool
+			  apush_d 2.5
+			  apush_d 2.5
+			  ge_d
+			  ret
+		  	end`,
+			expected: true,
+		},
+		"ge_d/false": {
+			code: `
+			fn main() bool
+			  apush_d -3.5
+			  apush_d 2.5
+			  ge_d
+			  ret
+		  	end`,
+			expected: false,
+		},
+		"div_i/zero": {
+			code: `
+			fn main() integer
+			  apush_i 1
+			  apush_i 0
+			  div_i
+			  ret
+		  	end`,
+			err: "division by zero",
+		},
 
 		"jmp": {
 			code: `
@@ -2177,6 +2398,42 @@ func TestInterpreter_Eval_StackUnderflow(t *testing.T) {
 		"asub_d": {
 			code: `asub_d 1`,
 		},
+		"mul_i": {
+			code: `mul_i`,
+		},
+		"mul_d": {
+			code: `mul_d`,
+		},
+		"div_i": {
+			code: `div_i`,
+		},
+		"div_d": {
+			code: `div_d`,
+		},
+		"lt_i": {
+			code: `lt_i`,
+		},
+		"lt_d": {
+			code: `lt_d`,
+		},
+		"le_i": {
+			code: `le_i`,
+		},
+		"le_d": {
+			code: `le_d`,
+		},
+		"gt_i": {
+			code: `gt_i`,
+		},
+		"gt_d": {
+			code: `gt_d`,
+		},
+		"ge_i": {
+			code: `ge_i`,
+		},
+		"ge_d": {
+			code: `ge_d`,
+		},
 		"jz": {
 			code: `
 L0:
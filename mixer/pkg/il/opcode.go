@@ -265,6 +265,56 @@ const (
 	// semantics.
 	ASubD Opcode = 117
 
+	// MulI pops two integer values from the stack, multiplies their value and pushes the result
+	// back into stack. The operation follows Go's integer multiplication semantics.
+	MulI Opcode = 118
+
+	// MulD pops two double values from the stack, multiplies their value and pushes the result
+	// back into stack. The operation follows Go's float multiplication semantics.
+	MulD Opcode = 119
+
+	// DivI pops two integer values from the stack, and divides the second popped value
+	// by the first one, then pushes the result back into stack. Raises an error if the
+	// divisor is zero. The operation follows Go's integer division semantics.
+	DivI Opcode = 120
+
+	// DivD pops two double values from the stack, and divides the second popped value
+	// by the first one, then pushes the result back into stack.
+	// The operation follows Go's float division semantics.
+	DivD Opcode = 121
+
+	// LtI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than the first one, otherwise pushes 0.
+	LtI Opcode = 130
+
+	// LtD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than the first one, otherwise pushes 0.
+	LtD Opcode = 131
+
+	// LeI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than or equal to the first one, otherwise pushes 0.
+	LeI Opcode = 132
+
+	// LeD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than or equal to the first one, otherwise pushes 0.
+	LeD Opcode = 133
+
+	// GtI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than the first one, otherwise pushes 0.
+	GtI Opcode = 134
+
+	// GtD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than the first one, otherwise pushes 0.
+	GtD Opcode = 135
+
+	// GeI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than or equal to the first one, otherwise pushes 0.
+	GeI Opcode = 136
+
+	// GeD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than or equal to the first one, otherwise pushes 0.
+	GeD Opcode = 137
+
 	// Jmp jumps to the given instruction address.
 	Jmp Opcode = 200
 
@@ -703,6 +753,56 @@ var opCodeInfos = map[Opcode]opcodeInfo{
 		OpcodeArgDouble,
 	}},
 
+	// MulI pops two integer values from the stack, multiplies their value and pushes the result
+	// back into stack. The operation follows Go's integer multiplication semantics.
+	MulI: {name: "MulI", keyword: "mul_i"},
+
+	// MulD pops two double values from the stack, multiplies their value and pushes the result
+	// back into stack. The operation follows Go's float multiplication semantics.
+	MulD: {name: "MulD", keyword: "mul_d"},
+
+	// DivI pops two integer values from the stack, and divides the second popped value
+	// by the first one, then pushes the result back into stack. Raises an error if the
+	// divisor is zero. The operation follows Go's integer division semantics.
+	DivI: {name: "DivI", keyword: "div_i"},
+
+	// DivD pops two double values from the stack, and divides the second popped value
+	// by the first one, then pushes the result back into stack.
+	// The operation follows Go's float division semantics.
+	DivD: {name: "DivD", keyword: "div_d"},
+
+	// LtI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than the first one, otherwise pushes 0.
+	LtI: {name: "LtI", keyword: "lt_i"},
+
+	// LtD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than the first one, otherwise pushes 0.
+	LtD: {name: "LtD", keyword: "lt_d"},
+
+	// LeI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than or equal to the first one, otherwise pushes 0.
+	LeI: {name: "LeI", keyword: "le_i"},
+
+	// LeD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is less than or equal to the first one, otherwise pushes 0.
+	LeD: {name: "LeD", keyword: "le_d"},
+
+	// GtI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than the first one, otherwise pushes 0.
+	GtI: {name: "GtI", keyword: "gt_i"},
+
+	// GtD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than the first one, otherwise pushes 0.
+	GtD: {name: "GtD", keyword: "gt_d"},
+
+	// GeI pops two integer values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than or equal to the first one, otherwise pushes 0.
+	GeI: {name: "GeI", keyword: "ge_i"},
+
+	// GeD pops two double values from the stack, and pushes 1 into the stack if the
+	// second popped value is greater than or equal to the first one, otherwise pushes 0.
+	GeD: {name: "GeD", keyword: "ge_d"},
+
 	// Jmp jumps to the given instruction address.
 	Jmp: {name: "Jmp", keyword: "jmp", args: []OpcodeArg{
 		// The address to jump to.
@@ -112,3 +112,8 @@ func (s stringMap) Get(key string) (string, bool) {
 	}
 	return str, found
 }
+
+// Len returns the number of entries in the stringmap
+func (s stringMap) Len() int {
+	return len(s.Entries)
+}
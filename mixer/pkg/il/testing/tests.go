@@ -383,14 +383,41 @@ end`,
 		conf: exprEvalAttrs,
 	},
 	{
-		E: `(x/y) == 30`,
+		E:    `(x/y) == 30`,
+		Type: descriptor.BOOL,
 		I: map[string]interface{}{
 			"x": int64(20),
 			"y": int64(10),
 		},
-		CompileErr: "unknown function: QUO",
-		AstErr:     "unknown function: QUO",
-		conf:       exprEvalAttrs,
+		R:    false,
+		conf: exprEvalAttrs,
+	},
+	{
+		E:    `(x/y) == 2`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"x": int64(20),
+			"y": int64(10),
+		},
+		R:    true,
+		conf: exprEvalAttrs,
+		IL: `
+fn eval() bool
+  resolve_i "x"
+  resolve_i "y"
+  div_i
+  aeq_i 2
+  ret
+end`,
+	},
+	{
+		E:    `request.size > 1e6`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"request.size": int64(2000000),
+		},
+		R:    true,
+		conf: exprEvalAttrs,
 	},
 	{
 		E:    `request.header["X-FORWARDED-HOST"] == "aaa"`,
@@ -3302,6 +3329,672 @@ end`,
 		},
 		R: false,
 	},
+
+	// Ordering comparisons
+	{
+		E:     `ai < 20`,
+		Bench: true,
+		Type:  descriptor.BOOL,
+		I: map[string]interface{}{
+			"ai": int64(10),
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_i "ai"
+  apush_i 20
+  lt_i
+  ret
+end`,
+	},
+	{
+		E:    `ai <= 20`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ai": int64(20),
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_i "ai"
+  apush_i 20
+  le_i
+  ret
+end`,
+	},
+	{
+		E:    `ai > bi`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ai": int64(20),
+			"bi": int64(20),
+		},
+		R: false,
+		IL: `
+fn eval() bool
+  resolve_i "ai"
+  resolve_i "bi"
+  gt_i
+  ret
+end`,
+	},
+	{
+		E:    `ai >= -5`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ai": int64(-5),
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_i "ai"
+  apush_i -5
+  ge_i
+  ret
+end`,
+	},
+	{
+		E:     `ad < 1.5`,
+		Bench: true,
+		Type:  descriptor.BOOL,
+		I: map[string]interface{}{
+			"ad": float64(1.25),
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_d "ad"
+  apush_d 1.500000
+  lt_d
+  ret
+end`,
+	},
+	{
+		E:    `ad <= bd`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ad": float64(1.5),
+			"bd": float64(1.25),
+		},
+		R: false,
+	},
+	{
+		E:    `ad > 1`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ad": float64(1.5),
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_d "ad"
+  apush_d 1.000000
+  gt_d
+  ret
+end`,
+	},
+	{
+		E:    `ad >= bd`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ad": float64(1.5),
+			"bd": float64(1.5),
+		},
+		R: true,
+	},
+	{
+		E:     `adur > "19ms"`,
+		Bench: true,
+		Type:  descriptor.BOOL,
+		I: map[string]interface{}{
+			"adur": duration20,
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_i "adur"
+  apush_i 19000000
+  gt_i
+  ret
+end`,
+	},
+	{
+		E:    `adur <= bdur`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"adur": duration20,
+			"bdur": duration19,
+		},
+		R: false,
+	},
+	{
+		E:    `at < bt`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"at": time1977,
+			"bt": time1999,
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_f "at"
+  resolve_f "bt"
+  call timestamp_lt
+  ret
+end`,
+	},
+	{
+		E:    `at <= bt`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"at": time1999,
+			"bt": time1999,
+		},
+		R: true,
+	},
+	{
+		E:    `at > bt`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"at": time1977,
+			"bt": time1999,
+		},
+		R: false,
+	},
+	{
+		E:    `at >= timestamp("2015-01-02T15:04:35Z")`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"at": time1999,
+		},
+		R: false,
+	},
+	{
+		E:          `ai < ad`,
+		CompileErr: `LT($ai, $ad) typeError got INT64 and DOUBLE operands`,
+	},
+	{
+		E:          `as > "abc"`,
+		CompileErr: `GT($as, "abc") typeError got STRING and STRING operands`,
+	},
+	{
+		E:          `ai < 1.5`,
+		CompileErr: `LT($ai, 1.5) typeError got INT64 and DOUBLE operands`,
+	},
+	{
+		E:    `ai < 20`,
+		Type: descriptor.BOOL,
+		I:    map[string]interface{}{},
+		Err:  "lookup failed: 'ai'",
+	},
+
+	// Arithmetic
+	{
+		E:     `ai + bi * 2`,
+		Bench: true,
+		Type:  descriptor.INT64,
+		I: map[string]interface{}{
+			"ai": int64(3),
+			"bi": int64(4),
+		},
+		R: int64(11),
+		IL: `
+fn eval() integer
+  resolve_i "ai"
+  resolve_i "bi"
+  apush_i 2
+  mul_i
+  add_i
+  ret
+end`,
+	},
+	{
+		E:    `(ai - bi) / 2`,
+		Type: descriptor.INT64,
+		I: map[string]interface{}{
+			"ai": int64(3),
+			"bi": int64(10),
+		},
+		R: int64(-3),
+		IL: `
+fn eval() integer
+  resolve_i "ai"
+  resolve_i "bi"
+  sub_i
+  apush_i 2
+  div_i
+  ret
+end`,
+	},
+	{
+		E:    `ai / bi`,
+		Type: descriptor.INT64,
+		I: map[string]interface{}{
+			"ai": int64(3),
+			"bi": int64(0),
+		},
+		Err: "division by zero",
+	},
+	{
+		E:     `ad * 2 + bd / 4`,
+		Bench: true,
+		Type:  descriptor.DOUBLE,
+		I: map[string]interface{}{
+			"ad": float64(1.5),
+			"bd": float64(1),
+		},
+		R: float64(3.25),
+		IL: `
+fn eval() double
+  resolve_d "ad"
+  apush_d 2.000000
+  mul_d
+  resolve_d "bd"
+  apush_d 4.000000
+  div_d
+  add_d
+  ret
+end`,
+	},
+	{
+		E:    `ad - bd`,
+		Type: descriptor.DOUBLE,
+		I: map[string]interface{}{
+			"ad": float64(1.5),
+			"bd": float64(2),
+		},
+		R: float64(-0.5),
+	},
+	{
+		E:    `adur + "1ms"`,
+		Type: descriptor.DURATION,
+		I: map[string]interface{}{
+			"adur": duration19,
+		},
+		R: duration20,
+		IL: `
+fn eval() duration
+  resolve_i "adur"
+  apush_i 1000000
+  add_i
+  ret
+end`,
+	},
+	{
+		E:    `adur - bdur`,
+		Type: descriptor.DURATION,
+		I: map[string]interface{}{
+			"adur": duration20,
+			"bdur": duration19,
+		},
+		R: time.Millisecond,
+	},
+	{
+		E:    `at + "1ms"`,
+		Type: descriptor.TIMESTAMP,
+		I: map[string]interface{}{
+			"at": t2,
+		},
+		R: t2.Add(time.Millisecond),
+		IL: `
+fn eval() interface
+  resolve_f "at"
+  apush_i 1000000
+  call timestamp_add
+  ret
+end`,
+	},
+	{
+		E:    `at - "1s"`,
+		Type: descriptor.TIMESTAMP,
+		I: map[string]interface{}{
+			"at": t,
+		},
+		R: t2,
+	},
+	{
+		E:    `at - bt`,
+		Type: descriptor.DURATION,
+		I: map[string]interface{}{
+			"at": t,
+			"bt": t2,
+		},
+		R: time.Second,
+	},
+	{
+		E:    `at - bt > "500ms"`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"at": t,
+			"bt": t2,
+		},
+		R: true,
+	},
+	{
+		E:    `(bi + 1) | ai`,
+		Type: descriptor.INT64,
+		I: map[string]interface{}{
+			"bi": int64(4),
+		},
+		R: int64(5),
+	},
+	{
+		E:          `at + bt`,
+		CompileErr: `ADD($at, $bt) typeError got TIMESTAMP and TIMESTAMP operands`,
+	},
+	{
+		E:          `adur * 2`,
+		CompileErr: `MUL($adur, 2) typeError got DURATION and INT64 operands`,
+	},
+	{
+		E:          `as + bs`,
+		CompileErr: `ADD($as, $bs) typeError got STRING and STRING operands`,
+	},
+
+	// Not
+	{
+		E:     `!ab`,
+		Bench: true,
+		Type:  descriptor.BOOL,
+		I: map[string]interface{}{
+			"ab": true,
+		},
+		R: false,
+		IL: `
+fn eval() bool
+  resolve_b "ab"
+  not
+  ret
+end`,
+	},
+	{
+		E:    `!(ai > 2) || ab`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ai": int64(1),
+			"ab": false,
+		},
+		R: true,
+	},
+	{
+		E:          `!ai`,
+		CompileErr: `NOT($ai) arg 1 ($ai) typeError got INT64, expected BOOL`,
+	},
+
+	// Conditional
+	{
+		E:     `ab ? as : bs`,
+		Bench: true,
+		Type:  descriptor.STRING,
+		I: map[string]interface{}{
+			"ab": true,
+			"as": "a",
+			"bs": "b",
+		},
+		R:          "a",
+		Referenced: []string{"ab", "as"},
+		IL: `
+fn eval() string
+  resolve_b "ab"
+  jz L0
+  resolve_s "as"
+  jmp L1
+L0:
+  resolve_s "bs"
+L1:
+  ret
+end`,
+	},
+	{
+		E:    `ab ? as : bs`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"ab": false,
+			"as": "a",
+			"bs": "b",
+		},
+		R:          "b",
+		Referenced: []string{"ab", "bs"},
+	},
+	{
+		E:    `ai > 10 ? "large" : ai > 5 ? "medium" : "small"`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"ai": int64(7),
+		},
+		R: "medium",
+	},
+	{
+		E:    `(ab ? ai : bi) * 2`,
+		Type: descriptor.INT64,
+		I: map[string]interface{}{
+			"ab": false,
+			"ai": int64(1),
+			"bi": int64(2),
+		},
+		R: int64(4),
+	},
+	{
+		E:    `(ab ? as : bs) | "c"`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"ab": true,
+		},
+		R: "c",
+	},
+	{
+		E:    `match("abc", ab ? as : "*")`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ab": false,
+		},
+		R: true,
+	},
+	{
+		E:          `ai ? as : bs`,
+		CompileErr: `CONDITIONAL($ai, $as, $bs) arg 1 ($ai) typeError got INT64, expected BOOL`,
+	},
+	{
+		E:          `ab ? as : bi`,
+		CompileErr: `CONDITIONAL($ab, $as, $bi) arg 3 ($bi) typeError got INT64, expected STRING`,
+	},
+	{
+		E:          `ab ? as`,
+		CompileErr: `unable to parse expression 'ab ? as': missing ':' in conditional expression`,
+	},
+
+	// String, list and time utilities
+	{
+		E:     `toLower(as) == "abc"`,
+		Bench: true,
+		Type:  descriptor.BOOL,
+		I: map[string]interface{}{
+			"as": "AbC",
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_s "as"
+  call toLower
+  aeq_s "abc"
+  ret
+end`,
+	},
+	{
+		E:    `toUpper(as)`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "AbC",
+		},
+		R: "ABC",
+	},
+	{
+		E:     `substring(as, 1, 3)`,
+		Bench: true,
+		Type:  descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "abcdef",
+		},
+		R: "bc",
+		IL: `
+fn eval() string
+  resolve_s "as"
+  apush_i 1
+  apush_i 3
+  call substring
+  ret
+end`,
+	},
+	{
+		E:    `substring(as, 2, 100)`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "abc",
+		},
+		R: "c",
+	},
+	{
+		E:    `substring(as, 2, 1)`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "abc",
+		},
+		Err: "invalid indices 2 and 1 for substring of 'abc'",
+	},
+	{
+		E:    `split(as, "/")["2"]`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "/api/v1",
+		},
+		R: "v1",
+	},
+	{
+		E:    `split(as, "/").size()`,
+		Type: descriptor.INT64,
+		I: map[string]interface{}{
+			"as": "/api/v1",
+		},
+		R: int64(3),
+	},
+	{
+		E:    `replace(as, "-", "_")`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "a-b-c",
+		},
+		R: "a_b_c",
+	},
+	{
+		E:    `regexReplace(as, "/v[0-9]+/", "/")`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "/api/v12/users",
+		},
+		R: "/api/users",
+	},
+	{
+		E:    `regexReplace(as, "(", "")`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "abc",
+		},
+		Err: "error parsing regexp: missing closing ): `(`",
+	},
+	{
+		E:    `concat(as, bs)`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"as": "foo",
+			"bs": "bar",
+		},
+		R: "foobar",
+	},
+	{
+		E:    `conditional(ab, as, "none")`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"ab": false,
+			"as": "foo",
+		},
+		R: "none",
+	},
+	{
+		E:     `aip.ipInRange("1.2.0.0/16")`,
+		Bench: true,
+		Type:  descriptor.BOOL,
+		I: map[string]interface{}{
+			"aip": []byte(net.ParseIP("1.2.3.4")),
+		},
+		R: true,
+		IL: `
+fn eval() bool
+  resolve_f "aip"
+  apush_s "1.2.0.0/16"
+  call ipInRange
+  ret
+end`,
+	},
+	{
+		E:    `aip.ipInRange("10.0.0.0/8")`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"aip": []byte(net.ParseIP("1.2.3.4")),
+		},
+		R: false,
+	},
+	{
+		E:    `aip.ipInRange("10.0.0.0")`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"aip": []byte(net.ParseIP("1.2.3.4")),
+		},
+		Err: "could not convert 10.0.0.0 to CIDR: invalid CIDR address: 10.0.0.0",
+	},
+	{
+		E:    `at + duration(as)`,
+		Type: descriptor.TIMESTAMP,
+		I: map[string]interface{}{
+			"at": t2,
+			"as": "1s",
+		},
+		R: t,
+	},
+	{
+		E:    `formatTimestamp(at, "2006-01-02")`,
+		Type: descriptor.STRING,
+		I: map[string]interface{}{
+			"at": time1977,
+		},
+		R: "1977-02-04",
+	},
+	{
+		E:     `as.size()`,
+		Bench: true,
+		Type:  descriptor.INT64,
+		I: map[string]interface{}{
+			"as": "abcd",
+		},
+		R: int64(4),
+		IL: `
+fn eval() integer
+  resolve_s "as"
+  call size
+  ret
+end`,
+	},
+	{
+		E:    `ar.size() > 1`,
+		Type: descriptor.BOOL,
+		I: map[string]interface{}{
+			"ar": map[string]string{"a": "b", "c": "d"},
+		},
+		R: true,
+	},
+	{
+		E:          `ai.size()`,
+		CompileErr: `$ai:size() target typeError got INT64, expected one of [STRING STRING_MAP]`,
+	},
 }
 
 // TestInfo is a structure that contains detailed test information. Depending
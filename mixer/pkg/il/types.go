@@ -80,6 +80,7 @@ func GetType(name string) (Type, bool) {
 // This is used for reference counting.
 type StringMap interface {
 	Get(key string) (value string, found bool)
+	Len() int
 }
 
 // MapGet abstracts over map[string]string and refcounted stringMap
@@ -96,3 +97,15 @@ func MapGet(tVal interface{}, tStr string) (ret string, found bool) {
 		panic(fmt.Sprintf("Unknown map type %T", v))
 	}
 }
+
+// MapLen abstracts over map[string]string and refcounted stringMap, returning the number of entries.
+func MapLen(tVal interface{}) int {
+	switch v := tVal.(type) {
+	case map[string]string:
+		return len(v)
+	case StringMap:
+		return v.Len()
+	default:
+		panic(fmt.Sprintf("Unknown map type %T", v))
+	}
+}
@@ -33,3 +33,23 @@ func TestGetType(t *testing.T) {
 		}
 	}
 }
+
+type testStringMap map[string]string
+
+func (m testStringMap) Get(key string) (string, bool) {
+	v, found := m[key]
+	return v, found
+}
+
+func (m testStringMap) Len() int {
+	return len(m)
+}
+
+func TestMapLen(t *testing.T) {
+	if n := MapLen(map[string]string{"a": "b", "c": "d"}); n != 2 {
+		t.Fatalf("MapLen() => %d, want 2", n)
+	}
+	if n := MapLen(testStringMap{"a": "b"}); n != 1 {
+		t.Fatalf("MapLen() => %d, want 1", n)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"errors"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// The conditional operator "c ? a : b" is not part of the go syntax, which is used to parse the
+// expressions. rewriteConditionals rewrites it into a call to the CONDITIONAL intrinsic, with the
+// same precedence and associativity as in C: the operator binds looser than all the others, and
+// "a ? b : c ? d : e" is "a ? b : (c ? d : e)".
+
+type lexeme struct {
+	tok  token.Token
+	text string
+}
+
+func (l lexeme) isQuestion() bool {
+	return l.tok == token.ILLEGAL && l.text == "?"
+}
+
+func rewriteConditionals(src string) (string, error) {
+	if !strings.Contains(src, "?") {
+		return src, nil
+	}
+
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, 0)
+
+	lexemes := []lexeme{}
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			// automatically inserted at the end of the line
+			continue
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		lexemes = append(lexemes, lexeme{tok, lit})
+	}
+	return rewriteLexemes(lexemes)
+}
+
+// rewriteLexemes rewrites a list of lexemes with balanced brackets.
+func rewriteLexemes(lexemes []lexeme) (string, error) {
+	// arguments of a call
+	if parts := splitTopLevel(lexemes, func(l lexeme) bool { return l.tok == token.COMMA }); len(parts) > 1 {
+		out := make([]string, 0, len(parts))
+		for _, part := range parts {
+			text, err := rewriteLexemes(part)
+			if err != nil {
+				return "", err
+			}
+			out = append(out, text)
+		}
+		return strings.Join(out, ", "), nil
+	}
+
+	question, colon := -1, -1
+	nested := 0
+	err := forEachTopLevel(lexemes, func(i int, l lexeme) {
+		switch {
+		case l.isQuestion() && question < 0:
+			question = i
+		case l.isQuestion():
+			nested++
+		case l.tok == token.COLON && question >= 0 && colon < 0:
+			if nested == 0 {
+				colon = i
+			} else {
+				nested--
+			}
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if question < 0 {
+		return rewriteBrackets(lexemes)
+	}
+	if colon < 0 {
+		return "", errors.New("missing ':' in conditional expression")
+	}
+
+	parts := [][]lexeme{lexemes[:question], lexemes[question+1 : colon], lexemes[colon+1:]}
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if len(part) == 0 {
+			return "", errors.New("missing operand in conditional expression")
+		}
+		text, err := rewriteLexemes(part)
+		if err != nil {
+			return "", err
+		}
+		out = append(out, text)
+	}
+	return "CONDITIONAL(" + strings.Join(out, ", ") + ")", nil
+}
+
+// rewriteBrackets rewrites the content of each top-level bracket in the list of lexemes.
+func rewriteBrackets(lexemes []lexeme) (string, error) {
+	out := make([]string, 0, len(lexemes))
+	for i := 0; i < len(lexemes); i++ {
+		l := lexemes[i]
+		if l.isQuestion() || l.tok == token.COLON {
+			return "", errors.New("unexpected '" + l.text + "' outside of a conditional expression")
+		}
+		if !isOpening(l.tok) {
+			out = append(out, l.text)
+			continue
+		}
+		end := closing(lexemes, i)
+		if end < 0 {
+			return "", errors.New("unbalanced '" + l.text + "'")
+		}
+		inner := ""
+		if end > i+1 {
+			var err error
+			if inner, err = rewriteLexemes(lexemes[i+1 : end]); err != nil {
+				return "", err
+			}
+		}
+		out = append(out, l.text+inner+lexemes[end].text)
+		i = end
+	}
+	return strings.Join(out, " "), nil
+}
+
+// splitTopLevel splits the list of lexemes at the top-level separators.
+func splitTopLevel(lexemes []lexeme, separator func(lexeme) bool) [][]lexeme {
+	parts := [][]lexeme{}
+	start := 0
+	_ = forEachTopLevel(lexemes, func(i int, l lexeme) {
+		if separator(l) {
+			parts = append(parts, lexemes[start:i])
+			start = i + 1
+		}
+	})
+	return append(parts, lexemes[start:])
+}
+
+// forEachTopLevel calls fn for the lexemes which are not within brackets.
+func forEachTopLevel(lexemes []lexeme, fn func(int, lexeme)) error {
+	for i := 0; i < len(lexemes); i++ {
+		if isOpening(lexemes[i].tok) {
+			end := closing(lexemes, i)
+			if end < 0 {
+				return errors.New("unbalanced '" + lexemes[i].text + "'")
+			}
+			i = end
+			continue
+		}
+		fn(i, lexemes[i])
+	}
+	return nil
+}
+
+func isOpening(tok token.Token) bool {
+	return tok == token.LPAREN || tok == token.LBRACK || tok == token.LBRACE
+}
+
+// closing returns the index of the bracket closing the one at the given index, or -1.
+func closing(lexemes []lexeme, open int) int {
+	depth := 0
+	for i := open; i < len(lexemes); i++ {
+		switch lexemes[i].tok {
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
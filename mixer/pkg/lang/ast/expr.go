@@ -19,6 +19,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -205,6 +206,10 @@ func (f *Function) EvalType(attrs AttributeDescriptorFinder, fMap map[string]Fun
 		return valueType, fmt.Errorf("unknown function: %s", f.Name)
 	}
 
+	if signatures, isOperator := operators[f.Name]; isOperator && f.Target == nil {
+		return f.evalOperatorType(signatures, attrs, fMap)
+	}
+
 	tmplType := dpb.VALUE_TYPE_UNSPECIFIED
 
 	if f.Target != nil {
@@ -219,6 +224,9 @@ func (f *Function) EvalType(attrs AttributeDescriptorFinder, fMap map[string]Fun
 		}
 
 		if fn.TargetType == dpb.VALUE_TYPE_UNSPECIFIED {
+			if !acceptsType(fn.TargetTypes, targetType) {
+				return valueType, fmt.Errorf("%s target typeError got %s, expected one of %v", f, targetType, fn.TargetTypes)
+			}
 			// all future args must be of this type.
 			tmplType = targetType
 		} else if targetType != fn.TargetType {
@@ -267,6 +275,72 @@ func (f *Function) EvalType(attrs AttributeDescriptorFinder, fMap map[string]Fun
 	return retType, nil
 }
 
+// acceptsType returns true if the type is one of the given types, or if no type is given.
+func acceptsType(types []dpb.ValueType, t dpb.ValueType) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, tt := range types {
+		if tt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// evalOperatorType returns the type of a binary operator, if the types of its operands match one of its signatures.
+func (f *Function) evalOperatorType(signatures []signature, attrs AttributeDescriptorFinder,
+	fMap map[string]FunctionMetadata) (valueType dpb.ValueType, err error) {
+	if len(f.Args) != 2 {
+		return valueType, fmt.Errorf("%s arity mismatch. Got %d arg(s), expected 2 arg(s)", f, len(f.Args))
+	}
+
+	var lhs, rhs dpb.ValueType
+	if lhs, err = f.Args[0].EvalType(attrs, fMap); err != nil {
+		return valueType, err
+	}
+	if rhs, err = f.Args[1].EvalType(attrs, fMap); err != nil {
+		return valueType, err
+	}
+
+	// numeric literals take the type of the other operand, as in "response.size > 1e6"
+	if lhs != rhs {
+		if f.Args[1].Const != nil && f.Args[1].Const.convertNumber(lhs) {
+			rhs = lhs
+		} else if f.Args[0].Const != nil && f.Args[0].Const.convertNumber(rhs) {
+			lhs = rhs
+		}
+	}
+
+	for _, sig := range signatures {
+		if sig.lhs == lhs && sig.rhs == rhs {
+			return sig.ret, nil
+		}
+	}
+	return valueType, fmt.Errorf("%s typeError got %s and %s operands", f, lhs, rhs)
+}
+
+// convertNumber converts a numeric constant to the given numeric type, if the value can be represented
+// exactly. Returns true if the constant is of the given type afterwards.
+func (c *Constant) convertNumber(t dpb.ValueType) bool {
+	switch {
+	case c.Type == t:
+		return true
+	case c.Type == dpb.INT64 && t == dpb.DOUBLE:
+		c.Value = float64(c.Value.(int64))
+	case c.Type == dpb.DOUBLE && t == dpb.INT64:
+		d := c.Value.(float64)
+		if d != math.Trunc(d) || d < math.MinInt64 || d >= math.MaxInt64 {
+			return false
+		}
+		c.Value = int64(d)
+	default:
+		return false
+	}
+	c.Type = t
+	return true
+}
+
 func generateVarName(selectors []string) string {
 	// a.b.c.d is a selector expression
 	// normally one walks down a chain of objects
@@ -287,6 +361,11 @@ func generateVarName(selectors []string) string {
 func process(ex ast.Expr, tgt *Expression) (err error) {
 	switch v := ex.(type) {
 	case *ast.UnaryExpr:
+		// negative numbers are parsed as the negation of a literal
+		if lit, ok := v.X.(*ast.BasicLit); ok && v.Op == token.SUB && (lit.Kind == token.INT || lit.Kind == token.FLOAT) {
+			tgt.Const, err = newConstant("-"+lit.Value, typeMap[lit.Kind])
+			return
+		}
 		tgt.Fn = &Function{Name: tMap[v.Op]}
 		if err = processFunc(tgt.Fn, []ast.Expr{v.X}); err != nil {
 			return
@@ -422,7 +501,12 @@ func processFunc(fn *Function, args []ast.Expr) (err error) {
 
 // Parse parses a given expression to ast.Expression.
 func Parse(src string) (ex *Expression, err error) {
-	a, err := parser.ParseExpr(src)
+	text, err := rewriteConditionals(src)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse expression '%s': %v", src, err)
+	}
+
+	a, err := parser.ParseExpr(text)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse expression '%s': %v", src, err)
 	}
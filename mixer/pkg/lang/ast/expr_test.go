@@ -60,6 +60,14 @@ func TestGoodParse(t *testing.T) {
 		{`"abc".matches("foo")`, `"abc":matches("foo")`},
 		{`"abc".prefix(23).matches("foo")`, `"abc":prefix(23):matches("foo")`},
 		{`"abc".matches("foo")`, `"abc":matches("foo")`},
+		{`a < 2 && b >= -1.5`, `LAND(LT($a, 2), GEQ($b, -1.5))`},
+		{`a + b * c - d / 2`, `SUB(ADD($a, MUL($b, $c)), QUO($d, 2))`},
+		{`a ? b : c`, `CONDITIONAL($a, $b, $c)`},
+		{`a == 1 || b ? "x" : "y"`, `CONDITIONAL(LOR(EQ($a, 1), $b), "x", "y")`},
+		{`a ? b ? c : d : e ? f : g`, `CONDITIONAL($a, CONDITIONAL($b, $c, $d), CONDITIONAL($e, $f, $g))`},
+		{`f(a ? 1 : 2, m[b ? "k" : "l"])`, `f(CONDITIONAL($a, 1, 2), INDEX($m, CONDITIONAL($b, "k", "l")))`},
+		{`(a ? b : c) | "d?"`, `OR(CONDITIONAL($a, $b, $c), "d?")`},
+		{`a == "b?c"`, `EQ($a, "b?c")`},
 	}
 	for idx, tt := range tests {
 		t.Run(fmt.Sprintf("[%d] %s", idx, tt.src), func(t *testing.T) {
@@ -207,6 +215,10 @@ func TestBadParse(t *testing.T) {
 		{`foo{}.bar()`, `unexpected expression`},
 		{`(foo{}).bar()`, `unexpected expression`},
 		{`a().b`, `unexpected expression`},
+		{`a ? b`, `missing ':' in conditional expression`},
+		{`a ? : b`, `missing operand in conditional expression`},
+		{`f(a : b) ? c : d`, `unexpected ':' outside of a conditional expression`},
+		{`f(a ? b : c`, `unbalanced '('`},
 	}
 	for idx, tt := range tests {
 		t.Run(fmt.Sprintf("[%d] %s", idx, tt.src), func(t *testing.T) {
@@ -266,6 +278,23 @@ func TestInternalTypeCheck(t *testing.T) {
 		{`x | y | "abc"`, dpb.STRING, []*ad{{"a", dpb.STRING}, {"b", dpb.STRING}}, nil, "unknown attribute"},
 		{`EQ("abc")`, dpb.BOOL, []*ad{{"a", dpb.STRING}, {"b", dpb.STRING}}, nil, "arity mismatch"},
 		{`a % 5`, dpb.BOOL, []*ad{{"a", dpb.INT64}}, nil, "unknown function"},
+		{`a < 5`, dpb.BOOL, []*ad{{"a", dpb.INT64}}, nil, success},
+		{`a < 5.5`, dpb.BOOL, []*ad{{"a", dpb.DOUBLE}}, nil, success},
+		{`a < 5.5`, dpb.BOOL, []*ad{{"a", dpb.INT64}}, nil, "LT($a, 5.5) typeError got INT64 and DOUBLE operands"},
+		{`a > 1e6`, dpb.BOOL, []*ad{{"a", dpb.INT64}}, nil, success},
+		{`a >= "10ms"`, dpb.BOOL, []*ad{{"a", dpb.DURATION}}, nil, success},
+		{`a <= b`, dpb.BOOL, []*ad{{"a", dpb.TIMESTAMP}, {"b", dpb.TIMESTAMP}}, nil, success},
+		{`a < b`, dpb.BOOL, []*ad{{"a", dpb.STRING}, {"b", dpb.STRING}}, nil, "typeError"},
+		{`a * 2 + b`, dpb.DOUBLE, []*ad{{"a", dpb.DOUBLE}, {"b", dpb.DOUBLE}}, nil, success},
+		{`a - b`, dpb.DURATION, []*ad{{"a", dpb.TIMESTAMP}, {"b", dpb.TIMESTAMP}}, nil, success},
+		{`a + "1s"`, dpb.TIMESTAMP, []*ad{{"a", dpb.TIMESTAMP}}, nil, success},
+		{`a + b`, dpb.TIMESTAMP, []*ad{{"a", dpb.TIMESTAMP}, {"b", dpb.TIMESTAMP}}, nil, "typeError"},
+		{`a / 2`, dpb.DURATION, []*ad{{"a", dpb.DURATION}}, nil, "typeError"},
+		{`!a`, dpb.BOOL, []*ad{{"a", dpb.BOOL}}, nil, success},
+		{`!a`, dpb.BOOL, []*ad{{"a", dpb.STRING}}, nil, "typeError"},
+		{`a ? b : "c"`, dpb.STRING, []*ad{{"a", dpb.BOOL}, {"b", dpb.STRING}}, nil, success},
+		{`a ? b : 1`, dpb.STRING, []*ad{{"a", dpb.BOOL}, {"b", dpb.STRING}}, nil, "typeError"},
+		{`b ? 1 : 2`, dpb.INT64, []*ad{{"b", dpb.STRING}}, nil, "typeError"},
 
 		{`fn1()`, dpb.BOOL, []*ad{{}}, []FunctionMetadata{
 			{Name: "fn1", Instance: false, ReturnType: dpb.BOOL, ArgumentTypes: []dpb.ValueType{}},
@@ -323,6 +352,16 @@ func TestInternalTypeCheck(t *testing.T) {
 				}},
 			},
 			`$no:matches("a") target typeError got INT64`},
+		{`a.size()`, dpb.INT64, []*ad{{"a", dpb.STRING_MAP}},
+			[]FunctionMetadata{
+				{Name: "size", Instance: true, TargetTypes: []dpb.ValueType{dpb.STRING, dpb.STRING_MAP}, ReturnType: dpb.INT64},
+			},
+			success},
+		{`a.size()`, dpb.INT64, []*ad{{"a", dpb.BOOL}},
+			[]FunctionMetadata{
+				{Name: "size", Instance: true, TargetTypes: []dpb.ValueType{dpb.STRING, dpb.STRING_MAP}, ReturnType: dpb.INT64},
+			},
+			"$a:size() target typeError got BOOL, expected one of [STRING STRING_MAP]"},
 		{`"abc".genericEquals("cba")`, dpb.BOOL, []*ad{},
 			[]FunctionMetadata{
 				{Name: "genericEquals", Instance: true, TargetType: dpb.VALUE_TYPE_UNSPECIFIED, ReturnType: dpb.BOOL, ArgumentTypes: []dpb.ValueType{
@@ -29,6 +29,10 @@ type FunctionMetadata struct {
 	// TargetType is the type of the instance method target, if this function is an instance method.
 	TargetType config.ValueType
 
+	// TargetTypes restricts the target of an instance method with an unspecified TargetType to one
+	// of the given types. If empty, a target of any type is accepted.
+	TargetTypes []config.ValueType
+
 	// ReturnType is the return type of the function.
 	ReturnType config.ValueType
 
@@ -37,7 +41,7 @@ type FunctionMetadata struct {
 }
 
 func intrinsics() []FunctionMetadata {
-	return []FunctionMetadata{
+	fns := []FunctionMetadata{
 		{
 			Name:          "EQ",
 			ReturnType:    config.BOOL,
@@ -68,7 +72,69 @@ func intrinsics() []FunctionMetadata {
 			ReturnType:    config.STRING,
 			ArgumentTypes: []config.ValueType{config.STRING_MAP, config.STRING},
 		},
+		{
+			Name:          "NOT",
+			ReturnType:    config.BOOL,
+			ArgumentTypes: []config.ValueType{config.BOOL},
+		},
+		{
+			Name:          "CONDITIONAL",
+			ReturnType:    config.VALUE_TYPE_UNSPECIFIED,
+			ArgumentTypes: []config.ValueType{config.BOOL, config.VALUE_TYPE_UNSPECIFIED, config.VALUE_TYPE_UNSPECIFIED},
+		},
 	}
+
+	// The operand types of the operators are checked against their signatures instead.
+	for name := range operators {
+		fns = append(fns, FunctionMetadata{
+			Name:          name,
+			ReturnType:    config.VALUE_TYPE_UNSPECIFIED,
+			ArgumentTypes: []config.ValueType{config.VALUE_TYPE_UNSPECIFIED, config.VALUE_TYPE_UNSPECIFIED},
+		})
+	}
+	return fns
+}
+
+// signature is an accepted combination of operand types of a binary operator, with the resulting type.
+type signature struct {
+	lhs, rhs config.ValueType
+	ret      config.ValueType
+}
+
+var comparisonSignatures = []signature{
+	{config.INT64, config.INT64, config.BOOL},
+	{config.DOUBLE, config.DOUBLE, config.BOOL},
+	{config.DURATION, config.DURATION, config.BOOL},
+	{config.TIMESTAMP, config.TIMESTAMP, config.BOOL},
+}
+
+// operators are the ordering and arithmetic operators, with the combinations of operand types they accept.
+var operators = map[string][]signature{
+	"LT":  comparisonSignatures,
+	"LEQ": comparisonSignatures,
+	"GT":  comparisonSignatures,
+	"GEQ": comparisonSignatures,
+	"ADD": {
+		{config.INT64, config.INT64, config.INT64},
+		{config.DOUBLE, config.DOUBLE, config.DOUBLE},
+		{config.DURATION, config.DURATION, config.DURATION},
+		{config.TIMESTAMP, config.DURATION, config.TIMESTAMP},
+	},
+	"SUB": {
+		{config.INT64, config.INT64, config.INT64},
+		{config.DOUBLE, config.DOUBLE, config.DOUBLE},
+		{config.DURATION, config.DURATION, config.DURATION},
+		{config.TIMESTAMP, config.DURATION, config.TIMESTAMP},
+		{config.TIMESTAMP, config.TIMESTAMP, config.DURATION},
+	},
+	"MUL": {
+		{config.INT64, config.INT64, config.INT64},
+		{config.DOUBLE, config.DOUBLE, config.DOUBLE},
+	},
+	"QUO": {
+		{config.INT64, config.INT64, config.INT64},
+		{config.DOUBLE, config.DOUBLE, config.DOUBLE},
+	},
 }
 
 // FuncMap generates a full function map, combining the intrinsic functions needed for type-checking,
@@ -59,8 +59,21 @@ func TestTypeCheck(t *testing.T) {
 		{"int == 2", dpb.BOOL, ""},
 		{"double == 2.0", dpb.BOOL, ""},
 		{`string | "foobar"`, dpb.STRING, ""},
+		{"int < 2", dpb.BOOL, ""},
+		{"double >= 1", dpb.BOOL, ""},
+		{`duration > "1s"`, dpb.BOOL, ""},
+		{"timestamp <= timestamp", dpb.BOOL, ""},
+		{"int * 2 + 1", dpb.INT64, ""},
+		{"double / 2", dpb.DOUBLE, ""},
+		{`timestamp - "1s"`, dpb.TIMESTAMP, ""},
+		{"timestamp - timestamp", dpb.DURATION, ""},
+		{"!bool", dpb.BOOL, ""},
+		{`bool ? string : "foobar"`, dpb.STRING, ""},
 		// invalid expressions
 		{"int | bool", dpb.VALUE_TYPE_UNSPECIFIED, "typeError"},
+		{"int < double", dpb.VALUE_TYPE_UNSPECIFIED, "typeError"},
+		{"ip + ip", dpb.VALUE_TYPE_UNSPECIFIED, "typeError"},
+		{"int ? 1 : 2", dpb.VALUE_TYPE_UNSPECIFIED, "typeError"},
 		{"stringmap | ", dpb.VALUE_TYPE_UNSPECIFIED, "failed to parse"},
 	}
 
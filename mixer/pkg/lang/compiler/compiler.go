@@ -279,6 +279,18 @@ func (g *generator) generateFunction(f *ast.Function, depth int, mode nilMode, v
 		g.generateIndex(f, depth, mode, valueJmpLabel)
 	case "OR":
 		g.generateOr(f, depth, mode, valueJmpLabel)
+	case "NOT":
+		g.generate(f.Args[0], depth+1, nmNone, "")
+		g.builder.Not()
+		g.jmpOnValue(mode, valueJmpLabel)
+	case "LT", "LEQ", "GT", "GEQ":
+		g.generateComparison(f, depth)
+		g.jmpOnValue(mode, valueJmpLabel)
+	case "ADD", "SUB", "MUL", "QUO":
+		g.generateArithmetic(f, depth)
+		g.jmpOnValue(mode, valueJmpLabel)
+	case "CONDITIONAL":
+		g.generateConditional(f, depth, mode, valueJmpLabel)
 	default:
 		// The parameters to a function (and the function itself) is expected to exist, regardless of whether
 		// we're in a nillable context. The call will either succeed or error out.
@@ -368,6 +380,133 @@ func (g *generator) generateNeq(f *ast.Function, depth int) {
 	g.builder.Not()
 }
 
+// jmpOnValue emits the jump to valueJmpLabel after an operator, which always yields a value, in a
+// nillable context.
+func (g *generator) jmpOnValue(mode nilMode, valueJmpLabel string) {
+	if mode == nmJmpOnValue {
+		g.builder.Jmp(valueJmpLabel)
+	}
+}
+
+func (g *generator) generateComparison(f *ast.Function, depth int) {
+	dvt, _ := f.Args[0].EvalType(g.finder, g.functions)
+	g.generate(f.Args[0], depth+1, nmNone, "")
+	g.generate(f.Args[1], depth+1, nmNone, "")
+
+	switch dvt {
+	case descriptor.INT64, descriptor.DURATION:
+		switch f.Name {
+		case "LT":
+			g.builder.LTInteger()
+		case "LEQ":
+			g.builder.LEInteger()
+		case "GT":
+			g.builder.GTInteger()
+		case "GEQ":
+			g.builder.GEInteger()
+		}
+
+	case descriptor.DOUBLE:
+		switch f.Name {
+		case "LT":
+			g.builder.LTDouble()
+		case "LEQ":
+			g.builder.LEDouble()
+		case "GT":
+			g.builder.GTDouble()
+		case "GEQ":
+			g.builder.GEDouble()
+		}
+
+	case descriptor.TIMESTAMP:
+		switch f.Name {
+		case "LT":
+			g.builder.Call("timestamp_lt")
+		case "LEQ":
+			g.builder.Call("timestamp_le")
+		case "GT":
+			g.builder.Call("timestamp_gt")
+		case "GEQ":
+			g.builder.Call("timestamp_ge")
+		}
+
+	default:
+		g.internalError("comparison for type not yet implemented: %v", dvt)
+	}
+}
+
+func (g *generator) generateArithmetic(f *ast.Function, depth int) {
+	lhs, _ := f.Args[0].EvalType(g.finder, g.functions)
+	rhs, _ := f.Args[1].EvalType(g.finder, g.functions)
+	g.generate(f.Args[0], depth+1, nmNone, "")
+	g.generate(f.Args[1], depth+1, nmNone, "")
+
+	switch lhs {
+	case descriptor.INT64, descriptor.DURATION:
+		switch f.Name {
+		case "ADD":
+			g.builder.AddInteger()
+		case "SUB":
+			g.builder.SubInteger()
+		case "MUL":
+			g.builder.MulInteger()
+		case "QUO":
+			g.builder.DivInteger()
+		}
+
+	case descriptor.DOUBLE:
+		switch f.Name {
+		case "ADD":
+			g.builder.AddDouble()
+		case "SUB":
+			g.builder.SubDouble()
+		case "MUL":
+			g.builder.MulDouble()
+		case "QUO":
+			g.builder.DivDouble()
+		}
+
+	case descriptor.TIMESTAMP:
+		switch {
+		case f.Name == "ADD":
+			g.builder.Call("timestamp_add")
+		case f.Name == "SUB" && rhs == descriptor.DURATION:
+			g.builder.Call("timestamp_subDuration")
+		case f.Name == "SUB":
+			g.builder.Call("timestamp_sub")
+		}
+
+	default:
+		g.internalError("arithmetic for type not yet implemented: %v", lhs)
+	}
+}
+
+func (g *generator) generateConditional(f *ast.Function, depth int, mode nilMode, valueJmpLabel string) {
+	lelse := g.builder.AllocateLabel()
+	lend := g.builder.AllocateLabel()
+
+	g.generate(f.Args[0], depth+1, nmNone, "")
+	g.builder.Jz(lelse)
+
+	switch mode {
+	case nmNone:
+		g.generate(f.Args[1], depth+1, nmNone, "")
+		g.builder.Jmp(lend)
+		g.builder.SetLabelPos(lelse)
+		g.generate(f.Args[2], depth+1, nmNone, "")
+
+	case nmJmpOnValue:
+		// Each branch jumps to valueJmpLabel if it resolves to a value, otherwise the
+		// conditional falls through, evaluated to nil.
+		g.generate(f.Args[1], depth+1, nmJmpOnValue, valueJmpLabel)
+		g.builder.Jmp(lend)
+		g.builder.SetLabelPos(lelse)
+		g.generate(f.Args[2], depth+1, nmJmpOnValue, valueJmpLabel)
+	}
+
+	g.builder.SetLabelPos(lend)
+}
+
 func (g *generator) generateLor(f *ast.Function, depth int) {
 	g.generate(f.Args[0], depth+1, nmNone, "")
 	lr := g.builder.AllocateLabel()
@@ -21,33 +21,55 @@ import (
 	"net/mail"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/net/idna"
 
 	config "istio.io/api/policy/v1beta1"
+	"istio.io/istio/mixer/pkg/il"
 	"istio.io/istio/mixer/pkg/il/interpreter"
 	"istio.io/istio/mixer/pkg/lang/ast"
+	"istio.io/istio/pkg/cache"
 )
 
 // Externs contains the list of standard external functions used during evaluation.
 var Externs = map[string]interpreter.Extern{
-	"ip":              interpreter.ExternFromFn("ip", externIP),
-	"ip_equal":        interpreter.ExternFromFn("ip_equal", externIPEqual),
-	"timestamp":       interpreter.ExternFromFn("timestamp", externTimestamp),
-	"timestamp_equal": interpreter.ExternFromFn("timestamp_equal", externTimestampEqual),
-	"dnsName":         interpreter.ExternFromFn("dnsName", externDNSName),
-	"dnsName_equal":   interpreter.ExternFromFn("dnsName_equal", externDNSNameEqual),
-	"email":           interpreter.ExternFromFn("email", externEmail),
-	"email_equal":     interpreter.ExternFromFn("email_equal", externEmailEqual),
-	"uri":             interpreter.ExternFromFn("uri", externURI),
-	"uri_equal":       interpreter.ExternFromFn("uri_equal", externURIEqual),
-	"match":           interpreter.ExternFromFn("match", externMatch),
-	"matches":         interpreter.ExternFromFn("matches", externMatches),
-	"startsWith":      interpreter.ExternFromFn("startsWith", externStartsWith),
-	"endsWith":        interpreter.ExternFromFn("endsWith", externEndsWith),
-	"emptyStringMap":  interpreter.ExternFromFn("emptyStringMap", externEmptyStringMap),
+	"ip":                    interpreter.ExternFromFn("ip", externIP),
+	"ip_equal":              interpreter.ExternFromFn("ip_equal", externIPEqual),
+	"timestamp":             interpreter.ExternFromFn("timestamp", externTimestamp),
+	"timestamp_equal":       interpreter.ExternFromFn("timestamp_equal", externTimestampEqual),
+	"timestamp_lt":          interpreter.ExternFromFn("timestamp_lt", externTimestampLt),
+	"timestamp_le":          interpreter.ExternFromFn("timestamp_le", externTimestampLe),
+	"timestamp_gt":          interpreter.ExternFromFn("timestamp_gt", externTimestampGt),
+	"timestamp_ge":          interpreter.ExternFromFn("timestamp_ge", externTimestampGe),
+	"timestamp_add":         interpreter.ExternFromFn("timestamp_add", externTimestampAdd),
+	"timestamp_sub":         interpreter.ExternFromFn("timestamp_sub", externTimestampSub),
+	"timestamp_subDuration": interpreter.ExternFromFn("timestamp_subDuration", externTimestampSubDuration),
+	"dnsName":               interpreter.ExternFromFn("dnsName", externDNSName),
+	"dnsName_equal":         interpreter.ExternFromFn("dnsName_equal", externDNSNameEqual),
+	"email":                 interpreter.ExternFromFn("email", externEmail),
+	"email_equal":           interpreter.ExternFromFn("email_equal", externEmailEqual),
+	"uri":                   interpreter.ExternFromFn("uri", externURI),
+	"uri_equal":             interpreter.ExternFromFn("uri_equal", externURIEqual),
+	"match":                 interpreter.ExternFromFn("match", externMatch),
+	"matches":               interpreter.ExternFromFn("matches", externMatches),
+	"startsWith":            interpreter.ExternFromFn("startsWith", externStartsWith),
+	"endsWith":              interpreter.ExternFromFn("endsWith", externEndsWith),
+	"emptyStringMap":        interpreter.ExternFromFn("emptyStringMap", externEmptyStringMap),
+	"toLower":               interpreter.ExternFromFn("toLower", externToLower),
+	"toUpper":               interpreter.ExternFromFn("toUpper", externToUpper),
+	"substring":             interpreter.ExternFromFn("substring", externSubstring),
+	"split":                 interpreter.ExternFromFn("split", externSplit),
+	"replace":               interpreter.ExternFromFn("replace", externReplace),
+	"regexReplace":          interpreter.ExternFromFn("regexReplace", externRegexReplace),
+	"concat":                interpreter.ExternFromFn("concat", externConcat),
+	"conditional":           interpreter.ExternFromFn("conditional", externConditional),
+	"ipInRange":             interpreter.ExternFromFn("ipInRange", externIPInRange),
+	"duration":              interpreter.ExternFromFn("duration", externDuration),
+	"formatTimestamp":       interpreter.ExternFromFn("formatTimestamp", externFormatTimestamp),
+	"size":                  interpreter.ExternFromFn("size", externSize),
 }
 
 // ExternFunctionMetadata is the type-metadata about externs. It gets used during compilations.
@@ -108,6 +130,70 @@ var ExternFunctionMetadata = []ast.FunctionMetadata{
 		ReturnType:    config.STRING_MAP,
 		ArgumentTypes: []config.ValueType{},
 	},
+	{
+		Name:          "toLower",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.STRING},
+	},
+	{
+		Name:          "toUpper",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.STRING},
+	},
+	{
+		Name:          "substring",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.STRING, config.INT64, config.INT64},
+	},
+	{
+		Name:          "split",
+		ReturnType:    config.STRING_MAP,
+		ArgumentTypes: []config.ValueType{config.STRING, config.STRING},
+	},
+	{
+		Name:          "replace",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.STRING, config.STRING, config.STRING},
+	},
+	{
+		Name:          "regexReplace",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.STRING, config.STRING, config.STRING},
+	},
+	{
+		Name:          "concat",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.STRING, config.STRING},
+	},
+	{
+		Name:          "conditional",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.BOOL, config.STRING, config.STRING},
+	},
+	{
+		Name:          "ipInRange",
+		Instance:      true,
+		TargetType:    config.IP_ADDRESS,
+		ReturnType:    config.BOOL,
+		ArgumentTypes: []config.ValueType{config.STRING},
+	},
+	{
+		Name:          "duration",
+		ReturnType:    config.DURATION,
+		ArgumentTypes: []config.ValueType{config.STRING},
+	},
+	{
+		Name:          "formatTimestamp",
+		ReturnType:    config.STRING,
+		ArgumentTypes: []config.ValueType{config.TIMESTAMP, config.STRING},
+	},
+	{
+		Name:          "size",
+		Instance:      true,
+		TargetTypes:   []config.ValueType{config.STRING, config.STRING_MAP},
+		ReturnType:    config.INT64,
+		ArgumentTypes: []config.ValueType{},
+	},
 }
 
 func externIP(in string) ([]byte, error) {
@@ -137,6 +223,34 @@ func externTimestampEqual(t1 time.Time, t2 time.Time) bool {
 	return t1.Equal(t2)
 }
 
+func externTimestampLt(t1 time.Time, t2 time.Time) bool {
+	return t1.Before(t2)
+}
+
+func externTimestampLe(t1 time.Time, t2 time.Time) bool {
+	return !t1.After(t2)
+}
+
+func externTimestampGt(t1 time.Time, t2 time.Time) bool {
+	return t1.After(t2)
+}
+
+func externTimestampGe(t1 time.Time, t2 time.Time) bool {
+	return !t1.Before(t2)
+}
+
+func externTimestampAdd(t time.Time, d time.Duration) time.Time {
+	return t.Add(d)
+}
+
+func externTimestampSub(t1 time.Time, t2 time.Time) time.Duration {
+	return t1.Sub(t2)
+}
+
+func externTimestampSubDuration(t time.Time, d time.Duration) time.Time {
+	return t.Add(-d)
+}
+
 // This IDNA profile is for performing validations, but does not otherwise modify the string.
 var externDNSNameProfile = idna.New(
 	idna.StrictDomainName(true),
@@ -321,3 +435,107 @@ func externEndsWith(str string, suffix string) bool {
 func externEmptyStringMap() map[string]string {
 	return map[string]string{}
 }
+
+func externToLower(str string) string {
+	return strings.ToLower(str)
+}
+
+func externToUpper(str string) string {
+	return strings.ToUpper(str)
+}
+
+// externSubstring returns the bytes of str between the begin and end indices. The end index is
+// capped at the length of the string.
+func externSubstring(str string, begin int64, end int64) (string, error) {
+	if end > int64(len(str)) {
+		end = int64(len(str))
+	}
+	if begin < 0 || begin > end {
+		return "", fmt.Errorf("invalid indices %d and %d for substring of '%s'", begin, end, str)
+	}
+	return str[begin:end], nil
+}
+
+// externSplit splits str around each instance of sep. As there are no lists in the expression
+// language, the parts are returned as a map from their indices ("0", "1", ...) to the parts.
+func externSplit(str string, sep string) map[string]string {
+	parts := strings.Split(str, sep)
+	m := make(map[string]string, len(parts))
+	for i, part := range parts {
+		m[strconv.Itoa(i)] = part
+	}
+	return m
+}
+
+func externReplace(str string, from string, to string) string {
+	return strings.Replace(str, from, to, -1)
+}
+
+// maxRegexCacheEntries bounds the number of compiled patterns kept by regexCache.
+const maxRegexCacheEntries = 1000
+
+// regexCache holds the patterns compiled by regexReplace, by pattern. The patterns are usually
+// constants of the configuration, and are then compiled once. The least recently used patterns
+// are evicted, so that patterns computed from attributes don't grow the cache without bound.
+var regexCache = cache.NewLRU(0, 0, maxRegexCacheEntries)
+
+func externRegexReplace(str string, pattern string, replacement string) (string, error) {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(str, replacement), nil
+}
+
+// compileRegex returns the compiled pattern from regexCache, compiling it if it is not cached.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Get(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Set(pattern, re)
+	return re, nil
+}
+
+func externConcat(s1 string, s2 string) string {
+	return s1 + s2
+}
+
+func externConditional(cond bool, s1 string, s2 string) string {
+	if cond {
+		return s1
+	}
+	return s2
+}
+
+func externIPInRange(ip []byte, cidr string) (bool, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, fmt.Errorf("could not convert %s to CIDR: %v", cidr, err)
+	}
+	return ipNet.Contains(net.IP(ip)), nil
+}
+
+func externDuration(in string) (time.Duration, error) {
+	d, err := time.ParseDuration(in)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert '%s' to DURATION: %v", in, err)
+	}
+	return d, nil
+}
+
+// externFormatTimestamp formats t with a Go time layout, like "2006-01-02T15:04:05Z07:00".
+func externFormatTimestamp(t time.Time, layout string) string {
+	return t.Format(layout)
+}
+
+// externSize returns the number of bytes of a string, or the number of entries of a string map.
+func externSize(v interface{}) int64 {
+	if str, ok := v.(string); ok {
+		return int64(len(str))
+	}
+	return int64(il.MapLen(v))
+}
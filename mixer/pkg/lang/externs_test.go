@@ -17,6 +17,8 @@ package lang
 import (
 	"bytes"
 	"net"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -87,6 +89,37 @@ func TestExternTimestampEqual_False(t *testing.T) {
 	}
 }
 
+func TestExternTimestampCompare(t *testing.T) {
+	t1, _ := externTimestamp("2015-01-02T15:04:35Z")
+	t2, _ := externTimestamp("2018-11-11T15:04:35Z")
+	if !externTimestampLt(t1, t2) || externTimestampLt(t2, t1) || externTimestampLt(t1, t1) {
+		t.Fatal("timestamp_lt")
+	}
+	if !externTimestampLe(t1, t2) || externTimestampLe(t2, t1) || !externTimestampLe(t1, t1) {
+		t.Fatal("timestamp_le")
+	}
+	if externTimestampGt(t1, t2) || !externTimestampGt(t2, t1) || externTimestampGt(t1, t1) {
+		t.Fatal("timestamp_gt")
+	}
+	if externTimestampGe(t1, t2) || !externTimestampGe(t2, t1) || !externTimestampGe(t1, t1) {
+		t.Fatal("timestamp_ge")
+	}
+}
+
+func TestExternTimestampArithmetic(t *testing.T) {
+	t1, _ := externTimestamp("2015-01-02T15:04:35Z")
+	t2, _ := externTimestamp("2015-01-02T15:05:35Z")
+	if d := externTimestampSub(t2, t1); d != time.Minute {
+		t.Fatalf("Unexpected duration: %v", d)
+	}
+	if ti := externTimestampAdd(t1, time.Minute); !ti.Equal(t2) {
+		t.Fatalf("Unexpected time: %v", ti)
+	}
+	if ti := externTimestampSubDuration(t2, time.Minute); !ti.Equal(t1) {
+		t.Fatalf("Unexpected time: %v", ti)
+	}
+}
+
 func TestExternDnsName_Positive(t *testing.T) {
 	positive := []string{
 		"foo",
@@ -487,3 +520,129 @@ func TestExternEmptyStringMap(t *testing.T) {
 		t.Errorf("emptyStringMap() returned non-empty map: %#v", m)
 	}
 }
+
+func TestExternSubstring(t *testing.T) {
+	var cases = []struct {
+		s     string
+		begin int64
+		end   int64
+		e     string
+		err   bool
+	}{
+		{"abcdef", 0, 3, "abc", false},
+		{"abcdef", 2, 6, "cdef", false},
+		{"abcdef", 2, 100, "cdef", false},
+		{"abcdef", 3, 3, "", false},
+		{"abcdef", 7, 100, "", true},
+		{"abcdef", -1, 3, "", true},
+		{"abcdef", 3, 2, "", true},
+	}
+
+	for _, c := range cases {
+		s, err := externSubstring(c.s, c.begin, c.end)
+		if (err != nil) != c.err || s != c.e {
+			t.Fatalf("substring failure: %+v => %q, %v", c, s, err)
+		}
+	}
+}
+
+func TestExternSplit(t *testing.T) {
+	m := externSplit("/a/b", "/")
+	want := map[string]string{"0": "", "1": "a", "2": "b"}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("split() => %v, want %v", m, want)
+	}
+}
+
+func TestExternStringUtilities(t *testing.T) {
+	if s := externToLower("AbC"); s != "abc" {
+		t.Fatalf("toLower() => %s", s)
+	}
+	if s := externToUpper("AbC"); s != "ABC" {
+		t.Fatalf("toUpper() => %s", s)
+	}
+	if s := externReplace("a.b.c", ".", "/"); s != "a/b/c" {
+		t.Fatalf("replace() => %s", s)
+	}
+	if s := externConcat("a", "b"); s != "ab" {
+		t.Fatalf("concat() => %s", s)
+	}
+	if s := externConditional(true, "a", "b"); s != "a" {
+		t.Fatalf("conditional() => %s", s)
+	}
+	if s := externConditional(false, "a", "b"); s != "b" {
+		t.Fatalf("conditional() => %s", s)
+	}
+}
+
+func TestExternRegexReplace(t *testing.T) {
+	s, err := externRegexReplace("/users/123/orders/45", "[0-9]+", "{id}")
+	if err != nil || s != "/users/{id}/orders/{id}" {
+		t.Fatalf("regexReplace() => %s, %v", s, err)
+	}
+	if _, err = externRegexReplace("abc", "(", ""); err == nil {
+		t.Fatal("Expected error not found.")
+	}
+
+	// The valid patterns are compiled once.
+	re, ok := regexCache.Get("[0-9]+")
+	if !ok {
+		t.Fatal("regexReplace() pattern is not cached")
+	}
+	if s, err = externRegexReplace("/users/678", "[0-9]+", "{id}"); err != nil || s != "/users/{id}" {
+		t.Fatalf("regexReplace() => %s, %v", s, err)
+	}
+	if cached, _ := regexCache.Get("[0-9]+"); cached != re {
+		t.Fatal("regexReplace() recompiled a cached pattern")
+	}
+	if _, ok = regexCache.Get("("); ok {
+		t.Fatal("regexReplace() cached an invalid pattern")
+	}
+}
+
+func TestExternIPInRange(t *testing.T) {
+	var cases = []struct {
+		ip   string
+		cidr string
+		e    bool
+		err  bool
+	}{
+		{"10.1.2.3", "10.0.0.0/8", true, false},
+		{"11.1.2.3", "10.0.0.0/8", false, false},
+		{"2001:db8::1", "2001:db8::/32", true, false},
+		{"10.1.2.3", "10.0.0.0", false, true},
+	}
+
+	for _, c := range cases {
+		b, err := externIPInRange(net.ParseIP(c.ip), c.cidr)
+		if (err != nil) != c.err || b != c.e {
+			t.Fatalf("ipInRange failure: %+v => %v, %v", c, b, err)
+		}
+	}
+}
+
+func TestExternDuration(t *testing.T) {
+	d, err := externDuration("1m30s")
+	if err != nil || d != 90*time.Second {
+		t.Fatalf("duration() => %v, %v", d, err)
+	}
+	if _, err = externDuration("1x"); err == nil || !strings.HasPrefix(err.Error(), "could not convert '1x' to DURATION") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestExternFormatTimestamp(t *testing.T) {
+	ti, _ := externTimestamp("2015-01-02T15:04:35Z")
+	if s := externFormatTimestamp(ti, "2006-01-02 15:04"); s != "2015-01-02 15:04" {
+		t.Fatalf("formatTimestamp() => %s", s)
+	}
+}
+
+func TestExternSize(t *testing.T) {
+	if n := externSize("abcd"); n != 4 {
+		t.Fatalf("size() => %d", n)
+	}
+	if n := externSize(map[string]string{"a": "b"}); n != 1 {
+		t.Fatalf("size() => %d", n)
+	}
+}
@@ -96,6 +96,9 @@ type Impl struct {
 	statePool *dispatchStatePool
 
 	gp *pool.GoroutinePool
+
+	// queues of report instances awaiting dispatch, nil if reports are dispatched synchronously.
+	reports *reportQueues
 }
 
 var _ Dispatcher = &Impl{}
@@ -152,6 +155,22 @@ func (d *Impl) ChangeRoute(new *routing.Table) *RoutingContext {
 	return old
 }
 
+// StartAsyncReports makes Report return as soon as the instances are created and queued for
+// their handlers, rather than after the handlers are done with them. The queued instances
+// are dispatched in batches. This must be called before any request is dispatched.
+func (d *Impl) StartAsyncReports(o AsyncReportOptions) {
+	d.reports = newReportQueues(o, d.gp, d.currentRoutingContext)
+}
+
+// StopAsyncReports dispatches the queued report instances and returns to synchronous reports.
+// This must be called once requests are no longer dispatched.
+func (d *Impl) StopAsyncReports() {
+	if d.reports != nil {
+		d.reports.close()
+		d.reports = nil
+	}
+}
+
 // Check implementation of runtime.Impl.
 func (d *Impl) Check(ctx context.Context, bag attribute.Bag) (*adapter.CheckResult, error) {
 	s := d.beginSession(ctx, tpb.TEMPLATE_VARIETY_CHECK, bag)
@@ -287,21 +306,30 @@ func (d *Impl) dispatch(session *session) error {
 			}
 
 			if session.variety == tpb.TEMPLATE_VARIETY_REPORT {
+				if d.reports != nil {
+					// Queue the instances, they are dispatched along with the ones of other requests.
+					if len(state.instances) > 0 {
+						d.reports.enqueue(session.ctx, r, destination, state.instances)
+					}
+					d.statePool.put(state)
+					continue
+				}
+
 				// Do a multi-instance dispatch for report.
 				d.dispatchToHandler(state)
 			}
 		}
 	}
 
-	// wait on the dispatch states and accumulate results
+	// wait on the dispatch states, and accumulate their results in dispatch order, so that the
+	// combined result depends on the configuration and not on how fast each handler answers.
+	session.waitForDispatches()
+
 	var buf *bytes.Buffer
 	code := rpc.OK
 
 	err = nil
-	for session.activeDispatches > 0 {
-		state := <-session.completed
-		session.activeDispatches--
-
+	for _, state := range session.states {
 		// Aggregate errors
 		if state.err != nil {
 			err = multierror.Append(err, state.err)
@@ -371,6 +399,13 @@ func (d *Impl) dispatch(session *session) error {
 	return nil
 }
 
+func (d *Impl) currentRoutingContext() *RoutingContext {
+	d.contextLock.RLock()
+	ctx := d.context
+	d.contextLock.RUnlock()
+	return ctx
+}
+
 func (d *Impl) acquireRoutingContext() *RoutingContext {
 	d.contextLock.RLock()
 	ctx := d.context
@@ -406,6 +441,7 @@ func (d *Impl) completeSession(s *session) {
 }
 
 func (d *Impl) dispatchToHandler(s *dispatchState) {
+	s.index = s.session.activeDispatches
 	s.session.activeDispatches++
 
 	d.gp.ScheduleWork(doDispatchToHandler, s)
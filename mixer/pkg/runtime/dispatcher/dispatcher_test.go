@@ -802,3 +802,71 @@ func TestRefCount(t *testing.T) {
 	}
 
 }
+
+func TestCheckResultsCombinedInDispatchOrder(t *testing.T) {
+	// Run a check dispatched to two handlers that answer in either order, and expect the same
+	// combined result.
+	check := func(firstToAnswer string) *adapter.CheckResult {
+		l := &data.Logger{}
+		commence := map[string]chan struct{}{
+			"tcheck": make(chan struct{}),
+			"thalt":  make(chan struct{}),
+		}
+		received := make(chan struct{}, 2)
+		templates := data.BuildTemplates(l,
+			data.FakeTemplateSettings{
+				Name: "tcheck",
+				CheckResults: []adapter.CheckResult{{
+					Status:        rpc.Status{Code: int32(rpc.PERMISSION_DENIED), Message: "tcheck denied"},
+					ValidDuration: time.Minute,
+				}},
+				ReceivedCallChannel:   received,
+				CommenceSignalChannel: commence["tcheck"],
+			},
+			data.FakeTemplateSettings{
+				Name: "thalt",
+				CheckResults: []adapter.CheckResult{{
+					Status:        rpc.Status{Code: int32(rpc.UNAUTHENTICATED), Message: "thalt denied"},
+					ValidDuration: time.Second,
+				}},
+				ReceivedCallChannel:   received,
+				CommenceSignalChannel: commence["thalt"],
+			})
+		config := data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1, data.InstanceHalt1, data.Rule4CheckAndHalt)
+
+		s := util.GetSnapshot(templates, data.BuildAdapters(l), data.ServiceConfig, config)
+		h := handler.NewTable(handler.Empty(), s, pool.NewGoroutinePool(1, false))
+		dispatcher := New("ident", gp, true)
+		_ = dispatcher.ChangeRoute(routing.BuildTable(h, s, compiled.NewBuilder(s.Attributes), "istio-system", true))
+
+		go func() {
+			<-received
+			<-received
+			commence[firstToAnswer] <- struct{}{}
+			// let the first handler complete its dispatch before the other one answers
+			time.Sleep(10 * time.Millisecond)
+			for name, c := range commence {
+				if name != firstToAnswer {
+					c <- struct{}{}
+				}
+			}
+		}()
+
+		bag := attribute.GetFakeMutableBagForTesting(map[string]interface{}{"ident": "dest.istio-system"})
+		cres, err := dispatcher.Check(context.TODO(), bag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cres
+	}
+
+	tcheckFirst := check("tcheck")
+	thaltFirst := check("thalt")
+	if !reflect.DeepEqual(tcheckFirst, thaltFirst) {
+		t.Fatalf("check results depend on the order of the answers: '%v' != '%v'", tcheckFirst, thaltFirst)
+	}
+	if tcheckFirst.ValidDuration != time.Second || !strings.Contains(tcheckFirst.Status.Message, "tcheck denied") ||
+		!strings.Contains(tcheckFirst.Status.Message, "thalt denied") {
+		t.Fatalf("unexpected combined check result: '%v'", tcheckFirst)
+	}
+}
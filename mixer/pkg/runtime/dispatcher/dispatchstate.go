@@ -28,6 +28,9 @@ import (
 type dispatchState struct {
 	session *session
 
+	// index of the dispatch in the session, in dispatch order.
+	index int
+
 	destination *routing.Destination
 	mapper      template.OutputMapperFn
 
@@ -45,6 +48,7 @@ type dispatchState struct {
 
 func (s *dispatchState) clear() {
 	s.session = nil
+	s.index = 0
 	s.destination = nil
 	s.mapper = nil
 	s.inputBag = nil
@@ -58,6 +58,28 @@ var (
 		Help:      "Histogram of inputs dispatched per request, by Mixer.",
 		Buckets:   countBuckets,
 	})
+
+	reportQueuedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "dispatcher",
+		Name:      "report_instances_queued",
+		Help:      "Total number of report instances queued for asynchronous dispatch, by handler.",
+	}, []string{handlerName})
+
+	reportDroppedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "dispatcher",
+		Name:      "report_instances_dropped",
+		Help:      "Total number of report instances dropped because the queue of their handler was full, by handler.",
+	}, []string{handlerName})
+
+	reportBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "mixer",
+		Subsystem: "dispatcher",
+		Name:      "report_batch_size",
+		Help:      "Histogram of the number of report instances dispatched at once to a handler, by Mixer.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000},
+	})
 )
 
 func init() {
@@ -65,6 +87,9 @@ func init() {
 	prometheus.MustRegister(destinationsPerRequest)
 	prometheus.MustRegister(instancesPerRequest)
 	prometheus.MustRegister(requestDuration)
+	prometheus.MustRegister(reportQueuedCount)
+	prometheus.MustRegister(reportDroppedCount)
+	prometheus.MustRegister(reportBatchSize)
 }
 
 // updateRequestCounters updates request related counters. Duration is the total request handling duration. Destinations
@@ -82,3 +107,14 @@ func updateRequestCounters(duration time.Duration, destinations int, inputs int,
 	instancesPerRequest.Observe(float64(inputs))
 	requestDuration.Observe(duration.Seconds())
 }
+
+// updateReportQueueCounters updates the counters of report instances queued for, and dropped from, the
+// asynchronous dispatch to the given handler.
+func updateReportQueueCounters(handler string, queued int, dropped int) {
+	if queued > 0 {
+		reportQueuedCount.WithLabelValues(handler).Add(float64(queued))
+	}
+	if dropped > 0 {
+		reportDroppedCount.WithLabelValues(handler).Add(float64(dropped))
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/pkg/runtime/routing"
+	"istio.io/istio/pkg/log"
+)
+
+// OverflowPolicy determines what happens to report instances when the queue of their handler is full.
+type OverflowPolicy int
+
+const (
+	// DropNewest discards the incoming instances that do not fit in the queue.
+	DropNewest OverflowPolicy = iota
+
+	// DropOldest discards the oldest queued instances to make room for the incoming ones.
+	DropOldest
+
+	// Block holds the Report call until there is room in the queue, pushing back on the
+	// caller. Instances are discarded if the call is cancelled while waiting.
+	Block
+)
+
+var overflowPolicyNames = []string{"drop-newest", "drop-oldest", "block"}
+
+func (p OverflowPolicy) String() string {
+	if p < 0 || int(p) >= len(overflowPolicyNames) {
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+	return overflowPolicyNames[p]
+}
+
+// ParseOverflowPolicy returns the overflow policy with the given name.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	for i, n := range overflowPolicyNames {
+		if n == name {
+			return OverflowPolicy(i), nil
+		}
+	}
+	return DropNewest, fmt.Errorf("unknown overflow policy '%s', expecting one of %v", name, overflowPolicyNames)
+}
+
+// AsyncReportOptions controls the asynchronous dispatching of reports.
+type AsyncReportOptions struct {
+	// QueueSize is the maximum number of instances waiting to be dispatched to a handler.
+	QueueSize int
+
+	// BatchSize is the maximum number of instances dispatched to a handler at once. A batch
+	// is dispatched as soon as it is full.
+	BatchSize int
+
+	// FlushInterval is the maximum amount of time instances wait before being dispatched.
+	FlushInterval time.Duration
+
+	// Overflow is what happens to instances when the queue of their handler is full.
+	Overflow OverflowPolicy
+}
+
+// reportQueues buffers the report instances for each destination, and dispatches them in
+// batches on the goroutine pool.
+type reportQueues struct {
+	opts AsyncReportOptions
+	gp   *pool.GoroutinePool
+
+	// current returns the routing context in effect, to recognize the queues of retired routes.
+	current func() *RoutingContext
+
+	mu     sync.Mutex
+	queues map[*routing.Destination]*reportQueue
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// reportQueue holds the instances waiting to be dispatched to a destination. Every queued
+// instance holds a reference on the routing context of the destination, so that its handler
+// is not closed before the instance is dispatched.
+type reportQueue struct {
+	destination *routing.Destination
+	routes      *RoutingContext
+	instances   chan interface{}
+
+	// flushing is set while a batch of the queue is scheduled or being dispatched, accessed atomically.
+	flushing int32
+
+	opts *AsyncReportOptions
+}
+
+func newReportQueues(opts AsyncReportOptions, gp *pool.GoroutinePool, current func() *RoutingContext) *reportQueues {
+	q := &reportQueues{
+		opts:    opts,
+		gp:      gp,
+		current: current,
+		queues:  make(map[*routing.Destination]*reportQueue),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go q.run()
+	return q
+}
+
+// enqueue queues the instances for the destination, applying the overflow policy if the queue
+// is full. The routing context must be held by the caller.
+func (q *reportQueues) enqueue(ctx context.Context, routes *RoutingContext, destination *routing.Destination,
+	instances []interface{}) {
+
+	q.mu.Lock()
+	rq, found := q.queues[destination]
+	if !found {
+		rq = &reportQueue{
+			destination: destination,
+			routes:      routes,
+			instances:   make(chan interface{}, q.opts.QueueSize),
+			opts:        &q.opts,
+		}
+		q.queues[destination] = rq
+	}
+	q.mu.Unlock()
+
+	queued, dropped := rq.add(ctx, instances)
+	updateReportQueueCounters(destination.HandlerName, queued, dropped)
+
+	if len(rq.instances) >= q.opts.BatchSize {
+		q.schedule(rq)
+	}
+}
+
+// add puts the instances in the queue and returns how many of them were queued and dropped.
+func (rq *reportQueue) add(ctx context.Context, instances []interface{}) (queued int, dropped int) {
+	for i, instance := range instances {
+		rq.routes.IncRef()
+		if rq.tryAdd(instance) {
+			queued++
+			continue
+		}
+
+		switch rq.opts.Overflow {
+		case DropOldest:
+			// make room, other callers may be competing for it
+			for !rq.tryAdd(instance) {
+				select {
+				case <-rq.instances:
+					rq.routes.DecRef()
+					dropped++
+				default:
+				}
+			}
+			queued++
+
+		case Block:
+			select {
+			case rq.instances <- instance:
+				queued++
+			case <-ctx.Done():
+				// give up on the rest of the call
+				rq.routes.DecRef()
+				return queued, dropped + len(instances) - i
+			}
+
+		default:
+			rq.routes.DecRef()
+			dropped++
+		}
+	}
+
+	return queued, dropped
+}
+
+func (rq *reportQueue) tryAdd(instance interface{}) bool {
+	select {
+	case rq.instances <- instance:
+		return true
+	default:
+		return false
+	}
+}
+
+// schedule dispatches a batch of the queue on the goroutine pool, unless one is already underway.
+func (q *reportQueues) schedule(rq *reportQueue) {
+	if atomic.CompareAndSwapInt32(&rq.flushing, 0, 1) {
+		q.gp.ScheduleWork(flushReportQueue, rq)
+	}
+}
+
+func flushReportQueue(param interface{}) {
+	rq := param.(*reportQueue)
+
+	// keep the worker while there are full batches waiting
+	rq.flush()
+	for len(rq.instances) >= rq.opts.BatchSize {
+		rq.flush()
+	}
+	atomic.StoreInt32(&rq.flushing, 0)
+}
+
+// flush dispatches the queued instances, up to a batch, to the destination handler.
+func (rq *reportQueue) flush() {
+	batch := make([]interface{}, 0, rq.opts.BatchSize)
+
+loop:
+	for len(batch) < rq.opts.BatchSize {
+		select {
+		case instance := <-rq.instances:
+			batch = append(batch, instance)
+		default:
+			break loop
+		}
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	rq.dispatch(batch)
+	atomic.AddInt32(&rq.routes.refCount, -int32(len(batch)))
+}
+
+func (rq *reportQueue) dispatch(batch []interface{}) {
+	destination := rq.destination
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("panic during report dispatch to %s: %v", destination.FriendlyName, r)
+			if log.DebugEnabled() {
+				log.Debugf("stack dump for report dispatch panic:\n%s", debug.Stack())
+			}
+			destination.Counters.Update(time.Since(start), true)
+		}
+	}()
+
+	err := destination.Template.DispatchReport(context.Background(), destination.Handler, batch)
+	destination.Counters.Update(time.Since(start), err != nil)
+	reportBatchSize.Observe(float64(len(batch)))
+
+	if err != nil {
+		log.Warnf("unable to dispatch %d report instances to %s: %v", len(batch), destination.FriendlyName, err)
+	}
+}
+
+// run periodically flushes the queues, and forgets the queues of retired routes once they are drained.
+func (q *reportQueues) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			current := q.current()
+
+			q.mu.Lock()
+			pending := make([]*reportQueue, 0, len(q.queues))
+			for destination, rq := range q.queues {
+				if len(rq.instances) > 0 {
+					pending = append(pending, rq)
+				} else if rq.routes != current && rq.routes.GetRefs() == 0 {
+					// the routes are no longer in use, so nothing can be added to this queue anymore
+					delete(q.queues, destination)
+				}
+			}
+			q.mu.Unlock()
+
+			for _, rq := range pending {
+				q.schedule(rq)
+			}
+
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// close stops the periodic flushes and dispatches all the queued instances. Reports must no
+// longer be enqueued at this point.
+func (q *reportQueues) close() {
+	close(q.stop)
+	<-q.done
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, rq := range q.queues {
+		// wait for the batch underway, if any
+		for !atomic.CompareAndSwapInt32(&rq.flushing, 0, 1) {
+			time.Sleep(time.Millisecond)
+		}
+		for len(rq.instances) > 0 {
+			rq.flush()
+		}
+	}
+	q.queues = nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/lang/compiled"
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/pkg/runtime/handler"
+	"istio.io/istio/mixer/pkg/runtime/routing"
+	"istio.io/istio/mixer/pkg/runtime/testing/data"
+	"istio.io/istio/mixer/pkg/runtime/testing/util"
+)
+
+func TestOverflowPolicy(t *testing.T) {
+	for _, p := range []OverflowPolicy{DropNewest, DropOldest, Block} {
+		parsed, err := ParseOverflowPolicy(p.String())
+		if err != nil {
+			t.Fatalf("ParseOverflowPolicy(%q) failed: %v", p.String(), err)
+		}
+		if parsed != p {
+			t.Fatalf("ParseOverflowPolicy(%q) = %v, want %v", p.String(), parsed, p)
+		}
+	}
+
+	if _, err := ParseOverflowPolicy("drop-all"); err == nil {
+		t.Fatal("ParseOverflowPolicy() succeeded for an unknown policy")
+	}
+
+	if s := OverflowPolicy(42).String(); s != "OverflowPolicy(42)" {
+		t.Fatalf("unexpected string for an unknown policy: %s", s)
+	}
+}
+
+func TestReportQueue_Add(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tst := range []struct {
+		name     string
+		policy   OverflowPolicy
+		ctx      context.Context
+		queued   int
+		dropped  int
+		expected []interface{}
+	}{
+		{name: "DropNewest", policy: DropNewest, ctx: context.Background(), queued: 2, dropped: 2, expected: []interface{}{1, 2}},
+		{name: "DropOldest", policy: DropOldest, ctx: context.Background(), queued: 4, dropped: 2, expected: []interface{}{3, 4}},
+		{name: "BlockCancelled", policy: Block, ctx: cancelled, queued: 2, dropped: 2, expected: []interface{}{1, 2}},
+	} {
+		t.Run(tst.name, func(tt *testing.T) {
+			rq := &reportQueue{
+				routes:    &RoutingContext{},
+				instances: make(chan interface{}, 2),
+				opts:      &AsyncReportOptions{Overflow: tst.policy},
+			}
+
+			queued, dropped := rq.add(tst.ctx, []interface{}{1, 2, 3, 4})
+			if queued != tst.queued || dropped != tst.dropped {
+				tt.Fatalf("add() = (%d, %d), want (%d, %d)", queued, dropped, tst.queued, tst.dropped)
+			}
+
+			if refs := rq.routes.GetRefs(); refs != int32(len(tst.expected)) {
+				tt.Fatalf("%d refs held, want one for each of the %d queued instances", refs, len(tst.expected))
+			}
+
+			for _, e := range tst.expected {
+				if i := <-rq.instances; i != e {
+					tt.Fatalf("got instance %v from the queue, want %v", i, e)
+				}
+			}
+		})
+	}
+}
+
+func TestReportQueue_AddBlock(t *testing.T) {
+	rq := &reportQueue{
+		routes:    &RoutingContext{},
+		instances: make(chan interface{}, 1),
+		opts:      &AsyncReportOptions{Overflow: Block},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = rq.add(context.Background(), []interface{}{1, 2})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("add() returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if i := <-rq.instances; i != 1 {
+		t.Fatalf("got instance %v from the queue, want 1", i)
+	}
+	<-done
+
+	if i := <-rq.instances; i != 2 {
+		t.Fatalf("got instance %v from the queue, want 2", i)
+	}
+}
+
+// newReportDispatcher returns a dispatcher routing reports to a single handler, which
+// logs the instances it receives in l.
+func newReportDispatcher(l *data.Logger) *Impl {
+	d := New("ident", gp, true)
+
+	templates := data.BuildTemplates(l)
+	adapters := data.BuildAdapters(l)
+	config := data.JoinConfigs(data.HandlerAReport1, data.InstanceReport1, data.RuleReport1)
+
+	s := util.GetSnapshot(templates, adapters, data.ServiceConfig, config)
+	h := handler.NewTable(handler.Empty(), s, pool.NewGoroutinePool(1, false))
+
+	expb := compiled.NewBuilder(s.Attributes)
+	r := routing.BuildTable(h, s, expb, "istio-system", true)
+	_ = d.ChangeRoute(r)
+
+	l.Clear()
+	return d
+}
+
+func report(t *testing.T, d *Impl) {
+	t.Helper()
+
+	bag := attribute.GetFakeMutableBagForTesting(map[string]interface{}{
+		"ident": "dest.istio-system",
+	})
+	if err := d.Report(context.TODO(), bag); err != nil {
+		t.Fatalf("unexpected error: '%v'", err)
+	}
+}
+
+func dispatches(l *data.Logger) int {
+	return strings.Count(l.String(), "DispatchReport => instances")
+}
+
+func TestAsyncReports_Batch(t *testing.T) {
+	l := &data.Logger{}
+	d := newReportDispatcher(l)
+
+	d.StartAsyncReports(AsyncReportOptions{
+		QueueSize:     10,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Overflow:      DropNewest,
+	})
+
+	report(t, d)
+	if n := dispatches(l); n != 0 {
+		t.Fatalf("%d dispatches before the batch was full", n)
+	}
+	if refs := d.currentRoutingContext().GetRefs(); refs != 1 {
+		t.Fatalf("%d refs held by the queued instances, want 1", refs)
+	}
+
+	// the goroutine pool is single threaded, so the full batch is dispatched inline.
+	report(t, d)
+	if n := dispatches(l); n != 1 {
+		t.Fatalf("%d dispatches for a full batch, want 1", n)
+	}
+	if !strings.Contains(l.String(), "instances: '[&Struct{Fields:map[string]*Value{},} &Struct{Fields:map[string]*Value{},}]'") {
+		t.Fatalf("the batch was not dispatched at once:\n%s", l.String())
+	}
+
+	// the instances that are left are dispatched when stopping.
+	report(t, d)
+	d.StopAsyncReports()
+	if n := dispatches(l); n != 2 {
+		t.Fatalf("%d dispatches after stopping, want 2", n)
+	}
+	if refs := d.currentRoutingContext().GetRefs(); refs != 0 {
+		t.Fatalf("%d refs still held after stopping", refs)
+	}
+
+	// reports are synchronous again.
+	report(t, d)
+	if n := dispatches(l); n != 3 {
+		t.Fatalf("%d dispatches after a synchronous report, want 3", n)
+	}
+}
+
+func TestAsyncReports_FlushInterval(t *testing.T) {
+	l := &data.Logger{}
+	d := newReportDispatcher(l)
+
+	d.StartAsyncReports(AsyncReportOptions{
+		QueueSize:     10,
+		BatchSize:     10,
+		FlushInterval: 10 * time.Millisecond,
+		Overflow:      DropNewest,
+	})
+	defer d.StopAsyncReports()
+
+	report(t, d)
+
+	// the reference is released once the instance is dispatched.
+	routes := d.currentRoutingContext()
+	deadline := time.Now().Add(5 * time.Second)
+	for routes.GetRefs() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queued instance was not dispatched")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := dispatches(l); n != 1 {
+		t.Fatalf("%d dispatches after the flush interval, want 1", n)
+	}
+}
+
+func TestAsyncReports_RetiredRoutes(t *testing.T) {
+	l := &data.Logger{}
+	d := newReportDispatcher(l)
+
+	d.StartAsyncReports(AsyncReportOptions{
+		QueueSize:     10,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		Overflow:      DropNewest,
+	})
+	defer d.StopAsyncReports()
+
+	report(t, d)
+
+	// the old routes stay referenced until their queued instances are dispatched.
+	old := d.ChangeRoute(routing.Empty())
+	if refs := old.GetRefs(); refs != 1 {
+		t.Fatalf("%d refs held on the old routes, want 1", refs)
+	}
+
+	d.reports.mu.Lock()
+	for _, rq := range d.reports.queues {
+		d.reports.schedule(rq)
+	}
+	d.reports.mu.Unlock()
+
+	if refs := old.GetRefs(); refs != 0 {
+		t.Fatalf("%d refs held on the old routes after dispatch", refs)
+	}
+	if n := dispatches(l); n != 1 {
+		t.Fatalf("%d dispatches, want 1", n)
+	}
+}
@@ -51,6 +51,9 @@ type session struct {
 	// channel for collecting states of completed dispatches.
 	completed chan *dispatchState
 
+	// states of the completed dispatches, in dispatch order.
+	states []*dispatchState
+
 	// The variety of the operation that is being performed.
 	variety tpb.TemplateVariety
 
@@ -71,6 +74,22 @@ func (s *session) ensureParallelism(minParallelism int) {
 	}
 }
 
+// waitForDispatches waits for the active dispatches to complete, and collects their states in
+// dispatch order.
+func (s *session) waitForDispatches() {
+	if cap(s.states) < s.activeDispatches {
+		s.states = make([]*dispatchState, s.activeDispatches)
+	} else {
+		s.states = s.states[:s.activeDispatches]
+	}
+
+	for s.activeDispatches > 0 {
+		state := <-s.completed
+		s.activeDispatches--
+		s.states[state.index] = state
+	}
+}
+
 func (s *session) clear() {
 	s.variety = 0
 	s.ctx = nil
@@ -85,6 +104,11 @@ func (s *session) clear() {
 	s.quotaResult = nil
 	s.checkResult = nil
 
+	for i := range s.states {
+		s.states[i] = nil
+	}
+	s.states = s.states[:0]
+
 	// Drain the channel
 	exit := false
 	for !exit {
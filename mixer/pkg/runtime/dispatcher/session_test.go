@@ -136,3 +136,25 @@ func TestSession_EnsureParallelism(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSession_WaitForDispatches(t *testing.T) {
+	s := &session{
+		activeDispatches: 3,
+		completed:        make(chan *dispatchState, 10),
+	}
+
+	// the dispatches complete in reverse order
+	for i := 2; i >= 0; i-- {
+		s.completed <- &dispatchState{index: i}
+	}
+	s.waitForDispatches()
+
+	if s.activeDispatches != 0 || len(s.states) != 3 {
+		t.Fatalf("%d active dispatches, %d states", s.activeDispatches, len(s.states))
+	}
+	for i, state := range s.states {
+		if state.index != i {
+			t.Fatalf("state %d has index %d", i, state.index)
+		}
+	}
+}
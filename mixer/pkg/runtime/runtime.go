@@ -110,6 +110,18 @@ func (c *Runtime) Dispatcher() dispatcher.Dispatcher {
 	return c.dispatcher
 }
 
+// StartAsyncReports directs Runtime to acknowledge reports once their instances are queued for
+// the handlers, and to dispatch them in batches. This must be called before serving requests.
+func (c *Runtime) StartAsyncReports(o dispatcher.AsyncReportOptions) {
+	c.dispatcher.StartAsyncReports(o)
+}
+
+// StopAsyncReports directs Runtime to dispatch the queued reports, and to dispatch reports
+// synchronously from then on. This must be called once requests are no longer served.
+func (c *Runtime) StopAsyncReports() {
+	c.dispatcher.StopAsyncReports()
+}
+
 // StartListening directs Runtime to start listening to configuration changes. As config changes, runtime processes
 // the confguration and creates a dispatcher.
 func (c *Runtime) StartListening() error {
@@ -17,10 +17,12 @@ package server
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/mixer/pkg/runtime"
+	"istio.io/istio/mixer/pkg/runtime/dispatcher"
 	"istio.io/istio/mixer/pkg/template"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/probe"
@@ -94,6 +96,40 @@ type Args struct {
 
 	// If true, each request to Mixer will be executed in a single go routine (useful for debugging)
 	SingleThreaded bool
+
+	// The certificate and private key files used to serve Mixer's gRPC API over TLS.
+	// If both are empty, the API is served in plaintext.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// The CA certificates file used to verify client certificates. If empty, client
+	// certificates are not requested.
+	TLSClientCAFile string
+
+	// If true, clients that do not present a certificate signed by TLSClientCAFile are rejected.
+	// Otherwise, client certificates are only verified when presented.
+	TLSRequireClientCert bool
+
+	// How often the TLS files are checked for changes, so that rotated certificates are picked
+	// up without restarting Mixer.
+	TLSReloadInterval time.Duration
+
+	// If true, reports are acknowledged once their instances are queued for the handlers, and
+	// the instances are dispatched to the handlers in batches.
+	AsyncReports bool
+
+	// Maximum number of report instances queued for each handler
+	ReportQueueSize int
+
+	// Maximum number of report instances dispatched to a handler at once
+	ReportBatchSize int
+
+	// Maximum amount of time report instances are queued before being dispatched
+	ReportFlushInterval time.Duration
+
+	// What happens to report instances when the queue of their handler is full: drop-newest,
+	// drop-oldest or block
+	ReportOverflowPolicy string
 }
 
 // DefaultArgs allocates an Args struct initialized with Mixer's default configuration.
@@ -113,6 +149,11 @@ func DefaultArgs() *Args {
 		LivenessProbeOptions:          &probe.Options{},
 		ReadinessProbeOptions:         &probe.Options{},
 		EnableProfiling:               true,
+		TLSReloadInterval:             time.Minute,
+		ReportQueueSize:               10000,
+		ReportBatchSize:               100,
+		ReportFlushInterval:           time.Second,
+		ReportOverflowPolicy:          dispatcher.DropNewest.String(),
 	}
 }
 
@@ -125,6 +166,40 @@ func (a *Args) validate() error {
 		return fmt.Errorf("adapter worker pool size must be >= 0 and <= 2^31-1, got pool size %d", a.AdapterWorkerPoolSize)
 	}
 
+	if (a.TLSCertFile == "") != (a.TLSKeyFile == "") {
+		return fmt.Errorf("the TLS certificate and key files must be specified together")
+	}
+
+	if a.TLSClientCAFile != "" && a.TLSCertFile == "" {
+		return fmt.Errorf("a TLS client CA file requires the TLS certificate and key files")
+	}
+
+	if a.TLSRequireClientCert && a.TLSClientCAFile == "" {
+		return fmt.Errorf("requiring client certificates needs a TLS client CA file")
+	}
+
+	if a.TLSCertFile != "" && a.TLSReloadInterval <= 0 {
+		return fmt.Errorf("TLS reload interval must be > 0, got %v", a.TLSReloadInterval)
+	}
+
+	if a.AsyncReports {
+		if a.ReportBatchSize <= 0 {
+			return fmt.Errorf("report batch size must be > 0, got %d", a.ReportBatchSize)
+		}
+
+		if a.ReportQueueSize < a.ReportBatchSize {
+			return fmt.Errorf("report queue size must be >= the report batch size %d, got %d", a.ReportBatchSize, a.ReportQueueSize)
+		}
+
+		if a.ReportFlushInterval <= 0 {
+			return fmt.Errorf("report flush interval must be > 0, got %v", a.ReportFlushInterval)
+		}
+
+		if _, err := dispatcher.ParseOverflowPolicy(a.ReportOverflowPolicy); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -144,6 +219,16 @@ func (a *Args) String() string {
 	fmt.Fprint(buf, "ConfigDefaultNamespace: ", a.ConfigDefaultNamespace, "\n")
 	fmt.Fprint(buf, "ConfigIdentityAttribute: ", a.ConfigIdentityAttribute, "\n")
 	fmt.Fprint(buf, "ConfigIdentityAttributeDomain: ", a.ConfigIdentityAttributeDomain, "\n")
+	fmt.Fprint(buf, "TLSCertFile: ", a.TLSCertFile, "\n")
+	fmt.Fprint(buf, "TLSKeyFile: ", a.TLSKeyFile, "\n")
+	fmt.Fprint(buf, "TLSClientCAFile: ", a.TLSClientCAFile, "\n")
+	fmt.Fprint(buf, "TLSRequireClientCert: ", a.TLSRequireClientCert, "\n")
+	fmt.Fprint(buf, "TLSReloadInterval: ", a.TLSReloadInterval, "\n")
+	fmt.Fprint(buf, "AsyncReports: ", a.AsyncReports, "\n")
+	fmt.Fprint(buf, "ReportQueueSize: ", a.ReportQueueSize, "\n")
+	fmt.Fprint(buf, "ReportBatchSize: ", a.ReportBatchSize, "\n")
+	fmt.Fprint(buf, "ReportFlushInterval: ", a.ReportFlushInterval, "\n")
+	fmt.Fprint(buf, "ReportOverflowPolicy: ", a.ReportOverflowPolicy, "\n")
 	fmt.Fprintf(buf, "LoggingOptions: %#v\n", *a.LoggingOptions)
 	fmt.Fprintf(buf, "TracingOptions: %#v\n", *a.TracingOptions)
 
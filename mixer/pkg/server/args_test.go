@@ -16,6 +16,7 @@ package server
 
 import (
 	"testing"
+	"time"
 )
 
 func TestValidation(t *testing.T) {
@@ -35,6 +36,57 @@ func TestValidation(t *testing.T) {
 	if err := a.validate(); err == nil {
 		t.Errorf("Got unexpected success")
 	}
+
+	for _, c := range []struct {
+		cert, key, ca  string
+		requireCert    bool
+		reloadInterval time.Duration
+		valid          bool
+	}{
+		{"cert.pem", "key.pem", "", false, time.Minute, true},
+		{"cert.pem", "key.pem", "ca.pem", true, time.Minute, true},
+		{"cert.pem", "", "", false, time.Minute, false},
+		{"", "key.pem", "", false, time.Minute, false},
+		{"", "", "ca.pem", false, time.Minute, false},
+		{"cert.pem", "key.pem", "", true, time.Minute, false},
+		{"cert.pem", "key.pem", "", false, 0, false},
+	} {
+		a = DefaultArgs()
+		a.TLSCertFile = c.cert
+		a.TLSKeyFile = c.key
+		a.TLSClientCAFile = c.ca
+		a.TLSRequireClientCert = c.requireCert
+		a.TLSReloadInterval = c.reloadInterval
+		if err := a.validate(); (err == nil) != c.valid {
+			t.Errorf("validate(%+v) returned %v, want valid: %v", c, err, c.valid)
+		}
+	}
+
+	for _, c := range []struct {
+		async            bool
+		queueSize, batch int
+		flushInterval    time.Duration
+		overflow         string
+		valid            bool
+	}{
+		{true, 100, 10, time.Second, "block", true},
+		{true, 10, 10, time.Second, "drop-oldest", true},
+		{false, 0, 0, 0, "", true},
+		{true, 100, 0, time.Second, "drop-newest", false},
+		{true, 10, 100, time.Second, "drop-newest", false},
+		{true, 100, 10, 0, "drop-newest", false},
+		{true, 100, 10, time.Second, "drop-all", false},
+	} {
+		a = DefaultArgs()
+		a.AsyncReports = c.async
+		a.ReportQueueSize = c.queueSize
+		a.ReportBatchSize = c.batch
+		a.ReportFlushInterval = c.flushInterval
+		a.ReportOverflowPolicy = c.overflow
+		if err := a.validate(); (err == nil) != c.valid {
+			t.Errorf("validate(%+v) returned %v, want valid: %v", c, err, c.valid)
+		}
+	}
 }
 
 func TestString(t *testing.T) {
@@ -24,6 +24,7 @@ import (
 	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
 	ot "github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	mixerpb "istio.io/api/mixer/v1"
 	"istio.io/istio/mixer/pkg/adapter"
@@ -48,9 +49,13 @@ type Server struct {
 	listener  net.Listener
 	monitor   *monitor
 	tracer    io.Closer
+	certs     *certReloader
 
 	dispatcher dispatcher.Dispatcher
 
+	// runtime that dispatches reports asynchronously, if enabled.
+	asyncReports *runtime.Runtime
+
 	// probes
 	livenessProbe  probe.Controller
 	readinessProbe probe.Controller
@@ -120,6 +125,14 @@ func newServer(a *Args, p *patchTable) (*Server, error) {
 	var interceptors []grpc.UnaryServerInterceptor
 	var err error
 
+	if a.TLSCertFile != "" {
+		if s.certs, err = newCertReloader(a); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("unable to setup TLS: %v", err)
+		}
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(s.certs.serverConfig())))
+	}
+
 	if a.TracingOptions.TracingEnabled() {
 		s.tracer, err = p.configTracing("istio-mixer", a.TracingOptions)
 		if err != nil {
@@ -180,6 +193,17 @@ func newServer(a *Args, p *patchTable) (*Server, error) {
 	}
 	s.dispatcher = rt.Dispatcher()
 
+	if a.AsyncReports {
+		policy, _ := dispatcher.ParseOverflowPolicy(a.ReportOverflowPolicy)
+		rt.StartAsyncReports(dispatcher.AsyncReportOptions{
+			QueueSize:     a.ReportQueueSize,
+			BatchSize:     a.ReportBatchSize,
+			FlushInterval: a.ReportFlushInterval,
+			Overflow:      policy,
+		})
+		s.asyncReports = rt
+	}
+
 	// get the grpc server wired up
 	grpc.EnableTracing = a.EnableGRPCTracing
 	s.server = grpc.NewServer(grpcOptions...)
@@ -232,6 +256,11 @@ func (s *Server) Close() error {
 		_ = s.Wait()
 	}
 
+	if s.asyncReports != nil {
+		// send the reports that were acknowledged but not dispatched yet
+		s.asyncReports.StopAsyncReports()
+	}
+
 	if s.listener != nil {
 		_ = s.listener.Close()
 	}
@@ -244,6 +273,10 @@ func (s *Server) Close() error {
 		_ = s.monitor.Close()
 	}
 
+	if s.certs != nil {
+		_ = s.certs.Close()
+	}
+
 	if s.gp != nil {
 		_ = s.gp.Close()
 	}
@@ -0,0 +1,179 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/log"
+)
+
+// certReloader provides the TLS configuration of the API server from the certificate,
+// key and client CA files, and reloads them when they change on disk so that rotated
+// certificates are used for new connections without restarting Mixer.
+type certReloader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+
+	mu       sync.RWMutex
+	config   *tls.Config
+	modTimes []time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newCertReloader(a *Args) (*certReloader, error) {
+	r := &certReloader{
+		certFile:   a.TLSCertFile,
+		keyFile:    a.TLSKeyFile,
+		caFile:     a.TLSClientCAFile,
+		clientAuth: tls.NoClientCert,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if r.caFile != "" {
+		r.clientAuth = tls.VerifyClientCertIfGiven
+		if a.TLSRequireClientCert {
+			r.clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	go r.watch(a.TLSReloadInterval)
+	return r, nil
+}
+
+// serverConfig returns the TLS configuration to hand to the gRPC server. It defers to
+// the latest loaded configuration for every handshake.
+func (r *certReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.config, nil
+		},
+	}
+}
+
+func (r *certReloader) files() []string {
+	if r.caFile == "" {
+		return []string{r.certFile, r.keyFile}
+	}
+	return []string{r.certFile, r.keyFile, r.caFile}
+}
+
+// changed reports whether any of the files has been modified since the last load.
+func (r *certReloader) changed() bool {
+	for i, f := range r.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			// the files are being replaced, try again later
+			return false
+		}
+		if !fi.ModTime().Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *certReloader) reload() error {
+	files := r.files()
+	modTimes := make([]time.Time, len(files))
+	for i, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("unable to access TLS file: %v", err)
+		}
+		modTimes[i] = fi.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load TLS certificate: %v", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2"},
+	}
+
+	if r.caFile != "" {
+		pem, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("unable to read TLS client CA file: %v", err)
+		}
+
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificates found in TLS client CA file %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.config = config
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *certReloader) watch(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+
+			// keep serving with the previous certificates if the new ones are unusable
+			if err := r.reload(); err != nil {
+				log.Errorf("Unable to reload the API server TLS certificates: %v", err)
+				continue
+			}
+			log.Infof("Reloaded the API server TLS certificates from %s", r.certFile)
+
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Close stops watching the files for changes.
+func (r *certReloader) Close() error {
+	close(r.stop)
+	<-r.done
+	return nil
+}
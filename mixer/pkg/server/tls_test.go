@@ -0,0 +1,279 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	mixerpb "istio.io/api/mixer/v1"
+	"istio.io/istio/mixer/pkg/config/storetest"
+	generatedTmplRepo "istio.io/istio/mixer/template"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// testPKI is a CA with the files of a server and a client certificate it signed.
+type testPKI struct {
+	dir string
+
+	caCert *x509.Certificate
+	caKey  crypto.PrivateKey
+
+	caFile, serverCertFile, serverKeyFile, clientCertFile, clientKeyFile string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "mixer-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &testPKI{
+		dir:            dir,
+		caFile:         filepath.Join(dir, "ca.pem"),
+		serverCertFile: filepath.Join(dir, "server-cert.pem"),
+		serverKeyFile:  filepath.Join(dir, "server-key.pem"),
+		clientCertFile: filepath.Join(dir, "client-cert.pem"),
+		clientKeyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	caPEM, caKeyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "istio.io",
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.caCert, err = util.ParsePemEncodedCertificate(caPEM); err != nil {
+		t.Fatal(err)
+	}
+	if p.caKey, err = util.ParsePemEncodedKey(caKeyPEM); err != nil {
+		t.Fatal(err)
+	}
+	p.write(t, p.caFile, caPEM)
+
+	p.issue(t, p.serverCertFile, p.serverKeyFile, "localhost", false)
+	p.issue(t, p.clientCertFile, p.clientKeyFile, "spiffe://cluster.local/ns/istio-system/sa/istio-ingress", true)
+
+	return p
+}
+
+// issue writes a new certificate for host, signed by the CA, and its key.
+func (p *testPKI) issue(t *testing.T, certFile, keyFile, host string, client bool) {
+	t.Helper()
+
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:       host,
+		Org:        "istio.io",
+		TTL:        time.Hour,
+		SignerCert: p.caCert,
+		SignerPriv: p.caKey,
+		IsClient:   client,
+		IsServer:   !client,
+		RSAKeySize: 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.write(t, certFile, certPEM)
+	p.write(t, keyFile, keyPEM)
+}
+
+func (p *testPKI) write(t *testing.T, file string, data []byte) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// make the change visible even on file systems with a coarse modification time
+	if fi, err := os.Stat(file); err == nil {
+		mtime := fi.ModTime().Add(time.Second)
+		_ = os.Chtimes(file, mtime, mtime)
+	}
+}
+
+func (p *testPKI) clientConfig(t *testing.T, withCert bool) *tls.Config {
+	t.Helper()
+
+	config := &tls.Config{
+		RootCAs:    x509.NewCertPool(),
+		ServerName: "localhost",
+	}
+	config.RootCAs.AddCert(p.caCert)
+
+	if withCert {
+		cert, err := tls.LoadX509KeyPair(p.clientCertFile, p.clientKeyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config
+}
+
+func (p *testPKI) cleanup() {
+	_ = os.RemoveAll(p.dir)
+}
+
+func servedCert(t *testing.T, r *certReloader) []byte {
+	t.Helper()
+
+	config, err := r.serverConfig().GetConfigForClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Certificates[0].Certificate[0]
+}
+
+func TestCertReloader(t *testing.T) {
+	p := newTestPKI(t)
+	defer p.cleanup()
+
+	a := DefaultArgs()
+	a.TLSCertFile = p.serverCertFile
+	a.TLSKeyFile = p.serverKeyFile
+	a.TLSClientCAFile = p.caFile
+	a.TLSReloadInterval = 10 * time.Millisecond
+
+	r, err := newCertReloader(a)
+	if err != nil {
+		t.Fatalf("newCertReloader() failed: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	config, _ := r.serverConfig().GetConfigForClient(nil)
+	if config.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Errorf("got client auth %v, want %v", config.ClientAuth, tls.VerifyClientCertIfGiven)
+	}
+
+	old := servedCert(t, r)
+
+	// a broken certificate is ignored
+	p.write(t, p.serverCertFile, []byte("not a certificate"))
+	time.Sleep(100 * time.Millisecond)
+	if !bytes.Equal(servedCert(t, r), old) {
+		t.Fatal("the certificate changed after an invalid one was written")
+	}
+
+	// a rotated certificate is picked up
+	p.issue(t, p.serverCertFile, p.serverKeyFile, "localhost", false)
+	deadline := time.Now().Add(5 * time.Second)
+	for bytes.Equal(servedCert(t, r), old) {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated certificate was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCertReloaderErrors(t *testing.T) {
+	p := newTestPKI(t)
+	defer p.cleanup()
+
+	for _, c := range []struct {
+		name                  string
+		certFile, keyFile, ca string
+	}{
+		{"missing cert", filepath.Join(p.dir, "missing.pem"), p.serverKeyFile, ""},
+		{"mismatched key", p.serverCertFile, p.clientKeyFile, ""},
+		{"missing ca", p.serverCertFile, p.serverKeyFile, filepath.Join(p.dir, "missing.pem")},
+		{"invalid ca", p.serverCertFile, p.serverKeyFile, p.serverKeyFile},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			a := DefaultArgs()
+			a.TLSCertFile = c.certFile
+			a.TLSKeyFile = c.keyFile
+			a.TLSClientCAFile = c.ca
+
+			if r, err := newCertReloader(a); err == nil {
+				_ = r.Close()
+				t.Error("newCertReloader() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	p := newTestPKI(t)
+	defer p.cleanup()
+
+	a := DefaultArgs()
+	a.APIPort = 0
+	a.MonitoringPort = 0
+	a.LoggingOptions.LogGrpc = false // Avoid introducing a race to the server tests.
+	a.Templates = generatedTmplRepo.SupportedTmplInfo
+	a.TLSCertFile = p.serverCertFile
+	a.TLSKeyFile = p.serverKeyFile
+	a.TLSClientCAFile = p.caFile
+	a.TLSRequireClientCert = true
+
+	var err error
+	if a.ConfigStore, err = storetest.SetupStoreForTest(globalCfg, serviceCfg); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(a)
+	if err != nil {
+		t.Fatalf("Unable to create server: %v", err)
+	}
+	s.Run()
+	defer func() { _ = s.Close() }()
+
+	for _, c := range []struct {
+		name    string
+		opt     grpc.DialOption
+		succeed bool
+	}{
+		{"plaintext", grpc.WithInsecure(), false},
+		{"no client cert", grpc.WithTransportCredentials(credentials.NewTLS(p.clientConfig(t, false))), false},
+		{"client cert", grpc.WithTransportCredentials(credentials.NewTLS(p.clientConfig(t, true))), true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			conn, err := grpc.Dial(s.Addr().String(), c.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = conn.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err = mixerpb.NewMixerClient(conn).Report(ctx, &mixerpb.ReportRequest{})
+			if c.succeed && err != nil {
+				t.Errorf("Got error during Report: %v", err)
+			} else if !c.succeed && err == nil {
+				t.Error("Got success, expecting failure")
+			}
+		})
+	}
+}
@@ -44,6 +44,7 @@ import (
 	configmonitor "istio.io/istio/pilot/pkg/config/monitor"
 	"istio.io/istio/pilot/pkg/kube/admit"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/v1alpha3"
 	envoy "istio.io/istio/pilot/pkg/proxy/envoy/v1"
 	"istio.io/istio/pilot/pkg/proxy/envoy/v1/mock"
 	envoyv2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
@@ -644,6 +645,7 @@ func (s *Server) initServiceControllers(args *PilotArgs) error {
 				ServiceDiscovery: &cloudfoundry.ServiceDiscovery{
 					Client:      client,
 					ServicePort: cfConfig.ServicePort,
+					Locality:    model.Locality{Region: cfConfig.Region, Zone: cfConfig.Zone},
 				},
 				ServiceAccounts: cloudfoundry.NewServiceAccounts(),
 			})
@@ -730,7 +732,16 @@ func (s *Server) initDiscoveryService(args *PilotArgs) error {
 	// For now we create the gRPC server sourcing data from Pilot's older data model.
 	s.initGrpcServer()
 	envoy.V2ClearCache = envoyv2.PushAll
+	if args.RDSv2 {
+		log.Info("xDS: enabling RDS")
+		v1alpha3.EnableRDSv2 = true
+	}
 	s.EnvoyXdsServer = envoyv2.NewDiscoveryServer(s.GRPCServer, environment)
+	// Instance events result in incremental EDS pushes, instead of a full push.
+	if err := s.EnvoyXdsServer.RegisterInstanceHandler(s.ServiceController); err != nil {
+		return fmt.Errorf("failed to register EDS instance handler: %v", err)
+	}
+	envoy.V2IncrementalEDS = true
 
 	s.EnvoyXdsServer.InitDebug(s.mux, s.ServiceController)
 
@@ -774,13 +785,6 @@ func (s *Server) initDiscoveryService(args *PilotArgs) error {
 			s.EnvoyXdsServer.GrpcServer.Stop()
 		}()
 
-		if args.RDSv2 {
-			log.Info("xDS: enabling RDS")
-			cache := envoyv2.NewConfigCache(s.ServiceController, s.configController)
-			cache.Register(s.GRPCServer)
-			cache.RegisterInput(s.ServiceController, s.configController)
-		}
-
 		return err
 	})
 
@@ -255,9 +255,64 @@ type ServiceInstance struct {
 	Service          *Service        `json:"service,omitempty"`
 	Labels           Labels          `json:"labels,omitempty"`
 	AvailabilityZone string          `json:"az,omitempty"`
+	Locality         Locality        `json:"locality,omitempty"`
 	ServiceAccount   string          `json:"serviceaccount,omitempty"`
 }
 
+// Locality is the failure domain of a service instance, as reported by the platform.
+// Endpoints in the same locality as the proxy are preferred, and the proxy fails over to
+// endpoints in other zones, then other regions, when they are unhealthy.
+//
+// For example, in kubernetes the region and zone are the labels of the node running the pod.
+type Locality struct {
+	// Region is the largest failure domain, for example a cloud region.
+	Region string `json:"region,omitempty"`
+
+	// Zone is a failure domain within a region, for example an availability zone.
+	Zone string `json:"zone,omitempty"`
+
+	// SubZone is a failure domain within a zone, for example a rack.
+	SubZone string `json:"subzone,omitempty"`
+}
+
+// IsEmpty returns true if no part of the locality is known.
+func (l Locality) IsEmpty() bool {
+	return l.Region == "" && l.Zone == "" && l.SubZone == ""
+}
+
+// String returns the locality in the "region/zone/subzone" format.
+func (l Locality) String() string {
+	return l.Region + "/" + l.Zone + "/" + l.SubZone
+}
+
+// ParseLocality parses a locality in the "region/zone/subzone" format. Missing trailing
+// parts are left empty.
+func ParseLocality(s string) Locality {
+	parts := strings.SplitN(s, "/", 3)
+	l := Locality{Region: parts[0]}
+	if len(parts) > 1 {
+		l.Zone = parts[1]
+	}
+	if len(parts) > 2 {
+		l.SubZone = parts[2]
+	}
+	return l
+}
+
+// MatchLevel returns the number of leading parts of the locality, from region to subzone,
+// that are the same as in the other locality.
+func (l Locality) MatchLevel(other Locality) int {
+	switch {
+	case l.Region != other.Region:
+		return 0
+	case l.Zone != other.Zone:
+		return 1
+	case l.SubZone != other.SubZone:
+		return 2
+	}
+	return 3
+}
+
 // ServiceDiscovery enumerates Istio service instances.
 type ServiceDiscovery interface {
 	// Services list declarations of all services in the system
@@ -418,6 +473,12 @@ func (s *Service) External() bool {
 	return s.ExternalName != ""
 }
 
+// WildcardHost predicate checks whether the service hostname is a wildcard, such as
+// *.googleapis.com. Wildcard hosts can only be declared by external services.
+func (s *Service) WildcardHost() bool {
+	return strings.HasPrefix(s.Hostname, "*")
+}
+
 // Key generates a unique string referencing service instances for a given port and labels.
 // The separator character must be exclusive to the regular expressions allowed in the
 // service declaration.
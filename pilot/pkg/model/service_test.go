@@ -208,3 +208,36 @@ func TestConvertCaseInsensitiveStringToProtocol(t *testing.T) {
 		}
 	}
 }
+
+func TestLocality(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Locality
+	}{
+		{"", Locality{}},
+		{"us-east1", Locality{Region: "us-east1"}},
+		{"us-east1/us-east1-b", Locality{Region: "us-east1", Zone: "us-east1-b"}},
+		{"us-east1/us-east1-b/rack1", Locality{Region: "us-east1", Zone: "us-east1-b", SubZone: "rack1"}},
+	}
+	for _, c := range cases {
+		if got := ParseLocality(c.in); got != c.want {
+			t.Errorf("ParseLocality(%q) => Got %#v, expected %#v", c.in, got, c.want)
+		}
+	}
+	if !ParseLocality("").IsEmpty() {
+		t.Error("Expecting an empty locality")
+	}
+
+	local := Locality{Region: "r1", Zone: "z1", SubZone: "s1"}
+	levels := map[Locality]int{
+		{Region: "r2", Zone: "z1", SubZone: "s1"}: 0,
+		{Region: "r1", Zone: "z2", SubZone: "s1"}: 1,
+		{Region: "r1", Zone: "z1", SubZone: "s2"}: 2,
+		local: 3,
+	}
+	for l, want := range levels {
+		if got := local.MatchLevel(l); got != want {
+			t.Errorf("MatchLevel(%v) => Got %d, expected %d", l, got, want)
+		}
+	}
+}
@@ -15,6 +15,8 @@
 package networking
 
 import (
+	"sync"
+
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -56,13 +58,14 @@ type PluginCallbacks struct {
 	// Not used typically
 	OnInboundCluster func(env model.Environment, node model.Proxy, service *model.Service, cluster *v2.Cluster) *v2.Cluster
 
-	// OnOutboundHttpRoute is called whenever a new set of virtual hosts (a set of virtual hosts with routes) is added to
-	// RDS in the outbound path. Can be used to add route specific metadata or additional headers to forward
-	OnOutboundRoute func(env model.Environment, node model.Proxy, service model.Service, route *v2.RouteConfiguration) *v2.RouteConfiguration
+	// OnOutboundRoute is called whenever a new set of virtual hosts (a set of virtual hosts with routes) is added to
+	// RDS in the outbound path. Can be used to add route specific metadata or additional headers to forward.
+	// The service is nil since outbound route configurations span several services.
+	OnOutboundRoute func(env model.Environment, node model.Proxy, service *model.Service, route *v2.RouteConfiguration) *v2.RouteConfiguration
 
 	// OnInboundRoute is called whenever a new set of virtual hosts are added to the inbound path.
 	// Can be used to enable route specific stuff like Lua filters or other metadata.
-	OnInboundRoute func(env model.Environment, node model.Proxy, service model.Service, route *v2.RouteConfiguration) *v2.RouteConfiguration
+	OnInboundRoute func(env model.Environment, node model.Proxy, service *model.Service, route *v2.RouteConfiguration) *v2.RouteConfiguration
 }
 
 // NewDataplane creates a new instance of dataplane configuration generator
@@ -72,11 +75,26 @@ func NewDataplane() *Networking {
 	return nil
 }
 
+var (
+	pluginsMutex sync.RWMutex
+	plugins      []*PluginCallbacks
+)
+
+// RegisterPlugin adds a plugin to the chain called by the config generators. Plugins are
+// called in registration order, each one receiving the output of the previous one. Callbacks
+// that are not set are skipped, and a callback returning nil leaves its input unchanged.
+// Typically called from the init() function of the package implementing the plugin.
+func RegisterPlugin(plugin *PluginCallbacks) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	plugins = append(plugins, plugin)
+}
+
 // NewPlugins returns a list of plugin instance handles. Each plugin implements the PluginCallbacks interfaces
 func NewPlugins() []*PluginCallbacks {
-	plugins := make([]*PluginCallbacks, 0)
-	// plugins = append(plugins, NewAuthNPlugin())
-	// plugins = append(plugins, NewMixerPlugin())
-	// plugins = append(plugins, NewAPImPlugin())
-	return plugins
+	pluginsMutex.RLock()
+	defer pluginsMutex.RUnlock()
+	out := make([]*PluginCallbacks, len(plugins))
+	copy(out, plugins)
+	return out
 }
@@ -0,0 +1,161 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package authn implements the authentication plugin, which sets up mTLS on the inbound
+// listeners and outbound clusters of services requiring it.
+package authn
+
+import (
+	"path"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pkg/log"
+)
+
+// NewPlugin returns the authentication plugin.
+func NewPlugin() *networking.PluginCallbacks {
+	return &networking.PluginCallbacks{
+		OnInboundListener: onInboundListener,
+		OnOutboundCluster: onOutboundCluster,
+	}
+}
+
+// onInboundListener requires client certificates on the listener if the authentication
+// policy of the service port requires mTLS.
+func onInboundListener(env model.Environment, node model.Proxy, service *model.Service,
+	listener *xdsapi.Listener) *xdsapi.Listener {
+	if service == nil || listener == nil {
+		return listener
+	}
+	port := inboundServicePort(env, node, service, listener)
+	if port == nil {
+		return listener
+	}
+	policy := model.GetConsolidateAuthenticationPolicy(env.Mesh, env.IstioConfigStore, service.Hostname, port)
+	if !model.RequireTLS(policy) {
+		return listener
+	}
+	for i := range listener.FilterChains {
+		listener.FilterChains[i].TlsContext = buildListenerTLSContext(model.AuthCertsPath)
+	}
+	return listener
+}
+
+// onOutboundCluster sets up mTLS to the destination service, unless the cluster forwards
+// to the original destination, the service is external to the mesh or excluded from mTLS,
+// or a destination rule already configured the TLS settings of the cluster.
+func onOutboundCluster(env model.Environment, node model.Proxy, service *model.Service,
+	cluster *xdsapi.Cluster) *xdsapi.Cluster {
+	if service == nil || cluster == nil || service.MeshExternal {
+		return cluster
+	}
+	if cluster.Type == xdsapi.Cluster_ORIGINAL_DST || cluster.TlsContext != nil {
+		return cluster
+	}
+	if isExcludedForMTLS(service.Hostname, env.Mesh.MtlsExcludedServices) {
+		return cluster
+	}
+	_, _, _, clusterPort := model.ParseSubsetKey(cluster.Name)
+	port, found := service.Ports.Get(clusterPort.Name)
+	if !found {
+		log.Debugf("authn: no port %q in service %s for cluster %s", clusterPort.Name, service.Hostname, cluster.Name)
+		return cluster
+	}
+	policy := model.GetConsolidateAuthenticationPolicy(env.Mesh, env.IstioConfigStore, service.Hostname, port)
+	if !model.RequireTLS(policy) {
+		return cluster
+	}
+	serviceAccounts := env.GetIstioServiceAccounts(service.Hostname, []string{port.Name})
+	cluster.TlsContext = BuildClusterTLSContext(model.AuthCertsPath, serviceAccounts)
+	return cluster
+}
+
+// inboundServicePort returns the service port of the proxy instance served by the listener.
+func inboundServicePort(env model.Environment, node model.Proxy, service *model.Service,
+	listener *xdsapi.Listener) *model.Port {
+	listenPort := int(listener.Address.GetSocketAddress().GetPortValue())
+	instances, err := env.GetProxyServiceInstances(node)
+	if err != nil {
+		log.Warnf("authn: failed to get service instances of proxy %s: %v", node.ID, err)
+		return nil
+	}
+	for _, instance := range instances {
+		if instance.Service.Hostname == service.Hostname && instance.Endpoint.Port == listenPort {
+			return instance.Endpoint.ServicePort
+		}
+	}
+	return nil
+}
+
+// isExcludedForMTLS returns true if the service is listed in the mesh mTLS exclusions.
+func isExcludedForMTLS(hostname string, mtlsExcludedServices []string) bool {
+	for _, excluded := range mtlsExcludedServices {
+		if hostname == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// buildListenerTLSContext returns a TLS context requiring client certificates signed by the
+// Istio CA.
+func buildListenerTLSContext(certsDir string) *auth.DownstreamTlsContext {
+	return &auth.DownstreamTlsContext{
+		CommonTlsContext: buildCommonTLSContext(certsDir, nil),
+		RequireClientCertificate: &types.BoolValue{
+			Value: true,
+		},
+	}
+}
+
+// BuildClusterTLSContext returns a TLS context verifying the service accounts of the
+// destination. The list of service accounts may be empty.
+func BuildClusterTLSContext(certsDir string, serviceAccounts []string) *auth.UpstreamTlsContext {
+	return &auth.UpstreamTlsContext{
+		CommonTlsContext: buildCommonTLSContext(certsDir, serviceAccounts),
+	}
+}
+
+func buildCommonTLSContext(certsDir string, serviceAccounts []string) *auth.CommonTlsContext {
+	return &auth.CommonTlsContext{
+		TlsCertificates: []*auth.TlsCertificate{
+			{
+				CertificateChain: &core.DataSource{
+					Specifier: &core.DataSource_Filename{
+						Filename: path.Join(certsDir, model.CertChainFilename),
+					},
+				},
+				PrivateKey: &core.DataSource{
+					Specifier: &core.DataSource_Filename{
+						Filename: path.Join(certsDir, model.KeyFilename),
+					},
+				},
+			},
+		},
+		ValidationContext: &auth.CertificateValidationContext{
+			TrustedCa: &core.DataSource{
+				Specifier: &core.DataSource_Filename{
+					Filename: path.Join(certsDir, model.RootCertFilename),
+				},
+			},
+			VerifySubjectAltName: serviceAccounts,
+		},
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authn
+
+import (
+	"reflect"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/proxy/envoy/v1/mock"
+)
+
+func buildEnv(authPolicy meshconfig.MeshConfig_AuthPolicy, excluded ...string) model.Environment {
+	mesh := model.DefaultMeshConfig()
+	mesh.AuthPolicy = authPolicy
+	mesh.MtlsExcludedServices = excluded
+	return model.Environment{
+		ServiceDiscovery: mock.Discovery,
+		ServiceAccounts:  mock.Discovery,
+		IstioConfigStore: model.MakeIstioStore(memory.Make(model.IstioConfigTypes)),
+		Mesh:             &mesh,
+	}
+}
+
+func buildListener(port int) *xdsapi.Listener {
+	return &xdsapi.Listener{
+		Address: core.Address{Address: &core.Address_SocketAddress{
+			SocketAddress: &core.SocketAddress{
+				Address:       mock.HelloInstanceV0,
+				PortSpecifier: &core.SocketAddress_PortValue{PortValue: uint32(port)},
+			},
+		}},
+		FilterChains: []listener.FilterChain{{}},
+	}
+}
+
+func TestOnOutboundCluster(t *testing.T) {
+	service := mock.WorldService
+	port := mock.GetPortHTTP(service)
+	clusterName := model.BuildSubsetKey(model.TrafficDirectionOutbound, "", service.Hostname, port)
+
+	cases := []struct {
+		name        string
+		env         model.Environment
+		clusterType xdsapi.Cluster_DiscoveryType
+		wantTLS     bool
+	}{
+		{"mtls", buildEnv(meshconfig.MeshConfig_MUTUAL_TLS), xdsapi.Cluster_EDS, true},
+		{"no auth", buildEnv(meshconfig.MeshConfig_NONE), xdsapi.Cluster_EDS, false},
+		{"original dst", buildEnv(meshconfig.MeshConfig_MUTUAL_TLS), xdsapi.Cluster_ORIGINAL_DST, false},
+		{"excluded", buildEnv(meshconfig.MeshConfig_MUTUAL_TLS, service.Hostname), xdsapi.Cluster_EDS, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := &xdsapi.Cluster{Name: clusterName, Type: c.clusterType}
+			out := onOutboundCluster(c.env, mock.HelloProxyV0, service, cluster)
+			if !c.wantTLS {
+				if out.TlsContext != nil {
+					t.Errorf("unexpected TLS context %v", out.TlsContext)
+				}
+				return
+			}
+			if out.TlsContext == nil {
+				t.Fatal("missing TLS context")
+			}
+			want := mock.Discovery.GetIstioServiceAccounts(service.Hostname, []string{port.Name})
+			got := out.TlsContext.CommonTlsContext.ValidationContext.VerifySubjectAltName
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got SANs %v, want %v", got, want)
+			}
+		})
+	}
+
+	// no mTLS to services external to the mesh
+	external := *service
+	external.MeshExternal = true
+	cluster := &xdsapi.Cluster{Name: clusterName, Type: xdsapi.Cluster_STRICT_DNS}
+	out := onOutboundCluster(buildEnv(meshconfig.MeshConfig_MUTUAL_TLS), mock.HelloProxyV0, &external, cluster)
+	if out.TlsContext != nil {
+		t.Errorf("unexpected TLS context for external service %v", out.TlsContext)
+	}
+}
+
+func TestOnInboundListener(t *testing.T) {
+	service := mock.HelloService
+	// the mock endpoint port of the http service port is the same as the service port
+	port := mock.GetPortHTTP(service).Port
+
+	l := onInboundListener(buildEnv(meshconfig.MeshConfig_MUTUAL_TLS), mock.HelloProxyV0, service, buildListener(port))
+	tls := l.FilterChains[0].TlsContext
+	if tls == nil {
+		t.Fatal("missing TLS context")
+	}
+	if tls.RequireClientCertificate == nil || !tls.RequireClientCertificate.Value {
+		t.Error("client certificate not required")
+	}
+
+	l = onInboundListener(buildEnv(meshconfig.MeshConfig_NONE), mock.HelloProxyV0, service, buildListener(port))
+	if l.FilterChains[0].TlsContext != nil {
+		t.Errorf("unexpected TLS context %v", l.FilterChains[0].TlsContext)
+	}
+
+	// listeners not matching a service instance of the proxy are left unchanged
+	l = onInboundListener(buildEnv(meshconfig.MeshConfig_MUTUAL_TLS), mock.HelloProxyV0, service, buildListener(12345))
+	if l.FilterChains[0].TlsContext != nil {
+		t.Errorf("unexpected TLS context %v", l.FilterChains[0].TlsContext)
+	}
+}
@@ -12,9 +12,228 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package mixer implements the mixer plugin, which adds the mixer filter to the sidecar
+// listeners. The filter reports the attributes of each request to mixer, and checks the
+// inbound requests against the mixer policies.
 package mixer
 
-// DoNothing holder file.
-func DoNothing() {
-	// do nothing.
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	v2_cluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/plugins/authn"
+	deprecated "istio.io/istio/pilot/pkg/proxy/envoy/v1"
+	"istio.io/istio/pkg/log"
+)
+
+// NewPlugin returns the mixer plugin.
+func NewPlugin() *networking.PluginCallbacks {
+	return &networking.PluginCallbacks{
+		OnInboundListener:  onInboundListener,
+		OnOutboundListener: onOutboundListener,
+	}
+}
+
+// enabled returns true if a mixer check or report server is configured in the mesh.
+func enabled(mesh *meshconfig.MeshConfig) bool {
+	return mesh.MixerCheckServer != "" || mesh.MixerReportServer != ""
+}
+
+// onInboundListener adds the server side mixer filter, which checks and reports the requests
+// or connections to the service. Policy checks are skipped if disabled in the mesh config.
+func onInboundListener(env model.Environment, node model.Proxy, service *model.Service,
+	l *xdsapi.Listener) *xdsapi.Listener {
+	if !enabled(env.Mesh) || service == nil || l == nil {
+		return l
+	}
+	instances, err := env.GetProxyServiceInstances(node)
+	if err != nil {
+		log.Warnf("mixer: failed to get service instances of proxy %s: %v", node.ID, err)
+		return l
+	}
+
+	for i := range l.FilterChains {
+		chain := &l.FilterChains[i]
+		for j, filter := range chain.Filters {
+			switch filter.Name {
+			case util.HTTPConnectionManager:
+				config := deprecated.BuildHTTPMixerFilterConfig(env.Mesh, node, instances, false, env.IstioConfigStore)
+				chain.Filters[j].Config = addHTTPFilter(filter.Config, config)
+			case util.TCPProxy:
+				instance := &model.ServiceInstance{Service: service}
+				config := deprecated.BuildTCPMixerFilterConfig(env.Mesh, node, instance)
+				mixerFilter := listener.Filter{
+					Name:   deprecated.MixerFilter,
+					Config: mixerConfigToStruct(config),
+				}
+				// The mixer filter must see the connection before tcp_proxy.
+				chain.Filters = append(chain.Filters[:j], append([]listener.Filter{mixerFilter}, chain.Filters[j:]...)...)
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return l
+}
+
+// onOutboundListener adds the client side mixer filter to HTTP listeners. The filter forwards
+// the source attributes to the destination and reports the requests, without policy checks.
+func onOutboundListener(env model.Environment, node model.Proxy, service *model.Service,
+	l *xdsapi.Listener) *xdsapi.Listener {
+	if !enabled(env.Mesh) || l == nil {
+		return l
+	}
+	instances, err := env.GetProxyServiceInstances(node)
+	if err != nil {
+		log.Warnf("mixer: failed to get service instances of proxy %s: %v", node.ID, err)
+		return l
+	}
+
+	for i := range l.FilterChains {
+		chain := &l.FilterChains[i]
+		for j, filter := range chain.Filters {
+			if filter.Name != util.HTTPConnectionManager {
+				continue
+			}
+			config := deprecated.BuildHTTPMixerFilterConfig(env.Mesh, node, instances, true, env.IstioConfigStore)
+			chain.Filters[j].Config = addHTTPFilter(filter.Config, config)
+		}
+	}
+	return l
+}
+
+// addHTTPFilter inserts the mixer filter at the head of the HTTP filters of an encoded
+// http_connection_manager config, and returns the new encoded config.
+func addHTTPFilter(config *types.Struct, mixerConfig *deprecated.FilterMixerConfig) *types.Struct {
+	connectionManager := &http_conn.HttpConnectionManager{}
+	if err := structToMessage(config, connectionManager); err != nil {
+		log.Warnf("mixer: failed to decode http connection manager: %v", err)
+		return config
+	}
+	filter := &http_conn.HttpFilter{
+		Name:   deprecated.MixerFilter,
+		Config: mixerConfigToStruct(mixerConfig),
+	}
+	connectionManager.HttpFilters = append([]*http_conn.HttpFilter{filter}, connectionManager.HttpFilters...)
+
+	out, err := util.MessageToStruct(connectionManager)
+	if err != nil {
+		log.Warnf("mixer: failed to encode http connection manager: %v", err)
+		return config
+	}
+	return out
+}
+
+// mixerConfigToStruct returns the mixerclient configuration in the V2 field of the mixer
+// filter config, as a proto struct.
+func mixerConfigToStruct(config *deprecated.FilterMixerConfig) *types.Struct {
+	out := &types.Struct{}
+	if config == nil || config.V2 == nil {
+		return out
+	}
+	data, err := json.Marshal(config.V2)
+	if err != nil {
+		log.Warnf("mixer: failed to encode mixer filter config: %v", err)
+		return out
+	}
+	if err := jsonpb.Unmarshal(bytes.NewReader(data), out); err != nil {
+		log.Warnf("mixer: failed to convert mixer filter config: %v", err)
+		return &types.Struct{}
+	}
+	return out
+}
+
+// structToMessage decodes a proto struct, as found in the envoy filter configs.
+func structToMessage(config *types.Struct, out proto.Message) error {
+	if config == nil {
+		return fmt.Errorf("missing config")
+	}
+	buf := &bytes.Buffer{}
+	if err := (&jsonpb.Marshaler{OrigName: true}).Marshal(buf, config); err != nil {
+		return err
+	}
+	return jsonpb.Unmarshal(buf, out)
+}
+
+// BuildClusters returns the mixer check and report clusters referenced by the mixer filters.
+func BuildClusters(env model.Environment) []*xdsapi.Cluster {
+	clusters := make([]*xdsapi.Cluster, 0)
+	if env.Mesh.MixerCheckServer != "" {
+		if c := buildCluster(env, env.Mesh.MixerCheckServer, deprecated.MixerCheckClusterName); c != nil {
+			clusters = append(clusters, c)
+		}
+	}
+	if env.Mesh.MixerReportServer != "" {
+		if c := buildCluster(env, env.Mesh.MixerReportServer, deprecated.MixerReportClusterName); c != nil {
+			clusters = append(clusters, c)
+		}
+	}
+	return clusters
+}
+
+// buildCluster builds a cluster for a mixer server, using mTLS if required by the control
+// plane auth policy.
+func buildCluster(env model.Environment, server, clusterName string) *xdsapi.Cluster {
+	host, portStr, err := net.SplitHostPort(server)
+	if err != nil {
+		log.Warnf("mixer: invalid server address %q: %v", server, err)
+		return nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		log.Warnf("mixer: invalid server port %q: %v", server, err)
+		return nil
+	}
+
+	mesh := env.Mesh
+	cluster := &xdsapi.Cluster{
+		Name: clusterName,
+		Type: xdsapi.Cluster_STRICT_DNS,
+		Hosts: []*core.Address{{
+			Address: &core.Address_SocketAddress{
+				SocketAddress: &core.SocketAddress{
+					Address:  host,
+					Protocol: core.TCP,
+					PortSpecifier: &core.SocketAddress_PortValue{
+						PortValue: uint32(port),
+					},
+				},
+			},
+		}},
+		ConnectTimeout: time.Duration(mesh.ConnectTimeout.Seconds) * time.Second,
+		CircuitBreakers: &v2_cluster.CircuitBreakers{
+			Thresholds: []*v2_cluster.CircuitBreakers_Thresholds{
+				{
+					MaxPendingRequests: &types.UInt32Value{Value: 10000},
+					MaxRequests:        &types.UInt32Value{Value: 10000},
+				},
+			},
+		},
+		Http2ProtocolOptions: &core.Http2ProtocolOptions{},
+	}
+
+	if mesh.DefaultConfig != nil &&
+		mesh.DefaultConfig.ControlPlaneAuthPolicy == meshconfig.AuthenticationPolicy_MUTUAL_TLS {
+		// apply TLS context to enable mutual TLS between the proxy and mixer
+		cluster.TlsContext = authn.BuildClusterTLSContext(model.AuthCertsPath, env.MixerSAN)
+	}
+	return cluster
 }
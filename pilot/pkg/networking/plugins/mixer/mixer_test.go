@@ -0,0 +1,121 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mixer
+
+import (
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	deprecated "istio.io/istio/pilot/pkg/proxy/envoy/v1"
+	"istio.io/istio/pilot/pkg/proxy/envoy/v1/mock"
+)
+
+func buildEnv(mixerServer string) model.Environment {
+	mesh := model.DefaultMeshConfig()
+	mesh.MixerCheckServer = mixerServer
+	mesh.MixerReportServer = mixerServer
+	return model.Environment{
+		ServiceDiscovery: mock.Discovery,
+		ServiceAccounts:  mock.Discovery,
+		IstioConfigStore: model.MakeIstioStore(memory.Make(model.IstioConfigTypes)),
+		Mesh:             &mesh,
+	}
+}
+
+func buildHTTPListener(t *testing.T) *xdsapi.Listener {
+	config, err := util.MessageToStruct(&http_conn.HttpConnectionManager{
+		StatPrefix:  "http",
+		HttpFilters: []*http_conn.HttpFilter{{Name: util.Router}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &xdsapi.Listener{
+		FilterChains: []listener.FilterChain{{
+			Filters: []listener.Filter{{Name: util.HTTPConnectionManager, Config: config}},
+		}},
+	}
+}
+
+func httpFilterNames(t *testing.T, l *xdsapi.Listener) []string {
+	connectionManager := &http_conn.HttpConnectionManager{}
+	if err := structToMessage(l.FilterChains[0].Filters[0].Config, connectionManager); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(connectionManager.HttpFilters))
+	for _, f := range connectionManager.HttpFilters {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestHTTPListeners(t *testing.T) {
+	env := buildEnv("istio-mixer:9091")
+	for name, callback := range map[string]func(model.Environment, model.Proxy, *model.Service,
+		*xdsapi.Listener) *xdsapi.Listener{
+		"inbound":  onInboundListener,
+		"outbound": onOutboundListener,
+	} {
+		t.Run(name, func(t *testing.T) {
+			l := callback(env, mock.HelloProxyV0, mock.HelloService, buildHTTPListener(t))
+			names := httpFilterNames(t, l)
+			if len(names) != 2 || names[0] != deprecated.MixerFilter || names[1] != util.Router {
+				t.Errorf("got HTTP filters %v, want [%s %s]", names, deprecated.MixerFilter, util.Router)
+			}
+		})
+	}
+
+	l := onInboundListener(buildEnv(""), mock.HelloProxyV0, mock.HelloService, buildHTTPListener(t))
+	if names := httpFilterNames(t, l); len(names) != 1 {
+		t.Errorf("got HTTP filters %v with mixer disabled", names)
+	}
+}
+
+func TestTCPListener(t *testing.T) {
+	l := &xdsapi.Listener{
+		FilterChains: []listener.FilterChain{{
+			Filters: []listener.Filter{{Name: util.TCPProxy, Config: &types.Struct{}}},
+		}},
+	}
+	l = onInboundListener(buildEnv("istio-mixer:9091"), mock.HelloProxyV0, mock.HelloService, l)
+	filters := l.FilterChains[0].Filters
+	if len(filters) != 2 || filters[0].Name != deprecated.MixerFilter || filters[1].Name != util.TCPProxy {
+		t.Errorf("got network filters %v, want [%s %s]", filters, deprecated.MixerFilter, util.TCPProxy)
+	}
+	if filters[0].Config == nil || len(filters[0].Config.Fields) == 0 {
+		t.Error("missing mixer filter config")
+	}
+}
+
+func TestBuildClusters(t *testing.T) {
+	clusters := BuildClusters(buildEnv("istio-mixer:9091"))
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2", len(clusters))
+	}
+	if clusters[0].Name != deprecated.MixerCheckClusterName || clusters[1].Name != deprecated.MixerReportClusterName {
+		t.Errorf("got clusters %s, %s", clusters[0].Name, clusters[1].Name)
+	}
+
+	if clusters := BuildClusters(buildEnv("")); len(clusters) != 0 {
+		t.Errorf("got %d clusters with mixer disabled", len(clusters))
+	}
+}
@@ -26,6 +26,7 @@ import (
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugins/mixer"
 	"istio.io/istio/pkg/log"
 )
 
@@ -40,6 +41,10 @@ const (
 
 	// Name used for the xds cluster.
 	xdsName = "xds-grpc"
+
+	// SystemCACertificates is the CA bundle of the proxy image, used to verify the upstream
+	// servers when the TLS settings don't specify CA certificates.
+	SystemCACertificates = "/etc/ssl/certs/ca-certificates.crt"
 )
 
 // TODO: Need to do inheritance of DestRules based on domain suffix match
@@ -57,7 +62,7 @@ func BuildClusters(env model.Environment, proxy model.Proxy) []*v2.Cluster {
 		return nil
 	}
 
-	clusters = append(clusters, buildOutboundClusters(env, services)...)
+	clusters = append(clusters, buildOutboundClusters(env, proxy, services)...)
 	for _, c := range clusters {
 		// Envoy requires a non-zero connect timeout
 		if c.ConnectTimeout == 0 {
@@ -72,7 +77,10 @@ func BuildClusters(env model.Environment, proxy model.Proxy) []*v2.Cluster {
 		}
 
 		managementPorts := env.ManagementPorts(proxy.IPAddress)
-		clusters = append(clusters, buildInboundClusters(env, instances, managementPorts)...)
+		clusters = append(clusters, buildInboundClusters(env, proxy, instances, managementPorts)...)
+
+		// clusters for the mixer servers used by the mixer filter of the sidecar listeners
+		clusters = append(clusters, mixer.BuildClusters(env)...)
 
 		// TODO: Bug? why only for sidecars?
 		// append cluster for JwksUri (for Jwt authentication) if necessary.
@@ -83,7 +91,7 @@ func BuildClusters(env model.Environment, proxy model.Proxy) []*v2.Cluster {
 	return clusters // TODO: normalize/dedup/order
 }
 
-func buildOutboundClusters(env model.Environment, services []*model.Service) []*v2.Cluster {
+func buildOutboundClusters(env model.Environment, proxy model.Proxy, services []*model.Service) []*v2.Cluster {
 	clusters := make([]*v2.Cluster, 0)
 	for _, service := range services {
 		config := env.DestinationRule(service.Hostname, "")
@@ -95,11 +103,14 @@ func buildOutboundClusters(env model.Environment, services []*model.Service) []*
 			defaultCluster := buildDefaultCluster(env, clusterName, convertResolution(service.Resolution), hosts)
 			updateEds(env, defaultCluster, service.Hostname)
 			setUpstreamProtocol(defaultCluster, port)
-			clusters = append(clusters, defaultCluster)
 
-			if config != nil {
+			if config == nil {
+				clusters = append(clusters, onOutboundCluster(env, proxy, service, defaultCluster))
+			} else {
 				destinationRule := config.Spec.(*networking.DestinationRule)
 				applyTrafficPolicy(defaultCluster, destinationRule.TrafficPolicy)
+				setExternalSNI(defaultCluster, service)
+				clusters = append(clusters, onOutboundCluster(env, proxy, service, defaultCluster))
 
 				for _, subset := range destinationRule.Subsets {
 					subsetClusterName := model.BuildSubsetKey(model.TrafficDirectionOutbound, subset.Name, service.Hostname, port)
@@ -108,7 +119,8 @@ func buildOutboundClusters(env model.Environment, services []*model.Service) []*
 					setUpstreamProtocol(subsetCluster, port)
 					applyTrafficPolicy(subsetCluster, destinationRule.TrafficPolicy)
 					applyTrafficPolicy(subsetCluster, subset.TrafficPolicy)
-					clusters = append(clusters, subsetCluster)
+					setExternalSNI(subsetCluster, service)
+					clusters = append(clusters, onOutboundCluster(env, proxy, service, subsetCluster))
 				}
 			}
 		}
@@ -158,10 +170,17 @@ func buildClusterHosts(env model.Environment, service *model.Service, port *mode
 		hosts = append(hosts, &host)
 	}
 
+	// External services without endpoints are resolved using their own hostname.
+	if len(hosts) == 0 && service.MeshExternal && !service.WildcardHost() {
+		host := buildAddress(service.Hostname, uint32(port.Port))
+		hosts = append(hosts, &host)
+	}
+
 	return hosts
 }
 
-func buildInboundClusters(env model.Environment, instances []*model.ServiceInstance, managementPorts []*model.Port) []*v2.Cluster {
+func buildInboundClusters(env model.Environment, proxy model.Proxy, instances []*model.ServiceInstance,
+	managementPorts []*model.Port) []*v2.Cluster {
 	clusters := make([]*v2.Cluster, 0)
 	for _, instance := range instances {
 		// This cluster name is mainly for stats.
@@ -169,7 +188,7 @@ func buildInboundClusters(env model.Environment, instances []*model.ServiceInsta
 		address := buildAddress("127.0.0.1", uint32(instance.Endpoint.Port))
 		localCluster := buildDefaultCluster(env, clusterName, v2.Cluster_STATIC, []*core.Address{&address})
 		setUpstreamProtocol(localCluster, instance.Endpoint.ServicePort)
-		clusters = append(clusters, localCluster)
+		clusters = append(clusters, onInboundCluster(env, proxy, instance.Service, localCluster))
 	}
 
 	// Add a passthrough cluster for traffic to management ports (health check ports)
@@ -302,14 +321,7 @@ func applyUpstreamTLSSettings(cluster *v2.Cluster, tls *networking.TLSSettings)
 	case networking.TLSSettings_SIMPLE:
 		cluster.TlsContext = &auth.UpstreamTlsContext{
 			CommonTlsContext: &auth.CommonTlsContext{
-				ValidationContext: &auth.CertificateValidationContext{
-					TrustedCa: &core.DataSource{
-						Specifier: &core.DataSource_Filename{
-							Filename: tls.CaCertificates,
-						},
-					},
-					VerifySubjectAltName: tls.SubjectAltNames,
-				},
+				ValidationContext: buildValidationContext(tls),
 			},
 			Sni: tls.Sni,
 		}
@@ -330,20 +342,42 @@ func applyUpstreamTLSSettings(cluster *v2.Cluster, tls *networking.TLSSettings)
 						},
 					},
 				},
-				ValidationContext: &auth.CertificateValidationContext{
-					TrustedCa: &core.DataSource{
-						Specifier: &core.DataSource_Filename{
-							Filename: tls.CaCertificates,
-						},
-					},
-					VerifySubjectAltName: tls.SubjectAltNames,
-				},
+				ValidationContext: buildValidationContext(tls),
 			},
 			Sni: tls.Sni,
 		}
 	}
 }
 
+// buildValidationContext returns the validation of the server certificate. Without CA
+// certificates, e.g. when originating TLS to an external host, the certificate is verified
+// with the CA bundle of the system.
+func buildValidationContext(tls *networking.TLSSettings) *auth.CertificateValidationContext {
+	caCertificates := tls.CaCertificates
+	if caCertificates == "" {
+		caCertificates = SystemCACertificates
+	}
+	return &auth.CertificateValidationContext{
+		TrustedCa: &core.DataSource{
+			Specifier: &core.DataSource_Filename{
+				Filename: caCertificates,
+			},
+		},
+		VerifySubjectAltName: tls.SubjectAltNames,
+	}
+}
+
+// setExternalSNI defaults the SNI of the TLS connections originated to an external service
+// to the service hostname. The hostname of wildcard services is not known in advance.
+func setExternalSNI(cluster *v2.Cluster, service *model.Service) {
+	if !service.MeshExternal || service.WildcardHost() || cluster.TlsContext == nil {
+		return
+	}
+	if cluster.TlsContext.Sni == "" {
+		cluster.TlsContext.Sni = service.Hostname
+	}
+}
+
 func setUpstreamProtocol(cluster *v2.Cluster, port *model.Port) {
 	if port.Protocol.IsHTTP() {
 		if port.Protocol == model.ProtocolHTTP2 || port.Protocol == model.ProtocolGRPC {
@@ -0,0 +1,70 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestApplyUpstreamTLSSettings(t *testing.T) {
+	testCases := []struct {
+		name       string
+		tls        *networking.TLSSettings
+		expectedCA string
+	}{
+		{
+			name:       "simple with CA certificates",
+			tls:        &networking.TLSSettings{Mode: networking.TLSSettings_SIMPLE, CaCertificates: "/etc/certs/root.pem"},
+			expectedCA: "/etc/certs/root.pem",
+		},
+		{
+			name:       "simple without CA certificates",
+			tls:        &networking.TLSSettings{Mode: networking.TLSSettings_SIMPLE},
+			expectedCA: SystemCACertificates,
+		},
+		{
+			name: "mutual without CA certificates",
+			tls: &networking.TLSSettings{Mode: networking.TLSSettings_MUTUAL,
+				ClientCertificate: "/etc/certs/cert.pem", PrivateKey: "/etc/certs/key.pem"},
+			expectedCA: SystemCACertificates,
+		},
+		{
+			name: "disabled",
+			tls:  &networking.TLSSettings{Mode: networking.TLSSettings_DISABLE},
+		},
+	}
+
+	for _, tc := range testCases {
+		cluster := &v2.Cluster{}
+		applyUpstreamTLSSettings(cluster, tc.tls)
+		if tc.expectedCA == "" {
+			if cluster.TlsContext != nil {
+				t.Errorf("%s: unexpected TLS context %v", tc.name, cluster.TlsContext)
+			}
+			continue
+		}
+		if cluster.TlsContext == nil || cluster.TlsContext.CommonTlsContext.ValidationContext == nil {
+			t.Errorf("%s: TLS context without validation context %v", tc.name, cluster.TlsContext)
+			continue
+		}
+		if ca := cluster.TlsContext.CommonTlsContext.ValidationContext.TrustedCa.GetFilename(); ca != tc.expectedCA {
+			t.Errorf("%s: trusted CA is %q, expecting %q", tc.name, ca, tc.expectedCA)
+		}
+	}
+}
@@ -18,14 +18,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
-	"strconv"
-	"strings"
 	"time"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
-	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
@@ -45,6 +42,10 @@ const (
 
 	envoyHTTPConnectionManager = "envoy.http_connection_manager"
 
+	// tlsInspector is the listener filter reading the SNI of TLS connections, used to select
+	// the filter chain of the outbound listeners for external TLS services.
+	tlsInspector = "envoy.listener.tls_inspector"
+
 	// HTTPStatPrefix indicates envoy stat prefix for http listeners
 	HTTPStatPrefix = "http"
 
@@ -68,6 +69,11 @@ var (
 	// Very verbose output in the logs - full LDS response logged for each sidecar.
 	// Use /debug/ldsz instead.
 	verboseDebug = os.Getenv("PILOT_DUMP_ALPHA3") != ""
+
+	// EnableRDSv2 makes sidecar and gateway HTTP listeners fetch their routes from the v2 RDS
+	// gRPC service in Pilot, instead of inlining the route configuration in the listener.
+	// Set by the --rdsv2 flag of pilot-discovery.
+	EnableRDSv2 = false
 )
 
 // ListenersALPNProtocols denotes the the list of ALPN protocols that the listener
@@ -172,14 +178,16 @@ func buildSidecarListeners(env model.Environment, node model.Proxy) ([]*xdsapi.L
 			listenAddress = WildcardAddress
 		}
 
+		routeConfig, faultFilters := buildSidecarOutboundHTTPRoutes(env, node, proxyInstances, services, RDSHttpProxy)
 		listeners = append(listeners, buildHTTPListener(buildHTTPListenerOpts{
-			env:            env,
-			proxy:          node,
-			proxyInstances: proxyInstances,
-			routeConfig:    buildSidecarOutboundHTTPRouteConfig(env, node, proxyInstances, services, RDSHttpProxy),
-			ip:             listenAddress,
-			port:           int(mesh.ProxyHttpPort),
-			//rds:              RDSHttpProxy,
+			env:              env,
+			proxy:            node,
+			proxyInstances:   proxyInstances,
+			routeConfig:      routeConfig,
+			faultFilters:     faultFilters,
+			ip:               listenAddress,
+			port:             int(mesh.ProxyHttpPort),
+			rds:              sidecarRDSName(RDSHttpProxy),
 			useRemoteAddress: useRemoteAddress,
 			direction:        traceOperation,
 			authnPolicy:      nil, /* authN policy is not needed for outbound listener */
@@ -221,7 +229,7 @@ func buildSidecarInboundListeners(env model.Environment, node model.Proxy,
 				env:              env,
 				proxy:            node,
 				proxyInstances:   proxyInstances,
-				routeConfig:      buildInboundHTTPRouteConfig(instance),
+				routeConfig:      buildInboundHTTPRouteConfig(env, node, instance),
 				ip:               endpoint.Address,
 				port:             endpoint.Port,
 				rds:              "",
@@ -233,26 +241,13 @@ func buildSidecarInboundListeners(env model.Environment, node model.Proxy,
 		case model.ProtocolTCP, model.ProtocolHTTPS, model.ProtocolMongo, model.ProtocolRedis:
 			l = buildTCPListener(buildInboundNetworkFilters(instance), endpoint.Address, uint32(endpoint.Port), protocol)
 
-			// TODO: set server-side mixer filter config
-			//if mesh.MixerCheckServer != "" || mesh.MixerReportServer != "" {
-			//	// config := v1.BuildTCPMixerFilterConfig(mesh, node, instance)
-			//	l.FilterChains = append(l.FilterChains, listener.FilterChain{
-			//		Filters: []listener.Filter{
-			//			{
-			//				// TODO(mostrowski): need proto version of mixer config.
-			//				// Config: messageToStruct(&config),
-			//			},
-			//		},
-			//	})
-			//}
-
 		default:
 			log.Debugf("Unsupported inbound protocol %v for port %#v", protocol, instance.Endpoint.ServicePort)
 		}
 
 		if l != nil {
-			// TODO: move to plugin
-			applyInboundAuth(authenticationPolicy, l)
+			// mTLS, mixer filter and other plugin settings
+			l = onInboundListener(env, node, instance.Service, l)
 			listeners = append(listeners, l)
 		}
 	}
@@ -280,6 +275,8 @@ func buildSidecarOutboundListeners(env model.Environment, node model.Proxy,
 	var tcpListeners, httpListeners []*xdsapi.Listener
 
 	wildcardListenerPorts := make(map[int]bool)
+	// external TLS services by port, served by a single listener selecting the service by SNI
+	sniServices := make(map[int][]*model.Service)
 	for _, service := range services {
 		for _, servicePort := range service.Ports {
 			clusterName := model.BuildSubsetKey(model.TrafficDirectionOutbound, "",
@@ -290,6 +287,10 @@ func buildSidecarOutboundListeners(env model.Environment, node model.Proxy,
 
 			switch servicePort.Protocol {
 			case model.ProtocolTCP, model.ProtocolHTTPS, model.ProtocolMongo, model.ProtocolRedis:
+				if service.MeshExternal && servicePort.Protocol == model.ProtocolHTTPS {
+					sniServices[servicePort.Port] = append(sniServices[servicePort.Port], service)
+					continue
+				}
 				if service.Resolution == model.Passthrough || node.Type == model.Router {
 					// ensure only one wildcard listener is created per port if its headless service
 					// or if its for a Router (where there is one wildcard TCP listener per port)
@@ -307,8 +308,7 @@ func buildSidecarOutboundListeners(env model.Environment, node model.Proxy,
 				}
 				listener := buildTCPListener(buildOutboundNetworkFilters(clusterName, addresses, servicePort),
 					listenAddress, uint32(servicePort.Port), servicePort.Protocol)
-				tcpListeners = append(tcpListeners, listener)
-				// TODO: Set SNI for HTTPS
+				tcpListeners = append(tcpListeners, onOutboundListener(env, node, service, listener))
 			case model.ProtocolHTTP2, model.ProtocolHTTP, model.ProtocolGRPC:
 				operation := http_conn.EGRESS
 				useRemoteAddress := false
@@ -319,27 +319,93 @@ func buildSidecarOutboundListeners(env model.Environment, node model.Proxy,
 					operation = http_conn.INGRESS
 				}
 
-				httpListeners = append(httpListeners, buildHTTPListener(buildHTTPListenerOpts{
-					env:            env,
-					proxy:          node,
-					proxyInstances: proxyInstances,
-					services:       services,
-					ip:             WildcardAddress,
-					port:           servicePort.Port,
-					//rds:              fmt.Sprintf("%d", servicePort.Port),
-					routeConfig:      buildSidecarOutboundHTTPRouteConfig(env, node, proxyInstances, services, fmt.Sprintf("%d", servicePort.Port)),
+				routeName := fmt.Sprintf("%d", servicePort.Port)
+				routeConfig, faultFilters := buildSidecarOutboundHTTPRoutes(env, node, proxyInstances, services, routeName)
+				listener := buildHTTPListener(buildHTTPListenerOpts{
+					env:              env,
+					proxy:            node,
+					proxyInstances:   proxyInstances,
+					services:         services,
+					ip:               WildcardAddress,
+					port:             servicePort.Port,
+					rds:              sidecarRDSName(routeName),
+					routeConfig:      routeConfig,
+					faultFilters:     faultFilters,
 					useRemoteAddress: useRemoteAddress,
 					direction:        operation,
 					authnPolicy:      nil, /* authn policy is not needed for outbound listener */
-				}))
-
+				})
+				httpListeners = append(httpListeners, onOutboundListener(env, node, service, listener))
 			}
 		}
 	}
 
+	sniPorts := make([]int, 0, len(sniServices))
+	for port := range sniServices {
+		sniPorts = append(sniPorts, port)
+	}
+	sort.Ints(sniPorts)
+	for _, port := range sniPorts {
+		if wildcardListenerPorts[port] {
+			log.Warnf("Omitting SNI listener for external services on port %d due to collision with a wildcard TCP listener", port)
+			continue
+		}
+		listener := buildOutboundSNIListener(sniServices[port], port)
+		tcpListeners = append(tcpListeners, onOutboundListener(env, node, nil, listener))
+	}
+
 	return append(tcpListeners, httpListeners...)
 }
 
+// buildOutboundSNIListener builds the wildcard listener for the external TLS services on a port.
+// Each service hostname, possibly a wildcard such as *.googleapis.com, gets a filter chain matching
+// the SNI of the connection and proxying it to the service cluster.
+func buildOutboundSNIListener(services []*model.Service, port int) *xdsapi.Listener {
+	filterChains := make([]listener.FilterChain, 0, len(services))
+	hostnames := make(map[string]bool)
+	for _, service := range services {
+		servicePort, exists := service.Ports.GetByPort(port)
+		if !exists || hostnames[service.Hostname] {
+			// Envoy rejects listeners with the same match in multiple filter chains
+			continue
+		}
+		hostnames[service.Hostname] = true
+
+		clusterName := model.BuildSubsetKey(model.TrafficDirectionOutbound, "", service.Hostname, servicePort)
+		config := &tcp_proxy.TcpProxy{
+			StatPrefix: clusterName,
+			Cluster:    clusterName,
+		}
+		filterChains = append(filterChains, listener.FilterChain{
+			FilterChainMatch: &listener.FilterChainMatch{
+				SniDomains: []string{service.Hostname},
+			},
+			Filters: []listener.Filter{
+				{
+					Name:   util.TCPProxy,
+					Config: messageToStruct(config),
+				},
+			},
+		})
+	}
+
+	return &xdsapi.Listener{
+		Name:    fmt.Sprintf("%s_%s_%d", model.ProtocolHTTPS, WildcardAddress, port),
+		Address: buildAddress(WildcardAddress, uint32(port)),
+		ListenerFilters: []listener.ListenerFilter{
+			{
+				Name: tlsInspector,
+			},
+		},
+		FilterChains: filterChains,
+		DeprecatedV1: &xdsapi.Listener_DeprecatedV1{
+			BindToPort: &google_protobuf.BoolValue{
+				Value: false,
+			},
+		},
+	}
+}
+
 // buildMgmtPortListeners creates inbound TCP only listeners for the management ports on
 // server (inbound). Management port listeners are slightly different from standard Inbound listeners
 // in that, they do not have mixer filters nor do they have inbound auth.
@@ -388,16 +454,6 @@ func buildMgmtPortListeners(managementPorts model.PortList, managementIP string)
 	return listeners
 }
 
-// TODO: move to plugins
-// applyInboundAuth adds ssl_context to the listener if the policy requires one.
-func applyInboundAuth(authenticationPolicy *authn.Policy, listener *xdsapi.Listener) {
-
-	if model.RequireTLS(authenticationPolicy) {
-		// TODO(mostrowski): figure out SSL
-		log.Debugf("TODO Apply authN policy %#v for %#v\n", authenticationPolicy, listener)
-	}
-}
-
 // options required to build an HTTPListener
 type buildHTTPListenerOpts struct { // nolint: maligned
 	env            model.Environment
@@ -405,8 +461,10 @@ type buildHTTPListenerOpts struct { // nolint: maligned
 	proxyInstances []*model.ServiceInstance
 	services       []*model.Service
 	routeConfig    *xdsapi.RouteConfiguration
-	ip             string
-	port           int
+	// faultFilters are the fault injection filters for the routes served by the listener
+	faultFilters []*http_conn.HttpFilter
+	ip           string
+	port         int
 	// bindToPort (default to false) should be set for ingress / gateway
 	bindToPort       bool
 	rds              string
@@ -456,22 +514,11 @@ func buildHTTPListener(opts buildHTTPListenerOpts) *xdsapi.Listener {
 	filters = append(filters, &http_conn.HttpFilter{
 		Name: util.CORS,
 	})
-	// TODO: need alphav3 fault filters.
-	// filters = append(filters, buildFaultFilters(opts.config, opts.env, opts.proxy)...)
+	filters = append(filters, opts.faultFilters...)
 	filters = append(filters, &http_conn.HttpFilter{
 		Name: util.Router,
 	})
 
-	/*	TODO(mostrowski): need to port internal build functions for mixer.
-		if opts.mesh.MixerCheckServer != "" || opts.mesh.MixerReportServer != "" {
-			mixerConfig := v1.BuildHTTPMixerFilterConfig(opts.mesh, opts.proxy, opts.proxyInstances, opts.outboundListener, opts.store)
-		filter := &http_conn.HttpFilter{
-			Name: v1.MixerFilter,
-			Config:messageToStruct(mixerConfig),
-		}
-			filters = append([]*http_conn.HttpFilter{filter}, filters...)
-		}
-	*/
 	refresh := time.Duration(mesh.RdsRefreshDelay.Seconds) * time.Second
 	if refresh == 0 {
 		// envoy crashes if 0. Will go away once we move to v2
@@ -494,7 +541,22 @@ func buildHTTPListener(opts buildHTTPListenerOpts) *xdsapi.Listener {
 		UseRemoteAddress: &google_protobuf.BoolValue{opts.useRemoteAddress},
 	}
 
-	if opts.rds != "" {
+	if opts.rds != "" && EnableRDSv2 && opts.proxy.Type != model.Ingress {
+		connectionManager.RouteSpecifier = &http_conn.HttpConnectionManager_Rds{
+			Rds: &http_conn.Rds{
+				RouteConfigName: opts.rds,
+				ConfigSource: core.ConfigSource{
+					ConfigSourceSpecifier: &core.ConfigSource_ApiConfigSource{
+						ApiConfigSource: &core.ApiConfigSource{
+							ApiType:      core.ApiConfigSource_GRPC,
+							ClusterNames: []string{xdsName},
+							RefreshDelay: &refresh,
+						},
+					},
+				},
+			},
+		}
+	} else if opts.rds != "" {
 		rds := &http_conn.HttpConnectionManager_Rds{
 			Rds: &http_conn.Rds{
 				RouteConfigName: opts.rds,
@@ -588,177 +650,11 @@ func buildTCPListener(filters []listener.Filter, ip string, port uint32, protoco
 	}
 }
 
-// TODO: find a proper home for these http related functions
-// buildDefaultHTTPRoute builds a default route.
-func buildDefaultHTTPRoute(clusterName string) *route.Route {
-	return &route.Route{
-		Match: route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
-		Decorator: &route.Decorator{
-			Operation: DefaultOperation,
-		},
-		Action: &route.Route_Route{
-			Route: &route.RouteAction{
-				ClusterSpecifier: &route.RouteAction_Cluster{Cluster: clusterName},
-			},
-		},
-	}
-}
-
-// buildInboundHTTPRouteConfig builds the route config with a single wildcard virtual host on the inbound path
-// TODO: enable mixer configuration, websockets, trace decorators
-func buildInboundHTTPRouteConfig(instance *model.ServiceInstance) *xdsapi.RouteConfiguration {
-	clusterName := model.BuildSubsetKey(model.TrafficDirectionInbound, "",
-		instance.Service.Hostname, instance.Endpoint.ServicePort)
-	defaultRoute := buildDefaultHTTPRoute(clusterName)
-
-	inboundVHost := route.VirtualHost{
-		Name:    fmt.Sprintf("%s|http|%d", model.TrafficDirectionInbound, instance.Endpoint.ServicePort.Port),
-		Domains: []string{"*"},
-		Routes:  []route.Route{*defaultRoute},
-	}
-
-	// TODO: mixer disabled for now as its configuration is still in old format
-	// set server-side mixer filter config for inbound HTTP routes
-	//if mesh.MixerCheckServer != "" || mesh.MixerReportServer != "" {
-	//	defaultRoute.OpaqueConfig = v1.BuildMixerOpaqueConfig(!mesh.DisablePolicyChecks, false, instance.Service.Hostname)
-	//}
-
-	return &xdsapi.RouteConfiguration{
-		Name:         clusterName,
-		VirtualHosts: []route.VirtualHost{inboundVHost},
-		ValidateClusters: &google_protobuf.BoolValue{
-			Value: false,
-		},
-	}
-}
-
-func buildSidecarOutboundHTTPRouteConfig(env model.Environment, node model.Proxy, _ []*model.ServiceInstance,
-	services []*model.Service, routeName string) *xdsapi.RouteConfiguration {
-
-	port := 0
-	if routeName != RDSHttpProxy {
-		var err error
-		port, err = strconv.Atoi(routeName)
-		if err != nil {
-			return nil
-		}
-	}
-
-	nameToServiceMap := make(map[string]*model.Service)
-	for _, svc := range services {
-		if port == 0 {
-			nameToServiceMap[svc.Hostname] = svc
-		} else {
-			if svcPort, exists := svc.Ports.GetByPort(port); exists {
-				nameToServiceMap[svc.Hostname] = &model.Service{
-					Hostname: svc.Hostname,
-					Address:  svc.Address,
-					Ports:    []*model.Port{svcPort},
-				}
-			}
-		}
-	}
-
-	// Get list of virtual services bound to the mesh gateway
-	virtualServices := env.VirtualServices([]string{model.IstioMeshGateway})
-	// TODO: Need to trim output based on source label/gateway match
-	guardedHosts := TranslateVirtualHosts(virtualServices,
-		nameToServiceMap, nil, node.Domain)
-	vHostPortMap := make(map[int][]route.VirtualHost)
-
-	// there should be only one guarded host in the return val since we supplied services with just one port
-	for _, guardedHost := range guardedHosts {
-		routes := make([]route.Route, 0)
-		for _, r := range guardedHost.Routes {
-			routes = append(routes, r.Route)
-		}
-
-		virtualHosts := make([]route.VirtualHost, 0)
-
-		for _, host := range guardedHost.Hosts {
-			virtualHosts = append(virtualHosts, route.VirtualHost{
-				Name:    fmt.Sprintf("%s:%d", host, guardedHost.Port),
-				Domains: []string{host},
-				Routes:  routes,
-			})
-		}
-
-		for _, svc := range guardedHost.Services {
-			domains := generateAltVirtualHosts(svc.Hostname, guardedHost.Port)
-			if len(svc.Address) > 0 {
-				// add a vhost match for the IP (if its non CIDR)
-				cidr := convertAddressToCidr(svc.Address)
-				if cidr.PrefixLen.Value == 32 {
-					domains = append(domains, svc.Address)
-					domains = append(domains, fmt.Sprintf("%s:%d", svc.Address, guardedHost.Port))
-				}
-			}
-			virtualHosts = append(virtualHosts, route.VirtualHost{
-				Name:    fmt.Sprintf("%s:%d", svc.Hostname, guardedHost.Port),
-				Domains: domains,
-				Routes:  routes,
-			})
-		}
-
-		vHostPortMap[guardedHost.Port] = virtualHosts
-	}
-
-	var virtualHosts []route.VirtualHost
-	if routeName == RDSHttpProxy {
-		virtualHosts = mergeAllVirtualHosts(vHostPortMap)
-	} else {
-		virtualHosts = vHostPortMap[port]
-	}
-
-	out := &xdsapi.RouteConfiguration{
-		Name:         fmt.Sprintf("%d", port),
-		VirtualHosts: virtualHosts,
-		ValidateClusters: &google_protobuf.BoolValue{
-			Value: false, // until we have rds
-		},
-	}
-	return out
-
-}
-
-// Given a service, and a port, this function generates all possible HTTP Host headers.
-// For example, a service of the form foo.local.campus.net on port 80 could be accessed as
-// http://foo:80 within the .local network, as http://foo.local:80 (by other clients in the campus.net domain),
-// as http://foo.local.campus:80, etc.
-func generateAltVirtualHosts(hostname string, port int) []string {
-	vhosts := []string{hostname, fmt.Sprintf("%s:%d", hostname, port)}
-	for i := len(hostname) - 1; i >= 0; i-- {
-		if hostname[i] == '.' {
-			variant := hostname[:i]
-			variantWithPort := fmt.Sprintf("%s:%d", variant, port)
-			vhosts = append(vhosts, variant)
-			vhosts = append(vhosts, variantWithPort)
-		}
-	}
-	return vhosts
-}
-
-// mergeAllVirtualHosts across all ports. On routes for ports other than port 80,
-// virtual hosts without an explicit port suffix (IP:PORT) should be stripped
-func mergeAllVirtualHosts(vHostPortMap map[int][]route.VirtualHost) []route.VirtualHost {
-	var virtualHosts []route.VirtualHost
-	for p, vhosts := range vHostPortMap {
-		if p == 80 {
-			virtualHosts = append(virtualHosts, vhosts...)
-		} else {
-			for _, vhost := range vhosts {
-				var newDomains []string
-				for _, domain := range vhost.Domains {
-					if strings.Contains(domain, ":") {
-						newDomains = append(newDomains, domain)
-					}
-				}
-				if len(newDomains) > 0 {
-					vhost.Domains = newDomains
-					virtualHosts = append(virtualHosts, vhost)
-				}
-			}
-		}
+// sidecarRDSName returns the route name for an outbound sidecar listener, if routes are
+// fetched using RDS. Otherwise the route configuration is inlined in the listener.
+func sidecarRDSName(routeName string) string {
+	if EnableRDSv2 {
+		return routeName
 	}
-	return virtualHosts
+	return ""
 }
@@ -0,0 +1,120 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func makeExternalService(hostname string, port int, protocol model.Protocol) *model.Service {
+	return &model.Service{
+		Hostname:     hostname,
+		MeshExternal: true,
+		Resolution:   model.DNSLB,
+		Ports: model.PortList{
+			{Name: "port", Port: port, Protocol: protocol},
+		},
+	}
+}
+
+func TestBuildOutboundSNIListener(t *testing.T) {
+	services := []*model.Service{
+		makeExternalService("*.googleapis.com", 443, model.ProtocolHTTPS),
+		makeExternalService("api.example.com", 443, model.ProtocolHTTPS),
+		// duplicate hosts get a single filter chain
+		makeExternalService("api.example.com", 443, model.ProtocolHTTPS),
+		// services without the port are ignored
+		makeExternalService("other.example.com", 8443, model.ProtocolHTTPS),
+	}
+
+	l := buildOutboundSNIListener(services, 443)
+	if len(l.ListenerFilters) != 1 || l.ListenerFilters[0].Name != tlsInspector {
+		t.Errorf("got listener filters %v, want %s", l.ListenerFilters, tlsInspector)
+	}
+
+	var sni []string
+	for _, chain := range l.FilterChains {
+		if chain.FilterChainMatch == nil {
+			t.Fatal("missing filter chain match")
+		}
+		sni = append(sni, chain.FilterChainMatch.SniDomains...)
+	}
+	want := []string{"*.googleapis.com", "api.example.com"}
+	if !reflect.DeepEqual(sni, want) {
+		t.Errorf("got SNI domains %v, want %v", sni, want)
+	}
+}
+
+func TestGenerateExternalVirtualHostDomains(t *testing.T) {
+	services := []*model.Service{
+		makeExternalService("*.googleapis.com", 80, model.ProtocolHTTP),
+	}
+	env := model.Environment{
+		IstioConfigStore: model.MakeIstioStore(memory.Make(model.IstioConfigTypes)),
+	}
+	rc := BuildSidecarOutboundHTTPRouteConfig(env, model.Proxy{Type: model.Sidecar}, nil, services, "80")
+	if rc == nil || len(rc.VirtualHosts) != 1 {
+		t.Fatalf("got route config %v, want a single virtual host", rc)
+	}
+	want := []string{"*.googleapis.com", "*.googleapis.com:80"}
+	if got := rc.VirtualHosts[0].Domains; !reflect.DeepEqual(got, want) {
+		t.Errorf("got domains %v, want %v", got, want)
+	}
+}
+
+func TestSidecarOutboundRouteDomainsAreUnique(t *testing.T) {
+	makeService := func(hostname string, ports ...int) *model.Service {
+		svc := &model.Service{Hostname: hostname, Resolution: model.ClientSideLB}
+		for _, port := range ports {
+			svc.Ports = append(svc.Ports, &model.Port{Name: fmt.Sprintf("http-%d", port), Port: port, Protocol: model.ProtocolHTTP})
+		}
+		return svc
+	}
+	// both services generate the "foo" and "foo:80" domains
+	services := []*model.Service{
+		makeService("foo.ns1.svc.cluster.local", 80, 8080),
+		makeService("foo.ns2.svc.cluster.local", 80, 8080),
+	}
+	env := model.Environment{
+		IstioConfigStore: model.MakeIstioStore(memory.Make(model.IstioConfigTypes)),
+	}
+
+	for _, routeName := range []string{"80", RDSHttpProxy} {
+		rc := BuildSidecarOutboundHTTPRouteConfig(env, model.Proxy{Type: model.Sidecar}, nil, services, routeName)
+		if rc == nil || len(rc.VirtualHosts) == 0 {
+			t.Fatalf("got route config %v for %s, want virtual hosts", rc, routeName)
+		}
+
+		domains := make(map[string]string)
+		for _, vhost := range rc.VirtualHosts {
+			for _, domain := range vhost.Domains {
+				if other, exists := domains[domain]; exists {
+					t.Errorf("got domain %s in virtual hosts %s and %s of route %s", domain, other, vhost.Name, routeName)
+				}
+				domains[domain] = vhost.Name
+			}
+		}
+		for _, domain := range []string{"foo", "foo.ns1", "foo.ns2", "foo.ns2.svc.cluster.local:80"} {
+			if _, exists := domains[domain]; !exists {
+				t.Errorf("got no virtual host for domain %s in route %s", domain, routeName)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+
+	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/plugins/authn"
+	"istio.io/istio/pilot/pkg/networking/plugins/mixer"
+)
+
+func init() {
+	// Built-in plugins. Additional plugins are added with networking.RegisterPlugin.
+	istionetworking.RegisterPlugin(authn.NewPlugin())
+	istionetworking.RegisterPlugin(mixer.NewPlugin())
+}
+
+// onInboundListener runs the plugin chain on a listener for a service instance of the proxy.
+func onInboundListener(env model.Environment, node model.Proxy, service *model.Service,
+	listener *xdsapi.Listener) *xdsapi.Listener {
+	for _, p := range istionetworking.NewPlugins() {
+		if p.OnInboundListener == nil {
+			continue
+		}
+		if out := p.OnInboundListener(env, node, service, listener); out != nil {
+			listener = out
+		}
+	}
+	return listener
+}
+
+// onOutboundListener runs the plugin chain on a listener for a destination service.
+func onOutboundListener(env model.Environment, node model.Proxy, service *model.Service,
+	listener *xdsapi.Listener) *xdsapi.Listener {
+	for _, p := range istionetworking.NewPlugins() {
+		if p.OnOutboundListener == nil {
+			continue
+		}
+		if out := p.OnOutboundListener(env, node, service, listener); out != nil {
+			listener = out
+		}
+	}
+	return listener
+}
+
+// onInboundCluster runs the plugin chain on a cluster for a service instance of the proxy.
+func onInboundCluster(env model.Environment, node model.Proxy, service *model.Service,
+	cluster *xdsapi.Cluster) *xdsapi.Cluster {
+	for _, p := range istionetworking.NewPlugins() {
+		if p.OnInboundCluster == nil {
+			continue
+		}
+		if out := p.OnInboundCluster(env, node, service, cluster); out != nil {
+			cluster = out
+		}
+	}
+	return cluster
+}
+
+// onOutboundCluster runs the plugin chain on a cluster for a destination service.
+func onOutboundCluster(env model.Environment, node model.Proxy, service *model.Service,
+	cluster *xdsapi.Cluster) *xdsapi.Cluster {
+	for _, p := range istionetworking.NewPlugins() {
+		if p.OnOutboundCluster == nil {
+			continue
+		}
+		if out := p.OnOutboundCluster(env, node, service, cluster); out != nil {
+			cluster = out
+		}
+	}
+	return cluster
+}
+
+// onInboundRoute runs the plugin chain on a route configuration for a service instance of the proxy.
+func onInboundRoute(env model.Environment, node model.Proxy, service *model.Service,
+	routeConfig *xdsapi.RouteConfiguration) *xdsapi.RouteConfiguration {
+	for _, p := range istionetworking.NewPlugins() {
+		if p.OnInboundRoute == nil {
+			continue
+		}
+		if out := p.OnInboundRoute(env, node, service, routeConfig); out != nil {
+			routeConfig = out
+		}
+	}
+	return routeConfig
+}
+
+// onOutboundRoute runs the plugin chain on an outbound route configuration.
+func onOutboundRoute(env model.Environment, node model.Proxy,
+	routeConfig *xdsapi.RouteConfiguration) *xdsapi.RouteConfiguration {
+	for _, p := range istionetworking.NewPlugins() {
+		if p.OnOutboundRoute == nil {
+			continue
+		}
+		if out := p.OnOutboundRoute(env, node, nil, routeConfig); out != nil {
+			routeConfig = out
+		}
+	}
+	return routeConfig
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"sync"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
+)
+
+var (
+	registerRoutePlugin sync.Once
+
+	// routePluginEnabled turns the test plugin on, plugins can't be unregistered
+	routePluginEnabled bool
+)
+
+// enableRoutePlugin registers a plugin adding a virtual host to the route configurations, named
+// after the direction and the service.
+func enableRoutePlugin() func() {
+	addVirtualHost := func(name string, rc *xdsapi.RouteConfiguration) *xdsapi.RouteConfiguration {
+		if !routePluginEnabled {
+			return nil
+		}
+		rc.VirtualHosts = append(rc.VirtualHosts, route.VirtualHost{Name: name, Domains: []string{name}})
+		return rc
+	}
+
+	registerRoutePlugin.Do(func() {
+		istionetworking.RegisterPlugin(&istionetworking.PluginCallbacks{
+			OnInboundRoute: func(_ model.Environment, _ model.Proxy, service *model.Service,
+				rc *xdsapi.RouteConfiguration) *xdsapi.RouteConfiguration {
+				return addVirtualHost("inbound|"+service.Hostname, rc)
+			},
+			OnOutboundRoute: func(_ model.Environment, _ model.Proxy, service *model.Service,
+				rc *xdsapi.RouteConfiguration) *xdsapi.RouteConfiguration {
+				if service != nil {
+					return nil
+				}
+				return addVirtualHost("outbound", rc)
+			},
+		})
+	})
+
+	routePluginEnabled = true
+	return func() { routePluginEnabled = false }
+}
+
+func lastVirtualHost(rc *xdsapi.RouteConfiguration) string {
+	if rc == nil || len(rc.VirtualHosts) == 0 {
+		return ""
+	}
+	return rc.VirtualHosts[len(rc.VirtualHosts)-1].Name
+}
+
+func TestRoutePlugins(t *testing.T) {
+	defer enableRoutePlugin()()
+
+	env := model.Environment{
+		IstioConfigStore: model.MakeIstioStore(memory.Make(model.IstioConfigTypes)),
+	}
+	node := model.Proxy{Type: model.Sidecar}
+	svc := makeExternalService("api.example.com", 80, model.ProtocolHTTP)
+
+	rc := BuildSidecarOutboundHTTPRouteConfig(env, node, nil, []*model.Service{svc}, "80")
+	if got := lastVirtualHost(rc); got != "outbound" {
+		t.Errorf("got last outbound virtual host %q, want the one added by the plugin", got)
+	}
+
+	instance := &model.ServiceInstance{
+		Service:  svc,
+		Endpoint: model.NetworkEndpoint{Address: "10.0.0.1", Port: 8080, ServicePort: svc.Ports[0]},
+	}
+	rc = buildInboundHTTPRouteConfig(env, node, instance)
+	if got := lastVirtualHost(rc); got != "inbound|api.example.com" {
+		t.Errorf("got last inbound virtual host %q, want the one added by the plugin", got)
+	}
+}
@@ -0,0 +1,419 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/util"
+	google_protobuf "github.com/gogo/protobuf/types"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// BuildRoutes produces the route configuration with the given name for a proxy. This is the RDS output.
+// Sidecar routes are named after the outbound service port, or RDSHttpProxy for the HTTP proxy port.
+// Router (gateway) routes are named after the gateway server port.
+func BuildRoutes(env model.Environment, node model.Proxy, routeName string) (*xdsapi.RouteConfiguration, error) {
+	var out *xdsapi.RouteConfiguration
+	var err error
+	switch node.Type {
+	case model.Sidecar:
+		out, err = buildSidecarRoutes(env, node, routeName)
+	case model.Router:
+		out, err = buildGatewayRoutes(env, node, routeName)
+	default:
+		return nil, fmt.Errorf("routes are not supported for %s proxies", node.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, fmt.Errorf("unknown route %q", routeName)
+	}
+	return out, nil
+}
+
+// buildSidecarRoutes produces the outbound route configuration for a sidecar
+func buildSidecarRoutes(env model.Environment, node model.Proxy, routeName string) (*xdsapi.RouteConfiguration, error) {
+	proxyInstances, err := env.GetProxyServiceInstances(node)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := env.Services()
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure services are ordered to simplify generation logic
+	sort.Slice(services, func(i, j int) bool { return services[i].Hostname < services[j].Hostname })
+
+	return BuildSidecarOutboundHTTPRouteConfig(env, node, proxyInstances, services, routeName), nil
+}
+
+// buildGatewayRoutes produces the route configuration for a gateway server port, using the virtual
+// services bound to the gateways exposing the port.
+func buildGatewayRoutes(env model.Environment, node model.Proxy, routeName string) (*xdsapi.RouteConfiguration, error) {
+	port, err := strconv.Atoi(routeName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway route name %q: %v", routeName, err)
+	}
+
+	gateways, err := env.IstioConfigStore.List(model.Gateway.Type, model.NamespaceAll)
+	if err != nil {
+		return nil, fmt.Errorf("listing gateways: %s", err)
+	}
+
+	gatewayNames := make(map[string]bool)
+	for _, config := range gateways {
+		gateway := config.Spec.(*networking.Gateway)
+		for _, server := range gateway.Servers {
+			if server.Port != nil && int(server.Port.Number) == port {
+				gatewayNames[config.Name] = true
+				break
+			}
+		}
+	}
+	names := make([]string, 0, len(gatewayNames))
+	for name := range gatewayNames {
+		names = append(names, name)
+	}
+
+	services, err := env.Services()
+	if err != nil {
+		return nil, err
+	}
+	nameToServiceMap := make(map[string]*model.Service, len(services))
+	for _, svc := range services {
+		nameToServiceMap[svc.Hostname] = svc
+	}
+	serviceByName := TranslateServiceHostname(nameToServiceMap, node.Domain)
+
+	virtualHosts := make([]route.VirtualHost, 0)
+	for _, virtualService := range env.VirtualServices(names) {
+		for _, guardedHost := range TranslateVirtualHost(virtualService, serviceByName, nil) {
+			routes := make([]route.Route, 0, len(guardedHost.Routes))
+			for _, r := range guardedHost.Routes {
+				if gatewayMatches(r.Gateways, gatewayNames) {
+					routes = append(routes, r.Route)
+				}
+			}
+			if len(routes) == 0 {
+				continue
+			}
+
+			hosts := append([]string{}, guardedHost.Hosts...)
+			for _, svc := range guardedHost.Services {
+				hosts = append(hosts, svc.Hostname)
+			}
+
+			virtualHosts = append(virtualHosts, route.VirtualHost{
+				Name:    fmt.Sprintf("%s:%d", virtualService.Name, port),
+				Domains: hosts,
+				Routes:  routes,
+			})
+		}
+	}
+	// the first virtual service claiming a host wins
+	virtualHosts = dedupeDomains(virtualHosts)
+
+	out := &xdsapi.RouteConfiguration{
+		Name:         routeName,
+		VirtualHosts: virtualHosts,
+		ValidateClusters: &google_protobuf.BoolValue{
+			Value: false,
+		},
+	}
+	return onOutboundRoute(env, node, out), nil
+}
+
+// gatewayMatches returns true if a route with the given gateway pre-condition applies to
+// one of the gateways.
+func gatewayMatches(routeGateways []string, gateways map[string]bool) bool {
+	if len(routeGateways) == 0 {
+		return true
+	}
+	for _, gateway := range routeGateways {
+		if gateways[gateway] {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceMatches returns true if a route guarded by the given source labels applies to one
+// of the proxy instances.
+func sourceMatches(sourceLabels map[string]string, proxyInstances []*model.ServiceInstance) bool {
+	if len(sourceLabels) == 0 {
+		return true
+	}
+	for _, instance := range proxyInstances {
+		if model.Labels(sourceLabels).SubsetOf(instance.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildDefaultHTTPRoute builds a default route.
+func buildDefaultHTTPRoute(clusterName string) *route.Route {
+	return &route.Route{
+		Match: route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+		Decorator: &route.Decorator{
+			Operation: DefaultOperation,
+		},
+		Action: &route.Route_Route{
+			Route: &route.RouteAction{
+				ClusterSpecifier: &route.RouteAction_Cluster{Cluster: clusterName},
+			},
+		},
+	}
+}
+
+// buildInboundHTTPRouteConfig builds the route config with a single wildcard virtual host on the inbound path
+// TODO: enable mixer configuration, websockets, trace decorators
+func buildInboundHTTPRouteConfig(env model.Environment, node model.Proxy, instance *model.ServiceInstance) *xdsapi.RouteConfiguration {
+	clusterName := model.BuildSubsetKey(model.TrafficDirectionInbound, "",
+		instance.Service.Hostname, instance.Endpoint.ServicePort)
+	defaultRoute := buildDefaultHTTPRoute(clusterName)
+
+	inboundVHost := route.VirtualHost{
+		Name:    fmt.Sprintf("%s|http|%d", model.TrafficDirectionInbound, instance.Endpoint.ServicePort.Port),
+		Domains: []string{"*"},
+		Routes:  []route.Route{*defaultRoute},
+	}
+
+	// TODO: mixer disabled for now as its configuration is still in old format
+	// set server-side mixer filter config for inbound HTTP routes
+	//if mesh.MixerCheckServer != "" || mesh.MixerReportServer != "" {
+	//	defaultRoute.OpaqueConfig = v1.BuildMixerOpaqueConfig(!mesh.DisablePolicyChecks, false, instance.Service.Hostname)
+	//}
+
+	out := &xdsapi.RouteConfiguration{
+		Name:         clusterName,
+		VirtualHosts: []route.VirtualHost{inboundVHost},
+		ValidateClusters: &google_protobuf.BoolValue{
+			Value: false,
+		},
+	}
+	return onInboundRoute(env, node, instance.Service, out)
+}
+
+// BuildSidecarOutboundHTTPRouteConfig builds the outbound route config for a sidecar. The route name is
+// either a port number, producing the virtual hosts for that service port, or RDSHttpProxy, which merges
+// the virtual hosts for all ports.
+func BuildSidecarOutboundHTTPRouteConfig(env model.Environment, node model.Proxy, proxyInstances []*model.ServiceInstance,
+	services []*model.Service, routeName string) *xdsapi.RouteConfiguration {
+	out, _ := buildSidecarOutboundHTTPRoutes(env, node, proxyInstances, services, routeName)
+	return out
+}
+
+// buildSidecarOutboundHTTPRoutes builds the outbound route config for a sidecar, and the fault filters
+// for the routes. Envoy applies faults in the HTTP filter chain, so the filters are installed on the
+// listener serving the route.
+func buildSidecarOutboundHTTPRoutes(env model.Environment, node model.Proxy, proxyInstances []*model.ServiceInstance,
+	services []*model.Service, routeName string) (*xdsapi.RouteConfiguration, []*http_conn.HttpFilter) {
+
+	port := 0
+	if routeName != RDSHttpProxy {
+		var err error
+		port, err = strconv.Atoi(routeName)
+		if err != nil {
+			return nil, nil
+		}
+	}
+
+	nameToServiceMap := make(map[string]*model.Service)
+	for _, svc := range services {
+		if port == 0 {
+			nameToServiceMap[svc.Hostname] = svc
+		} else {
+			if svcPort, exists := svc.Ports.GetByPort(port); exists {
+				nameToServiceMap[svc.Hostname] = &model.Service{
+					Hostname:     svc.Hostname,
+					Address:      svc.Address,
+					Ports:        []*model.Port{svcPort},
+					MeshExternal: svc.MeshExternal,
+					Resolution:   svc.Resolution,
+				}
+			}
+		}
+	}
+
+	// Get list of virtual services bound to the mesh gateway
+	virtualServices := env.VirtualServices([]string{model.IstioMeshGateway})
+	guardedHosts := TranslateVirtualHosts(virtualServices,
+		nameToServiceMap, nil, node.Domain)
+	vHostPortMap := make(map[int][]route.VirtualHost)
+	faults := make([]*http_conn.HttpFilter, 0)
+
+	// there should be only one guarded host in the return val since we supplied services with just one port
+	for _, guardedHost := range guardedHosts {
+		routes := make([]route.Route, 0)
+		for _, r := range guardedHost.Routes {
+			// routes guarded by source labels apply only if the proxy is one of the sources
+			if !sourceMatches(r.SourceLabels, proxyInstances) {
+				continue
+			}
+			routes = append(routes, r.Route)
+			for _, fault := range r.Faults {
+				faults = append(faults, &http_conn.HttpFilter{
+					Name:   util.Fault,
+					Config: messageToStruct(fault),
+				})
+			}
+		}
+
+		virtualHosts := make([]route.VirtualHost, 0)
+
+		for _, host := range guardedHost.Hosts {
+			virtualHosts = append(virtualHosts, route.VirtualHost{
+				Name:    fmt.Sprintf("%s:%d", host, guardedHost.Port),
+				Domains: []string{host},
+				Routes:  routes,
+			})
+		}
+
+		for _, svc := range guardedHost.Services {
+			var domains []string
+			if svc.MeshExternal {
+				// External hosts are matched as written, short names would turn a wildcard host
+				// such as *.googleapis.com into "*".
+				domains = []string{svc.Hostname, fmt.Sprintf("%s:%d", svc.Hostname, guardedHost.Port)}
+			} else {
+				domains = generateAltVirtualHosts(svc.Hostname, guardedHost.Port)
+			}
+			if len(svc.Address) > 0 {
+				// add a vhost match for the IP (if its non CIDR)
+				cidr := convertAddressToCidr(svc.Address)
+				if cidr.PrefixLen.Value == 32 {
+					domains = append(domains, svc.Address)
+					domains = append(domains, fmt.Sprintf("%s:%d", svc.Address, guardedHost.Port))
+				}
+			}
+			virtualHosts = append(virtualHosts, route.VirtualHost{
+				Name:    fmt.Sprintf("%s:%d", svc.Hostname, guardedHost.Port),
+				Domains: domains,
+				Routes:  routes,
+			})
+		}
+
+		vHostPortMap[guardedHost.Port] = append(vHostPortMap[guardedHost.Port], virtualHosts...)
+	}
+
+	var virtualHosts []route.VirtualHost
+	if routeName == RDSHttpProxy {
+		virtualHosts = mergeAllVirtualHosts(vHostPortMap)
+	} else {
+		virtualHosts = vHostPortMap[port]
+	}
+	// services sharing a short name or an address generate the same domains
+	virtualHosts = dedupeDomains(virtualHosts)
+
+	out := &xdsapi.RouteConfiguration{
+		Name:         routeName,
+		VirtualHosts: virtualHosts,
+		ValidateClusters: &google_protobuf.BoolValue{
+			Value: false,
+		},
+	}
+	return onOutboundRoute(env, node, out), faults
+}
+
+// Given a service, and a port, this function generates all possible HTTP Host headers.
+// For example, a service of the form foo.local.campus.net on port 80 could be accessed as
+// http://foo:80 within the .local network, as http://foo.local:80 (by other clients in the campus.net domain),
+// as http://foo.local.campus:80, etc.
+func generateAltVirtualHosts(hostname string, port int) []string {
+	vhosts := []string{hostname, fmt.Sprintf("%s:%d", hostname, port)}
+	for i := len(hostname) - 1; i >= 0; i-- {
+		if hostname[i] == '.' {
+			variant := hostname[:i]
+			variantWithPort := fmt.Sprintf("%s:%d", variant, port)
+			vhosts = append(vhosts, variant)
+			vhosts = append(vhosts, variantWithPort)
+		}
+	}
+	return vhosts
+}
+
+// mergeAllVirtualHosts across all ports. On routes for ports other than port 80,
+// virtual hosts without an explicit port suffix (IP:PORT) should be stripped
+func mergeAllVirtualHosts(vHostPortMap map[int][]route.VirtualHost) []route.VirtualHost {
+	// port 80 first, so that its virtual hosts keep the domains without a port
+	ports := make([]int, 0, len(vHostPortMap))
+	for p := range vHostPortMap {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i] == 80 || ports[j] == 80 {
+			return ports[i] == 80 && ports[j] != 80
+		}
+		return ports[i] < ports[j]
+	})
+
+	var virtualHosts []route.VirtualHost
+	for _, p := range ports {
+		vhosts := vHostPortMap[p]
+		if p == 80 {
+			virtualHosts = append(virtualHosts, vhosts...)
+		} else {
+			for _, vhost := range vhosts {
+				var newDomains []string
+				for _, domain := range vhost.Domains {
+					if strings.Contains(domain, ":") {
+						newDomains = append(newDomains, domain)
+					}
+				}
+				if len(newDomains) > 0 {
+					vhost.Domains = newDomains
+					virtualHosts = append(virtualHosts, vhost)
+				}
+			}
+		}
+	}
+	return virtualHosts
+}
+
+// dedupeDomains removes the domains claimed by a previous virtual host, as envoy rejects route
+// configurations with the same domain in multiple virtual hosts. Virtual hosts left without
+// domains are dropped.
+func dedupeDomains(virtualHosts []route.VirtualHost) []route.VirtualHost {
+	out := make([]route.VirtualHost, 0, len(virtualHosts))
+	domains := make(map[string]bool)
+	for _, vhost := range virtualHosts {
+		vhostDomains := make([]string, 0, len(vhost.Domains))
+		for _, domain := range vhost.Domains {
+			if !domains[domain] {
+				domains[domain] = true
+				vhostDomains = append(vhostDomains, domain)
+			}
+		}
+		if len(vhostDomains) == 0 {
+			continue
+		}
+		vhost.Domains = vhostDomains
+		out = append(out, vhost)
+	}
+	return out
+}
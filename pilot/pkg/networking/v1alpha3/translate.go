@@ -23,6 +23,8 @@ import (
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	fault "github.com/envoyproxy/go-control-plane/envoy/config/filter/fault/v2"
+	http_fault "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
 	"github.com/gogo/protobuf/types"
 
 	networking "istio.io/api/networking/v1alpha3"
@@ -167,7 +169,7 @@ func TranslateVirtualHost(in model.Config, serviceByName ServiceByName, subsetSe
 		serviceByPort[80] = nil
 	}
 
-	out := make([]GuardedHost, len(serviceByPort))
+	out := make([]GuardedHost, 0, len(serviceByPort))
 	for port, services := range serviceByPort {
 		clusterNaming := TranslateDestination(serviceByName, subsetSelector, in.ConfigMeta.Namespace, port)
 		routes := TranslateRoutes(in, clusterNaming)
@@ -229,6 +231,9 @@ type GuardedRoute struct {
 
 	// Gateways pre-condition
 	Gateways []string
+
+	// Faults to inject on requests matching the route, one for each destination cluster
+	Faults []*http_fault.HTTPFault
 }
 
 // TranslateRoutes creates virtual host routes from the v1alpha3 config.
@@ -257,7 +262,6 @@ func TranslateRoutes(in model.Config, name ClusterNaming) []GuardedRoute {
 }
 
 // TranslateRoute translates HTTP routes
-// TODO: fault filters -- issue https://github.com/istio/api/issues/388
 func TranslateRoute(in *networking.HTTPRoute,
 	match *networking.HTTPMatchRequest,
 	operation string,
@@ -269,6 +273,8 @@ func TranslateRoute(in *networking.HTTPRoute,
 		},
 	}
 
+	var faults []*http_fault.HTTPFault
+
 	if redirect := in.Redirect; redirect != nil {
 		out.Action = &route.Route_Redirect{
 			Redirect: &route.RedirectAction{
@@ -294,7 +300,7 @@ func TranslateRoute(in *networking.HTTPRoute,
 		}
 
 		if len(in.AppendHeaders) > 0 {
-			action.RequestHeadersToAdd = make([]*core.HeaderValueOption, len(in.AppendHeaders))
+			action.RequestHeadersToAdd = make([]*core.HeaderValueOption, 0, len(in.AppendHeaders))
 			for key, value := range in.AppendHeaders {
 				action.RequestHeadersToAdd = append(action.RequestHeadersToAdd, &core.HeaderValueOption{
 					Header: &core.HeaderValue{
@@ -309,7 +315,7 @@ func TranslateRoute(in *networking.HTTPRoute,
 			action.RequestMirrorPolicy = &route.RouteAction_RequestMirrorPolicy{Cluster: name(in.Mirror)}
 		}
 
-		weighted := make([]*route.WeightedCluster_ClusterWeight, len(in.Route))
+		weighted := make([]*route.WeightedCluster_ClusterWeight, 0, len(in.Route))
 		for _, dst := range in.Route {
 			weighted = append(weighted, &route.WeightedCluster_ClusterWeight{
 				Name:   name(dst.Destination),
@@ -327,12 +333,21 @@ func TranslateRoute(in *networking.HTTPRoute,
 				},
 			}
 		}
+
+		if in.Fault != nil {
+			for _, cluster := range weighted {
+				if f := TranslateFault(in.Fault, cluster.Name, out.Match.Headers); f != nil {
+					faults = append(faults, f)
+				}
+			}
+		}
 	}
 
 	return GuardedRoute{
 		Route:        out,
 		SourceLabels: match.GetSourceLabels(),
 		Gateways:     match.GetGateways(),
+		Faults:       faults,
 	}
 }
 
@@ -409,6 +424,46 @@ func TranslateHeaderMatcher(name string, in *networking.StringMatch) route.Heade
 	return out
 }
 
+// TranslateFault translates the fault injection policy for a destination cluster. The fault applies to
+// requests matching the headers of the route.
+func TranslateFault(in *networking.HTTPFaultInjection, cluster string, headers []*route.HeaderMatcher) *http_fault.HTTPFault {
+	if in == nil {
+		return nil
+	}
+
+	out := &http_fault.HTTPFault{
+		UpstreamCluster: cluster,
+		Headers:         headers,
+	}
+
+	if abort := in.Abort; abort != nil && abort.Percent > 0 {
+		if status, ok := abort.ErrorType.(*networking.HTTPFaultInjection_Abort_HttpStatus); ok {
+			out.Abort = &http_fault.FaultAbort{
+				Percent:   uint32(abort.Percent),
+				ErrorType: &http_fault.FaultAbort_HttpStatus{HttpStatus: uint32(status.HttpStatus)},
+			}
+		}
+	}
+
+	if delay := in.Delay; delay != nil && delay.Percent > 0 {
+		if fixed, ok := delay.HttpDelayType.(*networking.HTTPFaultInjection_Delay_FixedDelay); ok {
+			d := convertGogoDurationToDuration(fixed.FixedDelay)
+			out.Delay = &fault.FaultDelay{
+				Type: fault.FaultDelay_FIXED,
+				FaultDelayType: &fault.FaultDelay_FixedDelay{
+					FixedDelay: &d,
+				},
+				Percent: uint32(delay.Percent),
+			}
+		}
+	}
+
+	if out.Abort == nil && out.Delay == nil {
+		return nil
+	}
+	return out
+}
+
 // TranslateRetryPolicy translates retry policy
 func TranslateRetryPolicy(in *networking.HTTPRetry) *route.RouteAction_RetryPolicy {
 	if in != nil && in.Attempts > 0 {
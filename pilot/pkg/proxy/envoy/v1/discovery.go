@@ -117,10 +117,17 @@ var (
 	clearCacheTimerSet bool
 	clearCacheMutex    sync.Mutex
 	clearCacheTime     = 1
+	// clearCacheV2Pending is set if a squashed clear must also invoke V2ClearCache.
+	clearCacheV2Pending bool
 
 	// V2ClearCache is a function to be called when the v1 cache is cleared. This is used to
 	// avoid adding a circular dependency from v1 to v2.
 	V2ClearCache func()
+
+	// V2IncrementalEDS is set when the v2 server handles registry instance events itself,
+	// pushing only the affected endpoints, and the listeners and clusters of the proxies
+	// running the changed instances. Instance events then only clear the v1 caches.
+	V2IncrementalEDS bool
 )
 
 func init() {
@@ -353,7 +360,7 @@ func NewDiscoveryService(ctl model.Controller, configCache model.ConfigStoreCach
 	if err := ctl.AppendServiceHandler(serviceHandler); err != nil {
 		return nil, err
 	}
-	instanceHandler := func(*model.ServiceInstance, model.Event) { out.clearCache() }
+	instanceHandler := func(*model.ServiceInstance, model.Event) { out.clearCaches(!V2IncrementalEDS) }
 	if err := ctl.AppendInstanceHandler(instanceHandler); err != nil {
 		return nil, err
 	}
@@ -470,9 +477,16 @@ func (ds *DiscoveryService) ClearCacheStats(_ *restful.Request, _ *restful.Respo
 // clearCache will clear all envoy caches. Called by service, instance and config handlers.
 // This will impact the performance, since envoy will need to recalculate.
 func (ds *DiscoveryService) clearCache() {
+	ds.clearCaches(true)
+}
+
+// clearCaches clears the v1 caches, and if pushV2 is set triggers a full v2 push as well.
+func (ds *DiscoveryService) clearCaches(pushV2 bool) {
 	clearCacheMutex.Lock()
 	defer clearCacheMutex.Unlock()
 
+	clearCacheV2Pending = clearCacheV2Pending || pushV2
+
 	if time.Since(lastClearCache) < time.Duration(clearCacheTime)*time.Second {
 		if !clearCacheTimerSet {
 			clearCacheTimerSet = true
@@ -480,7 +494,7 @@ func (ds *DiscoveryService) clearCache() {
 				clearCacheMutex.Lock()
 				clearCacheTimerSet = false
 				clearCacheMutex.Unlock()
-				ds.clearCache() // it's after time - so will clear the cache
+				ds.clearCaches(false) // it's after time - so will clear the cache, and push v2 if pending
 			})
 		}
 		return
@@ -492,9 +506,10 @@ func (ds *DiscoveryService) clearCache() {
 	ds.cdsCache.clear()
 	ds.rdsCache.clear()
 	ds.ldsCache.clear()
-	if V2ClearCache != nil {
+	if clearCacheV2Pending && V2ClearCache != nil {
 		V2ClearCache()
 	}
+	clearCacheV2Pending = false
 }
 
 // ListAllEndpoints responds with all Services and is not restricted to a single service-key
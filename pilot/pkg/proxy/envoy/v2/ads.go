@@ -0,0 +1,497 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/v1alpha3"
+	"istio.io/istio/pkg/log"
+)
+
+// ADS multiplexes CDS, EDS, LDS and RDS over a single gRPC stream. Envoy may otherwise
+// receive a listener referencing a cluster it doesn't know about yet, since the separate
+// streams are not ordered. On each push ADS sends the watched types in dependency order:
+// clusters, then endpoints, then listeners and finally routes.
+
+var (
+	adsDebug = os.Getenv("PILOT_DEBUG_ADS") != "0"
+
+	adsClientsMutex sync.RWMutex
+	adsClients      = map[string]*XdsConnection{}
+)
+
+// XdsConnection is a connection from an envoy using the aggregated discovery service.
+type XdsConnection struct {
+	// PeerAddr is the address of the client envoy, from network layer
+	PeerAddr string
+
+	// Time of connection, for debugging
+	Connect time.Time
+
+	// ConID is the connection identifier, used as a key in the connection table.
+	// Currently based on the node name and a counter.
+	ConID string
+
+	modelNode *model.Proxy
+
+	// Sending on this channel results in a push of all watched types, in order.
+	pushChannel chan struct{}
+
+	// edsCon tracks the clusters watched for EDS over this stream. It is registered with
+	// each EdsCluster, so endpoint changes can be pushed to the connection.
+	edsCon *EdsConnection
+
+	// mutex protects Routes, Watches and the clusters of edsCon, which are read by the debug
+	// handler and when the stream is closed.
+	mutex sync.RWMutex
+
+	// Routes is the list of route configurations requested by envoy.
+	Routes []string
+
+	// Watches holds the state of each type requested over the stream, keyed by type URL.
+	Watches map[string]*XdsWatch
+}
+
+// XdsWatch tracks the versions and nonces exchanged with envoy for a single resource type.
+type XdsWatch struct {
+	// NonceSent is the nonce of the last response sent for the type. Requests carrying
+	// a different nonce are stale and are ignored.
+	NonceSent string
+
+	// VersionSent is the version of the last response sent for the type.
+	VersionSent string
+
+	// VersionAcked is the last version envoy accepted.
+	VersionAcked string
+
+	// LastNack is the error detail of the last rejected response, if any.
+	LastNack string
+}
+
+// StreamAggregatedResources implements the ADS interface.
+func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	peerInfo, ok := peer.FromContext(stream.Context())
+	peerAddr := unknownPeerAddressStr
+	if ok {
+		peerAddr = peerInfo.Addr.String()
+	}
+	var discReq *xdsapi.DiscoveryRequest
+	var receiveError error
+	reqChannel := make(chan *xdsapi.DiscoveryRequest, 1)
+
+	con := &XdsConnection{
+		pushChannel: make(chan struct{}, 1),
+		PeerAddr:    peerAddr,
+		Connect:     time.Now(),
+		Watches:     map[string]*XdsWatch{},
+		edsCon: &EdsConnection{
+			pushChannel: make(chan bool, 1),
+			PeerAddr:    peerAddr,
+			Clusters:    []string{},
+			Connect:     time.Now(),
+			ads:         true,
+		},
+	}
+	go func() {
+		defer close(reqChannel)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				log.Errorf("ADS: close for client %s %q terminated with errors %v",
+					con.ConID, peerAddr, err)
+				s.removeAdsCon(con)
+				if status.Code(err) == codes.Canceled || err == io.EOF {
+					return
+				}
+				receiveError = err
+				return
+			}
+			reqChannel <- req
+		}
+	}()
+
+	for {
+		// Block until either a request is received or a push is triggered.
+		select {
+		case discReq, ok = <-reqChannel:
+			if !ok {
+				return receiveError
+			}
+			if con.modelNode == nil {
+				if discReq.Node == nil {
+					return status.Errorf(codes.InvalidArgument, "missing node in initial ADS request")
+				}
+				nt, err := model.ParseServiceNode(discReq.Node.Id)
+				if err != nil {
+					return err
+				}
+				con.modelNode = &nt
+				con.ConID = connectionID(discReq.Node.Id)
+				addAdsCon(con.ConID, con)
+			}
+			s.setNode(con.edsCon, discReq.Node)
+
+			if !con.acceptRequest(discReq) {
+				continue
+			}
+
+			var err error
+			switch discReq.TypeUrl {
+			case clusterType:
+				err = s.pushCds(con, stream)
+			case endpointType:
+				clusters := discReq.GetResourceNames()
+				s.updateAdsEdsWatch(con, clusters)
+				err = s.pushEds(con, stream)
+			case listenerType:
+				err = s.pushLds(con, stream)
+			case routeType:
+				con.mutex.Lock()
+				con.Routes = discReq.GetResourceNames()
+				con.mutex.Unlock()
+				err = s.pushRoute(con, stream)
+			default:
+				log.Warnf("ADS: Unknown watched resources %s", discReq.String())
+			}
+			if err != nil {
+				return err
+			}
+
+		case <-con.edsCon.pushChannel:
+			if err := s.pushEds(con, stream); err != nil {
+				return err
+			}
+
+		case <-con.pushChannel:
+			// Full pushes and the instance events of the proxy may change its locality.
+			con.edsCon.invalidateLocality()
+			if err := s.pushAll(con, stream); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// acceptRequest records the request in the per-type watch state and returns true if the
+// request needs a response. ACKs and NACKs for the last response, as well as requests carrying
+// a stale nonce, don't need a response - unless the set of watched resources has changed.
+func (con *XdsConnection) acceptRequest(discReq *xdsapi.DiscoveryRequest) bool {
+	con.mutex.Lock()
+	defer con.mutex.Unlock()
+
+	w := con.Watches[discReq.TypeUrl]
+	if w == nil {
+		w = &XdsWatch{}
+		con.Watches[discReq.TypeUrl] = w
+	}
+
+	if discReq.ResponseNonce == "" || w.NonceSent == "" {
+		// Initial request for the type, or envoy reconnecting with a nonce from a previous stream.
+		if adsDebug {
+			log.Infof("ADS: REQ %s %s %v raw: %s", con.ConID, con.PeerAddr, discReq.TypeUrl, discReq.String())
+		}
+		return true
+	}
+
+	if discReq.ResponseNonce != w.NonceSent {
+		// Envoy is responding to an older push, a newer one is already on the way.
+		if adsDebug {
+			log.Infof("ADS: stale nonce %s %s %s (expecting %s)", con.ConID, discReq.TypeUrl,
+				discReq.ResponseNonce, w.NonceSent)
+		}
+		return false
+	}
+
+	if discReq.ErrorDetail != nil {
+		w.LastNack = discReq.ErrorDetail.String()
+		log.Warnf("ADS: NACK %s %s %s %v", con.PeerAddr, con.ConID, discReq.TypeUrl, discReq.String())
+	} else {
+		w.VersionAcked = discReq.VersionInfo
+		w.LastNack = ""
+		if adsDebug {
+			log.Infof("ADS: ACK %s %s %s", con.ConID, discReq.TypeUrl, discReq.VersionInfo)
+		}
+	}
+
+	// Envoy sends the full list of watched names in each request, for EDS and RDS
+	// a change in the list means new resources must be sent.
+	switch discReq.TypeUrl {
+	case endpointType:
+		return !sameNames(con.edsCon.Clusters, discReq.GetResourceNames())
+	case routeType:
+		return !sameNames(con.Routes, discReq.GetResourceNames())
+	}
+	return false
+}
+
+// send marshals and sends a response on the stream, recording the nonce and version.
+func (con *XdsConnection) send(stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer,
+	response *xdsapi.DiscoveryResponse) error {
+	con.mutex.Lock()
+	w := con.Watches[response.TypeUrl]
+	if w == nil {
+		w = &XdsWatch{}
+		con.Watches[response.TypeUrl] = w
+	}
+	w.NonceSent = response.Nonce
+	w.VersionSent = response.VersionInfo
+	con.mutex.Unlock()
+
+	if err := stream.Send(response); err != nil {
+		log.Warnf("ADS: Send failure %s %s, closing grpc %v", con.ConID, response.TypeUrl, err)
+		return err
+	}
+	return nil
+}
+
+// watched returns true if envoy requested the given type on this connection.
+func (con *XdsConnection) watched(typeURL string) bool {
+	con.mutex.RLock()
+	defer con.mutex.RUnlock()
+	return con.Watches[typeURL] != nil
+}
+
+// pushAll sends all the types watched by the connection, in the order required by envoy:
+// CDS, EDS, LDS and then RDS.
+func (s *DiscoveryServer) pushAll(con *XdsConnection, stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	if con.watched(clusterType) {
+		if err := s.pushCds(con, stream); err != nil {
+			return err
+		}
+	}
+	if len(con.edsCon.Clusters) > 0 {
+		if err := s.pushEds(con, stream); err != nil {
+			return err
+		}
+	}
+	if con.watched(listenerType) {
+		if err := s.pushLds(con, stream); err != nil {
+			return err
+		}
+	}
+	if len(con.Routes) > 0 {
+		if err := s.pushRoute(con, stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DiscoveryServer) pushCds(con *XdsConnection, stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	rawClusters := v1alpha3.BuildClusters(s.env, *con.modelNode)
+	response := cdsDiscoveryResponse(rawClusters)
+	if err := con.send(stream, response); err != nil {
+		return err
+	}
+	if adsDebug {
+		log.Infof("ADS: CDS PUSH for %s %q clusters:%d", con.ConID, con.PeerAddr, len(rawClusters))
+	}
+	return nil
+}
+
+func (s *DiscoveryServer) pushEds(con *XdsConnection, stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	if len(con.edsCon.Clusters) == 0 {
+		// Corner case: push received before envoy watched any cluster.
+		return nil
+	}
+	s.refreshLocality(con.edsCon)
+	response := s.endpoints(con.edsCon.Clusters, con.edsCon.Locality)
+	if err := con.send(stream, response); err != nil {
+		return err
+	}
+	if adsDebug {
+		log.Infof("ADS: EDS PUSH for %s %q clusters:%d", con.ConID, con.PeerAddr, len(con.edsCon.Clusters))
+	}
+	return nil
+}
+
+func (s *DiscoveryServer) pushLds(con *XdsConnection, stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	ls, err := v1alpha3.BuildListeners(s.env, *con.modelNode)
+	if err != nil {
+		log.Warnf("ADS: LDS config failure, closing grpc %v", err)
+		return err
+	}
+	response, err := ldsDiscoveryResponse(ls, *con.modelNode)
+	if err != nil {
+		log.Warnf("ADS: LDS config failure, closing grpc %v", err)
+		return err
+	}
+	if err = con.send(stream, response); err != nil {
+		return err
+	}
+	if adsDebug {
+		log.Infof("ADS: LDS PUSH for %s %q listeners:%d", con.ConID, con.PeerAddr, len(ls))
+	}
+	return nil
+}
+
+func (s *DiscoveryServer) pushRoute(con *XdsConnection, stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	rc := s.generateRawRoutes(con.Routes, *con.modelNode)
+	response := routeDiscoveryResponse(rc)
+	if err := con.send(stream, response); err != nil {
+		return err
+	}
+	if adsDebug {
+		log.Infof("ADS: RDS PUSH for %s %q routes:%v", con.ConID, con.PeerAddr, con.Routes)
+	}
+	return nil
+}
+
+// updateAdsEdsWatch updates the list of clusters watched over the ADS connection, and
+// registers the connection with the EdsCluster of each newly watched cluster.
+func (s *DiscoveryServer) updateAdsEdsWatch(con *XdsConnection, clusters []string) {
+	con.mutex.Lock()
+	previous := con.edsCon.Clusters
+	con.edsCon.Clusters = clusters
+	con.mutex.Unlock()
+
+	watched := map[string]bool{}
+	for _, c := range clusters {
+		watched[c] = true
+	}
+	wasWatched := map[string]bool{}
+	for _, c := range previous {
+		wasWatched[c] = true
+		if !watched[c] {
+			s.removeEdsCon(c, con.ConID, con.edsCon)
+		}
+	}
+	for _, c := range clusters {
+		if !wasWatched[c] {
+			s.addEdsCon(c, con.ConID, con.edsCon)
+		}
+	}
+}
+
+// sameNames returns true if the two lists of resource names have the same elements.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := make(map[string]bool, len(a))
+	for _, n := range a {
+		m[n] = true
+	}
+	for _, n := range b {
+		if !m[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// adsPushAll pushes all watched types to all ADS connections.
+func adsPushAll() {
+	adsClientsMutex.RLock()
+	// Create a temp map to avoid locking the add/remove
+	tmpMap := map[string]*XdsConnection{}
+	for k, v := range adsClients {
+		tmpMap[k] = v
+	}
+	adsClientsMutex.RUnlock()
+
+	for _, client := range tmpMap {
+		client.pushChannel <- struct{}{}
+	}
+}
+
+// Adsz implements a status and debug interface for ADS.
+// It is mapped to /debug/adsz on the monitor port (9093).
+func Adsz(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+	if req.Form.Get("debug") != "" {
+		adsDebug = req.Form.Get("debug") == "1"
+		return
+	}
+	if req.Form.Get("push") != "" {
+		adsPushAll()
+		adsClientsMutex.RLock()
+		fmt.Fprintf(w, "Pushed to %d servers", len(adsClients))
+		adsClientsMutex.RUnlock()
+		return
+	}
+
+	// adsStatus is the debug view of a connection.
+	type adsStatus struct {
+		PeerAddr string
+		Connect  time.Time
+		Clusters []string
+		Routes   []string
+		Watches  map[string]XdsWatch
+	}
+
+	out := map[string]adsStatus{}
+	adsClientsMutex.RLock()
+	for id, con := range adsClients {
+		con.mutex.RLock()
+		st := adsStatus{
+			PeerAddr: con.PeerAddr,
+			Connect:  con.Connect,
+			Clusters: con.edsCon.Clusters,
+			Routes:   con.Routes,
+			Watches:  map[string]XdsWatch{},
+		}
+		for t, w := range con.Watches {
+			st.Watches[t] = *w
+		}
+		con.mutex.RUnlock()
+		out[id] = st
+	}
+	adsClientsMutex.RUnlock()
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	_, _ = w.Write(data)
+}
+
+func addAdsCon(s string, connection *XdsConnection) {
+	adsClientsMutex.Lock()
+	defer adsClientsMutex.Unlock()
+	adsClients[s] = connection
+}
+
+func (s *DiscoveryServer) removeAdsCon(con *XdsConnection) {
+	con.mutex.RLock()
+	clusters := con.edsCon.Clusters
+	con.mutex.RUnlock()
+	for _, c := range clusters {
+		s.removeEdsCon(c, con.ConID, con.edsCon)
+	}
+
+	adsClientsMutex.Lock()
+	defer adsClientsMutex.Unlock()
+	if adsClients[con.ConID] == nil {
+		log.Errorf("ADS: Removing connection for non-existing node %s.", con.ConID)
+	}
+	delete(adsClients, con.ConID)
+}
@@ -0,0 +1,245 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/tests/util"
+)
+
+const (
+	typePrefix   = "type.googleapis.com/envoy.api.v2."
+	clusterType  = typePrefix + "Cluster"
+	endpointType = typePrefix + "ClusterLoadAssignment"
+	listenerType = typePrefix + "Listener"
+
+	service3Cluster = "outbound|http-main||service3.default.svc.cluster.local"
+)
+
+func connectADS(url string, t *testing.T) ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient {
+	conn, err := grpc.Dial(url, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal("Connection failed", err)
+	}
+
+	xds := ads.NewAggregatedDiscoveryServiceClient(conn)
+	adsstr, err := xds.StreamAggregatedResources(context.Background())
+	if err != nil {
+		t.Fatal("Rpc failed", err)
+	}
+	return adsstr
+}
+
+func sendADSReq(t *testing.T, adsstr ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient,
+	typeURL string, names []string, nonce string) {
+	sendADSNodeReq(t, adsstr, sidecarId(app3Ip, "app3"), typeURL, names, nonce)
+}
+
+func sendADSNodeReq(t *testing.T, adsstr ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient,
+	node string, typeURL string, names []string, nonce string) {
+	err := adsstr.Send(&xdsapi.DiscoveryRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id: node,
+		},
+		TypeUrl:       typeURL,
+		ResourceNames: names,
+		ResponseNonce: nonce,
+	})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+}
+
+func recvADS(t *testing.T, adsstr ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient,
+	typeURL string) *xdsapi.DiscoveryResponse {
+	res, err := adsstr.Recv()
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if res.TypeUrl != typeURL {
+		t.Fatalf("Expecting %s got %s", typeURL, res.TypeUrl)
+	}
+	if len(res.Resources) == 0 {
+		t.Fatalf("No %s resources", typeURL)
+	}
+	return res
+}
+
+// TestAdsOrdering verifies that CDS, EDS and LDS are served over a single stream, and that a
+// push sends the watched types in order.
+func TestAdsOrdering(t *testing.T) {
+	initLocalPilotTestEnv()
+
+	adsstr := connectADS(util.MockPilotGrpcAddr, t)
+	defer func() { _ = adsstr.CloseSend() }()
+
+	sendADSReq(t, adsstr, clusterType, nil, "")
+	cds := recvADS(t, adsstr, clusterType)
+	sendADSReq(t, adsstr, clusterType, nil, cds.Nonce)
+
+	sendADSReq(t, adsstr, endpointType, []string{service3Cluster}, "")
+	eds := recvADS(t, adsstr, endpointType)
+	sendADSReq(t, adsstr, endpointType, []string{service3Cluster}, eds.Nonce)
+
+	sendADSReq(t, adsstr, listenerType, nil, "")
+	lds := recvADS(t, adsstr, listenerType)
+	sendADSReq(t, adsstr, listenerType, nil, lds.Nonce)
+
+	v2.PushAll()
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-time.After(5 * time.Second):
+			_ = adsstr.CloseSend()
+		case <-done:
+		}
+	}()
+	defer close(done)
+
+	for _, typeURL := range []string{clusterType, endpointType, listenerType} {
+		recvADS(t, adsstr, typeURL)
+	}
+}
+
+// TestAdsStaleNonce verifies that an ACK carrying an old nonce doesn't result in a response.
+func TestAdsStaleNonce(t *testing.T) {
+	initLocalPilotTestEnv()
+
+	adsstr := connectADS(util.MockPilotGrpcAddr, t)
+	defer func() { _ = adsstr.CloseSend() }()
+
+	sendADSReq(t, adsstr, clusterType, nil, "")
+	_ = recvADS(t, adsstr, clusterType)
+
+	// Stale ACK, followed by a new watch - the next response must be for the new watch.
+	sendADSReq(t, adsstr, clusterType, nil, "stale-nonce")
+	sendADSReq(t, adsstr, listenerType, nil, "")
+	_ = recvADS(t, adsstr, listenerType)
+}
+
+// recvADSWithTimeout is recvADS failing the test if no response is received within 5 seconds.
+func recvADSWithTimeout(t *testing.T, adsstr ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient,
+	typeURL string) *xdsapi.DiscoveryResponse {
+	type result struct {
+		res *xdsapi.DiscoveryResponse
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		res, err := adsstr.Recv()
+		ch <- result{res, err}
+	}()
+
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatal("Recv failed", r.err)
+		}
+		if r.res.TypeUrl != typeURL {
+			t.Fatalf("Expecting %s got %s", typeURL, r.res.TypeUrl)
+		}
+		return r.res
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timeout waiting for %s", typeURL)
+	}
+	return nil
+}
+
+// TestAdsEdsReRequest verifies that envoy can request again clusters it already watches,
+// together with new ones, and that the connection keeps receiving pushes afterwards.
+func TestAdsEdsReRequest(t *testing.T) {
+	initLocalPilotTestEnv()
+
+	adsstr := connectADS(util.MockPilotGrpcAddr, t)
+	defer func() { _ = adsstr.CloseSend() }()
+
+	mainCluster := service3Cluster
+	statusCluster := "outbound|http-status||service3.default.svc.cluster.local"
+	customCluster := "outbound|custom||service3.default.svc.cluster.local"
+
+	nonce := ""
+	for _, clusters := range [][]string{
+		{mainCluster, statusCluster},
+		// Overlapping list, with the already watched clusters first.
+		{mainCluster, statusCluster, customCluster},
+		{customCluster},
+	} {
+		sendADSReq(t, adsstr, endpointType, clusters, nonce)
+		res := recvADSWithTimeout(t, adsstr, endpointType)
+		if len(res.Resources) != len(clusters) {
+			t.Fatalf("Expecting %d load assignments for %v, got %d", len(clusters), clusters, len(res.Resources))
+		}
+		nonce = res.Nonce
+	}
+	sendADSReq(t, adsstr, endpointType, []string{customCluster}, nonce)
+
+	v2.PushAll()
+	res := recvADSWithTimeout(t, adsstr, endpointType)
+	if len(res.Resources) != 1 {
+		t.Fatalf("Expecting 1 load assignment after the push, got %d", len(res.Resources))
+	}
+}
+
+// TestAdsProxyInstanceUpdate verifies that the listeners and clusters of a proxy are pushed when
+// its own instances change, without waiting for a full push.
+func TestAdsProxyInstanceUpdate(t *testing.T) {
+	server := initLocalPilotTestEnv()
+
+	hostname := "service6.default.svc.cluster.local"
+	ports := testPorts(0)
+	server.EnvoyXdsServer.MemRegistry.AddService(hostname, &model.Service{
+		Hostname: hostname,
+		Address:  "10.1.0.6",
+		Ports:    ports,
+	})
+	node := sidecarId("10.2.0.8", "app6")
+
+	adsstr := connectADS(util.MockPilotGrpcAddr, t)
+	defer func() { _ = adsstr.CloseSend() }()
+
+	sendADSNodeReq(t, adsstr, node, clusterType, nil, "")
+	cds := recvADSWithTimeout(t, adsstr, clusterType)
+	sendADSNodeReq(t, adsstr, node, clusterType, nil, cds.Nonce)
+
+	sendADSNodeReq(t, adsstr, node, listenerType, nil, "")
+	lds := recvADSWithTimeout(t, adsstr, listenerType)
+	sendADSNodeReq(t, adsstr, node, listenerType, nil, lds.Nonce)
+
+	server.EnvoyXdsServer.MemRegistry.AddInstance(hostname, "app6", &model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{
+			Address:     "10.2.0.8",
+			Port:        2080,
+			ServicePort: ports[0],
+		},
+	})
+
+	recvADSWithTimeout(t, adsstr, clusterType)
+	res := recvADSWithTimeout(t, adsstr, listenerType)
+	if len(res.Resources) <= len(lds.Resources) {
+		t.Errorf("Expecting the inbound listener of the new instance, got %d listeners, had %d",
+			len(res.Resources), len(lds.Resources))
+	}
+}
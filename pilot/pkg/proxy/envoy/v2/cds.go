@@ -61,8 +61,8 @@ type CdsConnection struct {
 	pushChannel chan bool
 }
 
-// clusters aggregate a DiscoveryResponse for pushing.
-func (con *CdsConnection) clusters(response []*xdsapi.Cluster) *xdsapi.DiscoveryResponse {
+// cdsDiscoveryResponse aggregates a DiscoveryResponse for pushing.
+func cdsDiscoveryResponse(response []*xdsapi.Cluster) *xdsapi.DiscoveryResponse {
 	out := &xdsapi.DiscoveryResponse{
 		// All resources for CDS ought to be of the type ClusterLoadAssignment
 		TypeUrl: clusterType,
@@ -163,7 +163,7 @@ func (s *DiscoveryServer) StreamClusters(stream xdsapi.ClusterDiscoveryService_S
 
 		rawClusters := v1alpha3.BuildClusters(s.env, *con.modelNode)
 
-		response := con.clusters(rawClusters)
+		response := cdsDiscoveryResponse(rawClusters)
 		err := stream.Send(response)
 		if err != nil {
 			log.Warnf("CDS: Send failure, closing grpc %v", err)
@@ -41,6 +41,8 @@ func (s *DiscoveryServer) InitDebug(mux *http.ServeMux, sctl *aggregate.Controll
 		ServiceAccounts:  s.MemRegistry,
 		Controller:       s.MemRegistry.controller,
 	})
+	// The memory registry is added after the registry handlers are registered.
+	_ = s.MemRegistry.controller.AppendInstanceHandler(s.instanceUpdate)
 
 	mux.HandleFunc("/debug/edsz", EDSz)
 
@@ -48,6 +50,12 @@ func (s *DiscoveryServer) InitDebug(mux *http.ServeMux, sctl *aggregate.Controll
 
 	mux.HandleFunc("/debug/ldsz", LDSz)
 
+	mux.HandleFunc("/debug/rdsz", Rdsz)
+
+	mux.HandleFunc("/debug/adsz", Adsz)
+
+	mux.HandleFunc("/debug/loadz", Loadz)
+
 	mux.HandleFunc("/debug/registryz", s.registryz)
 }
 
@@ -93,7 +101,7 @@ type MemServiceDiscovery struct {
 	GetServiceError               error
 	InstancesError                error
 	GetProxyServiceInstancesError error
-	controller                    model.Controller
+	controller                    *memServiceController
 }
 
 // ClearErrors clear errors used for mocking failures during model.MemServiceDiscovery interface methods
@@ -120,13 +128,11 @@ func (sd *MemServiceDiscovery) AddInstance(service string, instanceName string,
 	instance.Service = svc
 	sd.ip2instance[instance.Endpoint.Address] = []*model.ServiceInstance{instance}
 
-	instanceList := sd.instances[service]
-	if instanceList == nil {
-		instanceList = []*model.ServiceInstance{instance}
-		sd.instances[service] = instanceList
-		return
+	sd.instances[service] = append(sd.instances[service], instance)
+
+	for _, h := range sd.controller.instHandlers {
+		h(instance, model.EventAdd)
 	}
-	sd.instances[service] = append(instanceList, instance)
 }
 
 // Services implements discovery interface
@@ -19,6 +19,8 @@ import (
 	"time"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	loadstats "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v2"
 	"google.golang.org/grpc"
 
 	"sync"
@@ -49,6 +51,7 @@ const (
 	endpointType = typePrefix + "ClusterLoadAssignment"
 	clusterType  = typePrefix + "Cluster"
 	listenerType = typePrefix + "Listener"
+	routeType    = typePrefix + "RouteConfiguration"
 )
 
 // DiscoveryServer is Pilot's gRPC implementation for Envoy's v2 xds APIs
@@ -70,6 +73,9 @@ func NewDiscoveryServer(grpcServer *grpc.Server, env model.Environment) *Discove
 	xdsapi.RegisterEndpointDiscoveryServiceServer(out.GrpcServer, out)
 	xdsapi.RegisterListenerDiscoveryServiceServer(out.GrpcServer, out)
 	xdsapi.RegisterClusterDiscoveryServiceServer(out.GrpcServer, out)
+	xdsapi.RegisterRouteDiscoveryServiceServer(out.GrpcServer, out)
+	ads.RegisterAggregatedDiscoveryServiceServer(out.GrpcServer, out)
+	loadstats.RegisterLoadReportingServiceServer(out.GrpcServer, out)
 
 	if len(periodicRefreshDuration) > 0 {
 		periodicRefresh()
@@ -110,6 +116,72 @@ func PushAll() {
 	edsPushAll() // we want endpoints ready first
 
 	ldsPushAll()
+
+	rdsPushAll()
+
+	// ADS connections get all types in order, CDS-EDS-LDS-RDS.
+	adsPushAll()
+}
+
+// pushProxy pushes the listeners and clusters of the proxies with the given IP address, over
+// ADS and LDS. Their inbound listeners and clusters are built from their own service instances,
+// which are not covered by the incremental EDS push. The instances also determine the locality
+// of the proxies, which is recomputed for their EDS connections.
+func pushProxy(ip string) {
+	if ip == "" {
+		return
+	}
+
+	adsClientsMutex.RLock()
+	for _, con := range adsClients {
+		if con.modelNode.IPAddress != ip {
+			continue
+		}
+		select {
+		case con.pushChannel <- struct{}{}:
+		default:
+			// A push is already pending for the connection.
+		}
+	}
+	adsClientsMutex.RUnlock()
+
+	ldsClientsMutex.RLock()
+	for _, con := range ldsClients {
+		if con.ipAddress != ip {
+			continue
+		}
+		select {
+		case con.pushChannel <- struct{}{}:
+		default:
+			// A push is already pending for the connection.
+		}
+	}
+	ldsClientsMutex.RUnlock()
+
+	edsClusterMutex.Lock()
+	// Create a temp map to avoid locking the add/remove
+	tmpMap := map[string]*EdsCluster{}
+	for k, v := range edsClusters {
+		tmpMap[k] = v
+	}
+	edsClusterMutex.Unlock()
+
+	for _, edsCluster := range tmpMap {
+		edsCluster.mutex.Lock()
+		for _, edsCon := range edsCluster.EdsClients {
+			// ADS connections recompute the locality on the push above.
+			if edsCon.ads || edsCon.ipAddress != ip {
+				continue
+			}
+			edsCon.invalidateLocality()
+			select {
+			case edsCon.pushChannel <- true:
+			default:
+				// A push is already pending for the connection.
+			}
+		}
+		edsCluster.mutex.Unlock()
+	}
 }
 
 func nonce() string {
@@ -22,8 +22,10 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
@@ -34,6 +36,7 @@ import (
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/gogo/protobuf/types"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"strings"
 
@@ -75,8 +78,27 @@ var (
 
 	// Tracks connections, increment on each new connection.
 	connectionNumber = int64(0)
+
+	// edsDebounce is the time to wait after a registry event before pushing the affected
+	// clusters, so a burst of endpoint changes results in a single push.
+	edsDebounce = 100 * time.Millisecond
+
+	// edsDirtyMutex protects the set of services changed since the last incremental push.
+	edsDirtyMutex sync.Mutex
+	// edsDirtyServices holds the hostnames of the services with changed instances.
+	edsDirtyServices = map[string]bool{}
+	// edsFirstDirty is the time of the first event in the pending batch, zero if none is pending.
+	edsFirstDirty time.Time
 )
 
+func init() {
+	if d := os.Getenv("PILOT_DEBOUNCE_EDS"); d != "" {
+		if t, err := time.ParseDuration(d); err == nil {
+			edsDebounce = t
+		}
+	}
+}
+
 // EdsCluster tracks eds-related info for monitored clusters. In practice it'll include
 // all clusters until we support on-demand cluster loading.
 type EdsCluster struct {
@@ -96,9 +118,11 @@ type EdsCluster struct {
 
 	// The discovery service this cluster is associated with.
 	discovery *DiscoveryServer
-}
 
-// TODO: add prom metrics !
+	// hostname is the service the cluster belongs to, used to find the clusters affected
+	// by an instance event.
+	hostname string
+}
 
 // EdsConnection represents a streaming grpc connection from an envoy server.
 // This is primarily intended for supporting push, but also for debug and statusz.
@@ -114,10 +138,61 @@ type EdsConnection struct {
 	// Sending on this channel results in  push. We may also make it a channel of objects so
 	// same info can be sent to all clients, without recomputing.
 	pushChannel chan bool
+
+	// ads is set for connections multiplexed over ADS, which get endpoints as part of the
+	// ordered ADS push on global invalidation.
+	ads bool
+
+	// Locality of the connected envoy, used to prioritize the endpoints in the same locality.
+	// It is computed when envoy connects, and again when envoy reports a different one, on
+	// full pushes and on the instance events of the proxy.
+	Locality model.Locality
+
+	// node is the last node sent by envoy.
+	node *core.Node
+
+	// ipAddress is the IP address of the proxy, from the first node sent by envoy.
+	ipAddress string
+
+	// localityStale is set, atomically, when the locality must be recomputed before the
+	// next push.
+	localityStale int32
 }
 
-// Endpoints aggregate a DiscoveryResponse for pushing.
-func (s *DiscoveryServer) endpoints(clusterNames []string) *xdsapi.DiscoveryResponse {
+// setNode records the node sent by envoy. The locality is computed from the first node, and
+// again when envoy reports a different locality.
+func (s *DiscoveryServer) setNode(con *EdsConnection, node *core.Node) {
+	if node == nil {
+		return
+	}
+	previous := con.node
+	con.node = node
+	if previous == nil {
+		if proxy, err := model.ParseServiceNode(node.Id); err == nil {
+			con.ipAddress = proxy.IPAddress
+		}
+	}
+	if previous == nil || fromCoreLocality(previous.Locality) != fromCoreLocality(node.Locality) {
+		con.Locality = s.proxyLocality(node)
+	}
+}
+
+// invalidateLocality marks the locality of the connection to be recomputed before the next push.
+func (con *EdsConnection) invalidateLocality() {
+	atomic.StoreInt32(&con.localityStale, 1)
+}
+
+// refreshLocality recomputes the locality of the connection if it was invalidated. The
+// locality depends on the instances of the proxy, which are expensive to look up.
+func (s *DiscoveryServer) refreshLocality(con *EdsConnection) {
+	if atomic.CompareAndSwapInt32(&con.localityStale, 1, 0) {
+		con.Locality = s.proxyLocality(con.node)
+	}
+}
+
+// Endpoints aggregate a DiscoveryResponse for pushing. The endpoints are prioritized based on
+// the locality of the envoy.
+func (s *DiscoveryServer) endpoints(clusterNames []string, locality model.Locality) *xdsapi.DiscoveryResponse {
 	out := &xdsapi.DiscoveryResponse{
 		// All resources for EDS ought to be of the type ClusterLoadAssignment
 		TypeUrl: endpointType,
@@ -132,7 +207,7 @@ func (s *DiscoveryServer) endpoints(clusterNames []string) *xdsapi.DiscoveryResp
 
 	out.Resources = make([]types.Any, 0, len(clusterNames))
 	for _, clusterName := range clusterNames {
-		clAssignmentRes := s.clusterEndpoints(clusterName)
+		clAssignmentRes := s.clusterEndpoints(clusterName, locality)
 		if clAssignmentRes != nil {
 			out.Resources = append(out.Resources, *clAssignmentRes)
 		}
@@ -141,8 +216,8 @@ func (s *DiscoveryServer) endpoints(clusterNames []string) *xdsapi.DiscoveryResp
 	return out
 }
 
-// Get the ClusterLoadAssignment for a cluster.
-func (s *DiscoveryServer) clusterEndpoints(clusterName string) *types.Any {
+// Get the ClusterLoadAssignment for a cluster, for an envoy in the given locality.
+func (s *DiscoveryServer) clusterEndpoints(clusterName string, locality model.Locality) *types.Any {
 	c := s.getOrAddEdsCluster(clusterName)
 	l := loadAssignment(c)
 	if l == nil { // fresh cluster
@@ -151,11 +226,43 @@ func (s *DiscoveryServer) clusterEndpoints(clusterName string) *types.Any {
 	}
 
 	// Previously computed load assignments. They are re-computed on cache invalidation or
-	// event, but don't have to be recomputed once for each sidecar.
-	clAssignmentRes, _ := types.MarshalAny(l)
+	// event, but don't have to be recomputed once for each sidecar. Only the priorities
+	// depend on the sidecar.
+	clAssignmentRes, _ := types.MarshalAny(prioritize(l, locality))
 	return clAssignmentRes
 }
 
+// prioritize returns a copy of the load assignment with the priority of each locality set
+// from its distance to the envoy: the same sub-zone first, then the same zone, the same
+// region and finally other regions. Envoy only sends traffic to a lower priority when the
+// endpoints with a higher priority are unhealthy. Priorities are consecutive, starting at 0.
+func prioritize(l *xdsapi.ClusterLoadAssignment, locality model.Locality) *xdsapi.ClusterLoadAssignment {
+	if l == nil || locality.IsEmpty() || len(l.Endpoints) == 0 {
+		return l
+	}
+
+	distances := make([]int, len(l.Endpoints))
+	used := map[int]bool{}
+	for i, ep := range l.Endpoints {
+		distances[i] = 3 - locality.MatchLevel(fromCoreLocality(ep.Locality))
+		used[distances[i]] = true
+	}
+	priority := map[int]uint32{}
+	for d := 0; d <= 3; d++ {
+		if used[d] {
+			priority[d] = uint32(len(priority))
+		}
+	}
+
+	out := *l
+	out.Endpoints = make([]endpoint.LocalityLbEndpoints, len(l.Endpoints))
+	for i, ep := range l.Endpoints {
+		ep.Priority = priority[distances[i]]
+		out.Endpoints[i] = ep
+	}
+	return &out
+}
+
 // Return the load assignment. The field can be updated by another routine.
 func loadAssignment(c *EdsCluster) *xdsapi.ClusterLoadAssignment {
 	c.mutex.Lock()
@@ -199,7 +306,8 @@ func updateCluster(clusterName string, edsCluster *EdsCluster) {
 	// Single port
 	var portName string
 
-	// This is a gross hack but Costin will insist on supporting everything from ancient Greece
+	// This is a gross hack but Costin will insist on supporting everything from ancient Greece.
+	// Keep in sync with clusterHostname.
 	if strings.Index(clusterName, "outbound") == 0 { //new style cluster names
 		var p *model.Port
 		var subsetName string
@@ -233,16 +341,31 @@ func updateCluster(clusterName string, edsCluster *EdsCluster) {
 		ClusterName: clusterName,
 		Endpoints:   locEps,
 	}
+	if lrsWeights {
+		applyLoadWeights(clusterName, locEps)
+	}
 	if len(locEps) > 0 && edsCluster.NonEmptyTime.IsZero() {
 		edsCluster.NonEmptyTime = time.Now()
 	}
 
 }
 
+// clusterHostname returns the hostname of the service a cluster belongs to, for both the old
+// and the new style cluster names.
+func clusterHostname(clusterName string) string {
+	if strings.Index(clusterName, "outbound") == 0 {
+		_, _, hostname, _ := model.ParseSubsetKey(clusterName)
+		return hostname
+	}
+	hostname, _, _ := model.ParseServiceKey(clusterName)
+	return hostname
+}
+
 // LocalityLbEndpointsFromInstances returns a list of Envoy v2 LocalityLbEndpoints.
 // Envoy v2 Endpoints are constructed from Pilot's older data structure involving
-// model.ServiceInstance objects. Envoy expects the endpoints grouped by zone, so
+// model.ServiceInstance objects. Envoy expects the endpoints grouped by locality, so
 // a map is created - in new data structures this should be part of the model.
+// The weight of each locality is the number of endpoints, so all endpoints get the same load.
 func localityLbEndpointsFromInstances(instances []*model.ServiceInstance) []endpoint.LocalityLbEndpoints {
 	localityEpMap := make(map[string]*endpoint.LocalityLbEndpoints)
 	for _, instance := range instances {
@@ -251,27 +374,71 @@ func localityLbEndpointsFromInstances(instances []*model.ServiceInstance) []endp
 			log.Errorf("EDS: unexpected pilot model endpoint v1 to v2 conversion: %v", err)
 			continue
 		}
-		// TODO: Need to accommodate region, zone and subzone. Older Pilot datamodel only has zone = availability zone.
-		// Once we do that, the key must be a | separated tupple.
-		locality := instance.AvailabilityZone
-		locLbEps, found := localityEpMap[locality]
+		locality := instanceLocality(instance)
+		key := locality.String()
+		locLbEps, found := localityEpMap[key]
 		if !found {
 			locLbEps = &endpoint.LocalityLbEndpoints{
 				Locality: &core.Locality{
-					Zone: instance.AvailabilityZone,
+					Region:  locality.Region,
+					Zone:    locality.Zone,
+					SubZone: locality.SubZone,
 				},
 			}
-			localityEpMap[locality] = locLbEps
+			localityEpMap[key] = locLbEps
 		}
 		locLbEps.LbEndpoints = append(locLbEps.LbEndpoints, *lbEp)
 	}
+	keys := make([]string, 0, len(localityEpMap))
+	for key := range localityEpMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 	out := make([]endpoint.LocalityLbEndpoints, 0, len(localityEpMap))
-	for _, locLbEps := range localityEpMap {
+	for _, key := range keys {
+		locLbEps := localityEpMap[key]
+		locLbEps.LoadBalancingWeight = &types.UInt32Value{Value: uint32(len(locLbEps.LbEndpoints))}
 		out = append(out, *locLbEps)
 	}
 	return out
 }
 
+// instanceLocality returns the locality of an instance. For registries that only report
+// the availability zone, it is used as the zone.
+func instanceLocality(instance *model.ServiceInstance) model.Locality {
+	if !instance.Locality.IsEmpty() {
+		return instance.Locality
+	}
+	return model.Locality{Zone: instance.AvailabilityZone}
+}
+
+func fromCoreLocality(l *core.Locality) model.Locality {
+	if l == nil {
+		return model.Locality{}
+	}
+	return model.Locality{Region: l.Region, Zone: l.Zone, SubZone: l.SubZone}
+}
+
+// proxyLocality returns the locality of a connected envoy. The locality in the node
+// is used if set, otherwise the locality of the service instances of the proxy.
+func (s *DiscoveryServer) proxyLocality(node *core.Node) model.Locality {
+	if node == nil {
+		return model.Locality{}
+	}
+	if l := fromCoreLocality(node.Locality); !l.IsEmpty() {
+		return l
+	}
+	proxy, err := model.ParseServiceNode(node.Id)
+	if err != nil {
+		return model.Locality{}
+	}
+	instances, err := s.env.GetProxyServiceInstances(proxy)
+	if err != nil || len(instances) == 0 {
+		return model.Locality{}
+	}
+	return instanceLocality(instances[0])
+}
+
 func connectionID(node string) string {
 	edsClusterMutex.Lock()
 	connectionNumber++
@@ -334,6 +501,7 @@ func (s *DiscoveryServer) StreamEndpoints(stream xdsapi.EndpointDiscoveryService
 			if node == "" && discReq.Node != nil {
 				node = connectionID(discReq.Node.Id)
 			}
+			s.setNode(con, discReq.Node)
 
 			clusters2 := discReq.GetResourceNames()
 			if initialRequestReceived {
@@ -378,7 +546,8 @@ func (s *DiscoveryServer) StreamEndpoints(stream xdsapi.EndpointDiscoveryService
 			continue
 		}
 
-		response := s.endpoints(con.Clusters)
+		s.refreshLocality(con)
+		response := s.endpoints(con.Clusters, con.Locality)
 		err := stream.Send(response)
 		if err != nil {
 			log.Warnf("EDS: Send failure, closing grpc %v", err)
@@ -392,7 +561,9 @@ func (s *DiscoveryServer) StreamEndpoints(stream xdsapi.EndpointDiscoveryService
 	}
 }
 
+// edsPushAll recomputes all clusters and pushes them to all non-ADS connections.
 func edsPushAll() {
+	start := time.Now()
 	edsClusterMutex.Lock()
 	// Create a temp map to avoid locking the add/remove
 	tmpMap := map[string]*EdsCluster{}
@@ -405,10 +576,105 @@ func edsPushAll() {
 		updateCluster(clusterName, edsCluster)
 		edsCluster.mutex.Lock()
 		for _, edsCon := range edsCluster.EdsClients {
-			edsCon.pushChannel <- true
+			if edsCon.ads {
+				continue
+			}
+			edsCon.invalidateLocality()
+			select {
+			case edsCon.pushChannel <- true:
+				edsPushes.With(prometheus.Labels{metricLabelPushType: pushTypeFull}).Inc()
+			default:
+				// A push is already pending for the connection, and will include the new endpoints.
+			}
 		}
 		edsCluster.mutex.Unlock()
 	}
+	edsClusterUpdates.With(prometheus.Labels{metricLabelPushType: pushTypeFull}).Add(float64(len(tmpMap)))
+	edsPushTime.With(prometheus.Labels{metricLabelPushType: pushTypeFull}).Observe(time.Since(start).Seconds())
+}
+
+// RegisterInstanceHandler adds a handler to the registry controller that marks the clusters of
+// the changed services dirty, instead of recomputing all clusters.
+func (s *DiscoveryServer) RegisterInstanceHandler(ctl model.Controller) error {
+	return ctl.AppendInstanceHandler(s.instanceUpdate)
+}
+
+// instanceUpdate is called by the registries when the instances of a service change. The
+// push is delayed by edsDebounce, and all the services changed in the meantime are pushed
+// together. The proxies running the changed instance get all their config right away.
+func (s *DiscoveryServer) instanceUpdate(si *model.ServiceInstance, event model.Event) {
+	if si == nil || si.Service == nil {
+		// Can't tell which clusters are affected.
+		edsPushAll()
+		return
+	}
+	if edsDebug {
+		log.Infof("EDS: %s instance event for %s", event, si.Service.Hostname)
+	}
+	s.edsMarkDirty(si.Service.Hostname)
+	// The inbound listeners and clusters of a proxy are built from its own instances.
+	pushProxy(si.Endpoint.Address)
+}
+
+// edsMarkDirty marks the clusters of a service for the next incremental push, scheduling
+// the push if none is pending.
+func (s *DiscoveryServer) edsMarkDirty(hostname string) {
+	edsDirtyMutex.Lock()
+	defer edsDirtyMutex.Unlock()
+
+	edsDirtyServices[hostname] = true
+	if edsFirstDirty.IsZero() {
+		edsFirstDirty = time.Now()
+		time.AfterFunc(edsDebounce, s.edsIncrementalPush)
+	}
+}
+
+// edsIncrementalPush recomputes the clusters of the services marked dirty since the last push,
+// and pushes them to the connections watching them - including ADS connections. A connection
+// watching several dirty clusters gets a single push.
+func (s *DiscoveryServer) edsIncrementalPush() {
+	edsDirtyMutex.Lock()
+	dirty := edsDirtyServices
+	firstDirty := edsFirstDirty
+	edsDirtyServices = map[string]bool{}
+	edsFirstDirty = time.Time{}
+	edsDirtyMutex.Unlock()
+
+	start := time.Now()
+	edsClusterMutex.Lock()
+	tmpMap := map[string]*EdsCluster{}
+	for k, v := range edsClusters {
+		if dirty[v.hostname] {
+			tmpMap[k] = v
+		}
+	}
+	edsClusterMutex.Unlock()
+
+	cons := map[*EdsConnection]bool{}
+	for clusterName, edsCluster := range tmpMap {
+		updateCluster(clusterName, edsCluster)
+		edsCluster.mutex.Lock()
+		for _, edsCon := range edsCluster.EdsClients {
+			cons[edsCon] = true
+		}
+		edsCluster.mutex.Unlock()
+	}
+
+	for edsCon := range cons {
+		select {
+		case edsCon.pushChannel <- true:
+		default:
+			// A push is already pending for the connection, and will include the new endpoints.
+		}
+	}
+
+	edsPushes.With(prometheus.Labels{metricLabelPushType: pushTypeIncremental}).Add(float64(len(cons)))
+	edsClusterUpdates.With(prometheus.Labels{metricLabelPushType: pushTypeIncremental}).Add(float64(len(tmpMap)))
+	edsPushTime.With(prometheus.Labels{metricLabelPushType: pushTypeIncremental}).Observe(time.Since(start).Seconds())
+	edsEventLatency.Observe(time.Since(firstDirty).Seconds())
+	if edsDebug {
+		log.Infof("EDS: incremental push services=%d clusters=%d connections=%d", len(dirty), len(tmpMap), len(cons))
+	}
 }
 
 // EDSz implements a status and debug interface for EDS.
@@ -441,8 +707,9 @@ func (s *DiscoveryServer) addEdsCon(clusterName string, node string, connection
 	existing := c.EdsClients[node]
 	c.mutex.Unlock()
 
-	// May replace an existing connection
-	if existing != nil {
+	// May replace an existing connection. The connection may already be registered when it
+	// requests the cluster again, and is the only reader of its own channel.
+	if existing != nil && existing != connection {
 		existing.pushChannel <- false // force closing it
 	}
 	c.mutex.Lock()
@@ -467,6 +734,7 @@ func (s *DiscoveryServer) getOrAddEdsCluster(clusterName string) *EdsCluster {
 		c = &EdsCluster{discovery: s,
 			EdsClients: map[string]*EdsConnection{},
 			FirstUse:   time.Now(),
+			hostname:   clusterHostname(clusterName),
 		}
 		edsClusters[clusterName] = c
 	}
@@ -505,8 +773,3 @@ func (s *DiscoveryServer) removeEdsCon(clusterName string, node string, connecti
 func (s *DiscoveryServer) FetchEndpoints(ctx context.Context, req *xdsapi.DiscoveryRequest) (*xdsapi.DiscoveryResponse, error) {
 	return nil, errors.New("not implemented")
 }
-
-// StreamLoadStats implements xdsapi.EndpointDiscoveryServiceServer.StreamLoadStats().
-func (s *DiscoveryServer) StreamLoadStats(xdsapi.EndpointDiscoveryService_StreamEndpointsServer) error {
-	return errors.New("unsupported streaming method")
-}
@@ -27,6 +27,7 @@ import (
 	"google.golang.org/grpc"
 
 	"istio.io/istio/pilot/pkg/bootstrap"
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/proxy/envoy/v2"
 
 	"istio.io/istio/pilot/pkg/proxy/envoy/v1/mock"
@@ -216,6 +217,233 @@ func TestEds(t *testing.T) {
 
 }
 
+// TestEdsIncremental verifies that an instance event is pushed to the connections watching
+// the clusters of the changed service, without a global push.
+func TestEdsIncremental(t *testing.T) {
+	server := initLocalPilotTestEnv()
+
+	hostname := "service4.default.svc.cluster.local"
+	ports := testPorts(0)
+	server.EnvoyXdsServer.MemRegistry.AddService(hostname, &model.Service{
+		Hostname: hostname,
+		Address:  "10.1.0.4",
+		Ports:    ports,
+	})
+
+	conn, err := grpc.Dial(util.MockPilotGrpcAddr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal("Connection failed", err)
+	}
+	edsstr, err := xdsapi.NewEndpointDiscoveryServiceClient(conn).StreamEndpoints(context.Background())
+	if err != nil {
+		t.Fatal("Rpc failed", err)
+	}
+	defer func() { _ = edsstr.CloseSend() }()
+	err = edsstr.Send(&xdsapi.DiscoveryRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id: sidecarId("10.2.0.4", "app4"),
+		},
+		ResourceNames: []string{"outbound|http-main||" + hostname}})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+	if _, err = edsstr.Recv(); err != nil {
+		t.Fatal("Recv failed", err)
+	}
+
+	server.EnvoyXdsServer.MemRegistry.AddInstance(hostname, "app4", &model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{
+			Address:     "10.2.0.4",
+			Port:        2080,
+			ServicePort: ports[0],
+		},
+		Labels: map[string]string{"version": "1"},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-time.After(5 * time.Second):
+			_ = edsstr.CloseSend()
+		case <-done:
+		}
+	}()
+	defer close(done)
+
+	res, err := edsstr.Recv()
+	if err != nil {
+		t.Fatal("Incremental push not received", err)
+	}
+	cla := &xdsapi.ClusterLoadAssignment{}
+	if err = cla.Unmarshal(res.Resources[0].Value); err != nil {
+		t.Fatal("Failed to parse proto ", err)
+	}
+	if len(cla.Endpoints) != 1 || len(cla.Endpoints[0].LbEndpoints) != 1 {
+		t.Fatal("Expecting the new endpoint, got ", cla.String())
+	}
+	if addr := cla.Endpoints[0].LbEndpoints[0].Endpoint.Address.GetSocketAddress().Address; addr != "10.2.0.4" {
+		t.Error("Expecting 10.2.0.4 got ", addr)
+	}
+}
+
+// TestEdsLocality verifies that endpoints are grouped by locality, and that the endpoints in
+// the locality of the envoy get the highest priority.
+func TestEdsLocality(t *testing.T) {
+	server := initLocalPilotTestEnv()
+
+	hostname := "service5.default.svc.cluster.local"
+	ports := testPorts(0)
+	server.EnvoyXdsServer.MemRegistry.AddService(hostname, &model.Service{
+		Hostname: hostname,
+		Address:  "10.1.0.5",
+		Ports:    ports,
+	})
+	for ip, zone := range map[string]string{"10.2.0.5": "az1", "10.2.0.6": "az2"} {
+		server.EnvoyXdsServer.MemRegistry.AddInstance(hostname, "app5", &model.ServiceInstance{
+			Endpoint: model.NetworkEndpoint{
+				Address:     ip,
+				Port:        2080,
+				ServicePort: ports[0],
+			},
+			Locality: model.Locality{Region: "region1", Zone: zone},
+		})
+	}
+
+	conn, err := grpc.Dial(util.MockPilotGrpcAddr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal("Connection failed", err)
+	}
+	edsstr, err := xdsapi.NewEndpointDiscoveryServiceClient(conn).StreamEndpoints(context.Background())
+	if err != nil {
+		t.Fatal("Rpc failed", err)
+	}
+	defer func() { _ = edsstr.CloseSend() }()
+	err = edsstr.Send(&xdsapi.DiscoveryRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id:       sidecarId("10.2.0.7", "app5"),
+			Locality: &envoy_api_v2_core1.Locality{Region: "region1", Zone: "az2"},
+		},
+		ResourceNames: []string{"outbound|http-main||" + hostname}})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+	checkEdsLocality(t, edsstr, "az2")
+
+	// A new locality reported by envoy applies to the next push.
+	err = edsstr.Send(&xdsapi.DiscoveryRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id:       sidecarId("10.2.0.7", "app5"),
+			Locality: &envoy_api_v2_core1.Locality{Region: "region1", Zone: "az1"},
+		},
+		ResourceNames: []string{"outbound|http-main||" + hostname, "outbound|http-status||" + hostname}})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+	checkEdsLocality(t, edsstr, "az1")
+}
+
+// TestEdsLocalityFromInstances verifies that the locality of an envoy that doesn't report one
+// is taken from its service instances, and updated when they change.
+func TestEdsLocalityFromInstances(t *testing.T) {
+	server := initLocalPilotTestEnv()
+
+	hostname := "service7.default.svc.cluster.local"
+	ports := testPorts(0)
+	server.EnvoyXdsServer.MemRegistry.AddService(hostname, &model.Service{
+		Hostname: hostname,
+		Address:  "10.1.0.7",
+		Ports:    ports,
+	})
+	for ip, zone := range map[string]string{"10.2.0.9": "az1", "10.2.0.10": "az2"} {
+		server.EnvoyXdsServer.MemRegistry.AddInstance(hostname, "app7", &model.ServiceInstance{
+			Endpoint: model.NetworkEndpoint{
+				Address:     ip,
+				Port:        2080,
+				ServicePort: ports[0],
+			},
+			Locality: model.Locality{Region: "region1", Zone: zone},
+		})
+	}
+	proxyHostname := "service8.default.svc.cluster.local"
+	server.EnvoyXdsServer.MemRegistry.AddService(proxyHostname, &model.Service{
+		Hostname: proxyHostname,
+		Address:  "10.1.0.8",
+		Ports:    ports,
+	})
+
+	conn, err := grpc.Dial(util.MockPilotGrpcAddr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal("Connection failed", err)
+	}
+	edsstr, err := xdsapi.NewEndpointDiscoveryServiceClient(conn).StreamEndpoints(context.Background())
+	if err != nil {
+		t.Fatal("Rpc failed", err)
+	}
+	defer func() { _ = edsstr.CloseSend() }()
+	err = edsstr.Send(&xdsapi.DiscoveryRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id: sidecarId("10.2.0.11", "app8"),
+		},
+		ResourceNames: []string{"outbound|http-main||" + hostname}})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+	if _, err = edsstr.Recv(); err != nil {
+		t.Fatal("Recv failed", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-time.After(5 * time.Second):
+			_ = edsstr.CloseSend()
+		case <-done:
+		}
+	}()
+	defer close(done)
+
+	// The proxy instance gets registered after envoy connected.
+	server.EnvoyXdsServer.MemRegistry.AddInstance(proxyHostname, "app8", &model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{
+			Address:     "10.2.0.11",
+			Port:        2080,
+			ServicePort: ports[0],
+		},
+		Locality: model.Locality{Region: "region1", Zone: "az2"},
+	})
+	checkEdsLocality(t, edsstr, "az2")
+}
+
+// checkEdsLocality verifies that the endpoints of the first cluster of an EDS response are
+// prioritized for an envoy in the given zone.
+func checkEdsLocality(t *testing.T, edsstr xdsapi.EndpointDiscoveryService_StreamEndpointsClient, zone string) {
+	t.Helper()
+
+	res, err := edsstr.Recv()
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	cla := &xdsapi.ClusterLoadAssignment{}
+	if err = cla.Unmarshal(res.Resources[0].Value); err != nil {
+		t.Fatal("Failed to parse proto ", err)
+	}
+	if len(cla.Endpoints) != 2 {
+		t.Fatal("Expecting 2 localities, got ", cla.String())
+	}
+	for _, ep := range cla.Endpoints {
+		want := uint32(1)
+		if ep.Locality.Zone == zone {
+			want = 0
+		}
+		if ep.Priority != want {
+			t.Errorf("Expecting priority %d for %s, got %d", want, ep.Locality.Zone, ep.Priority)
+		}
+		if ep.LoadBalancingWeight == nil || ep.LoadBalancingWeight.Value != 1 {
+			t.Errorf("Expecting weight 1 for %s, got %v", ep.Locality.Zone, ep.LoadBalancingWeight)
+		}
+	}
+}
+
 // Verify the endpoint debug interface is installed and returns some string.
 // TODO: parse response, check if data captured matches what we expect.
 // TODO: use this in integration tests.
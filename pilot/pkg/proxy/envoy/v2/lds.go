@@ -55,6 +55,10 @@ type LdsConnection struct {
 	// Node is the name of the remote node
 	Node string
 
+	// ipAddress is the IP address of the remote node, used to push the listeners when the
+	// instances of the node change.
+	ipAddress string
+
 	// Sending on this channel results in  push. We may also make it a channel of objects so
 	// same info can be sent to all clients, without recomputing.
 	pushChannel chan struct{}
@@ -132,6 +136,7 @@ func (s *DiscoveryServer) StreamListeners(stream xdsapi.ListenerDiscoveryService
 			initialRequestReceived = true
 			nodeID = nt.ID
 			con.Node = nodeID
+			con.ipAddress = nt.IPAddress
 			addLdsCon(nodeID, con)
 
 			if ldsDebug {
@@ -0,0 +1,315 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	loadstats "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v2"
+	"github.com/gogo/protobuf/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pkg/log"
+)
+
+// LRS receives the load reports of the envoys, per cluster and locality. The reports are
+// aggregated in memory, exported as metrics and on /debug/loadz. If PILOT_LRS_WEIGHTS is
+// set, the EDS locality weights are scaled by the success ratio of each locality.
+
+var (
+	lrsDebug = os.Getenv("PILOT_DEBUG_LRS") != "0"
+
+	// lrsWeights enables using the aggregated load as EDS locality weights.
+	lrsWeights = os.Getenv("PILOT_LRS_WEIGHTS") == "1"
+
+	// lrsInterval is the load reporting interval requested from envoy.
+	lrsInterval = 10 * time.Second
+
+	lrsMutex sync.RWMutex
+	// lrsClusters holds the aggregated load, keyed by cluster name and locality key.
+	lrsClusters = map[string]map[string]*LocalityLoad{}
+)
+
+func init() {
+	if d := os.Getenv("PILOT_LRS_INTERVAL"); d != "" {
+		if t, err := time.ParseDuration(d); err == nil {
+			lrsInterval = t
+		}
+	}
+}
+
+// LocalityLoad is the load reported for a locality of a cluster.
+type LocalityLoad struct {
+	Locality *core.Locality
+
+	// TotalSuccessfulRequests is the number of successful requests reported since startup.
+	TotalSuccessfulRequests uint64
+
+	// TotalErrorRequests is the number of failed requests reported since startup.
+	TotalErrorRequests uint64
+
+	// Nodes holds the last report of each envoy sending traffic to the locality.
+	Nodes map[string]*NodeLoad
+}
+
+// NodeLoad is the last load report of an envoy for a locality.
+type NodeLoad struct {
+	SuccessfulRequests uint64
+	ErrorRequests      uint64
+	RequestsInProgress uint64
+
+	// Time of the report, for debugging
+	Time time.Time
+}
+
+// StreamLoadStats implements loadstats.LoadReportingServiceServer.StreamLoadStats().
+func (s *DiscoveryServer) StreamLoadStats(stream loadstats.LoadReportingService_StreamLoadStatsServer) error {
+	peerInfo, ok := peer.FromContext(stream.Context())
+	peerAddr := unknownPeerAddressStr
+	if ok {
+		peerAddr = peerInfo.Addr.String()
+	}
+
+	// node is the id of the reporting envoy. Reports from a restarted envoy replace the
+	// previous ones.
+	var node string
+	// clusters is the list of clusters envoy was last asked to report.
+	var clusters []string
+	defer func() {
+		if node != "" {
+			removeNodeLoad(node)
+		}
+	}()
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if status.Code(err) == codes.Canceled || err == io.EOF {
+				return nil
+			}
+			log.Errorf("LRS: close for client %s %q terminated with errors %v", node, peerAddr, err)
+			return err
+		}
+
+		if node == "" {
+			if req.Node == nil {
+				return status.Errorf(codes.InvalidArgument, "missing node in LRS request")
+			}
+			node = req.Node.Id
+			if lrsDebug {
+				log.Infof("LRS: REQ %s %s", node, peerAddr)
+			}
+		}
+
+		// The response selects the clusters to report and the reporting interval. It is sent
+		// again when the clusters watched using EDS change, so that envoy reports on the new ones.
+		if names := lrsClusterNames(); clusters == nil || !reflect.DeepEqual(names, clusters) {
+			if err := stream.Send(&loadstats.LoadStatsResponse{
+				Clusters:              names,
+				LoadReportingInterval: types.DurationProto(lrsInterval),
+			}); err != nil {
+				log.Warnf("LRS: Send failure, closing grpc %v", err)
+				return err
+			}
+			clusters = names
+		}
+
+		changed := recordLoad(node, req.ClusterStats)
+		for _, clusterName := range changed {
+			s.edsMarkDirty(clusterHostname(clusterName))
+		}
+		if lrsDebug && len(req.ClusterStats) > 0 {
+			log.Infof("LRS: report %s clusters=%d weight changes=%v", node, len(req.ClusterStats), changed)
+		}
+	}
+}
+
+// lrsClusterNames returns the clusters envoy is asked to report, which are the clusters
+// watched using EDS.
+func lrsClusterNames() []string {
+	edsClusterMutex.Lock()
+	defer edsClusterMutex.Unlock()
+	out := make([]string, 0, len(edsClusters))
+	for name := range edsClusters {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// localityKey returns the key of a locality in the load map.
+func localityKey(l *core.Locality) string {
+	if l == nil {
+		return ""
+	}
+	return l.Region + "/" + l.Zone + "/" + l.SubZone
+}
+
+// recordLoad aggregates a load report of a node. If load weights are enabled, it returns the
+// clusters with changed locality weights.
+func recordLoad(node string, stats []*endpoint.ClusterStats) []string {
+	lrsMutex.Lock()
+	defer lrsMutex.Unlock()
+
+	var changed []string
+	now := time.Now()
+	for _, cs := range stats {
+		if cs == nil {
+			continue
+		}
+		localities := lrsClusters[cs.ClusterName]
+		if localities == nil {
+			localities = map[string]*LocalityLoad{}
+			lrsClusters[cs.ClusterName] = localities
+		}
+		before := localityWeights(localities)
+		for _, ls := range cs.UpstreamLocalityStats {
+			if ls == nil {
+				continue
+			}
+			key := localityKey(ls.Locality)
+			l := localities[key]
+			if l == nil {
+				l = &LocalityLoad{Locality: ls.Locality, Nodes: map[string]*NodeLoad{}}
+				localities[key] = l
+			}
+			l.TotalSuccessfulRequests += ls.TotalSuccessfulRequests
+			l.TotalErrorRequests += ls.TotalErrorRequests
+			l.Nodes[node] = &NodeLoad{
+				SuccessfulRequests: ls.TotalSuccessfulRequests,
+				ErrorRequests:      ls.TotalErrorRequests,
+				RequestsInProgress: ls.TotalRequestsInProgress,
+				Time:               now,
+			}
+
+			lrsRequests.With(prometheus.Labels{metricLabelCluster: cs.ClusterName,
+				metricLabelLocality: key, metricLabelResult: "success"}).Add(float64(ls.TotalSuccessfulRequests))
+			lrsRequests.With(prometheus.Labels{metricLabelCluster: cs.ClusterName,
+				metricLabelLocality: key, metricLabelResult: "error"}).Add(float64(ls.TotalErrorRequests))
+			lrsRequestsInProgress.With(prometheus.Labels{metricLabelCluster: cs.ClusterName,
+				metricLabelLocality: key}).Set(float64(l.requestsInProgress()))
+		}
+		if lrsWeights && !sameWeights(before, localityWeights(localities)) {
+			changed = append(changed, cs.ClusterName)
+		}
+	}
+	lrsReports.Inc()
+	return changed
+}
+
+// removeNodeLoad drops the last reports of a node, when its stream is closed.
+func removeNodeLoad(node string) {
+	lrsMutex.Lock()
+	defer lrsMutex.Unlock()
+	for clusterName, localities := range lrsClusters {
+		for key, l := range localities {
+			if _, f := l.Nodes[node]; !f {
+				continue
+			}
+			delete(l.Nodes, node)
+			lrsRequestsInProgress.With(prometheus.Labels{metricLabelCluster: clusterName,
+				metricLabelLocality: key}).Set(float64(l.requestsInProgress()))
+		}
+	}
+}
+
+func (l *LocalityLoad) requestsInProgress() uint64 {
+	var n uint64
+	for _, nl := range l.Nodes {
+		n += nl.RequestsInProgress
+	}
+	return n
+}
+
+// weight returns the EDS weight of the locality, between 1 and 100, based on the ratio of
+// successful requests in the last reports. Localities without traffic get the full weight.
+func (l *LocalityLoad) weight() uint32 {
+	var success, errs uint64
+	for _, nl := range l.Nodes {
+		success += nl.SuccessfulRequests
+		errs += nl.ErrorRequests
+	}
+	if success+errs == 0 {
+		return 100
+	}
+	return 1 + uint32(99*success/(success+errs))
+}
+
+func localityWeights(localities map[string]*LocalityLoad) map[string]uint32 {
+	out := make(map[string]uint32, len(localities))
+	for key, l := range localities {
+		out[key] = l.weight()
+	}
+	return out
+}
+
+func sameWeights(a, b map[string]uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// applyLoadWeights scales the locality weights of a cluster by the reported load.
+func applyLoadWeights(clusterName string, locEps []endpoint.LocalityLbEndpoints) {
+	lrsMutex.RLock()
+	defer lrsMutex.RUnlock()
+	localities := lrsClusters[clusterName]
+	if len(localities) == 0 {
+		return
+	}
+	for i := range locEps {
+		w := uint32(100)
+		if l := localities[localityKey(locEps[i].Locality)]; l != nil {
+			w = l.weight()
+		}
+		locEps[i].LoadBalancingWeight = &types.UInt32Value{Value: w * uint32(len(locEps[i].LbEndpoints))}
+	}
+}
+
+// Loadz implements a status and debug interface for LRS.
+// It is mapped to /debug/loadz on the monitor port (9093).
+func Loadz(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+	if req.Form.Get("debug") != "" {
+		lrsDebug = req.Form.Get("debug") == "1"
+		return
+	}
+	lrsMutex.RLock()
+	data, err := json.MarshalIndent(lrsClusters, "", "  ")
+	lrsMutex.RUnlock()
+	if err != nil {
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	loadstats "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v2"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/tests/util"
+)
+
+func connectLRS(url string, t *testing.T) loadstats.LoadReportingService_StreamLoadStatsClient {
+	conn, err := grpc.Dial(url, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal("Connection failed", err)
+	}
+
+	lrs := loadstats.NewLoadReportingServiceClient(conn)
+	lrsstr, err := lrs.StreamLoadStats(context.Background())
+	if err != nil {
+		t.Fatal("Rpc failed", err)
+	}
+	err = lrsstr.Send(&loadstats.LoadStatsRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id: sidecarId(app3Ip, "app3"),
+		},
+	})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+	return lrsstr
+}
+
+// TestLRS verifies that load reports are aggregated and returned by the debug interface.
+func TestLRS(t *testing.T) {
+	initLocalPilotTestEnv()
+
+	lrsstr := connectLRS(util.MockPilotGrpcAddr, t)
+	defer func() { _ = lrsstr.CloseSend() }()
+
+	res, err := lrsstr.Recv()
+	if err != nil {
+		t.Fatal("Failed to receive LRS", err)
+	}
+	if res.LoadReportingInterval == nil {
+		t.Error("Missing load reporting interval")
+	}
+
+	err = lrsstr.Send(&loadstats.LoadStatsRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id: sidecarId(app3Ip, "app3"),
+		},
+		ClusterStats: []*endpoint.ClusterStats{{
+			ClusterName: service3Cluster,
+			UpstreamLocalityStats: []*endpoint.UpstreamLocalityStats{{
+				Locality:                &envoy_api_v2_core1.Locality{Region: "region1", Zone: "lrs-zone"},
+				TotalSuccessfulRequests: 10,
+				TotalErrorRequests:      1,
+				TotalRequestsInProgress: 2,
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+
+	// The report is processed asynchronously.
+	loadzURL := fmt.Sprintf("http://localhost:%d/debug/loadz", util.MockPilotHTTPPort)
+	var statusStr string
+	for i := 0; i < 50; i++ {
+		res, err := http.Get(loadzURL)
+		if err != nil {
+			t.Fatal("Failed to fetch /loadz", err)
+		}
+		data, err := ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			t.Fatal("Failed to read /loadz", err)
+		}
+		statusStr = string(data)
+		if strings.Contains(statusStr, "lrs-zone") {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("Load report not found ", statusStr)
+}
+
+// TestLRSClusterUpdate verifies that envoy is asked to report the clusters watched after its
+// first load report.
+func TestLRSClusterUpdate(t *testing.T) {
+	initLocalPilotTestEnv()
+
+	lrsstr := connectLRS(util.MockPilotGrpcAddr, t)
+	defer func() { _ = lrsstr.CloseSend() }()
+
+	if _, err := lrsstr.Recv(); err != nil {
+		t.Fatal("Failed to receive LRS", err)
+	}
+
+	// Watching a new cluster changes the clusters to report.
+	lrsCluster := "outbound|lrs||service3.default.svc.cluster.local"
+	adsstr := connectADS(util.MockPilotGrpcAddr, t)
+	defer func() { _ = adsstr.CloseSend() }()
+	sendADSReq(t, adsstr, endpointType, []string{lrsCluster}, "")
+	_ = recvADSWithTimeout(t, adsstr, endpointType)
+
+	err := lrsstr.Send(&loadstats.LoadStatsRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id: sidecarId(app3Ip, "app3"),
+		},
+	})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+
+	res, err := lrsstr.Recv()
+	if err != nil {
+		t.Fatal("Failed to receive LRS", err)
+	}
+	for _, c := range res.Clusters {
+		if c == lrsCluster {
+			return
+		}
+	}
+	t.Errorf("Expecting %s in the clusters to report, got %v", lrsCluster, res.Clusters)
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "pilot"
+	metricsSubsystem = "xds"
+
+	// metricLabelPushType distinguishes global (full) pushes from incremental pushes.
+	metricLabelPushType = "type"
+
+	pushTypeFull        = "full"
+	pushTypeIncremental = "incremental"
+
+	metricLabelCluster  = "cluster"
+	metricLabelLocality = "locality"
+	metricLabelResult   = "result"
+)
+
+var (
+	edsPushes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "eds_pushes",
+			Help:      "Count of EDS pushes to connected envoys",
+		}, []string{metricLabelPushType})
+
+	edsClusterUpdates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "eds_cluster_updates",
+			Help:      "Count of EDS cluster load assignments recomputed",
+		}, []string{metricLabelPushType})
+
+	edsPushTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "eds_push_time_seconds",
+			Help:      "Time to recompute the affected EDS clusters and notify their connections",
+			Buckets:   []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10},
+		}, []string{metricLabelPushType})
+
+	edsEventLatency = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "eds_event_latency_seconds",
+			Help:      "Time from the first registry event of a debounced batch to the incremental EDS push",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30},
+		})
+
+	lrsReports = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "lrs_reports",
+			Help:      "Count of load reports received from envoys",
+		})
+
+	lrsRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "lrs_requests",
+			Help:      "Count of upstream requests reported by envoys, per cluster, locality and result",
+		}, []string{metricLabelCluster, metricLabelLocality, metricLabelResult})
+
+	lrsRequestsInProgress = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "lrs_requests_in_progress",
+			Help:      "Upstream requests in progress in the last reports, per cluster and locality",
+		}, []string{metricLabelCluster, metricLabelLocality})
+)
+
+func init() {
+	prometheus.MustRegister(edsPushes)
+	prometheus.MustRegister(edsClusterUpdates)
+	prometheus.MustRegister(edsPushTime)
+	prometheus.MustRegister(edsEventLatency)
+	prometheus.MustRegister(lrsReports)
+	prometheus.MustRegister(lrsRequests)
+	prometheus.MustRegister(lrsRequestsInProgress)
+}
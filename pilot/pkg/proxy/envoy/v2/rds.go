@@ -0,0 +1,288 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/v1alpha3"
+	"istio.io/istio/pkg/log"
+)
+
+var (
+	rdsDebug = os.Getenv("PILOT_DEBUG_RDS") != "0"
+
+	rdsClientsMutex sync.RWMutex
+	rdsClients      = map[string]*RdsConnection{}
+)
+
+// RdsConnection is a route discovery connection from an envoy.
+type RdsConnection struct {
+	// PeerAddr is the address of the client envoy, from network layer
+	PeerAddr string
+
+	// Time of connection, for debugging
+	Connect time.Time
+
+	// ConID is the connection identifier, used as a key in the connection table.
+	ConID string
+
+	// Node is the name of the remote node
+	Node string
+
+	// Routes is the list of route names watched by envoy.
+	Routes []string
+
+	// Sending on this channel results in  push.
+	pushChannel chan struct{}
+
+	// RouteConfigs is the last RDS response pushed, for debugging.
+	RouteConfigs []*xdsapi.RouteConfiguration
+}
+
+// StreamRoutes implements xdsapi.RouteDiscoveryServiceServer.StreamRoutes().
+func (s *DiscoveryServer) StreamRoutes(stream xdsapi.RouteDiscoveryService_StreamRoutesServer) error {
+	peerInfo, ok := peer.FromContext(stream.Context())
+	peerAddr := unknownPeerAddressStr
+	if ok {
+		peerAddr = peerInfo.Addr.String()
+	}
+	var discReq *xdsapi.DiscoveryRequest
+	var receiveError error
+	reqChannel := make(chan *xdsapi.DiscoveryRequest, 1)
+	node := model.Proxy{}
+
+	// true if the stream received the initial discovery request.
+	initialRequestReceived := false
+
+	con := &RdsConnection{
+		pushChannel: make(chan struct{}, 1),
+		PeerAddr:    peerAddr,
+		Connect:     time.Now(),
+	}
+	go func() {
+		defer close(reqChannel)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				log.Errorf("RDS: close for client %s %q terminated with errors %v",
+					con.ConID, peerAddr, err)
+				removeRdsCon(con.ConID)
+				if status.Code(err) == codes.Canceled || err == io.EOF {
+					return
+				}
+				receiveError = err
+				return
+			}
+			reqChannel <- req
+		}
+	}()
+	for {
+		// Block until either a request is received or a push is triggered.
+		select {
+		case discReq, ok = <-reqChannel:
+			if !ok {
+				return receiveError
+			}
+			nt, err := model.ParseServiceNode(discReq.Node.Id)
+			if err != nil {
+				return err
+			}
+			node = nt
+
+			routes := discReq.GetResourceNames()
+			if initialRequestReceived && sameNames(routes, con.Routes) {
+				// Given that Pilot holds an eventually consistent data model, Pilot ignores any acknowledgements
+				// from Envoy, whether they indicate ack success or ack failure of Pilot's previous responses.
+				if discReq.ErrorDetail != nil {
+					log.Warnf("RDS: ACK ERROR %v %s %v", peerAddr, nt.ID, discReq.String())
+				}
+				if rdsDebug {
+					log.Infof("RDS: ACK %s %s %v", con.ConID, discReq.VersionInfo, routes)
+				}
+				continue
+			}
+			if !initialRequestReceived {
+				initialRequestReceived = true
+				con.ConID = connectionID(nt.ID)
+				con.Node = nt.ID
+				addRdsCon(con.ConID, con)
+			}
+			rdsClientsMutex.Lock()
+			con.Routes = routes
+			rdsClientsMutex.Unlock()
+
+			if rdsDebug {
+				log.Infof("RDS: REQ %s %v %v raw: %s", con.ConID, peerAddr, routes, discReq.String())
+			}
+
+		case <-con.pushChannel:
+		}
+
+		if len(con.Routes) == 0 {
+			// Corner case: push received before the first request.
+			continue
+		}
+
+		rc := s.generateRawRoutes(con.Routes, node)
+		rdsClientsMutex.Lock()
+		con.RouteConfigs = rc
+		rdsClientsMutex.Unlock()
+
+		response := routeDiscoveryResponse(rc)
+		err := stream.Send(response)
+		if err != nil {
+			log.Warnf("RDS: Send failure, closing grpc %v", err)
+			return err
+		}
+		if rdsDebug {
+			log.Infof("RDS: PUSH for node:%s addr:%q routes:%v", con.ConID, peerAddr, con.Routes)
+		}
+	}
+}
+
+// FetchRoutes implements xdsapi.RouteDiscoveryServiceServer.FetchRoutes().
+func (s *DiscoveryServer) FetchRoutes(ctx context.Context, req *xdsapi.DiscoveryRequest) (*xdsapi.DiscoveryResponse, error) {
+	if req.Node == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "missing node in RDS request")
+	}
+	node, err := model.ParseServiceNode(req.Node.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid node %q: %v", req.Node.Id, err)
+	}
+	rc := s.generateRawRoutes(req.GetResourceNames(), node)
+	return routeDiscoveryResponse(rc), nil
+}
+
+// generateRawRoutes builds the route configurations with the given names for a node. Routes that
+// can't be built are logged and skipped.
+func (s *DiscoveryServer) generateRawRoutes(routeNames []string, node model.Proxy) []*xdsapi.RouteConfiguration {
+	rc := make([]*xdsapi.RouteConfiguration, 0, len(routeNames))
+	for _, routeName := range routeNames {
+		r, err := v1alpha3.BuildRoutes(s.env, node, routeName)
+		if err != nil {
+			log.Warnf("RDS: failed to build route %s for node %s: %v", routeName, node.ID, err)
+			continue
+		}
+		rc = append(rc, r)
+	}
+	return rc
+}
+
+// routeDiscoveryResponse returns a DiscoveryResponse for the given route configurations.
+func routeDiscoveryResponse(rc []*xdsapi.RouteConfiguration) *xdsapi.DiscoveryResponse {
+	resp := &xdsapi.DiscoveryResponse{
+		TypeUrl:     routeType,
+		VersionInfo: versionInfo(),
+		Nonce:       nonce(),
+	}
+	for _, r := range rc {
+		rr, _ := types.MarshalAny(r)
+		resp.Resources = append(resp.Resources, *rr)
+	}
+	return resp
+}
+
+// rdsPushAll implements old style invalidation, generated when any rule or endpoint changes.
+func rdsPushAll() {
+	rdsClientsMutex.RLock()
+	// Create a temp map to avoid locking the add/remove
+	tmpMap := map[string]*RdsConnection{}
+	for k, v := range rdsClients {
+		tmpMap[k] = v
+	}
+	rdsClientsMutex.RUnlock()
+
+	for _, client := range tmpMap {
+		client.pushChannel <- struct{}{}
+	}
+}
+
+// Rdsz implements a status and debug interface for RDS.
+// It is mapped to /debug/rdsz on the monitor port (9093).
+func Rdsz(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+	if req.Form.Get("debug") != "" {
+		rdsDebug = req.Form.Get("debug") == "1"
+		return
+	}
+	if req.Form.Get("push") != "" {
+		rdsPushAll()
+		rdsClientsMutex.RLock()
+		fmt.Fprintf(w, "Pushed to %d servers", len(rdsClients))
+		rdsClientsMutex.RUnlock()
+		return
+	}
+
+	rdsClientsMutex.RLock()
+	defer rdsClientsMutex.RUnlock()
+
+	// Route configurations need jsonpb, same as the listeners in LDSz.
+	fmt.Fprint(w, "[\n")
+	comma2 := false
+	for _, c := range rdsClients {
+		if comma2 {
+			fmt.Fprint(w, ",\n")
+		} else {
+			comma2 = true
+		}
+		fmt.Fprintf(w, "\n\n  {\"node\": \"%s\", \"addr\": \"%s\", \"connect\": \"%v\",\"routes\":[\n", c.Node, c.PeerAddr, c.Connect)
+		comma1 := false
+		for _, rc := range c.RouteConfigs {
+			if comma1 {
+				fmt.Fprint(w, ",\n")
+			} else {
+				comma1 = true
+			}
+			jsonm := &jsonpb.Marshaler{}
+			dbgString, _ := jsonm.MarshalToString(rc)
+			if _, err := w.Write([]byte(dbgString)); err != nil {
+				return
+			}
+		}
+		fmt.Fprint(w, "]}\n")
+	}
+	fmt.Fprint(w, "]\n")
+}
+
+func addRdsCon(s string, connection *RdsConnection) {
+	rdsClientsMutex.Lock()
+	defer rdsClientsMutex.Unlock()
+	rdsClients[s] = connection
+}
+
+func removeRdsCon(s string) {
+	rdsClientsMutex.Lock()
+	defer rdsClientsMutex.Unlock()
+
+	if rdsClients[s] == nil {
+		log.Errorf("RDS: Removing connection for non-existing node %s.", s)
+	}
+	delete(rdsClients, s)
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/tests/util"
+)
+
+func connectRDS(url, nodeID string, routes []string, t *testing.T) xdsapi.RouteDiscoveryService_StreamRoutesClient {
+	conn, err := grpc.Dial(url, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal("Connection failed", err)
+	}
+
+	xds := xdsapi.NewRouteDiscoveryServiceClient(conn)
+	rdsstr, err := xds.StreamRoutes(context.Background())
+	if err != nil {
+		t.Fatal("Rpc failed", err)
+	}
+	err = rdsstr.Send(&xdsapi.DiscoveryRequest{
+		Node: &envoy_api_v2_core1.Node{
+			Id: nodeID,
+		},
+		ResourceNames: routes,
+	})
+	if err != nil {
+		t.Fatal("Send failed", err)
+	}
+	return rdsstr
+}
+
+// TestRDS is running RDSv2 tests.
+func TestRDS(t *testing.T) {
+	initLocalPilotTestEnv()
+
+	t.Run("sidecar", func(t *testing.T) {
+		rdsr := connectRDS(util.MockPilotGrpcAddr, sidecarId(app3Ip, "app3"), []string{"1080"}, t)
+
+		res, err := rdsr.Recv()
+		if err != nil {
+			t.Fatal("Failed to receive RDS", err)
+			return
+		}
+
+		strResponse, _ := model.ToJSONWithIndent(res, " ")
+		_ = ioutil.WriteFile(util.IstioOut+"/rdsv2_sidecar.json", []byte(strResponse), 0644)
+
+		if len(res.Resources) != 1 {
+			t.Fatal("Expecting one route configuration, got ", len(res.Resources))
+		}
+		rc := &xdsapi.RouteConfiguration{}
+		if err := types.UnmarshalAny(&res.Resources[0], rc); err != nil {
+			t.Fatal("Failed to decode route configuration", err)
+		}
+		if rc.Name != "1080" {
+			t.Error("Unexpected route name ", rc.Name)
+		}
+		if len(rc.VirtualHosts) == 0 {
+			t.Error("No virtual hosts for service3")
+		}
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		conn, err := grpc.Dial(util.MockPilotGrpcAddr, grpc.WithInsecure())
+		if err != nil {
+			t.Fatal("Connection failed", err)
+		}
+		defer conn.Close() // nolint: errcheck
+
+		res, err := xdsapi.NewRouteDiscoveryServiceClient(conn).FetchRoutes(context.Background(),
+			&xdsapi.DiscoveryRequest{
+				Node: &envoy_api_v2_core1.Node{
+					Id: sidecarId(app3Ip, "app3"),
+				},
+				ResourceNames: []string{"1080"},
+			})
+		if err != nil {
+			t.Fatal("Failed to fetch RDS", err)
+		}
+		if len(res.Resources) != 1 {
+			t.Fatal("Expecting one route configuration, got ", len(res.Resources))
+		}
+	})
+}
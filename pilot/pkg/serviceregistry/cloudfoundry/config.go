@@ -42,6 +42,11 @@ type Config struct {
 	// Cloud Foundry currently only supports applications exposing a single HTTP or TCP port
 	// It is typically set to 8080.
 	ServicePort int `yaml:"service_port" validate:"nonzero"`
+
+	// Region and Zone are the locality of the Cloud Foundry applications. Copilot doesn't
+	// report the availability zone of each backend, so all backends share this locality.
+	Region string `yaml:"region,omitempty"`
+	Zone   string `yaml:"zone,omitempty"`
 }
 
 // LoadConfig reads configuration data from a YAML file
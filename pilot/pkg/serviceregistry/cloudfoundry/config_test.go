@@ -61,6 +61,8 @@ func newTestState() *testState {
 				PollInterval:     90 * time.Second,
 			},
 			ServicePort: 8080,
+			Region:      "us-east-1",
+			Zone:        "us-east-1a",
 		},
 	}
 }
@@ -35,6 +35,9 @@ type ServiceDiscovery struct {
 	// Cloud Foundry currently only supports applications exposing a single HTTP or TCP port
 	// It is typically 8080
 	ServicePort int
+
+	// Locality of all the backends, since it is not reported by copilot
+	Locality model.Locality
 }
 
 // Services implements a service catalog operation
@@ -98,6 +101,7 @@ func (sd *ServiceDiscovery) Instances(hostname string, ports []string, tagsList
 				MeshExternal: false,
 				Resolution:   model.ClientSideLB,
 			},
+			Locality: sd.Locality,
 		})
 	}
 
@@ -54,6 +54,10 @@ func (c *Controller) Services() ([]*model.Service, error) {
 		if err != nil {
 			return nil, err
 		}
+		// services without instances have no known ports
+		if len(endpoints) == 0 {
+			continue
+		}
 		services = append(services, convertService(endpoints))
 	}
 
@@ -87,14 +91,34 @@ func (c *Controller) getServices() (map[string][]string, error) {
 	return data, nil
 }
 
+// getCatalogService returns the instances of a service, whatever their health
 func (c *Controller) getCatalogService(name string, q *api.QueryOptions) ([]*api.CatalogService, error) {
-	endpoints, _, err := c.client.Catalog().Service(name, "", q)
+	entries, err := c.getServiceEntries(name, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertHealthEntries(entries), nil
+}
+
+// getHealthyCatalogService returns the healthy instances of a service
+func (c *Controller) getHealthyCatalogService(name string, q *api.QueryOptions) ([]*api.CatalogService, error) {
+	entries, err := c.getServiceEntries(name, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertHealthEntries(healthyEntries(entries)), nil
+}
+
+func (c *Controller) getServiceEntries(name string, q *api.QueryOptions) ([]*api.ServiceEntry, error) {
+	entries, _, err := c.client.Health().Service(name, "", false, q)
 	if err != nil {
 		log.Warnf("Could not retrieve service catalogue from consul: %v", err)
 		return nil, err
 	}
 
-	return endpoints, nil
+	return entries, nil
 }
 
 // ManagementPorts retries set of health check ports by instance IP.
@@ -105,8 +129,8 @@ func (c *Controller) ManagementPorts(addr string) model.PortList {
 	return nil
 }
 
-// Instances retrieves instances for a service and its ports that match
-// any of the supplied labels. All instances match an empty tag list.
+// Instances retrieves the healthy instances for a service and its ports that
+// match any of the supplied labels. All instances match an empty tag list.
 func (c *Controller) Instances(hostname string, ports []string,
 	labels model.LabelsCollection) ([]*model.ServiceInstance, error) {
 	// Get actual service by name
@@ -121,7 +145,7 @@ func (c *Controller) Instances(hostname string, ports []string,
 		portMap[port] = true
 	}
 
-	endpoints, err := c.getCatalogService(name, nil)
+	endpoints, err := c.getHealthyCatalogService(name, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -76,12 +77,20 @@ var (
 	}
 )
 
+// mockServer is an in-process fake of the consul catalog HTTP API, supporting blocking queries.
 type mockServer struct {
 	Server      *httptest.Server
 	Services    map[string][]string
 	Productpage []*api.CatalogService
 	Reviews     []*api.CatalogService
-	Lock        sync.Mutex
+	// Health is the status of the instances, by ID. Instances are passing by default.
+	Health map[string]string
+	Lock   sync.Mutex
+
+	// index is the raft index of the catalog, incremented by each update
+	index uint64
+	// changed is closed when the catalog is updated
+	changed chan struct{}
 }
 
 func newServer() *mockServer {
@@ -89,6 +98,9 @@ func newServer() *mockServer {
 		Productpage: make([]*api.CatalogService, len(productpage)),
 		Reviews:     make([]*api.CatalogService, len(reviews)),
 		Services:    make(map[string][]string),
+		Health:      make(map[string]string),
+		index:       1,
+		changed:     make(chan struct{}),
 	}
 
 	copy(m.Reviews, reviews)
@@ -98,35 +110,105 @@ func newServer() *mockServer {
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.block(r)
+
+		m.Lock.Lock()
+		var data []byte
 		if r.URL.Path == "/v1/catalog/services" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Services)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/reviews" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Reviews)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/productpage" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Productpage)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			fmt.Fprintln(w, string(data))
+			data, _ = json.Marshal(&m.Services)
+		} else if r.URL.Path == "/v1/health/service/reviews" {
+			data, _ = json.Marshal(m.serviceEntries(m.Reviews))
+		} else if r.URL.Path == "/v1/health/service/productpage" {
+			data, _ = json.Marshal(m.serviceEntries(m.Productpage))
 		} else {
-			data, _ := json.Marshal(&[]*api.CatalogService{})
-			w.Header().Set("Content-Type", "application/json")
-			fmt.Fprintln(w, string(data))
+			data, _ = json.Marshal(&[]*api.ServiceEntry{})
 		}
+		index := m.index
+		m.Lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+		fmt.Fprintln(w, string(data))
 	}))
 
 	m.Server = server
 	return &m
 }
 
+// serviceEntries converts catalog services to the entries of the health API, with a service check
+// in the status set in Health.
+func (m *mockServer) serviceEntries(endpoints []*api.CatalogService) []*api.ServiceEntry {
+	out := make([]*api.ServiceEntry, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		status, exists := m.Health[endpoint.ID]
+		if !exists {
+			status = api.HealthPassing
+		}
+		out = append(out, &api.ServiceEntry{
+			Node: &api.Node{
+				ID:         endpoint.ID,
+				Node:       endpoint.Node,
+				Address:    endpoint.Address,
+				Datacenter: endpoint.Datacenter,
+				Meta:       endpoint.NodeMeta,
+			},
+			Service: &api.AgentService{
+				ID:      endpoint.ServiceID,
+				Service: endpoint.ServiceName,
+				Tags:    endpoint.ServiceTags,
+				Port:    endpoint.ServicePort,
+				Address: endpoint.ServiceAddress,
+			},
+			Checks: api.HealthChecks{
+				{
+					Node:        endpoint.Node,
+					CheckID:     "service:" + endpoint.ServiceID,
+					Status:      status,
+					ServiceID:   endpoint.ServiceID,
+					ServiceName: endpoint.ServiceName,
+				},
+			},
+		})
+	}
+	return out
+}
+
+// block waits until the catalog changes, for blocking queries with an index that is not stale.
+func (m *mockServer) block(r *http.Request) {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	if index == 0 {
+		return
+	}
+	wait := 5 * time.Minute
+	if d, err := time.ParseDuration(r.URL.Query().Get("wait")); err == nil {
+		wait = d
+	}
+
+	m.Lock.Lock()
+	if index < m.index {
+		m.Lock.Unlock()
+		return
+	}
+	changed := m.changed
+	m.Lock.Unlock()
+
+	select {
+	case <-changed:
+	case <-time.After(wait):
+	case <-r.Context().Done():
+	}
+}
+
+// Update changes the catalog and unblocks the pending blocking queries.
+func (m *mockServer) Update(f func()) {
+	m.Lock.Lock()
+	defer m.Lock.Unlock()
+	f()
+	m.index++
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
 func TestInstances(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
@@ -189,6 +271,29 @@ func TestInstances(t *testing.T) {
 	}
 }
 
+func TestInstancesUnhealthy(t *testing.T) {
+	ts := newServer()
+	defer ts.Server.Close()
+	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	if err != nil {
+		t.Errorf("could not create Consul Controller: %v", err)
+	}
+
+	ts.Health["333-333-333"] = api.HealthCritical
+	ts.Health["444-444-444"] = api.HealthWarning
+
+	instances, err := controller.Instances(serviceHostname("reviews"), []string{}, model.LabelsCollection{})
+	if err != nil {
+		t.Errorf("client encountered error during Instances(): %v", err)
+	}
+	if len(instances) != 1 {
+		t.Fatalf("Instances() did not filter unhealthy instances => %d, want 1", len(instances))
+	}
+	if instances[0].Endpoint.Address != "172.19.0.6" {
+		t.Errorf("Instances() returned wrong instance => %q, want %q", instances[0].Endpoint.Address, "172.19.0.6")
+	}
+}
+
 func TestInstancesBadHostname(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
@@ -331,6 +436,39 @@ func TestServices(t *testing.T) {
 	}
 }
 
+func TestServicesUnhealthy(t *testing.T) {
+	ts := newServer()
+	defer ts.Server.Close()
+	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	if err != nil {
+		t.Errorf("could not create Consul Controller: %v", err)
+	}
+
+	// services are listed even when none of their instances is healthy
+	ts.Health["111-111-111"] = api.HealthCritical
+
+	services, err := controller.Services()
+	if err != nil {
+		t.Errorf("client encountered error during Services(): %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Services() returned wrong # of services: %d, want 2", len(services))
+	}
+
+	service, err := controller.GetService(serviceHostname("productpage"))
+	if err != nil || service == nil {
+		t.Errorf("GetService() of a service without healthy instances => (%v, %v)", service, err)
+	}
+
+	instances, err := controller.Instances(serviceHostname("productpage"), []string{}, model.LabelsCollection{})
+	if err != nil {
+		t.Errorf("client encountered error during Instances(): %v", err)
+	}
+	if len(instances) != 0 {
+		t.Errorf("Instances() did not filter unhealthy instances => %d, want 0", len(instances))
+	}
+}
+
 func TestServicesError(t *testing.T) {
 	ts := newServer()
 	controller, err := NewController(ts.Server.URL, 3*time.Second)
@@ -371,6 +509,26 @@ func TestGetProxyServiceInstances(t *testing.T) {
 	}
 }
 
+func TestGetProxyServiceInstancesUnhealthy(t *testing.T) {
+	ts := newServer()
+	defer ts.Server.Close()
+	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	if err != nil {
+		t.Errorf("could not create Consul Controller: %v", err)
+	}
+
+	// the sidecar of an unhealthy instance keeps its inbound configuration
+	ts.Health["111-111-111"] = api.HealthCritical
+
+	services, err := controller.GetProxyServiceInstances(model.Proxy{IPAddress: "172.19.0.11"})
+	if err != nil {
+		t.Errorf("client encountered error during GetProxyServiceInstances(): %v", err)
+	}
+	if len(services) != 1 {
+		t.Errorf("GetProxyServiceInstances() returned wrong # of endpoints => %d, want 1", len(services))
+	}
+}
+
 func TestGetProxyServiceInstancesError(t *testing.T) {
 	ts := newServer()
 	controller, err := NewController(ts.Server.URL, 3*time.Second)
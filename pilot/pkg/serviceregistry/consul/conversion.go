@@ -28,18 +28,70 @@ import (
 const (
 	protocolTagName = "protocol"
 	externalTagName = "external"
+
+	// Node metadata keys for the locality of the instances on the node. If the region is not
+	// set, the datacenter is used.
+	regionTagName  = "region"
+	zoneTagName    = "zone"
+	subzoneTagName = "subzone"
 )
 
+// convertLabels converts the tags of a consul service to labels. Tags are split on the first
+// "|" or "=" separator.
 func convertLabels(labels []string) model.Labels {
 	out := make(model.Labels, len(labels))
 	for _, tag := range labels {
-		vals := strings.Split(tag, "|")
-		// Labels not of form "key|value" are ignored to avoid possible collisions
-		if len(vals) > 1 {
-			out[vals[0]] = vals[1]
+		// Labels not of form "key|value" or "key=value" are ignored to avoid possible collisions
+		if i := strings.IndexAny(tag, "|="); i > 0 {
+			out[tag[:i]] = tag[i+1:]
 		} else {
-			log.Warnf("Tag %v ignored since it is not of form key|value", tag)
+			log.Warnf("Tag %v ignored since it is not of form key|value or key=value", tag)
+		}
+	}
+	return out
+}
+
+// healthyEntries returns the entries of the consul health API for which the service and node
+// health checks are all passing.
+func healthyEntries(entries []*api.ServiceEntry) []*api.ServiceEntry {
+	out := make([]*api.ServiceEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Node == nil || entry.Service == nil {
+			continue
+		}
+		if status := entry.Checks.AggregatedStatus(); status != api.HealthPassing {
+			log.Debugf("Instance %s of service %s on node %s ignored since its health is %q",
+				entry.Service.ID, entry.Service.Service, entry.Node.Node, status)
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// convertHealthEntries converts the entries of the consul health API to catalog services.
+func convertHealthEntries(entries []*api.ServiceEntry) []*api.CatalogService {
+	out := make([]*api.CatalogService, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Node == nil || entry.Service == nil {
+			continue
 		}
+		out = append(out, &api.CatalogService{
+			ID:                       entry.Node.ID,
+			Node:                     entry.Node.Node,
+			Address:                  entry.Node.Address,
+			Datacenter:               entry.Node.Datacenter,
+			TaggedAddresses:          entry.Node.TaggedAddresses,
+			NodeMeta:                 entry.Node.Meta,
+			ServiceID:                entry.Service.ID,
+			ServiceName:              entry.Service.Service,
+			ServiceAddress:           entry.Service.Address,
+			ServiceTags:              entry.Service.Tags,
+			ServicePort:              entry.Service.Port,
+			ServiceEnableTagOverride: entry.Service.EnableTagOverride,
+			CreateIndex:              entry.Service.CreateIndex,
+			ModifyIndex:              entry.Service.ModifyIndex,
+		})
 	}
 	return out
 }
@@ -126,6 +178,7 @@ func convertInstance(instance *api.CatalogService) *model.ServiceInstance {
 			ServicePort: port,
 		},
 		AvailabilityZone: instance.Datacenter,
+		Locality:         convertLocality(instance),
 		Service: &model.Service{
 			Hostname: serviceHostname(instance.ServiceName),
 			Address:  instance.ServiceAddress,
@@ -139,6 +192,19 @@ func convertInstance(instance *api.CatalogService) *model.ServiceInstance {
 	}
 }
 
+// convertLocality returns the locality of a consul instance, from the metadata of its node.
+func convertLocality(instance *api.CatalogService) model.Locality {
+	region := instance.NodeMeta[regionTagName]
+	if region == "" {
+		region = instance.Datacenter
+	}
+	return model.Locality{
+		Region:  region,
+		Zone:    instance.NodeMeta[zoneTagName],
+		SubZone: instance.NodeMeta[subzoneTagName],
+	}
+}
+
 // serviceHostname produces FQDN for a consul service
 func serviceHostname(name string) string {
 	// TODO include datacenter in Hostname?
@@ -42,6 +42,7 @@ var (
 	goodLabels = []string{
 		"key1|val1",
 		"version|v1",
+		"env=prod",
 	}
 
 	badLabels = []string{
@@ -88,7 +89,7 @@ func TestConvertInstance(t *testing.T) {
 		ServiceName: name,
 		ServiceTags: []string{
 			fmt.Sprintf("%v|%v", tagKey1, tagVal1),
-			fmt.Sprintf("%v|%v", tagKey2, tagVal2),
+			fmt.Sprintf("%v=%v", tagKey2, tagVal2),
 		},
 		ServiceAddress: ip,
 		ServicePort:    port,
@@ -114,6 +115,10 @@ func TestConvertInstance(t *testing.T) {
 		t.Errorf("convertInstance() => %v, want %v", out.AvailabilityZone, dc)
 	}
 
+	if want := (model.Locality{Region: dc}); out.Locality != want {
+		t.Errorf("convertInstance() locality => %v, want %v", out.Locality, want)
+	}
+
 	if out.Endpoint.Address != ip {
 		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.Address, ip)
 	}
@@ -148,6 +153,21 @@ func TestConvertInstance(t *testing.T) {
 	}
 }
 
+func TestConvertLocality(t *testing.T) {
+	inst := &api.CatalogService{
+		Datacenter: "dc1",
+		NodeMeta: map[string]string{
+			regionTagName:  "us-east1",
+			zoneTagName:    "us-east1-b",
+			subzoneTagName: "rack1",
+		},
+	}
+	want := model.Locality{Region: "us-east1", Zone: "us-east1-b", SubZone: "rack1"}
+	if got := convertLocality(inst); got != want {
+		t.Errorf("convertLocality() => %v, want %v", got, want)
+	}
+}
+
 func TestServiceHostname(t *testing.T) {
 	out := serviceHostname("productpage")
 
@@ -15,8 +15,11 @@
 package consul
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -25,8 +28,13 @@ import (
 	"istio.io/istio/pkg/log"
 )
 
-type consulServices map[string][]string
-type consulServiceInstances []*api.CatalogService
+const (
+	// blockingQueryWait bounds the duration of the consul blocking queries.
+	blockingQueryWait = 5 * time.Minute
+
+	// maxBackoff bounds the delay before retrying a failed query.
+	maxBackoff = time.Minute
+)
 
 // Monitor handles service and instance changes
 type Monitor interface {
@@ -42,108 +50,177 @@ type InstanceHandler func(instance *api.CatalogService, event model.Event) error
 type ServiceHandler func(instances []*api.CatalogService, event model.Event) error
 
 type consulMonitor struct {
-	discovery            *api.Client
-	instanceCachedRecord consulServiceInstances
-	serviceCachedRecord  consulServices
-	instanceHandlers     []InstanceHandler
-	serviceHandlers      []ServiceHandler
-	period               time.Duration
+	discovery        *api.Client
+	instanceHandlers []InstanceHandler
+	serviceHandlers  []ServiceHandler
+
+	// period is the initial delay before retrying a failed query, and the polling period of
+	// consul servers not supporting blocking queries.
+	period time.Duration
+
+	// waitTime bounds the duration of the blocking queries.
+	waitTime time.Duration
 }
 
-// NewConsulMonitor polls for changes in Consul Services and CatalogServices
+// NewConsulMonitor watches for changes in Consul Services and CatalogServices, using blocking queries
+// for the list of services and for each service.
 func NewConsulMonitor(client *api.Client, period time.Duration) Monitor {
 	return &consulMonitor{
-		discovery:            client,
-		period:               period,
-		instanceCachedRecord: make(consulServiceInstances, 0),
-		serviceCachedRecord:  make(consulServices),
-		instanceHandlers:     make([]InstanceHandler, 0),
-		serviceHandlers:      make([]ServiceHandler, 0),
+		discovery:        client,
+		period:           period,
+		waitTime:         blockingQueryWait,
+		instanceHandlers: make([]InstanceHandler, 0),
+		serviceHandlers:  make([]ServiceHandler, 0),
 	}
 }
 
 func (m *consulMonitor) Start(stop <-chan struct{}) {
-	m.run(stop)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+	m.watchServices(ctx)
 }
 
-func (m *consulMonitor) run(stop <-chan struct{}) {
-	ticker := time.NewTicker(m.period)
+// watchServices watches the list of services, and starts or stops the watch of each service.
+func (m *consulMonitor) watchServices(ctx context.Context) {
+	watches := make(map[string]context.CancelFunc)
+	var index uint64
+	backoff := m.period
 	for {
-		select {
-		case <-stop:
-			ticker.Stop()
+		q := &api.QueryOptions{WaitIndex: index, WaitTime: m.waitTime}
+		services, meta, err := m.discovery.Catalog().Services(q.WithContext(ctx))
+		if ctx.Err() != nil {
 			return
-		case <-ticker.C:
-			m.updateServiceRecord()
-			m.updateInstanceRecord()
 		}
-	}
-}
+		if err != nil {
+			log.Warnf("Could not fetch services: %v", err)
+			if !sleep(ctx, backoff) {
+				return
+			}
+			backoff = nextBackoff(backoff)
+			continue
+		}
+		backoff = m.period
+		if index != 0 && meta.LastIndex == index {
+			// the blocking query timed out without changes
+			continue
+		}
+		index = nextIndex(index, meta.LastIndex)
 
-func (m *consulMonitor) updateServiceRecord() {
-	svcs, _, err := m.discovery.Catalog().Services(nil)
-	if err != nil {
-		log.Warnf("Could not fetch services: %v", err)
-		return
-	}
-	newRecord := consulServices(svcs)
-	if !reflect.DeepEqual(newRecord, m.serviceCachedRecord) {
-		// This is only a work-around solution currently
-		// Since Handler functions generally act as a refresher
-		// regardless of the input, thus passing in meaningless
-		// input should make functionalities work
-		//TODO
-		obj := []*api.CatalogService{}
-		var event model.Event
-		for _, f := range m.serviceHandlers {
-			go func(handler ServiceHandler) {
-				if err := handler(obj, event); err != nil {
-					log.Warnf("Error executing service handler function: %v", err)
-				}
-			}(f)
+		for name := range services {
+			if _, exists := watches[name]; !exists {
+				serviceCtx, cancel := context.WithCancel(ctx)
+				watches[name] = cancel
+				go m.watchService(ctx, serviceCtx, name)
+			}
+		}
+		for name, cancel := range watches {
+			if _, exists := services[name]; !exists {
+				cancel()
+				delete(watches, name)
+			}
+		}
+
+		if index == 0 {
+			// the server does not support blocking queries, fall back to polling
+			if !sleep(ctx, m.period) {
+				return
+			}
 		}
-		m.serviceCachedRecord = newRecord
 	}
 }
 
-func (m *consulMonitor) updateInstanceRecord() {
-	svcs, _, err := m.discovery.Catalog().Services(nil)
-	if err != nil {
-		log.Warnf("Could not fetch instances: %v", err)
-		return
-	}
-	for _, tags := range svcs {
-		sort.Strings(tags)
-	}
+// watchService watches the instances of a service, until the service is removed from the catalog
+// (serviceCtx is done) or the monitor is stopped (ctx is done). Handlers are called for the
+// instances that changed, and for the service when it is added, removed or its ports change.
+func (m *consulMonitor) watchService(ctx, serviceCtx context.Context, name string) {
+	instances := make(map[string]*api.CatalogService)
+	var ports string
+	added := false
 
-	instances := make([]*api.CatalogService, 0)
-	for name := range svcs {
-		endpoints, _, err := m.discovery.Catalog().Service(name, "", nil)
+	var index uint64
+	backoff := m.period
+	for {
+		q := &api.QueryOptions{WaitIndex: index, WaitTime: m.waitTime}
+		entries, meta, err := m.discovery.Health().Service(name, "", false, q.WithContext(serviceCtx))
+		if serviceCtx.Err() != nil {
+			if ctx.Err() == nil && added {
+				// the service was removed from the catalog
+				for _, instance := range instances {
+					m.notifyInstance(instance, model.EventDelete)
+				}
+				m.notifyService(sortedInstances(instances), model.EventDelete)
+			}
+			return
+		}
 		if err != nil {
-			log.Warnf("Could not retrieve service catalogue from consul: %v", err)
+			log.Warnf("Could not retrieve service catalogue from consul for %s: %v", name, err)
+			// if the watch is stopped meanwhile, the next iteration handles it
+			sleep(serviceCtx, backoff)
+			backoff = nextBackoff(backoff)
+			continue
+		}
+		backoff = m.period
+		if index != 0 && meta.LastIndex == index {
 			continue
 		}
-		instances = append(instances, endpoints...)
+		index = nextIndex(index, meta.LastIndex)
+		// The service is known from all its instances, but only the healthy ones get traffic.
+		endpoints := convertHealthEntries(entries)
+		healthy := convertHealthEntries(healthyEntries(entries))
+
+		current := make(map[string]*api.CatalogService, len(healthy))
+		for _, endpoint := range healthy {
+			current[instanceKey(endpoint)] = endpoint
+		}
+
+		if len(endpoints) > 0 {
+			newPorts := servicePorts(endpoints)
+			if !added {
+				added = true
+				m.notifyService(endpoints, model.EventAdd)
+			} else if newPorts != ports {
+				m.notifyService(endpoints, model.EventUpdate)
+			}
+			ports = newPorts
+		}
+
+		for key, instance := range current {
+			old, exists := instances[key]
+			if !exists {
+				m.notifyInstance(instance, model.EventAdd)
+			} else if !reflect.DeepEqual(old, instance) {
+				m.notifyInstance(instance, model.EventUpdate)
+			}
+		}
+		for key, instance := range instances {
+			if _, exists := current[key]; !exists {
+				m.notifyInstance(instance, model.EventDelete)
+			}
+		}
+		instances = current
+
+		if index == 0 {
+			sleep(serviceCtx, m.period)
+		}
 	}
+}
 
-	newRecord := consulServiceInstances(instances)
-	sort.Sort(newRecord)
-	if !reflect.DeepEqual(newRecord, m.instanceCachedRecord) {
-		// This is only a work-around solution currently
-		// Since Handler functions generally act as a refresher
-		// regardless of the input, thus passing in meaningless
-		// input should make functionalities work
-		// TODO
-		obj := &api.CatalogService{}
-		var event model.Event
-		for _, f := range m.instanceHandlers {
-			go func(handler InstanceHandler) {
-				if err := handler(obj, event); err != nil {
-					log.Warnf("Error executing instance handler function: %v", err)
-				}
-			}(f)
+func (m *consulMonitor) notifyService(instances []*api.CatalogService, event model.Event) {
+	for _, handler := range m.serviceHandlers {
+		if err := handler(instances, event); err != nil {
+			log.Warnf("Error executing service handler function: %v", err)
+		}
+	}
+}
+
+func (m *consulMonitor) notifyInstance(instance *api.CatalogService, event model.Event) {
+	for _, handler := range m.instanceHandlers {
+		if err := handler(instance, event); err != nil {
+			log.Warnf("Error executing instance handler function: %v", err)
 		}
-		m.instanceCachedRecord = newRecord
 	}
 }
 
@@ -155,17 +232,60 @@ func (m *consulMonitor) AppendInstanceHandler(h InstanceHandler) {
 	m.instanceHandlers = append(m.instanceHandlers, h)
 }
 
-// Len of the array
-func (a consulServiceInstances) Len() int {
-	return len(a)
+// nextIndex returns the index for the next blocking query. As recommended by consul, the index
+// is reset if it goes backwards.
+func nextIndex(index, lastIndex uint64) uint64 {
+	if lastIndex < index {
+		return 0
+	}
+	return lastIndex
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// sleep waits for the given duration, returning false if the context is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// instanceKey identifies a service instance in the catalog.
+func instanceKey(instance *api.CatalogService) string {
+	return instance.ID + "/" + instance.Node + "/" + instance.ServiceID
 }
 
-// Swap i and j
-func (a consulServiceInstances) Swap(i, j int) {
-	a[i], a[j] = a[j], a[i]
+// servicePorts returns a key for the ports of a service, as computed by convertService.
+func servicePorts(instances []*api.CatalogService) string {
+	set := make(map[string]bool)
+	for _, instance := range instances {
+		set[fmt.Sprintf("%d/%s/%s", instance.ServicePort,
+			instance.NodeMeta[protocolTagName], instance.NodeMeta[externalTagName])] = true
+	}
+	ports := make([]string, 0, len(set))
+	for port := range set {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	return strings.Join(ports, ",")
 }
 
-// Less i and j
-func (a consulServiceInstances) Less(i, j int) bool {
-	return a[i].ID < a[j].ID
+func sortedInstances(instances map[string]*api.CatalogService) []*api.CatalogService {
+	out := make([]*api.CatalogService, 0, len(instances))
+	for _, instance := range instances {
+		out = append(out, instance)
+	}
+	sort.Slice(out, func(i, j int) bool { return instanceKey(out[i]) < instanceKey(out[j]) })
+	return out
 }
@@ -15,6 +15,8 @@
 package consul
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
@@ -30,9 +32,6 @@ const (
 )
 
 func TestController(t *testing.T) {
-	// https://github.com/istio/istio/issues/2318
-	t.SkipNow()
-
 	ts := newServer()
 	defer ts.Server.Close()
 	conf := api.DefaultConfig()
@@ -60,6 +59,7 @@ func TestController(t *testing.T) {
 	}
 
 	ctl := NewConsulMonitor(cl, resync)
+	ctl.(*consulMonitor).waitTime = time.Second
 	ctl.AppendInstanceHandler(func(instance *api.CatalogService, event model.Event) error {
 		incrementCount()
 		return nil
@@ -74,8 +74,11 @@ func TestController(t *testing.T) {
 	go ctl.Start(stop)
 	defer close(stop)
 
+	// initial sync -> one event per service and per instance
 	time.Sleep(notifyThreshold)
-	getCountAndReset()
+	if i := getCountAndReset(); i != 6 {
+		t.Errorf("got %d notifications from controller, want %d", i, 6)
+	}
 
 	time.Sleep(notifyThreshold)
 	if i := getCountAndReset(); i != 0 {
@@ -83,11 +86,9 @@ func TestController(t *testing.T) {
 	}
 
 	// re-ordering of service instances -> does not trigger update
-	ts.Lock.Lock()
-	tmpReview := reviews[0]
-	reviews[0] = reviews[len(reviews)-1]
-	reviews[len(reviews)-1] = tmpReview
-	ts.Lock.Unlock()
+	ts.Update(func() {
+		ts.Reviews[0], ts.Reviews[len(ts.Reviews)-1] = ts.Reviews[len(ts.Reviews)-1], ts.Reviews[0]
+	})
 
 	time.Sleep(notifyThreshold)
 	if i := getCountAndReset(); i != 0 {
@@ -95,29 +96,81 @@ func TestController(t *testing.T) {
 	}
 
 	// same service, new tag -> triggers instance update
-	ts.Lock.Lock()
-	ts.Productpage[0].ServiceTags = append(ts.Productpage[0].ServiceTags, "new|tag")
-	ts.Lock.Unlock()
+	ts.Update(func() {
+		tagged := *ts.Productpage[0]
+		tagged.ServiceTags = append([]string{"new|tag"}, tagged.ServiceTags...)
+		ts.Productpage = []*api.CatalogService{&tagged}
+	})
 	time.Sleep(notifyThreshold)
 	if i := getCountAndReset(); i != 1 {
-		t.Errorf("got %d notifications from controller, want %d", i, 2)
+		t.Errorf("got %d notifications from controller, want %d", i, 1)
+	}
+
+	// failing health check -> triggers instance delete
+	ts.Update(func() {
+		ts.Health["222-222-222"] = api.HealthCritical
+	})
+	time.Sleep(notifyThreshold)
+	if i := getCountAndReset(); i != 1 {
+		t.Errorf("got %d notifications from controller, want %d", i, 1)
 	}
 
-	// delete a service instance -> trigger instance update
-	ts.Lock.Lock()
-	ts.Reviews = reviews[0:1]
-	ts.Lock.Unlock()
+	// passing health check again -> triggers instance add
+	ts.Update(func() {
+		ts.Health["222-222-222"] = api.HealthPassing
+	})
 	time.Sleep(notifyThreshold)
 	if i := getCountAndReset(); i != 1 {
 		t.Errorf("got %d notifications from controller, want %d", i, 1)
 	}
 
+	// delete the tcp and one http service instances -> trigger two instance deletes, and a
+	// service update as the tcp port is gone
+	ts.Update(func() {
+		ts.Reviews = reviews[0:1]
+	})
+	time.Sleep(notifyThreshold)
+	if i := getCountAndReset(); i != 3 {
+		t.Errorf("got %d notifications from controller, want %d", i, 3)
+	}
+
 	// delete a service -> trigger service and instance update
-	ts.Lock.Lock()
-	delete(ts.Services, "productpage")
-	ts.Lock.Unlock()
+	ts.Update(func() {
+		delete(ts.Services, "productpage")
+	})
 	time.Sleep(notifyThreshold)
 	if i := getCountAndReset(); i != 2 {
 		t.Errorf("got %d notifications from controller, want %d", i, 2)
 	}
 }
+
+func TestControllerErrorBackoff(t *testing.T) {
+	fails := 0
+	failsMutex := sync.Mutex{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failsMutex.Lock()
+		fails++
+		failsMutex.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	conf := api.DefaultConfig()
+	conf.Address = ts.URL
+	cl, err := api.NewClient(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctl := NewConsulMonitor(cl, resync)
+	stop := make(chan struct{})
+	go ctl.Start(stop)
+	time.Sleep(notifyThreshold)
+	close(stop)
+
+	// with exponential backoff, 5, 10, 20ms... only a few queries are made
+	failsMutex.Lock()
+	defer failsMutex.Unlock()
+	if fails == 0 || fails > 6 {
+		t.Errorf("got %d failed queries in %v, want between 1 and 6", fails, notifyThreshold)
+	}
+}
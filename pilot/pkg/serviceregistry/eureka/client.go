@@ -34,7 +34,13 @@ type instance struct { // nolint: maligned
 	Status     string `json:"status"`
 	Port       port   `json:"port"`
 	SecurePort port   `json:"securePort"`
-	// TODO: read dataCenterInfo for AZ support
+	// DataCenterInfo holds the availability zone of instances running in AWS
+	DataCenterInfo dataCenterInfo `json:"dataCenterInfo"`
+	Metadata       metadata       `json:"metadata,omitempty"`
+}
+
+type dataCenterInfo struct {
+	Name     string   `json:"name"`
 	Metadata metadata `json:"metadata,omitempty"`
 }
 
@@ -66,17 +66,8 @@ func (c *controller) Run(stop <-chan struct{}) {
 			sortApplications(apps)
 
 			if !reflect.DeepEqual(apps, cachedApps) {
+				c.notify(cachedApps, apps)
 				cachedApps = apps
-				// TODO: feed with real events.
-				// The handlers are being fed dummy events. This is sufficient with simplistic
-				// handlers that invalidate the cache on any event but will not work with smarter
-				// handlers.
-				for _, h := range c.serviceHandlers {
-					go h(&model.Service{}, model.EventAdd)
-				}
-				for _, h := range c.instanceHandlers {
-					go h(&model.ServiceInstance{}, model.EventAdd)
-				}
 			}
 		case <-stop:
 			ticker.Stop()
@@ -84,3 +75,61 @@ func (c *controller) Run(stop <-chan struct{}) {
 		}
 	}
 }
+
+// notify feeds the handlers with an event for each service added, removed or changed between
+// two polls, and an instance event for each service whose instances changed, so that the
+// endpoints of the service are pushed.
+func (c *controller) notify(oldApps, newApps []*application) {
+	oldServices := convertServices(oldApps, nil)
+	newServices := convertServices(newApps, nil)
+
+	for hostname, service := range newServices {
+		if old, exists := oldServices[hostname]; !exists {
+			c.serviceEvent(service, model.EventAdd)
+		} else if !reflect.DeepEqual(old, service) {
+			c.serviceEvent(service, model.EventUpdate)
+		}
+	}
+	for hostname, service := range oldServices {
+		if _, exists := newServices[hostname]; !exists {
+			c.serviceEvent(service, model.EventDelete)
+		}
+	}
+
+	oldInstances := instancesByHostname(convertServiceInstances(oldServices, oldApps))
+	newInstances := instancesByHostname(convertServiceInstances(newServices, newApps))
+
+	for hostname, instances := range newInstances {
+		if old, exists := oldInstances[hostname]; !exists {
+			c.instanceEvent(instances[0], model.EventAdd)
+		} else if !reflect.DeepEqual(old, instances) {
+			c.instanceEvent(instances[0], model.EventUpdate)
+		}
+	}
+	for hostname, instances := range oldInstances {
+		if _, exists := newInstances[hostname]; !exists {
+			c.instanceEvent(instances[0], model.EventDelete)
+		}
+	}
+}
+
+func (c *controller) serviceEvent(service *model.Service, event model.Event) {
+	for _, h := range c.serviceHandlers {
+		go h(service, event)
+	}
+}
+
+func (c *controller) instanceEvent(instance *model.ServiceInstance, event model.Event) {
+	for _, h := range c.instanceHandlers {
+		go h(instance, event)
+	}
+}
+
+// instancesByHostname groups service instances by the hostname of their service.
+func instancesByHostname(instances []*model.ServiceInstance) map[string][]*model.ServiceInstance {
+	out := make(map[string][]*model.ServiceInstance)
+	for _, instance := range instances {
+		out[instance.Service.Hostname] = append(out[instance.Service.Hostname], instance)
+	}
+	return out
+}
@@ -15,6 +15,8 @@
 package eureka
 
 import (
+	"reflect"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -102,3 +104,66 @@ func TestController(t *testing.T) {
 		t.Errorf("got %d notifications from controller, want %d", c, 2)
 	}
 }
+
+func TestControllerEvents(t *testing.T) {
+	events := make(chan string, 10)
+	ctl := NewController(&mockSyncClient{}, resync).(*controller)
+	_ = ctl.AppendServiceHandler(func(service *model.Service, event model.Event) {
+		events <- "service " + event.String() + " " + service.Hostname
+	})
+	_ = ctl.AppendInstanceHandler(func(instance *model.ServiceInstance, event model.Event) {
+		events <- "instance " + event.String() + " " + instance.Service.Hostname
+	})
+
+	hello := makeInstance("hello.world.local", "10.0.0.1", 8080, -1, nil)
+	hello2 := makeInstance("hello.world.local", "10.0.0.2", 8080, -1, nil)
+	world := makeInstance("world.local", "10.0.0.3", 8080, -1, nil)
+
+	cases := []struct {
+		old, new []*application
+		expected []string
+	}{
+		{
+			nil,
+			[]*application{{Name: "APP", Instances: []*instance{hello, world}}},
+			[]string{"instance add hello.world.local", "instance add world.local",
+				"service add hello.world.local", "service add world.local"},
+		},
+		{
+			// instance-only changes are notified for the service of the instances
+			[]*application{{Name: "APP", Instances: []*instance{hello, world}}},
+			[]*application{{Name: "APP", Instances: []*instance{hello, hello2, world}}},
+			[]string{"instance update hello.world.local"},
+		},
+		{
+			[]*application{{Name: "APP", Instances: []*instance{hello, world}}},
+			[]*application{{Name: "APP", Instances: []*instance{hello}}},
+			[]string{"instance delete world.local", "service delete world.local"},
+		},
+	}
+
+	for _, c := range cases {
+		ctl.notify(c.old, c.new)
+
+		got := make([]string, 0, len(c.expected))
+		for range c.expected {
+			select {
+			case event := <-events:
+				got = append(got, event)
+			case <-time.After(time.Second):
+			}
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("got events %v, want %v", got, c.expected)
+		}
+
+		time.Sleep(notifyThreshold)
+		if len(events) != 0 {
+			t.Errorf("got %d unexpected events", len(events))
+			for len(events) > 0 {
+				<-events
+			}
+		}
+	}
+}
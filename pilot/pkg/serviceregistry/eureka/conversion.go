@@ -16,6 +16,8 @@ package eureka
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/log"
@@ -94,8 +96,9 @@ func convertServiceInstances(services map[string]*model.Service, apps []*applica
 						Port:        port.Port,
 						ServicePort: port,
 					},
-					Service: services[instance.Hostname],
-					Labels:  convertLabels(instance.Metadata),
+					Service:  services[instance.Hostname],
+					Labels:   convertLabels(instance.Metadata),
+					Locality: convertLocality(instance),
 				})
 			}
 		}
@@ -122,6 +125,14 @@ func convertPorts(instance *instance) model.PortList {
 
 const protocolMetadata = "istio.protocol" // metadata key for port protocol
 
+const (
+	// availabilityZoneMetadata is the data center info key for the AWS availability zone
+	availabilityZoneMetadata = "availability-zone"
+	// zoneMetadata is the metadata key for the zone of instances outside AWS, as used by
+	// Spring Cloud Netflix
+	zoneMetadata = "zone"
+)
+
 func convertProtocol(md metadata) model.Protocol {
 	name := md[protocolMetadata]
 
@@ -136,6 +147,18 @@ func convertProtocol(md metadata) model.Protocol {
 	return model.ProtocolTCP // default protocol
 }
 
+// convertLocality returns the locality of an instance. The region of AWS instances is derived
+// from the availability zone, e.g. us-east-1c is in the us-east-1 region.
+func convertLocality(instance *instance) model.Locality {
+	if az := instance.DataCenterInfo.Metadata[availabilityZoneMetadata]; az != "" {
+		return model.Locality{
+			Region: strings.TrimRightFunc(az, unicode.IsLetter),
+			Zone:   az,
+		}
+	}
+	return model.Locality{Zone: instance.Metadata[zoneMetadata]}
+}
+
 func convertLabels(metadata metadata) model.Labels {
 	labels := make(model.Labels)
 	for k, v := range metadata {
@@ -208,6 +208,30 @@ func TestConvertProtocol(t *testing.T) {
 	}
 }
 
+func TestConvertLocality(t *testing.T) {
+	aws := makeInstance("foo.bar.local", "10.0.0.1", 5000, -1, nil)
+	aws.DataCenterInfo = dataCenterInfo{
+		Name:     "Amazon",
+		Metadata: metadata{availabilityZoneMetadata: "us-east-1c"},
+	}
+	spring := makeInstance("foo.bar.local", "10.0.0.2", 5000, -1, metadata{zoneMetadata: "zone1"})
+
+	localityTests := []struct {
+		in  *instance
+		out model.Locality
+	}{
+		{in: makeInstance("foo.bar.local", "10.0.0.3", 5000, -1, nil), out: model.Locality{}},
+		{in: aws, out: model.Locality{Region: "us-east-1", Zone: "us-east-1c"}},
+		{in: spring, out: model.Locality{Zone: "zone1"}},
+	}
+
+	for _, tt := range localityTests {
+		if locality := convertLocality(tt.in); locality != tt.out {
+			t.Errorf("convertLocality(%v) => %v, want %v", tt.in.IPAddress, locality, tt.out)
+		}
+	}
+}
+
 func TestConvertLabels(t *testing.T) {
 	md := metadata{
 		"@class":         "java.util.Collections$EmptyMap",
@@ -15,6 +15,8 @@
 package external
 
 import (
+	"strings"
+
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/model"
@@ -43,6 +45,11 @@ func convertService(externalService *networking.ExternalService) []*model.Servic
 		case networking.ExternalService_STATIC:
 			resolution = model.ClientSideLB
 		}
+		if strings.HasPrefix(host, "*") && resolution == model.DNSLB && len(externalService.Endpoints) == 0 {
+			// Wildcard hosts without endpoints can't be resolved, the connections are
+			// forwarded to the address resolved by the application.
+			resolution = model.Passthrough
+		}
 
 		svcPorts := make(model.PortList, 0, len(externalService.Ports))
 		for _, port := range externalService.Ports {
@@ -76,6 +76,14 @@ var httpDNSnoEndpoints = &networking.ExternalService{
 	Discovery: networking.ExternalService_DNS,
 }
 
+var httpsDNSWildcardNoEndpoints = &networking.ExternalService{
+	Hosts: []string{"*.googleapis.com"},
+	Ports: []*networking.Port{
+		{Number: 443, Name: "https-port", Protocol: "https"},
+	},
+	Discovery: networking.ExternalService_DNS,
+}
+
 var httpDNS = &networking.ExternalService{
 	Hosts: []string{"*.google.com"},
 	Ports: []*networking.Port{
@@ -215,6 +223,13 @@ func TestConvertService(t *testing.T) {
 				map[string]int{"http-port": 80, "http-alt-port": 8080}, model.DNSLB),
 			},
 		},
+		{
+			// external service DNS with a wildcard host and no endpoints
+			externalSvc: httpsDNSWildcardNoEndpoints,
+			services: []*model.Service{makeService("*.googleapis.com",
+				map[string]int{"https-port": 443}, model.Passthrough),
+			},
+		},
 		{
 			// external service dns
 			externalSvc: httpDNS,
@@ -240,28 +240,37 @@ func (c *Controller) serviceByKey(name, namespace string) (*v1.Service, bool) {
 
 // GetPodAZ retrieves the AZ for a pod.
 func (c *Controller) GetPodAZ(pod *v1.Pod) (string, bool) {
+	locality, found := c.GetPodLocality(pod)
+	if !found {
+		return "", false
+	}
+	return fmt.Sprintf("%v/%v", locality.Region, locality.Zone), true
+}
+
+// GetPodLocality retrieves the locality of a pod, from the region and zone labels of its node.
+func (c *Controller) GetPodLocality(pod *v1.Pod) (model.Locality, bool) {
 	// NodeName is set by the scheduler after the pod is created
 	// https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#late-initialization
 	node, exists, err := c.nodes.informer.GetStore().GetByKey(pod.Spec.NodeName)
 	if !exists || err != nil {
 		log.Warnf("unable to get node %q for pod %q: %v", pod.Spec.NodeName, pod.Name, err)
-		return "", false
+		return model.Locality{}, false
 	}
 	region, exists := node.(*v1.Node).Labels[NodeRegionLabel]
 	if !exists {
 		if azDebug {
 			log.Warnf("unable to retrieve region label for pod: %v", pod.Name)
 		}
-		return "", false
+		return model.Locality{}, false
 	}
 	zone, exists := node.(*v1.Node).Labels[NodeZoneLabel]
 	if !exists {
 		if azDebug {
 			log.Warnf("unable to retrieve zone label for pod: %v", pod.Name)
 		}
-		return "", false
+		return model.Locality{}, false
 	}
-	return fmt.Sprintf("%v/%v", region, zone), true
+	return model.Locality{Region: region, Zone: zone}, true
 }
 
 // ManagementPorts implements a service catalog operation
@@ -324,8 +333,12 @@ func (c *Controller) Instances(hostname string, ports []string,
 
 					pod, exists := c.pods.getPodByIP(ea.IP)
 					az, sa := "", ""
+					var locality model.Locality
 					if exists {
-						az, _ = c.GetPodAZ(pod)
+						if l, found := c.GetPodLocality(pod); found {
+							locality = l
+							az = fmt.Sprintf("%v/%v", l.Region, l.Zone)
+						}
 						sa = kubeToIstioServiceAccount(pod.Spec.ServiceAccountName, pod.GetNamespace(), c.domainSuffix)
 					}
 
@@ -341,6 +354,7 @@ func (c *Controller) Instances(hostname string, ports []string,
 								Service:          svc,
 								Labels:           labels,
 								AvailabilityZone: az,
+								Locality:         locality,
 								ServiceAccount:   sa,
 							})
 						}
@@ -384,8 +398,12 @@ func (c *Controller) GetProxyServiceInstances(proxy model.Proxy) ([]*model.Servi
 						labels, _ := c.pods.labelsByIP(ea.IP)
 						pod, exists := c.pods.getPodByIP(ea.IP)
 						az, sa := "", ""
+						var locality model.Locality
 						if exists {
-							az, _ = c.GetPodAZ(pod)
+							if l, found := c.GetPodLocality(pod); found {
+								locality = l
+								az = fmt.Sprintf("%v/%v", l.Region, l.Zone)
+							}
 							sa = kubeToIstioServiceAccount(pod.Spec.ServiceAccountName, pod.GetNamespace(), c.domainSuffix)
 							if kubeNodes[ea.IP].PodName != pod.GetName() || kubeNodes[ea.IP].Namespace != pod.GetNamespace() {
 								log.Warnf("Endpoint %v with pod %v in namespace %v is inconsistent "+
@@ -404,6 +422,7 @@ func (c *Controller) GetProxyServiceInstances(proxy model.Proxy) ([]*model.Servi
 							Service:          svc,
 							Labels:           labels,
 							AvailabilityZone: az,
+							Locality:         locality,
 							ServiceAccount:   sa,
 						})
 					}
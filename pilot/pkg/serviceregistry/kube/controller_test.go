@@ -209,6 +209,10 @@ func TestController_getPodAZ(t *testing.T) {
 					if !reflect.DeepEqual(az, wantAZ) {
 						t.Errorf("Wanted az: %s, got: %s", wantAZ, az)
 					}
+					locality, _ := controller.GetPodLocality(pod)
+					if got := locality.Region + "/" + locality.Zone; got != wantAZ {
+						t.Errorf("Wanted locality: %s, got: %s", wantAZ, got)
+					}
 				} else {
 					if found {
 						t.Errorf("Unexpectedly found az: %s for pod: %s", az, pod.ObjectMeta.Name)
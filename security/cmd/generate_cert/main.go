@@ -44,7 +44,8 @@ var (
 	org            = flag.String("organization", "Juju org", "Organization for the cert.")
 	outCert        = flag.String("out-cert", "cert.pem", "Output certificate file.")
 	outPriv        = flag.String("out-priv", "priv.pem", "Output private key file.")
-	keySize        = flag.Int("key-size", 2048, "Size of the generated private key")
+	keySize        = flag.Int("key-size", 2048, "Size of the generated RSA private key")
+	keyAlgo        = flag.String("key-algorithm", "RSA", "Algorithm of the generated private key: RSA, ECDSA-P256 or ECDSA-P384.")
 )
 
 func fatalf(template string, args ...interface{}) {
@@ -55,6 +56,10 @@ func fatalf(template string, args ...interface{}) {
 func checkCmdLine() {
 	flag.Parse()
 
+	if _, err := util.ParseKeyAlgorithm(*keyAlgo); err != nil {
+		fatalf("Invalid --key-algorithm: %s.", err)
+	}
+
 	hasCert, hasPriv := len(*signerCertFile) != 0, len(*signerPrivFile) != 0
 	if *isSelfSigned {
 		if hasCert || hasPriv {
@@ -105,6 +110,7 @@ func main() {
 		IsCA:         *isCA,
 		IsSelfSigned: *isSelfSigned,
 		IsClient:     *isClient,
+		KeyAlgorithm: util.KeyAlgorithm(*keyAlgo),
 		RSAKeySize:   *keySize,
 	})
 
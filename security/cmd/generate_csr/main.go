@@ -31,7 +31,8 @@ var (
 	org     = flag.String("organization", "Juju org", "Organization for the cert.")
 	outCsr  = flag.String("out-csr", "csr.pem", "Output csr file.")
 	outPriv = flag.String("out-priv", "priv.pem", "Output private key file.")
-	keySize = flag.Int("key-size", 2048, "Size of the generated private key")
+	keySize = flag.Int("key-size", 2048, "Size of the generated RSA private key")
+	keyAlgo = flag.String("key-algorithm", "RSA", "Algorithm of the generated private key: RSA, ECDSA-P256 or ECDSA-P384.")
 )
 
 func fatalf(template string, args ...interface{}) {
@@ -54,10 +55,16 @@ func saveCreds(csrPem []byte, privPem []byte) {
 func main() {
 	flag.Parse()
 
+	keyAlgorithm, err := util.ParseKeyAlgorithm(*keyAlgo)
+	if err != nil {
+		fatalf("Invalid --key-algorithm: %s.", err)
+	}
+
 	csrPem, privPem, err := util.GenCSR(util.CertOptions{
-		Host:       *host,
-		Org:        *org,
-		RSAKeySize: *keySize,
+		Host:         *host,
+		Org:          *org,
+		KeyAlgorithm: keyAlgorithm,
+		RSAKeySize:   *keySize,
 	})
 
 	if err != nil {
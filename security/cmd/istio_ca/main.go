@@ -34,10 +34,13 @@ import (
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ca/controller"
+	"istio.io/istio/security/pkg/pki/issuance"
+	"istio.io/istio/security/pkg/pki/util"
 	probecontroller "istio.io/istio/security/pkg/probe"
 	"istio.io/istio/security/pkg/registry"
 	"istio.io/istio/security/pkg/registry/kube"
 	"istio.io/istio/security/pkg/server/grpc"
+	"istio.io/istio/security/pkg/server/revocation"
 )
 
 const (
@@ -56,6 +59,16 @@ const (
 
 	defaultProbeCheckInterval = 30 * time.Second
 
+	defaultRootCertRotationCheckInterval = time.Hour
+
+	defaultRootCertRotationThreshold = 30 * 24 * time.Hour
+
+	defaultRootCertRotationGracePeriod = 24 * time.Hour
+
+	defaultMonitoringPort = 9093
+
+	defaultRevocationValidity = time.Hour
+
 	// The default issuer organization for self-signed CA certificate.
 	selfSignedCAOrgDefault = "k8s.cluster.local"
 
@@ -77,6 +90,11 @@ type cliOptions struct { // nolint: maligned
 	selfSignedCAOrg     string
 	selfSignedCACertTTL time.Duration
 
+	// The root certificate rotation of the self-signed CA.
+	rootCertRotationCheckInterval time.Duration
+	rootCertRotationThreshold     time.Duration
+	rootCertRotationGracePeriod   time.Duration
+
 	workloadCertTTL    time.Duration
 	maxWorkloadCertTTL time.Duration
 	// The length of certificate rotation grace period, configured as the ratio of the certificate TTL.
@@ -84,12 +102,18 @@ type cliOptions struct { // nolint: maligned
 	// The minimum grace period for workload cert rotation.
 	workloadCertMinGracePeriod time.Duration
 
+	// The algorithm of the generated CA and workload keys.
+	keyAlgorithm string
+
 	grpcHostname string
 	grpcPort     int
 
 	upstreamCAAddress  string
 	upstreamCACertFile string
 	upstreamAuth       string
+	// The key and certificate chain authenticating the CA to the upstream CA with mtls.
+	upstreamAuthKeyFile       string
+	upstreamAuthCertChainFile string
 
 	// The path to the file which indicates the liveness of the server by its existence.
 	// This will be used for k8s liveness probe. If empty, it does nothing.
@@ -98,6 +122,15 @@ type cliOptions struct { // nolint: maligned
 
 	loggingOptions *log.Options
 
+	// The port of the metrics and status endpoints.
+	monitoringPort int
+
+	// The file recording the issued certificates. If empty, they are recorded in memory.
+	issuanceLogFile string
+	// The port of the CRL and OCSP endpoints, and the validity of the CRL and OCSP responses.
+	revocationPort     int
+	revocationValidity time.Duration
+
 	// Whether to append DNS names to the certificate
 	appendDNSNames bool
 }
@@ -165,6 +198,15 @@ func init() {
 			selfSignedCAOrgDefault))
 	flags.DurationVar(&opts.selfSignedCACertTTL, "self-signed-ca-cert-ttl", defaultSelfSignedCACertTTL,
 		"The TTL of self-signed CA root certificate")
+	flags.DurationVar(&opts.rootCertRotationCheckInterval, "root-cert-rotation-check-interval",
+		defaultRootCertRotationCheckInterval, "How often the self-signed CA checks whether to rotate its root "+
+			"certificate. If 0, the root certificate is not rotated.")
+	flags.DurationVar(&opts.rootCertRotationThreshold, "root-cert-rotation-threshold",
+		defaultRootCertRotationThreshold, "The self-signed CA generates a new root certificate when the current one "+
+			"expires in less than this. It should be at least the grace period plus the max workload cert TTL.")
+	flags.DurationVar(&opts.rootCertRotationGracePeriod, "root-cert-rotation-grace-period",
+		defaultRootCertRotationGracePeriod, "How long a new root certificate is trusted by the workloads before "+
+			"the self-signed CA signs certificates with it.")
 
 	// Certificate signing configuration.
 	flags.DurationVar(&opts.workloadCertTTL, "workload-cert-ttl", defaultWorkloadCertTTL,
@@ -176,6 +218,8 @@ func init() {
 			"workload certificate TTL.")
 	flags.DurationVar(&opts.workloadCertMinGracePeriod, "workload-cert-min-grace-period",
 		defaultWorkloadMinCertGracePeriod, "The minimum workload certificate rotation grace period.")
+	flags.StringVar(&opts.keyAlgorithm, "key-algorithm", string(util.RSA),
+		fmt.Sprintf("The algorithm of the self-signed CA key and of the workload keys, one of %v.", util.KeyAlgorithms))
 
 	// gRPC server for signing CSRs.
 	flags.StringVar(&opts.grpcHostname, "grpc-hostname", "localhost", "The hostname for GRPC server.")
@@ -184,11 +228,15 @@ func init() {
 
 	// Upstream CA configuration
 	flags.StringVar(&opts.upstreamCAAddress, "upstream-ca-address", "", "The IP:port address of the upstream CA. "+
-		"When set, the CA will rely on the upstream Istio CA to provision its own certificate.")
+		"When set, the CA forwards the certificate signing requests to the upstream Istio CA.")
 	flags.StringVar(&opts.upstreamCACertFile, "upstream-ca-cert-file", "",
 		"Path to the certificate for authenticating upstream CA.")
 	flags.StringVar(&opts.upstreamAuth, "upstream-auth", "mtls",
-		"Specifies how the Istio CA is authenticated to the upstream CA.")
+		"Specifies how the Istio CA is authenticated to the upstream CA, one of mtls or gcp.")
+	flags.StringVar(&opts.upstreamAuthCertChainFile, "upstream-auth-cert-chain-file", "",
+		"Path to the certificate chain authenticating the Istio CA to the upstream CA with mtls.")
+	flags.StringVar(&opts.upstreamAuthKeyFile, "upstream-auth-key-file", "",
+		"Path to the private key authenticating the Istio CA to the upstream CA with mtls.")
 
 	// Liveness Probe configuration
 	flags.StringVar(&opts.LivenessProbeOptions.Path, "liveness-probe-path", "",
@@ -201,6 +249,19 @@ func init() {
 	flags.BoolVar(&opts.appendDNSNames, "append-dns-names", true,
 		"Append DNS names to the certificates for webhook services.")
 
+	flags.IntVar(&opts.monitoringPort, "monitoring-port", defaultMonitoringPort,
+		"The port number for the metrics and status endpoints. If 0, they are not served.")
+
+	// Issuance log and revocation.
+	flags.StringVar(&opts.issuanceLogFile, "issuance-log-file", "",
+		"Path to the file recording the issued and revoked certificates. If unspecified, they are only "+
+			"recorded in memory and the revocations are lost when the CA restarts.")
+	flags.IntVar(&opts.revocationPort, "revocation-port", 0, "The port number for the CRL ("+
+		revocation.CRLPath+") and OCSP responder ("+revocation.OCSPPath+") endpoints. If 0, they are not served. "+
+		"They are not served in integrated mode either, as the upstream CA signs the certificates.")
+	flags.DurationVar(&opts.revocationValidity, "revocation-validity", defaultRevocationValidity,
+		"The time until the next update of the CRL and of the OCSP responses.")
+
 	rootCmd.AddCommand(version.CobraCommand())
 
 	rootCmd.AddCommand(collateral.CobraCommand(rootCmd, &doc.GenManHeader{
@@ -250,10 +311,11 @@ func runCA() {
 	}
 
 	cs := createClientset()
-	ca := createCA(cs.CoreV1())
+	ca, rotator := createCA(cs.CoreV1())
 	// For workloads in K8s, we apply the configured workload cert TTL.
 	sc, err := controller.NewSecretController(ca, opts.workloadCertTTL, opts.workloadCertGracePeriodRatio,
-		opts.workloadCertMinGracePeriod, cs.CoreV1(), opts.listenedNamespace, webhooks)
+		opts.workloadCertMinGracePeriod, util.KeyAlgorithm(opts.keyAlgorithm), cs.CoreV1(), opts.listenedNamespace,
+		webhooks)
 	if err != nil {
 		fatalf("failed to create secret controller: %v", err)
 	}
@@ -261,6 +323,26 @@ func runCA() {
 	stopCh := make(chan struct{})
 	sc.Run(stopCh)
 
+	if rotator != nil {
+		rotator.Run(stopCh)
+	}
+
+	if opts.monitoringPort > 0 {
+		if err := startMonitoring(opts.monitoringPort, rotator); err != nil {
+			fatalf("failed to start the monitoring server: %v", err)
+		}
+	}
+
+	if opts.revocationPort > 0 && opts.upstreamCAAddress != "" {
+		log.Warnf("The revocation status is not served on port %d in integrated mode, as the Istio CA "+
+			"doesn't hold the key of the upstream CA signing the certificates", opts.revocationPort)
+	} else if opts.revocationPort > 0 {
+		revocationServer := revocation.New(ca, opts.revocationValidity, opts.revocationPort)
+		if err := revocationServer.Run(); err != nil {
+			fatalf("failed to start the revocation server: %v", err)
+		}
+	}
+
 	if opts.grpcPort > 0 {
 		// start registry if gRPC server is to be started
 		reg := registry.GetIdentityRegistry()
@@ -304,24 +386,28 @@ func createClientset() *kubernetes.Clientset {
 	return cs
 }
 
-func createCA(core corev1.SecretsGetter) ca.CertificateAuthority {
+func createCA(core corev1.SecretsGetter) (*ca.IstioCA, *ca.RootCertRotator) {
 	var caOpts *ca.IstioCAOptions
 	var err error
+	selfSigned := false
 
 	if opts.upstreamCAAddress != "" {
-		log.Info("Rely on upstream CA to provision CA certificate")
+		log.Info("Forward certificate signing requests to the upstream CA")
 		caOpts, err = ca.NewIntegratedIstioCAOptions(opts.upstreamCAAddress, opts.upstreamCACertFile,
-			opts.upstreamAuth, opts.workloadCertTTL, opts.maxWorkloadCertTTL)
+			opts.upstreamAuth, opts.upstreamAuthCertChainFile, opts.upstreamAuthKeyFile, opts.workloadCertTTL,
+			opts.maxWorkloadCertTTL)
 		if err != nil {
 			fatalf("Failed to create a integrated Istio CA (error: %v)", err)
 		}
 	} else if opts.selfSignedCA {
 		log.Info("Use self-signed certificate as the CA certificate")
 		caOpts, err = ca.NewSelfSignedIstioCAOptions(opts.selfSignedCACertTTL, opts.workloadCertTTL,
-			opts.maxWorkloadCertTTL, opts.selfSignedCAOrg, opts.istioCaStorageNamespace, core)
+			opts.maxWorkloadCertTTL, opts.selfSignedCAOrg, opts.istioCaStorageNamespace,
+			util.KeyAlgorithm(opts.keyAlgorithm), core)
 		if err != nil {
 			fatalf("Failed to create a self-signed Istio CA (error: %v)", err)
 		}
+		selfSigned = true
 	} else {
 		log.Info("Use certificate from argument as the CA certificate")
 		caOpts, err = ca.NewPluggedCertIstioCAOptions(opts.certChainFile, opts.signingCertFile, opts.signingKeyFile,
@@ -334,6 +420,12 @@ func createCA(core corev1.SecretsGetter) ca.CertificateAuthority {
 	caOpts.LivenessProbeOptions = opts.LivenessProbeOptions
 	caOpts.ProbeCheckInterval = opts.probeCheckInterval
 
+	if opts.issuanceLogFile != "" {
+		if caOpts.IssuanceLog, err = issuance.NewFileLog(opts.issuanceLogFile); err != nil {
+			fatalf("Failed to open the issuance log (error: %v)", err)
+		}
+	}
+
 	istioCA, err := ca.NewIstioCA(caOpts)
 	if err != nil {
 		log.Errorf("Failed to create an Istio CA (error: %v)", err)
@@ -353,7 +445,24 @@ func createCA(core corev1.SecretsGetter) ca.CertificateAuthority {
 		}
 	}
 
-	return istioCA
+	var rotator *ca.RootCertRotator
+	if selfSigned && opts.rootCertRotationCheckInterval > 0 {
+		rotator, err = ca.NewRootCertRotator(istioCA, core, ca.RootCertRotatorOptions{
+			CheckInterval:     opts.rootCertRotationCheckInterval,
+			RotationThreshold: opts.rootCertRotationThreshold,
+			GracePeriod:       opts.rootCertRotationGracePeriod,
+			MaxCertTTL:        opts.maxWorkloadCertTTL,
+			CACertTTL:         opts.selfSignedCACertTTL,
+			Org:               opts.selfSignedCAOrg,
+			KeyAlgorithm:      util.KeyAlgorithm(opts.keyAlgorithm),
+			Namespace:         opts.istioCaStorageNamespace,
+		})
+		if err != nil {
+			fatalf("Failed to create the root certificate rotator (error: %v)", err)
+		}
+	}
+
+	return istioCA, rotator
 }
 
 func generateConfig() *rest.Config {
@@ -374,6 +483,10 @@ func generateConfig() *rest.Config {
 }
 
 func verifyCommandLineOptions() {
+	if _, err := util.ParseKeyAlgorithm(opts.keyAlgorithm); err != nil {
+		fatalf("Invalid '-key-algorithm' option (%v)", err)
+	}
+
 	if opts.selfSignedCA {
 		return
 	}
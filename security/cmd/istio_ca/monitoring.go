@@ -0,0 +1,63 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/version"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+const (
+	metricsPath          = "/metrics"
+	versionPath          = "/version"
+	rootCertRotationPath = "/root-cert-rotation"
+)
+
+// startMonitoring serves the metrics of the CA, and the status of the root certificate rotation
+// when the rotator is not nil.
+func startMonitoring(port int, rotator *ca.RootCertRotator) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("cannot listen on port %d (error: %v)", port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.Handler())
+	mux.HandleFunc(versionPath, func(out http.ResponseWriter, req *http.Request) {
+		if _, err := out.Write([]byte(version.Info.String())); err != nil {
+			log.Errorf("Unable to write version string: %v", err)
+		}
+	})
+	if rotator != nil {
+		mux.Handle(rootCertRotationPath, rotator)
+	}
+
+	go func() {
+		log.Infof("Starting monitoring server on port %d", port)
+
+		// http.Serve() always returns a non-nil error.
+		err := http.Serve(listener, mux)
+		log.Warnf("Monitoring server returns an error: %v", err)
+	}()
+
+	return nil
+}
@@ -29,6 +29,7 @@ import (
 	"istio.io/istio/pkg/version"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/server/grpc"
 )
 
@@ -169,7 +170,8 @@ func createCA(core corev1.SecretsGetter) ca.CertificateAuthority {
 		log.Info("Use self-signed certificate as the CA certificate")
 
 		caOpts, err = ca.NewSelfSignedIstioCAOptions(opts.signingCertTTL, 0, /* unused */
-			opts.maxIssuedCertTTL, opts.selfSignedCAOrg, opts.istioCaStorageNamespace, core)
+			opts.maxIssuedCertTTL, opts.selfSignedCAOrg, opts.istioCaStorageNamespace,
+			util.RSA, core)
 		if err != nil {
 			fatalf("Failed to create a self-signed Istio Multicluster CA (error: %v)", err)
 		}
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	"istio.io/istio/pkg/version"
 	"istio.io/istio/security/cmd/node_agent/na"
 	"istio.io/istio/security/pkg/cmd"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
 )
 
 var (
@@ -55,7 +57,9 @@ func init() {
 	flags.StringVar(&cAClientConfig.Org, "org", "", "Organization for the cert")
 	flags.DurationVar(&cAClientConfig.RequestedCertTTL, "workload-cert-ttl", 19*time.Hour,
 		"The requested TTL for the workload")
-	flags.IntVar(&cAClientConfig.RSAKeySize, "key-size", 2048, "Size of generated private key")
+	flags.StringVar(&cAClientConfig.KeyAlgorithm, "key-algorithm", string(pkiutil.RSA),
+		fmt.Sprintf("Algorithm of generated private key, one of %v", pkiutil.KeyAlgorithms))
+	flags.IntVar(&cAClientConfig.RSAKeySize, "key-size", 2048, "Size of generated RSA private key")
 	flags.StringVar(&cAClientConfig.CAAddress,
 		"ca-address", "istio-ca:8060", "Istio CA address")
 
@@ -69,6 +73,11 @@ func init() {
 		"key", "/etc/certs/key.pem", "Node Agent private key file")
 	flags.StringVar(&cAClientConfig.RootCertFile, "root-cert",
 		"/etc/certs/root-cert.pem", "Root Certificate file")
+	flags.StringVar(&cAClientConfig.OCSPResponderURL, "ocsp-responder-url", "",
+		"URL of the OCSP responder of Istio CA, e.g. http://istio-ca:8061/ocsp. When set, "+
+			"the installed certificate is renewed as soon as it is reported revoked")
+	flags.DurationVar(&cAClientConfig.RevocationCheckInterval, "revocation-check-interval",
+		5*time.Minute, "Interval between the revocation checks of the installed certificate")
 
 	naConfig.LoggingOptions.AttachCobraFlags(rootCmd)
 	cmd.InitializeFlags(rootCmd)
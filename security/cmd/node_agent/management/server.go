@@ -60,6 +60,9 @@ func New(cfg *na.Config) (*Server, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("unablet to create when config is nil")
 	}
+	if _, err := pkiutil.ParseKeyAlgorithm(cfg.CAClientConfig.KeyAlgorithm); err != nil {
+		return nil, err
+	}
 	pc, err := platform.NewClient(cfg.CAClientConfig.Env, cfg.CAClientConfig.RootCertFile, cfg.CAClientConfig.KeyFile,
 		cfg.CAClientConfig.CertChainFile, cfg.CAClientConfig.CAAddress)
 	if err != nil {
@@ -155,10 +158,11 @@ func (s *Server) WorkloadAdded(ctx context.Context, request *pb.WorkloadInfo) (*
 	id := fmt.Sprintf("spiffe://cluster.local/ns/%s/sa/%s", ns, sa)
 
 	priv, csrReq, err := s.caClient.CreateCSRRequest(&pkiutil.CertOptions{
-		Host:       id,
-		Org:        s.config.CAClientConfig.Org,
-		RSAKeySize: s.config.CAClientConfig.RSAKeySize,
-		TTL:        s.config.CAClientConfig.RequestedCertTTL,
+		Host:         id,
+		Org:          s.config.CAClientConfig.Org,
+		KeyAlgorithm: pkiutil.KeyAlgorithm(s.config.CAClientConfig.KeyAlgorithm),
+		RSAKeySize:   s.config.CAClientConfig.RSAKeySize,
+		TTL:          s.config.CAClientConfig.RequestedCertTTL,
 	})
 	if err != nil {
 		return nil, logReturn("failed to create csr", err)
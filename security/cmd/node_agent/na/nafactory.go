@@ -17,7 +17,9 @@ package na
 import (
 	"fmt"
 
+	"istio.io/istio/security/pkg/caclient"
 	"istio.io/istio/security/pkg/caclient/grpc"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/platform"
 	"istio.io/istio/security/pkg/util"
 )
@@ -33,9 +35,13 @@ func NewNodeAgent(cfg *Config) (NodeAgent, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("nil configuration passed")
 	}
+	if _, err := pkiutil.ParseKeyAlgorithm(cfg.CAClientConfig.KeyAlgorithm); err != nil {
+		return nil, err
+	}
 	na := &nodeAgentInternal{
-		config:   cfg,
-		certUtil: util.NewCertUtil(cfg.CAClientConfig.CSRGracePeriodPercentage),
+		config:            cfg,
+		certUtil:          util.NewCertUtil(cfg.CAClientConfig.CSRGracePeriodPercentage),
+		revocationChecker: caclient.NewRevocationChecker(&cfg.CAClientConfig),
 	}
 
 	pc, err := platform.NewClient(cfg.CAClientConfig.Env, cfg.CAClientConfig.RootCertFile, cfg.CAClientConfig.KeyFile,
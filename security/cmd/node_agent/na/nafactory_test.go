@@ -68,6 +68,15 @@ func TestNewNodeAgent(t *testing.T) {
 			},
 			expectedErr: "invalid env somethig else specified",
 		},
+		"Unsupported key algorithm test": {
+			config: &Config{
+				CAClientConfig: caclient.Config{
+					Env:          "gcp",
+					KeyAlgorithm: "DSA",
+				},
+			},
+			expectedErr: `unsupported key algorithm "DSA", expecting one of [RSA ECDSA-P256 ECDSA-P384]`,
+		},
 	}
 
 	for id, c := range testCases {
@@ -36,6 +36,8 @@ type nodeAgentInternal struct {
 	cAClient grpc.CAGrpcClient
 	identity string
 	certUtil util.CertUtil
+	// revocationChecker checks the installed certificate while waiting for its renewal, if not nil.
+	revocationChecker caclient.RevocationChecker
 }
 
 // Start starts the node Agent.
@@ -81,8 +83,8 @@ func (na *nodeAgentInternal) Start() error {
 				log.Infof("CSR is approved successfully. Will renew cert in %s", waitTime.String())
 				retries = 0
 				retrialInterval = na.config.CAClientConfig.CSRInitialRetrialInterval
-				timer := time.NewTimer(waitTime)
-				<-timer.C
+				caclient.WaitForRenewal(waitTime, na.revocationChecker,
+					na.config.CAClientConfig.RevocationCheckInterval, resp.SignedCert, resp.CertChain, nil)
 				success = true
 			}
 		} else {
@@ -115,9 +117,10 @@ func (na *nodeAgentInternal) Start() error {
 
 func (na *nodeAgentInternal) createRequest() ([]byte, *pb.CsrRequest, error) {
 	csr, privKey, err := pkiutil.GenCSR(pkiutil.CertOptions{
-		Host:       na.identity,
-		Org:        na.config.CAClientConfig.Org,
-		RSAKeySize: na.config.CAClientConfig.RSAKeySize,
+		Host:         na.identity,
+		Org:          na.config.CAClientConfig.Org,
+		KeyAlgorithm: pkiutil.KeyAlgorithm(na.config.CAClientConfig.KeyAlgorithm),
+		RSAKeySize:   na.config.CAClientConfig.RSAKeySize,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -45,6 +45,8 @@ func TestStartWithArgs(t *testing.T) {
 		},
 		LoggingOptions: log.DefaultOptions(),
 	}
+	revocationConfig := generalConfig
+	revocationConfig.CAClientConfig.RevocationCheckInterval = time.Millisecond
 	signedCert := []byte(`TESTCERT`)
 	certChain := []byte(`CERTCHAIN`)
 	testCases := map[string]struct {
@@ -55,6 +57,7 @@ func TestStartWithArgs(t *testing.T) {
 		expectedErr string
 		sendTimes   int
 		fileContent []byte
+		revocation  caclient.RevocationChecker
 	}{
 		"Success": {
 			config: &generalConfig,
@@ -139,11 +142,24 @@ func TestStartWithArgs(t *testing.T) {
 			expectedErr: "node agent can't get the CSR approved from Istio CA after max number of retries (3)",
 			sendTimes:   4,
 		},
+		"Installed certificate revoked": {
+			// The certificates are renewed as soon as they are reported revoked, long before
+			// their renewal time.
+			config: &revocationConfig,
+			pc:     mockpc.FakeClient{nil, "", "service1", "", []byte{}, "", true},
+			cAClient: &mockclient.FakeCAClient{
+				0, &pb.CsrResponse{IsApproved: true, SignedCert: signedCert, CertChain: certChain}, nil},
+			certUtil:    mockutil.FakeCertUtil{time.Hour, nil},
+			revocation:  &fakeRevocationChecker{fmt.Errorf("certificate 1 was revoked")},
+			expectedErr: "node agent can't get the CSR approved from Istio CA after max number of retries (3)",
+			sendTimes:   12,
+			fileContent: append(signedCert, certChain...),
+		},
 	}
 
 	for id, c := range testCases {
 		log.Errorf("Start to test %s", id)
-		na := nodeAgentInternal{c.config, c.pc, c.cAClient, "service1", c.certUtil}
+		na := nodeAgentInternal{c.config, c.pc, c.cAClient, "service1", c.certUtil, c.revocation}
 		err := na.Start()
 		if err.Error() != c.expectedErr {
 			t.Errorf("Test case [%s]: incorrect error message: %s VS (expected) %s", id, err.Error(), c.expectedErr)
@@ -155,3 +171,11 @@ func TestStartWithArgs(t *testing.T) {
 		// TODO(incfly): add check to compare fileContent equals to the saved secrets after we can read from SecretServer.
 	}
 }
+
+type fakeRevocationChecker struct {
+	err error
+}
+
+func (c *fakeRevocationChecker) CheckRevocation(certPEM, certChainPEM []byte) error {
+	return c.err
+}
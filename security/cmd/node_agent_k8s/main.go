@@ -15,6 +15,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -28,6 +29,7 @@ import (
 	"istio.io/istio/security/cmd/node_agent/na"
 	"istio.io/istio/security/cmd/node_agent_k8s/workload/handler"
 	wlapi "istio.io/istio/security/cmd/node_agent_k8s/workloadapi"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
 )
 
 const (
@@ -73,7 +75,9 @@ func init() {
 	flags.StringVar(&cAClientConfig.Org, "org", "", "Organization for the cert")
 	flags.DurationVar(&cAClientConfig.RequestedCertTTL, "workload-cert-ttl", 19*time.Hour,
 		"The requested TTL for the workload")
-	flags.IntVar(&cAClientConfig.RSAKeySize, "key-size", 2048, "Size of generated private key")
+	flags.StringVar(&cAClientConfig.KeyAlgorithm, "key-algorithm", string(pkiutil.RSA),
+		fmt.Sprintf("Algorithm of generated private key, one of %v", pkiutil.KeyAlgorithms))
+	flags.IntVar(&cAClientConfig.RSAKeySize, "key-size", 2048, "Size of generated RSA private key")
 
 	// TODO(incfly): is it better to check ca address reachability when program starts? Also refactor this address into some constants package.
 	flags.StringVar(&cAClientConfig.CAAddress, "ca-address",
@@ -29,6 +29,9 @@ type Config struct {
 	// Requested TTL of the certificates
 	RequestedCertTTL time.Duration
 
+	// Algorithm of the private key, one of the names of pki/util.KeyAlgorithm
+	KeyAlgorithm string
+
 	// Size of RSA private key
 	RSAKeySize int
 
@@ -62,4 +65,13 @@ type Config struct {
 
 	// RootCertFile defines the root cert of the CA client.
 	RootCertFile string
+
+	// OCSPResponderURL is the URL of the OCSP responder of the CA. When it is set, the installed
+	// certificate is checked with the responder every RevocationCheckInterval, and the key and
+	// certificate are renewed at once when it is reported revoked.
+	OCSPResponderURL string
+
+	// RevocationCheckInterval is the interval between the revocation checks of the installed
+	// certificate.
+	RevocationCheckInterval time.Duration
 }
@@ -55,6 +55,10 @@ func (s *FakeIstioCAGrpcServer) HandleCSR(ctx context.Context, req *pb.CsrReques
 	return s.response, nil
 }
 
+func (s *FakeIstioCAGrpcServer) RevokeCertificate(ctx context.Context, req *pb.RevocationRequest) (*pb.RevocationResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func TestSendCSRAgainstLocalInstance(t *testing.T) {
 	// create a local grpc server
 	s := grpc.NewServer()
@@ -47,11 +47,13 @@ func NewKeyCertBundleRotator(cfg *Config, bundle pkiutil.KeyCertBundle) (*KeyCer
 	}
 
 	return &KeyCertBundleRotator{
-		certUtil:  util.NewCertUtil(cfg.CSRGracePeriodPercentage),
-		retriever: cAClient,
-		keycert:   bundle,
-		stopCh:    make(chan bool, 1),
-		stopped:   true,
+		certUtil:                util.NewCertUtil(cfg.CSRGracePeriodPercentage),
+		retriever:               cAClient,
+		revocationChecker:       NewRevocationChecker(cfg),
+		revocationCheckInterval: cfg.RevocationCheckInterval,
+		keycert:                 bundle,
+		stopCh:                  make(chan bool, 1),
+		stopped:                 true,
 	}, nil
 }
 
@@ -69,6 +71,10 @@ type KeyCertBundleRotator struct {
 	stopped      bool
 	retriever    KeyCertRetriever
 	stoppedMutex sync.Mutex
+	// revocationChecker checks the installed certificate every revocationCheckInterval while
+	// waiting for its renewal, if not nil.
+	revocationChecker       RevocationChecker
+	revocationCheckInterval time.Duration
 }
 
 // Start periodically rotates the KeyCertBundle by interacting with the upstream CA.
@@ -91,19 +97,16 @@ func (c *KeyCertBundleRotator) Start(errCh chan<- error) {
 	}()
 
 	for {
-		certBytes, _, _, _ := c.keycert.GetAllPem()
+		certBytes, _, certChainBytes, _ := c.keycert.GetAllPem()
 		if len(certBytes) != 0 {
 			waitTime, ttlErr := c.certUtil.GetWaitTime(certBytes, time.Now())
 			if ttlErr != nil {
 				log.Errorf("Error getting TTL from cert: %v. Rotate immediately.", ttlErr)
 			} else {
-				timer := time.NewTimer(waitTime)
 				log.Infof("Will rotate key and cert in %v.", waitTime)
-				select {
-				case <-c.stopCh:
+				if !WaitForRenewal(waitTime, c.revocationChecker, c.revocationCheckInterval,
+					certBytes, certChainBytes, c.stopCh) {
 					return
-				case <-timer.C:
-					// Continue in the loop.
 				}
 			}
 		}
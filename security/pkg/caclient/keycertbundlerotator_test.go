@@ -101,6 +101,7 @@ func TestKeyCertBundleRotator(t *testing.T) {
 		retriever   KeyCertRetriever
 		certutil    util.CertUtil
 		keycert     pkiutil.KeyCertBundle
+		revocation  RevocationChecker
 		updated     bool
 		expectedErr string
 	}{
@@ -147,6 +148,40 @@ func TestKeyCertBundleRotator(t *testing.T) {
 			updated:     false,
 			expectedErr: "",
 		},
+		"Immediate update of a revoked cert": {
+			retriever: &fakeKeyCertRetriever{
+				NewCert:    newCert,
+				CertChain:  newCertChain,
+				PrivateKey: newKey,
+			},
+			certutil: &utilmock.FakeCertUtil{Duration: time.Duration(time.Hour)},
+			keycert: &pkimock.FakeKeyCertBundle{
+				CertBytes:      oldCert,
+				PrivKeyBytes:   oldKey,
+				CertChainBytes: oldCertChain,
+				RootCertBytes:  oldRootCert,
+			},
+			revocation:  &fakeRevocationChecker{err: fmt.Errorf("certificate 1 was revoked")},
+			updated:     true,
+			expectedErr: "",
+		},
+		"Wait update of a cert not revoked": {
+			retriever: &fakeKeyCertRetriever{
+				NewCert:    newCert,
+				CertChain:  newCertChain,
+				PrivateKey: newKey,
+			},
+			certutil: &utilmock.FakeCertUtil{Duration: time.Duration(time.Hour)},
+			keycert: &pkimock.FakeKeyCertBundle{
+				CertBytes:      oldCert,
+				PrivKeyBytes:   oldKey,
+				CertChainBytes: oldCertChain,
+				RootCertBytes:  oldRootCert,
+			},
+			revocation:  &fakeRevocationChecker{},
+			updated:     false,
+			expectedErr: "",
+		},
 		"CA Client error": {
 			retriever: &fakeKeyCertRetriever{Err: fmt.Errorf("error1")},
 			certutil:  &utilmock.FakeCertUtil{Duration: time.Duration(0)},
@@ -180,11 +215,13 @@ func TestKeyCertBundleRotator(t *testing.T) {
 
 	for id, tc := range testCases {
 		rotator := KeyCertBundleRotator{
-			certUtil:  tc.certutil,
-			retriever: tc.retriever,
-			keycert:   tc.keycert,
-			stopCh:    make(chan bool, 1),
-			stopped:   true,
+			certUtil:                tc.certutil,
+			retriever:               tc.retriever,
+			keycert:                 tc.keycert,
+			stopCh:                  make(chan bool, 1),
+			stopped:                 true,
+			revocationChecker:       tc.revocation,
+			revocationCheckInterval: time.Millisecond * 50,
 		}
 		errCh := make(chan error)
 		go rotator.Start(errCh)
@@ -0,0 +1,179 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"istio.io/istio/pkg/log"
+)
+
+// ocspRequestTimeout is the timeout of the requests to the OCSP responder.
+const ocspRequestTimeout = 10 * time.Second
+
+// RevocationChecker checks whether a certificate issued by the CA has been revoked. It is used to
+// check the installed certificate while it waits for its renewal: a certificate that the CA has
+// just signed is never revoked yet.
+type RevocationChecker interface {
+	// CheckRevocation returns an error if the first certificate in certPEM has been revoked.
+	// The certificate of its issuer is looked up in certChainPEM and the trusted root certificates.
+	CheckRevocation(certPEM, certChainPEM []byte) error
+}
+
+// NewRevocationChecker returns the RevocationChecker for the given configuration, or nil if
+// the configuration doesn't enable the revocation check.
+func NewRevocationChecker(cfg *Config) RevocationChecker {
+	if cfg.OCSPResponderURL == "" {
+		return nil
+	}
+	return NewOCSPRevocationChecker(cfg.OCSPResponderURL, cfg.RootCertFile)
+}
+
+// OCSPRevocationChecker checks the revocation of certificates with the OCSP responder of the CA.
+// It fails open: certificates are only reported revoked when the responder says so, and are
+// accepted with a warning when the responder can't be reached or its response can't be verified,
+// so that an unavailable responder doesn't force the renewal of every certificate.
+type OCSPRevocationChecker struct {
+	responderURL string
+	rootCertFile string
+	client       *http.Client
+}
+
+// NewOCSPRevocationChecker creates an OCSPRevocationChecker querying the given responder. The
+// root certificates are read from rootCertFile for every check, as they change when the root
+// certificate of the CA is rotated.
+func NewOCSPRevocationChecker(responderURL, rootCertFile string) *OCSPRevocationChecker {
+	return &OCSPRevocationChecker{
+		responderURL: responderURL,
+		rootCertFile: rootCertFile,
+		client:       &http.Client{Timeout: ocspRequestTimeout},
+	}
+}
+
+// CheckRevocation returns an error if the OCSP responder reports the certificate revoked.
+func (c *OCSPRevocationChecker) CheckRevocation(certPEM, certChainPEM []byte) error {
+	certs := parseCertificates(certPEM)
+	if len(certs) == 0 {
+		return fmt.Errorf("failed to parse the certificate")
+	}
+	cert := certs[0]
+	serialNumber := cert.SerialNumber.Text(16)
+
+	response, err := c.query(cert, certs[1:], certChainPEM)
+	if err != nil {
+		log.Warnf("Failed to check the revocation of certificate %s with %s, accepting it (%v)",
+			serialNumber, c.responderURL, err)
+		return nil
+	}
+	if response.Status == ocsp.Revoked {
+		return fmt.Errorf("certificate %s was revoked at %v (reason %d)", serialNumber,
+			response.RevokedAt, response.RevocationReason)
+	}
+	return nil
+}
+
+// WaitForRenewal waits for waitTime, the time left before the renewal of the installed
+// certificate certPEM. Meanwhile, the certificate is checked with checker every interval, unless
+// checker is nil or interval isn't positive, and the wait ends early when it is reported revoked,
+// so that the key and certificate are renewed at once. It returns false when stopCh receives a
+// value before the end of the wait.
+func WaitForRenewal(waitTime time.Duration, checker RevocationChecker, interval time.Duration,
+	certPEM, certChainPEM []byte, stopCh <-chan bool) bool {
+	timer := time.NewTimer(waitTime)
+	defer timer.Stop()
+
+	var tickCh <-chan time.Time
+	if checker != nil && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tickCh = ticker.C
+	}
+	for {
+		select {
+		case <-stopCh:
+			return false
+		case <-timer.C:
+			return true
+		case <-tickCh:
+			if err := checker.CheckRevocation(certPEM, certChainPEM); err != nil {
+				log.Warnf("The installed certificate is revoked, renewing the key and certificate now (%v)", err)
+				return true
+			}
+		}
+	}
+}
+
+// query sends the OCSP request for the certificate to the responder.
+func (c *OCSPRevocationChecker) query(cert *x509.Certificate, chain []*x509.Certificate,
+	certChainPEM []byte) (*ocsp.Response, error) {
+	candidates := append(append([]*x509.Certificate{}, chain...), parseCertificates(certChainPEM)...)
+	if rootCertPEM, err := ioutil.ReadFile(c.rootCertFile); err == nil {
+		candidates = append(candidates, parseCertificates(rootCertPEM)...)
+	}
+	issuer := findIssuer(cert, candidates)
+	if issuer == nil {
+		return nil, fmt.Errorf("the issuer of the certificate is not found")
+	}
+
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResponse, err := c.client.Post(c.responderURL, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the OCSP responder returns status %d", httpResponse.StatusCode)
+	}
+	body, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+	return ocsp.ParseResponseForCert(body, cert, issuer)
+}
+
+// findIssuer returns the certificate that signed cert among the candidates, or nil.
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if bytes.Equal(cert.RawIssuer, candidate.RawSubject) && cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// parseCertificates returns the certificates in the PEM data, skipping the invalid ones.
+func parseCertificates(data []byte) []*x509.Certificate {
+	certs := []*x509.Certificate{}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
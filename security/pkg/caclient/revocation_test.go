@@ -0,0 +1,205 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"crypto"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type fakeRevocationChecker struct {
+	err error
+}
+
+func (c *fakeRevocationChecker) CheckRevocation(certPEM, certChainPEM []byte) error {
+	return c.err
+}
+
+func TestNewRevocationChecker(t *testing.T) {
+	if checker := NewRevocationChecker(&Config{}); checker != nil {
+		t.Errorf("NewRevocationChecker() without OCSP responder returns %v, expecting nil", checker)
+	}
+	if _, ok := NewRevocationChecker(&Config{OCSPResponderURL: "http://istio-ca:8061/ocsp"}).(*OCSPRevocationChecker); !ok {
+		t.Errorf("NewRevocationChecker() with OCSP responder doesn't return an OCSPRevocationChecker")
+	}
+}
+
+func TestOCSPRevocationChecker(t *testing.T) {
+	rootCertPEM, rootKeyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		Org:          "Root CA",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCert, err := util.ParsePemEncodedCertificate(rootCertPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootKey, err := util.ParsePemEncodedKey(rootKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:       "spiffe://cluster.local/ns/default/sa/foo",
+		TTL:        time.Hour,
+		SignerCert: rootCert,
+		SignerPriv: rootKey,
+		RSAKeySize: 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "revocation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootCertFile := path.Join(dir, "root-cert.pem")
+	if err = ioutil.WriteFile(rootCertFile, rootCertPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		status       int
+		httpStatus   int
+		rootCertFile string
+		expectedErr  string
+	}{
+		"Good": {
+			status:       ocsp.Good,
+			httpStatus:   http.StatusOK,
+			rootCertFile: rootCertFile,
+		},
+		"Revoked": {
+			status:       ocsp.Revoked,
+			httpStatus:   http.StatusOK,
+			rootCertFile: rootCertFile,
+			expectedErr:  "was revoked at",
+		},
+		"Unknown": {
+			status:       ocsp.Unknown,
+			httpStatus:   http.StatusOK,
+			rootCertFile: rootCertFile,
+		},
+		// The certificate is accepted when the revocation can't be checked.
+		"Responder error": {
+			httpStatus:   http.StatusInternalServerError,
+			rootCertFile: rootCertFile,
+		},
+		"Issuer not found": {
+			status:       ocsp.Revoked,
+			httpStatus:   http.StatusOK,
+			rootCertFile: path.Join(dir, "missing.pem"),
+		},
+	}
+
+	for id, tc := range testCases {
+		responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			request, err := ocsp.ParseRequest(body)
+			if err != nil || tc.httpStatus != http.StatusOK {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			now := time.Now()
+			response, err := ocsp.CreateResponse(rootCert, rootCert, ocsp.Response{
+				Status:           tc.status,
+				SerialNumber:     request.SerialNumber,
+				ThisUpdate:       now,
+				NextUpdate:       now.Add(time.Hour),
+				RevokedAt:        now.Add(-time.Minute),
+				RevocationReason: ocsp.KeyCompromise,
+			}, rootKey.(crypto.Signer))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write(response)
+		}))
+
+		err := NewOCSPRevocationChecker(responder.URL, tc.rootCertFile).CheckRevocation(certPEM, nil)
+		responder.Close()
+		if len(tc.expectedErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("%s: expecting error containing %q, got %v", id, tc.expectedErr, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+		}
+	}
+}
+
+func TestFindIssuer(t *testing.T) {
+	rootCertPEM, rootKeyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRootCertPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCert := parseCertificates(rootCertPEM)[0]
+	rootKey, err := util.ParsePemEncodedKey(rootKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:       "spiffe://cluster.local/ns/default/sa/foo",
+		TTL:        time.Hour,
+		SignerCert: rootCert,
+		SignerPriv: rootKey,
+		RSAKeySize: 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := parseCertificates(certPEM)[0]
+
+	// Both roots have the same subject during a root certificate rotation.
+	candidates := parseCertificates(append(otherRootCertPEM, rootCertPEM...))
+	if issuer := findIssuer(cert, candidates); issuer == nil || !issuer.Equal(rootCert) {
+		t.Errorf("findIssuer() returns %v, expecting the root certificate", issuer)
+	}
+	if issuer := findIssuer(cert, []*x509.Certificate{candidates[0]}); issuer != nil {
+		t.Errorf("findIssuer() returns %v, expecting nil", issuer)
+	}
+}
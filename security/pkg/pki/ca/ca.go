@@ -15,8 +15,10 @@
 package ca
 
 import (
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"sync"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -25,7 +27,9 @@ import (
 
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/probe"
+	"istio.io/istio/security/pkg/pki/issuance"
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/platform"
 )
 
 const (
@@ -39,7 +43,17 @@ const (
 	// cASecret stores the key/cert of self-signed CA for persistency purpose.
 	cASecret = "istio-ca-secret"
 
-	// The size of a private key for a self-signed Istio CA.
+	// cANextCertID and cANextPrivateKeyID store the root certificate and key generated by a
+	// rotation of the self-signed CA, until they replace the current ones.
+	cANextCertID       = "ca-next-cert.pem"
+	cANextPrivateKeyID = "ca-next-key.pem"
+	// cAPreviousCertID and cAPreviousPrivateKeyID store the root certificate and key replaced by
+	// a rotation, until the root certificate is retired. The key only signs the revocation status
+	// of the certificates issued with it.
+	cAPreviousCertID       = "ca-previous-cert.pem"
+	cAPreviousPrivateKeyID = "ca-previous-key.pem"
+
+	// The size of a RSA private key for a self-signed Istio CA.
 	caKeySize = 2048
 )
 
@@ -61,6 +75,11 @@ type CertificateAuthority interface {
 	Sign(csrPEM []byte, ttl time.Duration, forCA bool) ([]byte, error)
 	// GetCAKeyCertBundle returns the KeyCertBundle used by CA.
 	GetCAKeyCertBundle() util.KeyCertBundle
+	// GetIssuanceLog returns the log of the certificates issued by CA.
+	GetIssuanceLog() issuance.Log
+	// GetPreviousCAKeyCertBundle returns the KeyCertBundle of the root certificate replaced by a
+	// rotation until it is retired, or nil.
+	GetPreviousCAKeyCertBundle() util.KeyCertBundle
 }
 
 // IstioCAOptions holds the configurations for creating an Istio CA.
@@ -71,10 +90,18 @@ type IstioCAOptions struct {
 	MaxCertTTL time.Duration
 
 	KeyCertBundle util.KeyCertBundle
+	// PreviousKeyCertBundle holds the root certificate and key replaced by a rotation, if any.
+	PreviousKeyCertBundle util.KeyCertBundle
 
 	UpstreamCAAddress   string
 	UpstreamCACertBytes []byte
 	UpstreamAuth        string
+	// UpstreamClient authenticates the Istio CA to the upstream CA. When UpstreamCAAddress is
+	// set, CSRs are forwarded to the upstream CA instead of being signed with KeyCertBundle.
+	UpstreamClient platform.Client
+
+	// IssuanceLog records the certificates issued by the CA. An in-memory log is used if it is nil.
+	IssuanceLog issuance.Log
 
 	LivenessProbeOptions *probe.Options
 	ProbeCheckInterval   time.Duration
@@ -87,12 +114,22 @@ type IstioCA struct {
 
 	keyCertBundle util.KeyCertBundle
 
+	// previousKeyCertBundle is updated by the root certificate rotation.
+	previousLock          sync.RWMutex
+	previousKeyCertBundle util.KeyCertBundle
+
+	// upstream signs the CSRs in integrated mode.
+	upstream *upstreamCA
+
+	issuanceLog issuance.Log
+
 	livenessProbe *probe.Probe
 }
 
 // NewSelfSignedIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate.
+// The CA key is generated with the given algorithm.
 func NewSelfSignedIstioCAOptions(caCertTTL, certTTL, maxCertTTL time.Duration, org string, namespace string,
-	core corev1.SecretsGetter) (caOpts *IstioCAOptions, err error) {
+	keyAlgorithm util.KeyAlgorithm, core corev1.SecretsGetter) (caOpts *IstioCAOptions, err error) {
 	// For the first time the CA is up, it generates a self-signed key/cert pair and write it to
 	// cASecret. For subsequent restart, CA will reads key/cert from cASecret.
 	caSecret, scrtErr := core.Secrets(namespace).Get(cASecret, metav1.GetOptions{})
@@ -104,14 +141,7 @@ func NewSelfSignedIstioCAOptions(caCertTTL, certTTL, maxCertTTL time.Duration, o
 	if scrtErr != nil {
 		log.Infof("Failed to get secret (error: %s), will create one", scrtErr)
 
-		options := util.CertOptions{
-			TTL:          caCertTTL,
-			Org:          org,
-			IsCA:         true,
-			IsSelfSigned: true,
-			RSAKeySize:   caKeySize,
-		}
-		pemCert, pemKey, ckErr := util.GenCertKeyFromOptions(options)
+		pemCert, pemKey, ckErr := genSelfSignedRootCert(caCertTTL, org, keyAlgorithm)
 		if ckErr != nil {
 			return nil, fmt.Errorf("unable to generate CA cert and key for self-signed CA (%v)", ckErr)
 		}
@@ -137,14 +167,59 @@ func NewSelfSignedIstioCAOptions(caCertTTL, certTTL, maxCertTTL time.Duration, o
 		}
 	} else {
 		if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromPem(caSecret.Data[cACertID],
-			caSecret.Data[cAPrivateKeyID], nil, caSecret.Data[cACertID]); err != nil {
+			caSecret.Data[cAPrivateKeyID], nil, trustedRootCerts(caSecret.Data)); err != nil {
 			return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 		}
+		if caOpts.PreviousKeyCertBundle, err = previousKeyCertBundle(caSecret.Data); err != nil {
+			return nil, err
+		}
 	}
 
 	return caOpts, nil
 }
 
+// genSelfSignedRootCert generates the root certificate and key of a self-signed CA.
+func genSelfSignedRootCert(caCertTTL time.Duration, org string, keyAlgorithm util.KeyAlgorithm) ([]byte, []byte, error) {
+	return util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          caCertTTL,
+		Org:          org,
+		IsCA:         true,
+		IsSelfSigned: true,
+		KeyAlgorithm: keyAlgorithm,
+		RSAKeySize:   caKeySize,
+	})
+}
+
+// trustedRootCerts returns the root certificates trusted by a self-signed CA, the signing one
+// first, followed by the one being rotated in or out, if any.
+func trustedRootCerts(data map[string][]byte) []byte {
+	var rootCerts []byte
+	for _, id := range []string{cACertID, cANextCertID, cAPreviousCertID} {
+		if len(data[id]) == 0 {
+			continue
+		}
+		if len(rootCerts) > 0 && rootCerts[len(rootCerts)-1] != '\n' {
+			rootCerts = append(rootCerts, '\n')
+		}
+		rootCerts = append(rootCerts, data[id]...)
+	}
+	return rootCerts
+}
+
+// previousKeyCertBundle returns the KeyCertBundle of the root certificate replaced by a rotation,
+// or nil if there is none or its key is not stored, as in the secrets of the previous releases.
+func previousKeyCertBundle(data map[string][]byte) (util.KeyCertBundle, error) {
+	certPEM, keyPEM := data[cAPreviousCertID], data[cAPreviousPrivateKeyID]
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, nil
+	}
+	bundle, err := util.NewVerifiedKeyCertBundleFromPem(certPEM, keyPEM, nil, certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the previous CA KeyCertBundle (%v)", err)
+	}
+	return bundle, nil
+}
+
 // NewPluggedCertIstioCAOptions returns a new IstioCAOptions instance using given certificate.
 func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile, rootCertFile string,
 	certTTL, maxCertTTL time.Duration) (caOpts *IstioCAOptions, err error) {
@@ -161,7 +236,8 @@ func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile
 }
 
 // NewIntegratedIstioCAOptions returns a new IstioCAOptions instance with upstream CA configuration.
-func NewIntegratedIstioCAOptions(upstreamCAAddress, upstreamCACertFile, upstreamAuth string,
+// The certificate chain and key files authenticate the Istio CA when upstreamAuth is mtls.
+func NewIntegratedIstioCAOptions(upstreamCAAddress, upstreamCACertFile, upstreamAuth, certChainFile, keyFile string,
 	workloadCertTTL, maxWorkloadCertTTL time.Duration) (caOpts *IstioCAOptions, err error) {
 	caOpts = &IstioCAOptions{
 		CAType:            integratedCA,
@@ -173,26 +249,45 @@ func NewIntegratedIstioCAOptions(upstreamCAAddress, upstreamCACertFile, upstream
 	if caOpts.KeyCertBundle, err = util.NewKeyCertBundleWithRootCertFromFile(upstreamCACertFile); err != nil {
 		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 	}
+	if caOpts.UpstreamClient, err = NewUpstreamClient(upstreamAuth, upstreamCAAddress, upstreamCACertFile,
+		certChainFile, keyFile); err != nil {
+		return nil, fmt.Errorf("failed to create upstream CA client (%v)", err)
+	}
 	return caOpts, nil
 }
 
 // NewIstioCA returns a new IstioCA instance.
 func NewIstioCA(opts *IstioCAOptions) (*IstioCA, error) {
 	ca := &IstioCA{
-		certTTL:       opts.CertTTL,
-		maxCertTTL:    opts.MaxCertTTL,
-		keyCertBundle: opts.KeyCertBundle,
-		livenessProbe: probe.NewProbe(),
+		certTTL:               opts.CertTTL,
+		maxCertTTL:            opts.MaxCertTTL,
+		keyCertBundle:         opts.KeyCertBundle,
+		previousKeyCertBundle: opts.PreviousKeyCertBundle,
+		issuanceLog:           opts.IssuanceLog,
+		livenessProbe:         probe.NewProbe(),
+	}
+	if ca.issuanceLog == nil {
+		ca.issuanceLog = issuance.NewMemoryLog()
+	}
+
+	if opts.UpstreamCAAddress != "" {
+		upstream, err := newUpstreamCA(opts)
+		if err != nil {
+			return nil, err
+		}
+		ca.upstream = upstream
 	}
 
 	return ca, nil
 }
 
 // Sign takes a PEM-encoded certificate signing request and returns a signed
-// certificate.
+// certificate. In integrated mode, the CSR is signed by the upstream CA and the
+// certificate is followed by the upstream certificate chain. The certificate is
+// only returned once it is recorded in the issuance log.
 func (ca *IstioCA) Sign(csrPEM []byte, ttl time.Duration, forCA bool) ([]byte, error) {
 	signingCert, signingKey, _, _ := ca.keyCertBundle.GetAll()
-	if signingCert == nil {
+	if signingCert == nil && ca.upstream == nil {
 		return nil, fmt.Errorf("Istio CA is not ready") // nolint
 	}
 
@@ -207,6 +302,17 @@ func (ca *IstioCA) Sign(csrPEM []byte, ttl time.Duration, forCA bool) ([]byte, e
 			"requested TTL %s is greater than the max allowed TTL %s", ttl, ca.maxCertTTL)
 	}
 
+	if ca.upstream != nil {
+		certChain, err := ca.upstream.sign(csrPEM, ttl, forCA)
+		if err != nil {
+			return nil, err
+		}
+		if err := ca.record(certChain, chainIssuer(certChain)); err != nil {
+			return nil, err
+		}
+		return certChain, nil
+	}
+
 	certBytes, err := util.GenCertFromCSR(csr, signingCert, csr.PublicKey, *signingKey, ttl, forCA)
 	if err != nil {
 		return nil, err
@@ -217,11 +323,67 @@ func (ca *IstioCA) Sign(csrPEM []byte, ttl time.Duration, forCA bool) ([]byte, e
 		Bytes: certBytes,
 	}
 	cert := pem.EncodeToMemory(block)
+	if err := ca.record(cert, signingCert); err != nil {
+		return nil, err
+	}
 
 	return cert, nil
 }
 
+// record adds the first certificate in the given PEM, signed by issuer, to the issuance log.
+func (ca *IstioCA) record(certPEM []byte, issuer *x509.Certificate) error {
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		return err
+	}
+	record, err := issuance.NewRecord(cert, issuer)
+	if err != nil {
+		return fmt.Errorf("failed to create the issuance record (%v)", err)
+	}
+	if err := ca.issuanceLog.Add(record); err != nil {
+		return fmt.Errorf("failed to record the issued certificate (%v)", err)
+	}
+	log.Infof("Issued certificate %s for %v, expiring at %v", record.SerialNumber, record.IDs,
+		record.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+// chainIssuer returns the second certificate of a certificate chain, which signed the first one,
+// or nil if there is none.
+func chainIssuer(certChain []byte) *x509.Certificate {
+	_, rest := pem.Decode(certChain)
+	block, _ := pem.Decode(rest)
+	if block == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
 // GetCAKeyCertBundle returns the KeyCertBundle for the CA.
 func (ca *IstioCA) GetCAKeyCertBundle() util.KeyCertBundle {
 	return ca.keyCertBundle
 }
+
+// GetIssuanceLog returns the log of the certificates issued by the CA.
+func (ca *IstioCA) GetIssuanceLog() issuance.Log {
+	return ca.issuanceLog
+}
+
+// GetPreviousCAKeyCertBundle returns the KeyCertBundle of the root certificate replaced by a
+// rotation until it is retired, or nil.
+func (ca *IstioCA) GetPreviousCAKeyCertBundle() util.KeyCertBundle {
+	ca.previousLock.RLock()
+	defer ca.previousLock.RUnlock()
+	return ca.previousKeyCertBundle
+}
+
+// setPreviousKeyCertBundle updates the KeyCertBundle of the replaced root certificate.
+func (ca *IstioCA) setPreviousKeyCertBundle(bundle util.KeyCertBundle) {
+	ca.previousLock.Lock()
+	defer ca.previousLock.Unlock()
+	ca.previousKeyCertBundle = bundle
+}
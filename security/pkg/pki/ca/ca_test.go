@@ -90,7 +90,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	caNamespace := "default"
 	client := fake.NewSimpleClientset()
 
-	caopts, err := NewSelfSignedIstioCAOptions(caCertTTL, defaultCertTTL, maxCertTTL, org, caNamespace, client.CoreV1())
+	caopts, err := NewSelfSignedIstioCAOptions(caCertTTL, defaultCertTTL, maxCertTTL, org, caNamespace, util.RSA, client.CoreV1())
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -160,7 +160,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	org := "test.ca.org"
 	caNamespace := "default"
 
-	caopts, err := NewSelfSignedIstioCAOptions(caCertTTL, certTTL, maxCertTTL, org, caNamespace, client.CoreV1())
+	caopts, err := NewSelfSignedIstioCAOptions(caCertTTL, certTTL, maxCertTTL, org, caNamespace, util.RSA, client.CoreV1())
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -231,6 +231,52 @@ func TestCreatePluggedCertCA(t *testing.T) {
 	}
 }
 
+func TestCreateIntegratedCA(t *testing.T) {
+	rootCertFile := "../testdata/multilevelpki/root-cert.pem"
+	certChainFile := "../testdata/multilevelpki/int2-cert-chain.pem"
+	keyFile := "../testdata/multilevelpki/int2-key.pem"
+
+	testCases := map[string]struct {
+		upstreamAuth string
+		expectedErr  string
+	}{
+		"mtls": {
+			upstreamAuth: UpstreamAuthMTLS,
+		},
+		"Unsupported upstream auth": {
+			upstreamAuth: "jwt",
+			expectedErr: "failed to create upstream CA client (unsupported upstream auth \"jwt\", " +
+				"expecting \"mtls\" or \"gcp\")",
+		},
+	}
+
+	for id, tc := range testCases {
+		caopts, err := NewIntegratedIstioCAOptions("localhost:8060", rootCertFile, tc.upstreamAuth, certChainFile,
+			keyFile, 30*time.Minute, time.Hour)
+		if len(tc.expectedErr) > 0 {
+			if err == nil {
+				t.Errorf("%s: Succeeded. Error expected: %v", id, tc.expectedErr)
+			} else if err.Error() != tc.expectedErr {
+				t.Errorf("%s: incorrect error message: %s VS %s", id, err.Error(), tc.expectedErr)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: Failed to create an integrated CA Options: %v", id, err)
+		}
+
+		ca, err := NewIstioCA(caopts)
+		if err != nil {
+			t.Fatalf("%s: Failed to create an integrated CA: %v", id, err)
+		}
+		if ca.upstream == nil {
+			t.Errorf("%s: CSRs are not forwarded to the upstream CA", id)
+		}
+		if _, _, _, rootCertBytes := ca.GetCAKeyCertBundle().GetAllPem(); !comparePem(rootCertBytes, rootCertFile) {
+			t.Errorf("%s: Failed to verify loading of root cert pem.", id)
+		}
+	}
+}
+
 func TestSignCSRForWorkload(t *testing.T) {
 	host := "spiffe://example.com/ns/foo/sa/bar"
 	opts := util.CertOptions{
@@ -285,6 +331,80 @@ func TestSignCSRForWorkload(t *testing.T) {
 	if !reflect.DeepEqual(expected, san) {
 		t.Errorf("Unexpected extensions: wanted %v but got %v", expected, san)
 	}
+
+	record, err := ca.GetIssuanceLog().Get(cert.SerialNumber)
+	if err != nil {
+		t.Error(err)
+	}
+	if record == nil {
+		t.Fatalf("The certificate is not recorded in the issuance log")
+	}
+	if !reflect.DeepEqual(record.IDs, []string{host}) || !record.NotAfter.Equal(cert.NotAfter) || record.Revoked() {
+		t.Errorf("Unexpected issuance record %+v", record)
+	}
+}
+
+func TestSignCSRWithECDSAKeys(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(time.Hour, 30*time.Minute, time.Hour, "test.ca.org", "default",
+		util.ECDSAP256, client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while createing self-signed CA: %v", err)
+	}
+
+	signingCert, _, _, rootCertBytes := ca.GetCAKeyCertBundle().GetAll()
+	if signingCert.PublicKeyAlgorithm != x509.ECDSA {
+		t.Errorf("Unexpected CA public key algorithm %v", signingCert.PublicKeyAlgorithm)
+	}
+
+	// An ECDSA CA signs CSRs of both RSA and ECDSA keys.
+	host := "spiffe://example.com/ns/foo/sa/bar"
+	testCases := map[string]struct {
+		keyAlgorithm util.KeyAlgorithm
+		keyUsage     x509.KeyUsage
+	}{
+		"RSA key": {
+			keyAlgorithm: util.RSA,
+			keyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		},
+		"ECDSA P-256 key": {
+			keyAlgorithm: util.ECDSAP256,
+			keyUsage:     x509.KeyUsageDigitalSignature,
+		},
+		"ECDSA P-384 key": {
+			keyAlgorithm: util.ECDSAP384,
+			keyUsage:     x509.KeyUsageDigitalSignature,
+		},
+	}
+	for id, tc := range testCases {
+		csrPEM, keyPEM, err := util.GenCSR(util.CertOptions{
+			Host:         host,
+			KeyAlgorithm: tc.keyAlgorithm,
+			RSAKeySize:   2048,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+
+		certPEM, err := ca.Sign(csrPEM, 30*time.Minute, false)
+		if err != nil {
+			t.Errorf("%s: Failed to sign the CSR: %v", id, err)
+			continue
+		}
+
+		fields := &util.VerifyFields{
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+			KeyUsage:    tc.keyUsage,
+			IsCA:        false,
+		}
+		if err = util.VerifyCertificate(keyPEM, certPEM, rootCertBytes, host, fields); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
 }
 
 func TestSignCSRForCA(t *testing.T) {
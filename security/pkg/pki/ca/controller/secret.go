@@ -54,7 +54,7 @@ const (
 	recommendedMinGracePeriodRatio = 0.2
 	recommendedMaxGracePeriodRatio = 0.8
 
-	// The size of a private key for a leaf certificate.
+	// The size of a RSA private key for a leaf certificate.
 	keySize = 2048
 
 	// The number of retries when requesting to create secret.
@@ -75,6 +75,8 @@ type SecretController struct {
 	// Length of the grace period for the certificate rotation.
 	gracePeriodRatio float32
 	minGracePeriod   time.Duration
+	// Algorithm of the generated private keys.
+	keyAlgorithm util.KeyAlgorithm
 
 	// DNS-enabled service account/service pair
 	dnsNames map[string]DNSNameEntry
@@ -90,7 +92,8 @@ type SecretController struct {
 
 // NewSecretController returns a pointer to a newly constructed SecretController instance.
 func NewSecretController(ca ca.CertificateAuthority, certTTL time.Duration, gracePeriodRatio float32, minGracePeriod time.Duration,
-	core corev1.CoreV1Interface, namespace string, dnsNames map[string]DNSNameEntry) (*SecretController, error) {
+	keyAlgorithm util.KeyAlgorithm, core corev1.CoreV1Interface, namespace string,
+	dnsNames map[string]DNSNameEntry) (*SecretController, error) {
 
 	if gracePeriodRatio < 0 || gracePeriodRatio > 1 {
 		return nil, fmt.Errorf("grace period ratio %f should be within [0, 1]", gracePeriodRatio)
@@ -105,6 +108,7 @@ func NewSecretController(ca ca.CertificateAuthority, certTTL time.Duration, grac
 		certTTL:          certTTL,
 		gracePeriodRatio: gracePeriodRatio,
 		minGracePeriod:   minGracePeriod,
+		keyAlgorithm:     keyAlgorithm,
 		core:             core,
 		dnsNames:         dnsNames,
 	}
@@ -277,8 +281,9 @@ func (sc *SecretController) generateKeyAndCert(saName string, saNamespace string
 		}
 	}
 	options := util.CertOptions{
-		Host:       id,
-		RSAKeySize: keySize,
+		Host:         id,
+		KeyAlgorithm: sc.keyAlgorithm,
+		RSAKeySize:   keySize,
 	}
 
 	csrPEM, keyPEM, err := util.GenCSR(options)
@@ -17,15 +17,18 @@ package mock
 import (
 	"time"
 
+	"istio.io/istio/security/pkg/pki/issuance"
 	"istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/pki/util/mock"
 )
 
 // FakeCA is a mock of CertificateAuthority.
 type FakeCA struct {
-	SignedCert    []byte
-	SignErr       error
-	KeyCertBundle util.KeyCertBundle
+	SignedCert            []byte
+	SignErr               error
+	KeyCertBundle         util.KeyCertBundle
+	PreviousKeyCertBundle util.KeyCertBundle
+	IssuanceLog           issuance.Log
 }
 
 // Sign returns the SignErr if SignErr is not nil, otherwise, it returns SignedCert.
@@ -44,3 +47,13 @@ func (ca *FakeCA) GetCAKeyCertBundle() util.KeyCertBundle {
 	}
 	return ca.KeyCertBundle
 }
+
+// GetIssuanceLog returns IssuanceLog.
+func (ca *FakeCA) GetIssuanceLog() issuance.Log {
+	return ca.IssuanceLog
+}
+
+// GetPreviousCAKeyCertBundle returns PreviousKeyCertBundle.
+func (ca *FakeCA) GetPreviousCAKeyCertBundle() util.KeyCertBundle {
+	return ca.PreviousKeyCertBundle
+}
@@ -0,0 +1,433 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// The root certificate of a self-signed CA is rotated in three steps, so that the workloads
+// always trust the root certificate of their peers:
+//   1. A new root certificate is generated before the current one expires, and published
+//      alongside it. The current root certificate still signs the certificates.
+//   2. After a grace period, during which the workloads receive the new root certificate, the
+//      new root certificate signs the certificates. The replaced one is still trusted.
+//   3. Once all the certificates signed by the replaced root certificate have expired, it is
+//      retired. Until then, its key still signs the revocation status of these certificates.
+// The state of the rotation is stored in the CA secret, to resume it when the CA restarts.
+
+const (
+	// rotationSwitchTimeAnnotation is when the new root certificate starts to sign certificates.
+	rotationSwitchTimeAnnotation = "istio.io/ca-root-switch-time"
+	// rotationRetireTimeAnnotation is when the replaced root certificate is retired.
+	rotationRetireTimeAnnotation = "istio.io/ca-root-retire-time"
+
+	metricsNamespace = "istio_ca"
+	metricLabelPhase = "phase"
+	metricLabelRole  = "role"
+)
+
+// RotationPhase is the step of the root certificate rotation that a self-signed CA is at.
+type RotationPhase string
+
+const (
+	// RotationIdle means that a single root certificate is trusted.
+	RotationIdle RotationPhase = "idle"
+	// RotationPublished means that a new root certificate is trusted, but doesn't sign certificates yet.
+	RotationPublished RotationPhase = "published"
+	// RotationSwitched means that the new root certificate signs certificates, and that the replaced
+	// one is still trusted.
+	RotationSwitched RotationPhase = "switched"
+)
+
+var (
+	rotationPhases = []RotationPhase{RotationIdle, RotationPublished, RotationSwitched}
+
+	rotationPhaseGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "root_cert_rotation_phase",
+			Help:      "Phase of the root certificate rotation, 1 for the current phase and 0 for the others.",
+		}, []string{metricLabelPhase})
+	rotationCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "root_cert_rotations_total",
+			Help:      "Number of times the root certificate rotation entered a phase.",
+		}, []string{metricLabelPhase})
+	rotationErrorCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "root_cert_rotation_errors_total",
+			Help:      "Number of root certificate rotation checks that failed.",
+		})
+	rootCertExpiryGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "root_cert_expiry_timestamp_seconds",
+			Help:      "Expiration time of the trusted root certificates, by role: signing, next or previous.",
+		}, []string{metricLabelRole})
+)
+
+func init() {
+	prometheus.MustRegister(rotationPhaseGauge)
+	prometheus.MustRegister(rotationCounter)
+	prometheus.MustRegister(rotationErrorCounter)
+	prometheus.MustRegister(rootCertExpiryGauge)
+}
+
+// RootCertRotatorOptions holds the configurations for rotating the root certificate of a self-signed CA.
+type RootCertRotatorOptions struct {
+	// How often the root certificate is checked.
+	CheckInterval time.Duration
+	// A new root certificate is generated when the current one expires in less than RotationThreshold.
+	RotationThreshold time.Duration
+	// How long the new root certificate is published before it signs certificates.
+	GracePeriod time.Duration
+	// The max TTL of the certificates signed by the CA. The replaced root certificate is retired
+	// MaxCertTTL after the new one starts to sign certificates.
+	MaxCertTTL time.Duration
+
+	// The options of the generated root certificates.
+	CACertTTL    time.Duration
+	Org          string
+	KeyAlgorithm util.KeyAlgorithm
+
+	// The namespace of the CA secret.
+	Namespace string
+}
+
+// RootCertRotationStatus describes where the root certificate rotation stands.
+type RootCertRotationStatus struct {
+	Phase RotationPhase `json:"phase"`
+
+	// Expiration time of the root certificates.
+	SigningRootExpiry  time.Time  `json:"signingRootExpiry"`
+	NextRootExpiry     *time.Time `json:"nextRootExpiry,omitempty"`
+	PreviousRootExpiry *time.Time `json:"previousRootExpiry,omitempty"`
+
+	// When the next phase of the rotation starts.
+	SwitchTime *time.Time `json:"switchTime,omitempty"`
+	RetireTime *time.Time `json:"retireTime,omitempty"`
+
+	LastCheck time.Time `json:"lastCheck"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// RootCertRotator rotates the root certificate of a self-signed Istio CA.
+type RootCertRotator struct {
+	ca   *IstioCA
+	core corev1.SecretsGetter
+	opts RootCertRotatorOptions
+
+	// indirection to support tests
+	now func() time.Time
+
+	mutex  sync.RWMutex
+	status RootCertRotationStatus
+}
+
+// NewRootCertRotator returns a RootCertRotator for the given self-signed CA.
+func NewRootCertRotator(ca *IstioCA, core corev1.SecretsGetter, opts RootCertRotatorOptions) (*RootCertRotator, error) {
+	if opts.CheckInterval <= 0 {
+		return nil, fmt.Errorf("root certificate check interval %v should be positive", opts.CheckInterval)
+	}
+	if opts.RotationThreshold < opts.GracePeriod+opts.MaxCertTTL {
+		return nil, fmt.Errorf("root certificate rotation threshold %v should be at least the grace period %v "+
+			"plus the max certificate TTL %v", opts.RotationThreshold, opts.GracePeriod, opts.MaxCertTTL)
+	}
+	if opts.RotationThreshold >= opts.CACertTTL {
+		return nil, fmt.Errorf("root certificate rotation threshold %v should be less than the CA certificate TTL %v",
+			opts.RotationThreshold, opts.CACertTTL)
+	}
+
+	return &RootCertRotator{
+		ca:   ca,
+		core: core,
+		opts: opts,
+		now:  time.Now,
+	}, nil
+}
+
+// Run checks the root certificate periodically until a value is sent to stopCh.
+func (r *RootCertRotator) Run(stopCh chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(r.opts.CheckInterval)
+		defer ticker.Stop()
+
+		r.check()
+		for {
+			select {
+			case <-ticker.C:
+				r.check()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Status returns where the root certificate rotation stands.
+func (r *RootCertRotator) Status() RootCertRotationStatus {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.status
+}
+
+// ServeHTTP writes the status of the root certificate rotation as JSON.
+func (r *RootCertRotator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Status()); err != nil {
+		log.Errorf("Failed to write the root certificate rotation status (error: %v)", err)
+	}
+}
+
+func (r *RootCertRotator) check() {
+	now := r.now()
+	status, err := r.rotate(now)
+	if err != nil {
+		log.Errorf("Failed to rotate the root certificate (error: %v)", err)
+		rotationErrorCounter.Inc()
+		status = r.Status()
+		status.LastError = err.Error()
+	}
+	status.LastCheck = now
+
+	r.mutex.Lock()
+	r.status = status
+	r.mutex.Unlock()
+}
+
+// rotate advances the rotation if it is time to, and updates the CA to the state stored in the
+// CA secret.
+func (r *RootCertRotator) rotate(now time.Time) (RootCertRotationStatus, error) {
+	secret, err := r.core.Secrets(r.opts.Namespace).Get(cASecret, metav1.GetOptions{})
+	if err != nil {
+		return RootCertRotationStatus{}, fmt.Errorf("failed to get the CA secret (%v)", err)
+	}
+	state, err := loadRotationState(secret.Data, secret.Annotations)
+	if err != nil {
+		return RootCertRotationStatus{}, err
+	}
+
+	advanced, err := state.advance(now, r.opts)
+	if err != nil {
+		return RootCertRotationStatus{}, err
+	}
+	if advanced {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		state.store(secret.Data, secret.Annotations)
+		if _, err = r.core.Secrets(r.opts.Namespace).Update(secret); err != nil {
+			return RootCertRotationStatus{}, fmt.Errorf("failed to update the CA secret (%v)", err)
+		}
+
+		phase := state.phase()
+		log.Infof("Root certificate rotation entered the %s phase", phase)
+		rotationCounter.WithLabelValues(string(phase)).Inc()
+	}
+
+	bundle := r.ca.GetCAKeyCertBundle()
+	rootCerts := trustedRootCerts(state.data)
+	certBytes, keyBytes, _, rootCertBytes := bundle.GetAllPem()
+	if !bytes.Equal(certBytes, state.data[cACertID]) || !bytes.Equal(keyBytes, state.data[cAPrivateKeyID]) ||
+		!bytes.Equal(rootCertBytes, rootCerts) {
+		if err = bundle.VerifyAndSetAll(state.data[cACertID], state.data[cAPrivateKeyID], nil, rootCerts); err != nil {
+			return RootCertRotationStatus{}, fmt.Errorf("failed to update the CA KeyCertBundle (%v)", err)
+		}
+	}
+
+	var previousCertBytes, previousKeyBytes []byte
+	if previous := r.ca.GetPreviousCAKeyCertBundle(); previous != nil {
+		previousCertBytes, previousKeyBytes, _, _ = previous.GetAllPem()
+	}
+	if !bytes.Equal(previousCertBytes, state.data[cAPreviousCertID]) ||
+		!bytes.Equal(previousKeyBytes, state.data[cAPreviousPrivateKeyID]) {
+		previous, err := previousKeyCertBundle(state.data)
+		if err != nil {
+			return RootCertRotationStatus{}, err
+		}
+		r.ca.setPreviousKeyCertBundle(previous)
+	}
+
+	return state.status(), nil
+}
+
+// rotationSecretIDs are the IDs of the root certificates and keys in the CA secret.
+var rotationSecretIDs = []string{cACertID, cAPrivateKeyID, cANextCertID, cANextPrivateKeyID, cAPreviousCertID,
+	cAPreviousPrivateKeyID}
+
+// rotationState is the state of the root certificate rotation stored in the CA secret.
+type rotationState struct {
+	// the root certificates and keys, by their ID in the CA secret
+	data map[string][]byte
+
+	switchTime time.Time
+	retireTime time.Time
+}
+
+func loadRotationState(data map[string][]byte, annotations map[string]string) (*rotationState, error) {
+	s := &rotationState{data: map[string][]byte{}}
+	for _, id := range rotationSecretIDs {
+		if len(data[id]) > 0 {
+			s.data[id] = data[id]
+		}
+	}
+
+	var err error
+	if t, ok := annotations[rotationSwitchTimeAnnotation]; ok {
+		if s.switchTime, err = time.Parse(time.RFC3339, t); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation of the CA secret (%v)", rotationSwitchTimeAnnotation, err)
+		}
+	}
+	if t, ok := annotations[rotationRetireTimeAnnotation]; ok {
+		if s.retireTime, err = time.Parse(time.RFC3339, t); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation of the CA secret (%v)", rotationRetireTimeAnnotation, err)
+		}
+	}
+	return s, nil
+}
+
+// store writes the state to the data and annotations of the CA secret.
+func (s *rotationState) store(data map[string][]byte, annotations map[string]string) {
+	for _, id := range rotationSecretIDs {
+		if len(s.data[id]) > 0 {
+			data[id] = s.data[id]
+		} else {
+			delete(data, id)
+		}
+	}
+
+	storeTime := func(key string, t time.Time) {
+		if t.IsZero() {
+			delete(annotations, key)
+		} else {
+			annotations[key] = t.UTC().Format(time.RFC3339)
+		}
+	}
+	storeTime(rotationSwitchTimeAnnotation, s.switchTime)
+	storeTime(rotationRetireTimeAnnotation, s.retireTime)
+}
+
+func (s *rotationState) phase() RotationPhase {
+	switch {
+	case len(s.data[cANextCertID]) > 0:
+		return RotationPublished
+	case len(s.data[cAPreviousCertID]) > 0:
+		return RotationSwitched
+	default:
+		return RotationIdle
+	}
+}
+
+// advance moves the rotation to its next phase if it is time to, and returns whether it did.
+func (s *rotationState) advance(now time.Time, opts RootCertRotatorOptions) (bool, error) {
+	switch s.phase() {
+	case RotationIdle:
+		cert, err := util.ParsePemEncodedCertificate(s.data[cACertID])
+		if err != nil {
+			return false, fmt.Errorf("failed to parse the root certificate (%v)", err)
+		}
+		if cert.NotAfter.Sub(now) >= opts.RotationThreshold {
+			return false, nil
+		}
+
+		certPEM, keyPEM, err := genSelfSignedRootCert(opts.CACertTTL, opts.Org, opts.KeyAlgorithm)
+		if err != nil {
+			return false, fmt.Errorf("failed to generate the new root certificate (%v)", err)
+		}
+		s.data[cANextCertID] = certPEM
+		s.data[cANextPrivateKeyID] = keyPEM
+		s.switchTime = now.Add(opts.GracePeriod)
+		return true, nil
+
+	case RotationPublished:
+		if now.Before(s.switchTime) {
+			return false, nil
+		}
+		s.data[cAPreviousCertID] = s.data[cACertID]
+		s.data[cAPreviousPrivateKeyID] = s.data[cAPrivateKeyID]
+		s.data[cACertID] = s.data[cANextCertID]
+		s.data[cAPrivateKeyID] = s.data[cANextPrivateKeyID]
+		delete(s.data, cANextCertID)
+		delete(s.data, cANextPrivateKeyID)
+		s.switchTime = time.Time{}
+		s.retireTime = now.Add(opts.MaxCertTTL)
+		return true, nil
+
+	case RotationSwitched:
+		if now.Before(s.retireTime) {
+			return false, nil
+		}
+		delete(s.data, cAPreviousCertID)
+		delete(s.data, cAPreviousPrivateKeyID)
+		s.retireTime = time.Time{}
+		return true, nil
+	}
+	return false, nil
+}
+
+// status returns the status of the rotation, and updates the metrics accordingly.
+func (s *rotationState) status() RootCertRotationStatus {
+	status := RootCertRotationStatus{Phase: s.phase()}
+	for _, p := range rotationPhases {
+		v := 0.0
+		if p == status.Phase {
+			v = 1
+		}
+		rotationPhaseGauge.WithLabelValues(string(p)).Set(v)
+	}
+
+	expiry := func(role, id string) *time.Time {
+		cert, err := util.ParsePemEncodedCertificate(s.data[id])
+		if err != nil {
+			rootCertExpiryGauge.DeleteLabelValues(role)
+			return nil
+		}
+		rootCertExpiryGauge.WithLabelValues(role).Set(float64(cert.NotAfter.Unix()))
+		return &cert.NotAfter
+	}
+	if t := expiry("signing", cACertID); t != nil {
+		status.SigningRootExpiry = *t
+	}
+	status.NextRootExpiry = expiry("next", cANextCertID)
+	status.PreviousRootExpiry = expiry("previous", cAPreviousCertID)
+
+	if !s.switchTime.IsZero() {
+		t := s.switchTime
+		status.SwitchTime = &t
+	}
+	if !s.retireTime.IsZero() {
+		t := s.retireTime
+		status.RetireTime = &t
+	}
+	return status
+}
@@ -0,0 +1,341 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestNewRootCertRotator(t *testing.T) {
+	testCases := map[string]struct {
+		opts        RootCertRotatorOptions
+		expectedErr string
+	}{
+		"Valid options": {
+			opts: RootCertRotatorOptions{
+				CheckInterval:     time.Minute,
+				RotationThreshold: 5 * time.Hour,
+				GracePeriod:       time.Hour,
+				MaxCertTTL:        2 * time.Hour,
+				CACertTTL:         10 * time.Hour,
+			},
+		},
+		"Invalid check interval": {
+			opts: RootCertRotatorOptions{
+				RotationThreshold: 5 * time.Hour,
+				GracePeriod:       time.Hour,
+				MaxCertTTL:        2 * time.Hour,
+				CACertTTL:         10 * time.Hour,
+			},
+			expectedErr: "root certificate check interval 0s should be positive",
+		},
+		"Threshold shorter than the rotation": {
+			opts: RootCertRotatorOptions{
+				CheckInterval:     time.Minute,
+				RotationThreshold: 2 * time.Hour,
+				GracePeriod:       time.Hour,
+				MaxCertTTL:        2 * time.Hour,
+				CACertTTL:         10 * time.Hour,
+			},
+			expectedErr: "root certificate rotation threshold 2h0m0s should be at least the grace period 1h0m0s " +
+				"plus the max certificate TTL 2h0m0s",
+		},
+		"Threshold longer than the CA certificate TTL": {
+			opts: RootCertRotatorOptions{
+				CheckInterval:     time.Minute,
+				RotationThreshold: 10 * time.Hour,
+				GracePeriod:       time.Hour,
+				MaxCertTTL:        2 * time.Hour,
+				CACertTTL:         10 * time.Hour,
+			},
+			expectedErr: "root certificate rotation threshold 10h0m0s should be less than the CA certificate TTL 10h0m0s",
+		},
+	}
+
+	for id, tc := range testCases {
+		_, err := NewRootCertRotator(nil, fake.NewSimpleClientset().CoreV1(), tc.opts)
+		if len(tc.expectedErr) > 0 {
+			if err == nil {
+				t.Errorf("%s: Succeeded. Error expected: %v", id, tc.expectedErr)
+			} else if err.Error() != tc.expectedErr {
+				t.Errorf("%s: incorrect error message: %s VS %s", id, err.Error(), tc.expectedErr)
+			}
+		} else if err != nil {
+			t.Errorf("%s: Unexpected Error: %v", id, err)
+		}
+	}
+}
+
+func TestRootCertRotation(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rotatorOpts := RootCertRotatorOptions{
+		CheckInterval:     time.Minute,
+		RotationThreshold: 5 * time.Hour,
+		GracePeriod:       time.Hour,
+		MaxCertTTL:        2 * time.Hour,
+		CACertTTL:         10 * time.Hour,
+		Org:               "test.ca.org",
+		KeyAlgorithm:      util.RSA,
+		Namespace:         "default",
+	}
+	caopts, err := NewSelfSignedIstioCAOptions(rotatorOpts.CACertTTL, time.Hour, rotatorOpts.MaxCertTTL,
+		rotatorOpts.Org, rotatorOpts.Namespace, util.RSA, client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA: %v", err)
+	}
+	rotator, err := NewRootCertRotator(ca, client.CoreV1(), rotatorOpts)
+	if err != nil {
+		t.Fatalf("Failed to create the root certificate rotator: %v", err)
+	}
+
+	start := time.Now()
+	oldRoot, _, _, _ := ca.GetCAKeyCertBundle().GetAllPem()
+	var newRoot []byte
+
+	for _, step := range []struct {
+		elapsed       time.Duration
+		phase         RotationPhase
+		rootCerts     func() [][]byte
+		signingRoot   func() []byte
+		secretCertIDs []string
+	}{
+		{
+			elapsed:       time.Hour,
+			phase:         RotationIdle,
+			rootCerts:     func() [][]byte { return [][]byte{oldRoot} },
+			signingRoot:   func() []byte { return oldRoot },
+			secretCertIDs: []string{cACertID},
+		},
+		{
+			// The root certificate expires in less than the threshold.
+			elapsed:       6 * time.Hour,
+			phase:         RotationPublished,
+			rootCerts:     func() [][]byte { return [][]byte{oldRoot, newRoot} },
+			signingRoot:   func() []byte { return oldRoot },
+			secretCertIDs: []string{cACertID, cANextCertID},
+		},
+		{
+			elapsed:       6*time.Hour + 30*time.Minute,
+			phase:         RotationPublished,
+			rootCerts:     func() [][]byte { return [][]byte{oldRoot, newRoot} },
+			signingRoot:   func() []byte { return oldRoot },
+			secretCertIDs: []string{cACertID, cANextCertID},
+		},
+		{
+			// The grace period has passed.
+			elapsed:       7 * time.Hour,
+			phase:         RotationSwitched,
+			rootCerts:     func() [][]byte { return [][]byte{newRoot, oldRoot} },
+			signingRoot:   func() []byte { return newRoot },
+			secretCertIDs: []string{cACertID, cAPreviousCertID},
+		},
+		{
+			elapsed:       8 * time.Hour,
+			phase:         RotationSwitched,
+			rootCerts:     func() [][]byte { return [][]byte{newRoot, oldRoot} },
+			signingRoot:   func() []byte { return newRoot },
+			secretCertIDs: []string{cACertID, cAPreviousCertID},
+		},
+		{
+			// The certificates signed by the old root certificate have expired.
+			elapsed:       9 * time.Hour,
+			phase:         RotationIdle,
+			rootCerts:     func() [][]byte { return [][]byte{newRoot} },
+			signingRoot:   func() []byte { return newRoot },
+			secretCertIDs: []string{cACertID},
+		},
+	} {
+		rotator.now = func() time.Time { return start.Add(step.elapsed) }
+		rotator.check()
+
+		status := rotator.Status()
+		if status.LastError != "" {
+			t.Fatalf("After %v: unexpected error: %s", step.elapsed, status.LastError)
+		}
+		if status.Phase != step.phase {
+			t.Fatalf("After %v: phase is %s, expected %s", step.elapsed, status.Phase, step.phase)
+		}
+
+		secret, err := client.CoreV1().Secrets("default").Get(cASecret, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("After %v: failed to get the CA secret: %v", step.elapsed, err)
+		}
+		if newRoot == nil && len(secret.Data[cANextCertID]) > 0 {
+			newRoot = secret.Data[cANextCertID]
+		}
+		for _, id := range []string{cACertID, cANextCertID, cAPreviousCertID} {
+			expected := false
+			for _, e := range step.secretCertIDs {
+				expected = expected || e == id
+			}
+			if expected != (len(secret.Data[id]) > 0) {
+				t.Errorf("After %v: %s in the CA secret: %t, expected %t", step.elapsed, id, !expected, expected)
+			}
+		}
+
+		signingCert, _, _, rootCerts := ca.GetCAKeyCertBundle().GetAllPem()
+		if !bytes.Equal(signingCert, step.signingRoot()) {
+			t.Errorf("After %v: the CA signs with an unexpected root certificate", step.elapsed)
+		}
+		if expected := bytes.Join(step.rootCerts(), nil); !bytes.Equal(rootCerts, expected) {
+			t.Errorf("After %v: the CA trusts unexpected root certificates:\n%s\nexpected:\n%s",
+				step.elapsed, rootCerts, expected)
+		}
+
+		// The CA keeps the key of the replaced root certificate until it is retired.
+		var previousRoot []byte
+		if previous := ca.GetPreviousCAKeyCertBundle(); previous != nil {
+			previousRoot, _, _, _ = previous.GetAllPem()
+		}
+		if switched := step.phase == RotationSwitched; switched != bytes.Equal(previousRoot, oldRoot) ||
+			switched != (len(secret.Data[cAPreviousPrivateKeyID]) > 0) {
+			t.Errorf("After %v: unexpected previous root certificate and key:\n%s", step.elapsed, previousRoot)
+		}
+
+		// The issued certificates are signed by the signing root certificate.
+		csrPEM, _, err := util.GenCSR(util.CertOptions{Host: "spiffe://example.com/ns/foo/sa/bar", RSAKeySize: 2048})
+		if err != nil {
+			t.Fatalf("After %v: failed to generate a CSR: %v", step.elapsed, err)
+		}
+		certPEM, err := ca.Sign(csrPEM, time.Hour, false)
+		if err != nil {
+			t.Fatalf("After %v: failed to sign a CSR: %v", step.elapsed, err)
+		}
+		cert, err := util.ParsePemEncodedCertificate(certPEM)
+		if err != nil {
+			t.Fatalf("After %v: failed to parse the issued certificate: %v", step.elapsed, err)
+		}
+		signingRoots := x509.NewCertPool()
+		signingRoots.AppendCertsFromPEM(step.signingRoot())
+		if _, err := cert.Verify(x509.VerifyOptions{Roots: signingRoots}); err != nil {
+			t.Errorf("After %v: the issued certificate is not signed by the signing root: %v", step.elapsed, err)
+		}
+
+		// The CA resumes the rotation when it restarts.
+		restartedOpts, err := NewSelfSignedIstioCAOptions(rotatorOpts.CACertTTL, time.Hour, rotatorOpts.MaxCertTTL,
+			rotatorOpts.Org, rotatorOpts.Namespace, util.RSA, client.CoreV1())
+		if err != nil {
+			t.Fatalf("After %v: failed to restart the CA: %v", step.elapsed, err)
+		}
+		restartedCert, _, _, restartedRootCerts := restartedOpts.KeyCertBundle.GetAllPem()
+		if !bytes.Equal(restartedCert, signingCert) || !bytes.Equal(restartedRootCerts, rootCerts) {
+			t.Errorf("After %v: the restarted CA doesn't use the rotated root certificates", step.elapsed)
+		}
+		if (restartedOpts.PreviousKeyCertBundle != nil) != (previousRoot != nil) {
+			t.Errorf("After %v: the restarted CA doesn't keep the replaced root certificate", step.elapsed)
+		}
+	}
+
+	if bytes.Equal(oldRoot, newRoot) {
+		t.Error("The root certificate was not rotated")
+	}
+}
+
+func TestRootCertRotationStatus(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rotatorOpts := RootCertRotatorOptions{
+		CheckInterval:     time.Minute,
+		RotationThreshold: 5 * time.Hour,
+		GracePeriod:       time.Hour,
+		MaxCertTTL:        2 * time.Hour,
+		CACertTTL:         10 * time.Hour,
+		Org:               "test.ca.org",
+		KeyAlgorithm:      util.RSA,
+		Namespace:         "default",
+	}
+	caopts, err := NewSelfSignedIstioCAOptions(rotatorOpts.CACertTTL, time.Hour, rotatorOpts.MaxCertTTL,
+		rotatorOpts.Org, rotatorOpts.Namespace, util.RSA, client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA: %v", err)
+	}
+	rotator, err := NewRootCertRotator(ca, client.CoreV1(), rotatorOpts)
+	if err != nil {
+		t.Fatalf("Failed to create the root certificate rotator: %v", err)
+	}
+
+	now := time.Now().Add(6 * time.Hour)
+	rotator.now = func() time.Time { return now }
+	rotator.check()
+
+	w := httptest.NewRecorder()
+	rotator.ServeHTTP(w, httptest.NewRequest("GET", "/root-cert-rotation", nil))
+	var status RootCertRotationStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode the status: %v", err)
+	}
+
+	if status.Phase != RotationPublished {
+		t.Errorf("Phase is %s, expected %s", status.Phase, RotationPublished)
+	}
+	if status.NextRootExpiry == nil || status.NextRootExpiry.Before(status.SigningRootExpiry) {
+		t.Errorf("Unexpected expiry of the next root certificate: %v", status.NextRootExpiry)
+	}
+	if status.SwitchTime == nil || !status.SwitchTime.Equal(now.Add(rotatorOpts.GracePeriod)) {
+		t.Errorf("Unexpected switch time: %v", status.SwitchTime)
+	}
+	if status.PreviousRootExpiry != nil || status.RetireTime != nil {
+		t.Errorf("Unexpected status of the switched phase: %+v", status)
+	}
+
+	// Failed checks are reported, and don't change the state.
+	if err := client.CoreV1().Secrets("default").Delete(cASecret, nil); err != nil {
+		t.Fatalf("Failed to delete the CA secret: %v", err)
+	}
+	rotator.check()
+	status = rotator.Status()
+	if !strings.HasPrefix(status.LastError, "failed to get the CA secret") || status.Phase != RotationPublished {
+		t.Errorf("Unexpected status after a failed check: %+v", status)
+	}
+}
+
+func TestTrustedRootCerts(t *testing.T) {
+	// The certificates in the secret don't always end with a newline.
+	roots := trustedRootCerts(map[string][]byte{
+		cACertID:         []byte(cert1Pem),
+		cAPrivateKeyID:   []byte(key1Pem),
+		cAPreviousCertID: []byte(cert1Pem),
+	})
+
+	count := 0
+	for block, rest := pem.Decode(roots); block != nil; block, rest = pem.Decode(rest) {
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			t.Errorf("Failed to parse root certificate %d: %v", count, err)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("%d root certificates, expected 2:\n%s", count, roots)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/security/pkg/caclient/grpc"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/platform"
+	pb "istio.io/istio/security/proto"
+)
+
+const (
+	// UpstreamAuthMTLS authenticates the Istio CA to the upstream CA with a client certificate.
+	UpstreamAuthMTLS = "mtls"
+	// UpstreamAuthGCP authenticates the Istio CA to the upstream CA with a GCE identity token.
+	UpstreamAuthGCP = "gcp"
+)
+
+// NewUpstreamClient returns the client authenticating the Istio CA to the upstream CA with the
+// given method. The certificate chain and key files are only used by the mtls method.
+func NewUpstreamClient(upstreamAuth, upstreamCAAddress, upstreamCACertFile, certChainFile,
+	keyFile string) (platform.Client, error) {
+	switch upstreamAuth {
+	case UpstreamAuthMTLS:
+		return platform.NewOnPremClientImpl(upstreamCACertFile, keyFile, certChainFile)
+	case UpstreamAuthGCP:
+		return platform.NewGcpClientImpl(upstreamCACertFile, upstreamCAAddress), nil
+	default:
+		return nil, fmt.Errorf("unsupported upstream auth %q, expecting %q or %q",
+			upstreamAuth, UpstreamAuthMTLS, UpstreamAuthGCP)
+	}
+}
+
+// upstreamCA forwards CSRs to an upstream Istio CA over the IstioCAService protocol.
+type upstreamCA struct {
+	address        string
+	platformClient platform.Client
+	protocolClient grpc.CAGrpcClient
+
+	roots *x509.CertPool
+
+	// the certificate chain last returned by the upstream CA, protected by lock
+	lock      sync.Mutex
+	certChain []byte
+}
+
+func newUpstreamCA(opts *IstioCAOptions) (*upstreamCA, error) {
+	if opts.UpstreamClient == nil {
+		return nil, fmt.Errorf("no client is configured for upstream CA %s", opts.UpstreamCAAddress)
+	}
+	if opts.KeyCertBundle == nil {
+		return nil, fmt.Errorf("no root certificate is configured for upstream CA %s", opts.UpstreamCAAddress)
+	}
+
+	_, _, _, rootCertBytes := opts.KeyCertBundle.GetAll()
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(rootCertBytes) {
+		return nil, fmt.Errorf("failed to parse the root certificate of upstream CA %s", opts.UpstreamCAAddress)
+	}
+
+	return &upstreamCA{
+		address:        opts.UpstreamCAAddress,
+		platformClient: opts.UpstreamClient,
+		protocolClient: &grpc.CAGrpcClientImpl{},
+		roots:          roots,
+	}, nil
+}
+
+// sign sends the CSR to the upstream CA and returns the signed certificate, followed by the
+// certificate chain of the upstream CA.
+func (u *upstreamCA) sign(csrPEM []byte, ttl time.Duration, forCA bool) ([]byte, error) {
+	cred, err := u.platformClient.GetAgentCredential()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the credential for upstream CA (%v)", err)
+	}
+
+	req := &pb.CsrRequest{
+		CsrPem:              csrPEM,
+		NodeAgentCredential: cred,
+		CredentialType:      u.platformClient.GetCredentialType(),
+		RequestedTtlMinutes: int32(ttl.Minutes()),
+		ForCA:               forCA,
+	}
+	resp, err := u.protocolClient.SendCSR(req, u.platformClient, u.address)
+	if err != nil {
+		return nil, fmt.Errorf("upstream CA %s failed to sign the CSR (%v)", u.address, err)
+	}
+	if resp == nil || !resp.IsApproved {
+		return nil, fmt.Errorf("upstream CA %s did not approve the CSR", u.address)
+	}
+
+	if err := u.verify(resp.SignedCert, resp.CertChain); err != nil {
+		return nil, fmt.Errorf("upstream CA %s returned an invalid certificate (%v)", u.address, err)
+	}
+	u.setCertChain(resp.CertChain)
+
+	certChain := make([]byte, 0, len(resp.SignedCert)+len(resp.CertChain))
+	certChain = append(certChain, resp.SignedCert...)
+	return append(certChain, resp.CertChain...), nil
+}
+
+// verify checks that the certificate was issued under the upstream root certificate.
+func (u *upstreamCA) verify(certBytes, certChainBytes []byte) error {
+	cert, err := util.ParsePemEncodedCertificate(certBytes)
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM(certChainBytes)
+	_, err = cert.Verify(x509.VerifyOptions{
+		Intermediates: intermediates,
+		Roots:         u.roots,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+// setCertChain caches the certificate chain returned by the upstream CA, to notice when it changes.
+func (u *upstreamCA) setCertChain(certChain []byte) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if !bytes.Equal(u.certChain, certChain) {
+		log.Infof("Certificate chain of upstream CA %s updated", u.address)
+		u.certChain = certChain
+	}
+}
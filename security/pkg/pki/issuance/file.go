@@ -0,0 +1,123 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issuance
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileLog is a Log appending the records to a file, one JSON object per line. A revocation
+// appends the updated record of the certificate, so the file is also an audit trail of the CA.
+// The records are indexed in memory, and replayed from the file when it is opened.
+type FileLog struct {
+	// lock serializes the writes to the file and the index.
+	lock    sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	index   *MemoryLog
+}
+
+// NewFileLog opens the log in the given file, creating it if it does not exist.
+func NewFileLog(path string) (*FileLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open issuance log %s (%v)", path, err)
+	}
+
+	index := NewMemoryLog()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to parse line %d of issuance log %s (%v)", line, path, err)
+		}
+		index.set(record)
+	}
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to read issuance log %s (%v)", path, err)
+	}
+	index.prune(time.Now())
+
+	return &FileLog{
+		file:    file,
+		encoder: json.NewEncoder(file),
+		index:   index,
+	}, nil
+}
+
+// Add records a certificate issued by the CA.
+func (l *FileLog) Add(record *Record) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.index.has(record.SerialNumber) {
+		return fmt.Errorf("certificate with serial number %s is already recorded", record.SerialNumber)
+	}
+	if err := l.encoder.Encode(record); err != nil {
+		return fmt.Errorf("failed to write issuance log (%v)", err)
+	}
+	return l.index.Add(record)
+}
+
+// Get returns the record of the certificate with the given serial number, or nil if the
+// certificate is not recorded.
+func (l *FileLog) Get(serialNumber *big.Int) (*Record, error) {
+	return l.index.Get(serialNumber)
+}
+
+// Revoke marks the certificate with the given serial number revoked.
+func (l *FileLog) Revoke(serialNumber *big.Int, reason int, revokedAt time.Time) (*Record, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	record, _ := l.index.Get(serialNumber)
+	if record == nil {
+		return nil, fmt.Errorf("certificate with serial number %s is not recorded", FormatSerialNumber(serialNumber))
+	}
+	if record.Revoked() {
+		return record, nil
+	}
+
+	record.RevokedAt = &revokedAt
+	record.RevocationReason = reason
+	if err := l.encoder.Encode(record); err != nil {
+		return nil, fmt.Errorf("failed to write issuance log (%v)", err)
+	}
+	return l.index.Revoke(serialNumber, reason, revokedAt)
+}
+
+// Revoked returns the records of the revoked certificates that have not expired.
+func (l *FileLog) Revoked(now time.Time) ([]*Record, error) {
+	return l.index.Revoked(now)
+}
+
+// Close closes the file of the log.
+func (l *FileLog) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.file.Close()
+}
@@ -0,0 +1,249 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package issuance records the certificates issued by the Istio CA, so that they can be
+// audited and revoked.
+package issuance
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// pruneInterval is the minimum interval between two removals of the records of expired
+// certificates from a MemoryLog.
+const pruneInterval = time.Hour
+
+// Record is the audit record of a certificate issued by the CA.
+type Record struct {
+	// SerialNumber is the hex-encoded serial number of the certificate.
+	SerialNumber string `json:"serialNumber"`
+	// IDs are the identities in the SAN extension of the certificate.
+	IDs       []string  `json:"ids,omitempty"`
+	IsCA      bool      `json:"isCA,omitempty"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	// IssuerKeyHash identifies the CA certificate that signed the certificate, as returned by
+	// KeyHash. It is empty if the issuer is unknown, e.g. in the records of the previous releases.
+	IssuerKeyHash string `json:"issuerKeyHash,omitempty"`
+	// RevokedAt is the time the certificate was revoked, nil if it is not revoked.
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// RevocationReason is the reason code of the revocation, as defined in RFC 5280 section 5.3.1.
+	RevocationReason int `json:"revocationReason,omitempty"`
+}
+
+// NewRecord returns the record of the given certificate, signed by the given CA certificate. The
+// issuer may be nil if it is unknown.
+func NewRecord(cert, issuer *x509.Certificate) (*Record, error) {
+	record := &Record{
+		SerialNumber: FormatSerialNumber(cert.SerialNumber),
+		IsCA:         cert.IsCA,
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+	}
+	if issuer != nil {
+		keyHash, err := KeyHash(issuer)
+		if err != nil {
+			return nil, err
+		}
+		record.IssuerKeyHash = keyHash
+	}
+	if util.ExtractSANExtension(cert.Extensions) != nil {
+		ids, err := util.ExtractIDs(cert.Extensions)
+		if err != nil {
+			return nil, err
+		}
+		record.IDs = ids
+	}
+	return record, nil
+}
+
+// Revoked returns whether the certificate is revoked.
+func (r *Record) Revoked() bool {
+	return r.RevokedAt != nil
+}
+
+func (r *Record) copy() *Record {
+	c := *r
+	c.IDs = append([]string(nil), r.IDs...)
+	if r.RevokedAt != nil {
+		revokedAt := *r.RevokedAt
+		c.RevokedAt = &revokedAt
+	}
+	return &c
+}
+
+// KeyHash returns the hex-encoded SHA-256 hash of the public key of a CA certificate, which
+// identifies the issuer of the certificates in the records.
+func KeyHash(issuer *x509.Certificate) (string, error) {
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return "", fmt.Errorf("failed to parse the public key of the issuer (%v)", err)
+	}
+	hash := sha256.Sum256(publicKeyInfo.PublicKey.RightAlign())
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// FormatSerialNumber returns the hex encoding of a serial number, as used in the records.
+func FormatSerialNumber(serialNumber *big.Int) string {
+	return serialNumber.Text(16)
+}
+
+// ParseSerialNumber parses a hex-encoded serial number. Colons between the bytes, as printed
+// by openssl, are accepted.
+func ParseSerialNumber(s string) (*big.Int, error) {
+	serialNumber, ok := new(big.Int).SetString(strings.Replace(s, ":", "", -1), 16)
+	if !ok || serialNumber.Sign() < 0 {
+		return nil, fmt.Errorf("invalid serial number %q", s)
+	}
+	return serialNumber, nil
+}
+
+// Log records the certificates issued by the CA, indexed by serial number.
+type Log interface {
+	// Add records a certificate issued by the CA.
+	Add(record *Record) error
+
+	// Get returns the record of the certificate with the given serial number, or nil if the
+	// certificate is not recorded.
+	Get(serialNumber *big.Int) (*Record, error)
+
+	// Revoke marks the certificate with the given serial number revoked at the given time, and
+	// returns its record. A certificate that is already revoked keeps its revocation time.
+	Revoke(serialNumber *big.Int, reason int, revokedAt time.Time) (*Record, error)
+
+	// Revoked returns the records of the revoked certificates that have not expired at the
+	// given time.
+	Revoked(now time.Time) ([]*Record, error)
+}
+
+// MemoryLog is a Log keeping the records in memory. The records of the certificates that have
+// expired are eventually removed.
+type MemoryLog struct {
+	lock      sync.RWMutex
+	records   map[string]*Record
+	lastPrune time.Time
+}
+
+// NewMemoryLog returns an empty MemoryLog.
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{
+		records:   make(map[string]*Record),
+		lastPrune: time.Now(),
+	}
+}
+
+// Add records a certificate issued by the CA.
+func (l *MemoryLog) Add(record *Record) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, exists := l.records[record.SerialNumber]; exists {
+		return fmt.Errorf("certificate with serial number %s is already recorded", record.SerialNumber)
+	}
+	l.records[record.SerialNumber] = record.copy()
+
+	if now := time.Now(); now.Sub(l.lastPrune) >= pruneInterval {
+		l.prune(now)
+	}
+	return nil
+}
+
+// Get returns the record of the certificate with the given serial number, or nil if the
+// certificate is not recorded.
+func (l *MemoryLog) Get(serialNumber *big.Int) (*Record, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if record, exists := l.records[FormatSerialNumber(serialNumber)]; exists {
+		return record.copy(), nil
+	}
+	return nil, nil
+}
+
+// Revoke marks the certificate with the given serial number revoked.
+func (l *MemoryLog) Revoke(serialNumber *big.Int, reason int, revokedAt time.Time) (*Record, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	record, err := l.revoke(serialNumber, reason, revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return record.copy(), nil
+}
+
+// Revoked returns the records of the revoked certificates that have not expired.
+func (l *MemoryLog) Revoked(now time.Time) ([]*Record, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	revoked := []*Record{}
+	for _, record := range l.records {
+		if record.Revoked() && record.NotAfter.After(now) {
+			revoked = append(revoked, record.copy())
+		}
+	}
+	return revoked, nil
+}
+
+// has returns whether the certificate with the given hex-encoded serial number is recorded.
+func (l *MemoryLog) has(serialNumber string) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	_, exists := l.records[serialNumber]
+	return exists
+}
+
+// revoke updates the record in place, the caller must hold the lock.
+func (l *MemoryLog) revoke(serialNumber *big.Int, reason int, revokedAt time.Time) (*Record, error) {
+	record, exists := l.records[FormatSerialNumber(serialNumber)]
+	if !exists {
+		return nil, fmt.Errorf("certificate with serial number %s is not recorded", FormatSerialNumber(serialNumber))
+	}
+	if !record.Revoked() {
+		record.RevokedAt = &revokedAt
+		record.RevocationReason = reason
+	}
+	return record, nil
+}
+
+// set replaces the record of a certificate, the caller must hold the lock.
+func (l *MemoryLog) set(record *Record) {
+	l.records[record.SerialNumber] = record
+}
+
+// prune removes the records of the expired certificates, the caller must hold the lock.
+func (l *MemoryLog) prune(now time.Time) {
+	for serialNumber, record := range l.records {
+		if !record.NotAfter.After(now) {
+			delete(l.records, serialNumber)
+		}
+	}
+	l.lastPrune = now
+}
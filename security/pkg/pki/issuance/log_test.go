@@ -0,0 +1,269 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issuance
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestNewRecord(t *testing.T) {
+	certPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "spiffe://cluster.local/ns/default/sa/foo",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := NewRecord(cert, nil)
+	if err != nil {
+		t.Fatalf("NewRecord() returns an unexpected error: %v", err)
+	}
+	expected := &Record{
+		SerialNumber: cert.SerialNumber.Text(16),
+		IDs:          []string{"spiffe://cluster.local/ns/default/sa/foo"},
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+	}
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("NewRecord() returns %+v, expecting %+v", record, expected)
+	}
+
+	// The issuer is identified by the hash of its public key.
+	record, err = NewRecord(cert, cert)
+	if err != nil {
+		t.Fatalf("NewRecord() returns an unexpected error: %v", err)
+	}
+	keyHash := sha256.Sum256(x509.MarshalPKCS1PublicKey(cert.PublicKey.(*rsa.PublicKey)))
+	expected.IssuerKeyHash = hex.EncodeToString(keyHash[:])
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("NewRecord() returns %+v, expecting %+v", record, expected)
+	}
+}
+
+func TestParseSerialNumber(t *testing.T) {
+	testCases := map[string]struct {
+		serialNumber string
+		expected     int64
+		expectedErr  string
+	}{
+		"Hex": {
+			serialNumber: "1f2e",
+			expected:     0x1f2e,
+		},
+		"Openssl format": {
+			serialNumber: "1F:2E",
+			expected:     0x1f2e,
+		},
+		"Invalid": {
+			serialNumber: "xyz",
+			expectedErr:  `invalid serial number "xyz"`,
+		},
+		"Negative": {
+			serialNumber: "-1",
+			expectedErr:  `invalid serial number "-1"`,
+		},
+	}
+
+	for id, tc := range testCases {
+		serialNumber, err := ParseSerialNumber(tc.serialNumber)
+		if len(tc.expectedErr) > 0 {
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Errorf("%s: expecting error %q, got %v", id, tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+		} else if serialNumber.Int64() != tc.expected {
+			t.Errorf("%s: serial number %v does not match expected %x", id, serialNumber, tc.expected)
+		}
+	}
+}
+
+func TestLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issuance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileLog, err := NewFileLog(path.Join(dir, "issuance.log"))
+	if err != nil {
+		t.Fatalf("NewFileLog() returns an unexpected error: %v", err)
+	}
+	defer fileLog.Close()
+
+	for name, l := range map[string]Log{"MemoryLog": NewMemoryLog(), "FileLog": fileLog} {
+		testLog(t, name, l)
+	}
+}
+
+func testLog(t *testing.T, name string, l Log) {
+	now := time.Now().Round(time.Second)
+	records := []*Record{
+		{SerialNumber: "1", IDs: []string{"foo"}, NotBefore: now, NotAfter: now.Add(time.Hour)},
+		{SerialNumber: "2", IDs: []string{"bar"}, NotBefore: now, NotAfter: now.Add(time.Hour)},
+		{SerialNumber: "3", IsCA: true, NotBefore: now, NotAfter: now.Add(2 * time.Hour)},
+	}
+	for _, r := range records {
+		if err := l.Add(r); err != nil {
+			t.Fatalf("%s: Add() returns an unexpected error: %v", name, err)
+		}
+	}
+	if err := l.Add(records[0]); err == nil ||
+		err.Error() != "certificate with serial number 1 is already recorded" {
+		t.Errorf("%s: Add() of a recorded certificate returns unexpected error %v", name, err)
+	}
+
+	if r, err := l.Get(big.NewInt(2)); err != nil || !reflect.DeepEqual(r, records[1]) {
+		t.Errorf("%s: Get() returns (%+v, %v), expecting %+v", name, r, err, records[1])
+	}
+	if r, err := l.Get(big.NewInt(4)); err != nil || r != nil {
+		t.Errorf("%s: Get() of an unknown certificate returns (%+v, %v)", name, r, err)
+	}
+
+	if _, err := l.Revoke(big.NewInt(4), 1, now); err == nil ||
+		err.Error() != "certificate with serial number 4 is not recorded" {
+		t.Errorf("%s: Revoke() of an unknown certificate returns unexpected error %v", name, err)
+	}
+	r, err := l.Revoke(big.NewInt(1), 1, now)
+	if err != nil {
+		t.Fatalf("%s: Revoke() returns an unexpected error: %v", name, err)
+	}
+	if !r.Revoked() || !r.RevokedAt.Equal(now) || r.RevocationReason != 1 {
+		t.Errorf("%s: Revoke() returns unexpected record %+v", name, r)
+	}
+	// The certificate keeps its first revocation.
+	if r, err = l.Revoke(big.NewInt(1), 4, now.Add(time.Minute)); err != nil || !r.RevokedAt.Equal(now) ||
+		r.RevocationReason != 1 {
+		t.Errorf("%s: second Revoke() returns (%+v, %v)", name, r, err)
+	}
+	if _, err = l.Revoke(big.NewInt(3), 2, now); err != nil {
+		t.Fatalf("%s: Revoke() returns an unexpected error: %v", name, err)
+	}
+	if r, _ = l.Get(big.NewInt(2)); r.Revoked() {
+		t.Errorf("%s: certificate 2 is unexpectedly revoked", name)
+	}
+
+	testCases := map[string]struct {
+		now      time.Time
+		expected []string
+	}{
+		"Before expiration": {
+			now:      now,
+			expected: []string{"1", "3"},
+		},
+		"After expiration of certificate 1": {
+			now:      now.Add(90 * time.Minute),
+			expected: []string{"3"},
+		},
+	}
+	for id, tc := range testCases {
+		revoked, err := l.Revoked(tc.now)
+		if err != nil {
+			t.Errorf("%s: %s: Revoked() returns an unexpected error: %v", name, id, err)
+			continue
+		}
+		serialNumbers := []string{}
+		for _, r := range revoked {
+			serialNumbers = append(serialNumbers, r.SerialNumber)
+		}
+		sort.Strings(serialNumbers)
+		if strings.Join(serialNumbers, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: %s: Revoked() returns %v, expecting %v", name, id, serialNumbers, tc.expected)
+		}
+	}
+}
+
+func TestFileLogReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issuance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "issuance.log")
+
+	l, err := NewFileLog(file)
+	if err != nil {
+		t.Fatalf("NewFileLog() returns an unexpected error: %v", err)
+	}
+	now := time.Now().Round(time.Second)
+	for _, r := range []*Record{
+		{SerialNumber: "a", IDs: []string{"foo"}, NotBefore: now, NotAfter: now.Add(time.Hour)},
+		{SerialNumber: "b", IDs: []string{"bar"}, NotBefore: now, NotAfter: now.Add(time.Hour)},
+		{SerialNumber: "c", NotBefore: now.Add(-2 * time.Hour), NotAfter: now.Add(-time.Hour)},
+	} {
+		if err = l.Add(r); err != nil {
+			t.Fatalf("Add() returns an unexpected error: %v", err)
+		}
+	}
+	if _, err = l.Revoke(big.NewInt(0xb), 1, now); err != nil {
+		t.Fatalf("Revoke() returns an unexpected error: %v", err)
+	}
+	if err = l.Close(); err != nil {
+		t.Fatalf("Close() returns an unexpected error: %v", err)
+	}
+
+	if l, err = NewFileLog(file); err != nil {
+		t.Fatalf("NewFileLog() returns an unexpected error: %v", err)
+	}
+	defer l.Close()
+	if r, _ := l.Get(big.NewInt(0xa)); r == nil || r.Revoked() {
+		t.Errorf("certificate a is not replayed: %+v", r)
+	}
+	if r, _ := l.Get(big.NewInt(0xb)); r == nil || !r.Revoked() || !r.RevokedAt.Equal(now) {
+		t.Errorf("revocation of certificate b is not replayed: %+v", r)
+	}
+	if r, _ := l.Get(big.NewInt(0xc)); r != nil {
+		t.Errorf("expired certificate c is replayed: %+v", r)
+	}
+
+	// The log keeps all the records, including the revocation.
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 4 {
+		t.Errorf("the log has %d lines, expecting 4", lines)
+	}
+
+	if err = ioutil.WriteFile(file, []byte("not json\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewFileLog(file); err == nil || !strings.HasPrefix(err.Error(), "failed to parse line 1") {
+		t.Errorf("NewFileLog() of a corrupted log returns unexpected error %v", err)
+	}
+}
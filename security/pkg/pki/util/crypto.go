@@ -23,6 +23,7 @@ import (
 
 const (
 	blockTypeECParameters    = "EC PARAMETERS"
+	blockTypeECPrivateKey    = "EC PRIVATE KEY"
 	blockTypeRSAPrivateKey   = "RSA PRIVATE KEY" // PKCS#5 private key
 	blockTypePKCS8PrivateKey = "PRIVATE KEY"     // PKCS#8 plain private key
 )
@@ -59,13 +60,19 @@ func ParsePemEncodedCSR(csrBytes []byte) (*x509.CertificateRequest, error) {
 
 // ParsePemEncodedKey takes a PEM-encoded key and parsed the bytes into a `crypto.PrivateKey`.
 func ParsePemEncodedKey(keyBytes []byte) (crypto.PrivateKey, error) {
-	kb, _ := pem.Decode(keyBytes)
+	kb, rest := pem.Decode(keyBytes)
 	if kb == nil {
 		return nil, fmt.Errorf("invalid PEM-encoded key")
 	}
 
 	switch kb.Type {
 	case blockTypeECParameters:
+		// OpenSSL writes the curve parameters ahead of the key, which is then in the next block.
+		if next, _ := pem.Decode(rest); next != nil && next.Type == blockTypeECPrivateKey {
+			kb = next
+		}
+		fallthrough
+	case blockTypeECPrivateKey:
 		key, err := x509.ParseECPrivateKey(kb.Bytes)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse the ECDSA private key")
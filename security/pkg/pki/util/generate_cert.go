@@ -20,6 +20,8 @@ package util
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -65,22 +67,79 @@ type CertOptions struct {
 	// Whether this certificate is for a server.
 	IsServer bool
 
+	// The algorithm of the private key to be generated, RSA if empty.
+	KeyAlgorithm KeyAlgorithm
+
 	// The size of RSA private key to be generated.
 	RSAKeySize int
 }
 
+// KeyAlgorithm is the algorithm, and parameters, of a private key.
+type KeyAlgorithm string
+
+const (
+	// RSA keys have the size given by CertOptions.RSAKeySize.
+	RSA KeyAlgorithm = "RSA"
+	// ECDSAP256 keys are ECDSA keys on the NIST P-256 curve.
+	ECDSAP256 KeyAlgorithm = "ECDSA-P256"
+	// ECDSAP384 keys are ECDSA keys on the NIST P-384 curve.
+	ECDSAP384 KeyAlgorithm = "ECDSA-P384"
+)
+
+// KeyAlgorithms lists the supported key algorithms.
+var KeyAlgorithms = []KeyAlgorithm{RSA, ECDSAP256, ECDSAP384}
+
+// ParseKeyAlgorithm returns the key algorithm with the given name. An empty name stands for RSA.
+func ParseKeyAlgorithm(name string) (KeyAlgorithm, error) {
+	if name == "" {
+		return RSA, nil
+	}
+	for _, a := range KeyAlgorithms {
+		if string(a) == name {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported key algorithm %q, expecting one of %v", name, KeyAlgorithms)
+}
+
+// genKey generates a private key with the algorithm and size given in the options.
+func genKey(options CertOptions) (crypto.Signer, error) {
+	switch options.KeyAlgorithm {
+	case "", RSA:
+		priv, err := rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("RSA key generation failed (%v)", err)
+		}
+		return priv, nil
+	case ECDSAP256:
+		return genECDSAKey(elliptic.P256())
+	case ECDSAP384:
+		return genECDSAKey(elliptic.P384())
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", options.KeyAlgorithm)
+	}
+}
+
+func genECDSAKey(curve elliptic.Curve) (crypto.Signer, error) {
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("ECDSA key generation failed (%v)", err)
+	}
+	return priv, nil
+}
+
 // GenCertKeyFromOptions generates a X.509 certificate and a private key with the given options.
 func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte, err error) {
-	// Generate a RSA private&public key pair.
+	// Generate a private&public key pair.
 	// The public key will be bound to the certificate generated below. The
 	// private key will be used to sign this certificate in the self-signed
 	// case, otherwise the certificate is signed by the signer private key
 	// as specified in the CertOptions.
-	priv, err := rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	priv, err := genKey(options)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cert generation fails at RSA key generation (%v)", err)
+		return nil, nil, fmt.Errorf("cert generation fails at key generation (%v)", err)
 	}
-	template, err := genCertTemplateFromOptions(options)
+	template, err := genCertTemplateFromOptions(options, priv.Public())
 	if err != nil {
 		return nil, nil, fmt.Errorf("cert generation fails at cert template creation (%v)", err)
 	}
@@ -88,14 +147,12 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	if !options.IsSelfSigned {
 		signerCert, signerKey = options.SignerCert, options.SignerPriv
 	}
-	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, &priv.PublicKey, signerKey)
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, priv.Public(), signerKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cert generation fails at X509 cert creation (%v)", err)
 	}
 
-	pemCert, pemKey = encodePem(false, certBytes, priv)
-	err = nil
-	return
+	return encodePem(false, certBytes, priv)
 }
 
 // GenCertFromCSR generates a X.509 certificate with the given CSR.
@@ -136,7 +193,8 @@ func LoadSignerCredsFromFiles(signerCertFile string, signerPrivFile string) (*x5
 }
 
 // genCertTemplateFromCSR generates a certificate template with the given CSR.
-// The NotBefore value of the cert is set to current time.
+// The NotBefore value of the cert is set to current time. The signature algorithm
+// is left for the signing key to determine, as it may differ from the CSR key.
 func genCertTemplateFromCSR(csr *x509.CertificateRequest, ttl time.Duration, isCA bool) (*x509.Certificate, error) {
 	var keyUsage x509.KeyUsage
 	extKeyUsages := []x509.ExtKeyUsage{}
@@ -144,8 +202,7 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, ttl time.Duration, isC
 		// If the cert is a CA cert, the private key is allowed to sign other certificates.
 		keyUsage = x509.KeyUsageCertSign
 	} else {
-		// Otherwise the private key is allowed for digital signature and key encipherment.
-		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		keyUsage = leafKeyUsage(csr.PublicKey)
 		// For now, we do not differentiate non-CA certs to be used on client auth or server auth.
 		extKeyUsages = append(extKeyUsages, x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth)
 	}
@@ -171,19 +228,17 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, ttl time.Duration, isC
 		ExtraExtensions:       exts,
 		DNSNames:              csr.DNSNames,
 		EmailAddresses:        csr.EmailAddresses,
-		IPAddresses:           csr.IPAddresses,
-		SignatureAlgorithm:    csr.SignatureAlgorithm}, nil
+		IPAddresses:           csr.IPAddresses}, nil
 }
 
-// genCertTemplateFromoptions generates a certificate template with the given options.
-func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error) {
+// genCertTemplateFromoptions generates a certificate template with the given options, for the given public key.
+func genCertTemplateFromOptions(options CertOptions, pub crypto.PublicKey) (*x509.Certificate, error) {
 	var keyUsage x509.KeyUsage
 	if options.IsCA {
 		// If the cert is a CA cert, the private key is allowed to sign other certificates.
 		keyUsage = x509.KeyUsageCertSign
 	} else {
-		// Otherwise the private key is allowed for digital signature and key encipherment.
-		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		keyUsage = leafKeyUsage(pub)
 	}
 
 	extKeyUsages := []x509.ExtKeyUsage{}
@@ -227,6 +282,16 @@ func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error)
 		ExtraExtensions:       exts}, nil
 }
 
+// leafKeyUsage returns the key usage of a non-CA certificate for the given public key.
+// RSA keys are allowed for digital signature and key encipherment, while ECDSA keys
+// can only be used for digital signature.
+func leafKeyUsage(pub crypto.PublicKey) x509.KeyUsage {
+	if _, ok := pub.(*ecdsa.PublicKey); ok {
+		return x509.KeyUsageDigitalSignature
+	}
+	return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+}
+
 func genSerialNum() (*big.Int, error) {
 	serialNumLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNum, err := rand.Int(rand.Reader, serialNumLimit)
@@ -236,14 +301,25 @@ func genSerialNum() (*big.Int, error) {
 	return serialNum, nil
 }
 
-func encodePem(isCSR bool, csrOrCert []byte, priv *rsa.PrivateKey) ([]byte, []byte) {
+func encodePem(isCSR bool, csrOrCert []byte, priv crypto.PrivateKey) ([]byte, []byte, error) {
 	encodeMsg := "CERTIFICATE"
 	if isCSR {
 		encodeMsg = "CERTIFICATE REQUEST"
 	}
 	csrOrCertPem := pem.EncodeToMemory(&pem.Block{Type: encodeMsg, Bytes: csrOrCert})
 
-	privDer := x509.MarshalPKCS1PrivateKey(priv)
-	privPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privDer})
-	return csrOrCertPem, privPem
+	var privBlock *pem.Block
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		privBlock = &pem.Block{Type: blockTypeRSAPrivateKey, Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case *ecdsa.PrivateKey:
+		privDer, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal the ECDSA private key (%v)", err)
+		}
+		privBlock = &pem.Block{Type: blockTypeECPrivateKey, Bytes: privDer}
+	default:
+		return nil, nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+	return csrOrCertPem, pem.EncodeToMemory(privBlock), nil
 }
@@ -179,6 +179,47 @@ func TestGenCertKeyFromOptions(t *testing.T) {
 				Org:         "MyOrg",
 			},
 		},
+		{
+			name: "Client cert with ECDSA P-256 key",
+			certOptions: CertOptions{
+				Host:         "spiffe://domain/ns/bar/sa/foo",
+				NotBefore:    notBefore,
+				TTL:          ttl,
+				SignerCert:   caCert,
+				SignerPriv:   caPriv,
+				IsClient:     true,
+				IsServer:     true,
+				KeyAlgorithm: ECDSAP256,
+			},
+			verifyFields: &VerifyFields{
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+				IsCA:        false,
+				KeyUsage:    x509.KeyUsageDigitalSignature,
+				NotBefore:   notBefore,
+				TTL:         ttl,
+				Org:         "MyOrg",
+			},
+		},
+		{
+			name: "Server cert with ECDSA P-384 key",
+			certOptions: CertOptions{
+				Host:         "test_server.com",
+				NotBefore:    notBefore,
+				TTL:          ttl,
+				SignerCert:   caCert,
+				SignerPriv:   caPriv,
+				IsServer:     true,
+				KeyAlgorithm: ECDSAP384,
+			},
+			verifyFields: &VerifyFields{
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				IsCA:        false,
+				KeyUsage:    x509.KeyUsageDigitalSignature,
+				NotBefore:   notBefore,
+				TTL:         ttl,
+				Org:         "MyOrg",
+			},
+		},
 		{
 			name: "Server cert with DNS for webhook",
 			certOptions: CertOptions{
@@ -287,6 +328,152 @@ func TestGenCertFromCSR(t *testing.T) {
 	}
 }
 
+func TestGenCertKeyFromOptionsWithECDSACA(t *testing.T) {
+	caCertPem, caPrivPem, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "test_ca.com",
+		TTL:          time.Hour,
+		Org:          "MyOrg",
+		IsCA:         true,
+		IsSelfSigned: true,
+		KeyAlgorithm: ECDSAP384,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the CA cert: %v", err)
+	}
+	fields := &VerifyFields{
+		ExtKeyUsage: []x509.ExtKeyUsage{},
+		KeyUsage:    x509.KeyUsageCertSign,
+		IsCA:        true,
+		Org:         "MyOrg",
+	}
+	if err := VerifyCertificate(caPrivPem, caCertPem, caCertPem, "test_ca.com", fields); err != nil {
+		t.Errorf("CA cert verification error: %v", err)
+	}
+
+	caCert, err := ParsePemEncodedCertificate(caCertPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPriv, err := ParsePemEncodedKey(caPrivPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an ECDSA CA can sign both RSA and ECDSA keys
+	for _, c := range []struct {
+		keyAlgorithm KeyAlgorithm
+		keyUsage     x509.KeyUsage
+	}{
+		{RSA, x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment},
+		{ECDSAP256, x509.KeyUsageDigitalSignature},
+	} {
+		certPem, privPem, err := GenCertKeyFromOptions(CertOptions{
+			Host:         "test_server.com",
+			TTL:          time.Hour,
+			SignerCert:   caCert,
+			SignerPriv:   caPriv,
+			IsServer:     true,
+			KeyAlgorithm: c.keyAlgorithm,
+			RSAKeySize:   1024,
+		})
+		if err != nil {
+			t.Fatalf("[%s] cert/key generation error: %v", c.keyAlgorithm, err)
+		}
+		fields := &VerifyFields{
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			KeyUsage:    c.keyUsage,
+			Org:         "MyOrg",
+		}
+		if err := VerifyCertificate(privPem, certPem, caCertPem, "test_server.com", fields); err != nil {
+			t.Errorf("[%s] cert verification error: %v", c.keyAlgorithm, err)
+		}
+		if cert, _ := ParsePemEncodedCertificate(certPem); cert.SignatureAlgorithm != x509.ECDSAWithSHA384 {
+			t.Errorf("[%s] unexpected signature algorithm %v", c.keyAlgorithm, cert.SignatureAlgorithm)
+		}
+	}
+}
+
+func TestGenCertKeyFromOptionsWithInvalidKeyAlgorithm(t *testing.T) {
+	_, _, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "test_ca.com",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		KeyAlgorithm: "DSA",
+	})
+	if err == nil || err.Error() != `cert generation fails at key generation (unsupported key algorithm "DSA")` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseKeyAlgorithm(t *testing.T) {
+	for name, want := range map[string]KeyAlgorithm{
+		"":           RSA,
+		"RSA":        RSA,
+		"ECDSA-P256": ECDSAP256,
+		"ECDSA-P384": ECDSAP384,
+	} {
+		if got, err := ParseKeyAlgorithm(name); err != nil || got != want {
+			t.Errorf("ParseKeyAlgorithm(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseKeyAlgorithm("ECDSA-P224"); err == nil {
+		t.Errorf("ParseKeyAlgorithm() succeeded for an unsupported algorithm")
+	}
+}
+
+// The certificate is signed with an algorithm fitting the CA key, rather than the one of the CSR.
+func TestGenCertFromECDSACSR(t *testing.T) {
+	caCertPem, caPrivPem, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "test_ca.com",
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the CA cert: %v", err)
+	}
+	caCert, err := ParsePemEncodedCertificate(caCertPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPriv, err := ParsePemEncodedKey(caPrivPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csrPem, _, err := GenCSR(CertOptions{Host: "spiffe://domain/ns/bar/sa/foo", KeyAlgorithm: ECDSAP256})
+	if err != nil {
+		t.Fatalf("failed to generate the CSR: %v", err)
+	}
+	csr, err := ParsePemEncodedCSR(csrPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	derBytes, err := GenCertFromCSR(csr, caCert, csr.PublicKey, caPriv, time.Hour, false)
+	if err != nil {
+		t.Fatalf("failed to GenCertFromCSR, error %v", err)
+	}
+	out, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		t.Fatalf("failed to parse generated certificate %v", err)
+	}
+	if out.SignatureAlgorithm != x509.SHA256WithRSA {
+		t.Errorf("unexpected signature algorithm %v", out.SignatureAlgorithm)
+	}
+	if out.PublicKeyAlgorithm != x509.ECDSA {
+		t.Errorf("unexpected public key algorithm %v", out.PublicKeyAlgorithm)
+	}
+	if out.KeyUsage != x509.KeyUsageDigitalSignature {
+		t.Errorf("unexpected key usage %v", out.KeyUsage)
+	}
+	if err := out.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("verification of the signed certificate failed %v", err)
+	}
+}
+
 func TestLoadSignerCredsFromFiles(t *testing.T) {
 	testCases := map[string]struct {
 		certFile    string
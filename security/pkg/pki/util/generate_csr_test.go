@@ -15,6 +15,8 @@
 package util
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/x509"
 	"encoding/pem"
 	"strings"
@@ -54,6 +56,45 @@ func TestGenCSR(t *testing.T) {
 	}
 }
 
+func TestGenCSRWithECDSAKey(t *testing.T) {
+	for _, c := range []struct {
+		keyAlgorithm KeyAlgorithm
+		curve        elliptic.Curve
+	}{
+		{ECDSAP256, elliptic.P256()},
+		{ECDSAP384, elliptic.P384()},
+	} {
+		csrPem, privPem, err := GenCSR(CertOptions{
+			Host:         "test_ca.com",
+			Org:          "MyOrg",
+			KeyAlgorithm: c.keyAlgorithm,
+		})
+		if err != nil {
+			t.Fatalf("[%s] failed to gen CSR: %v", c.keyAlgorithm, err)
+		}
+
+		csr, err := ParsePemEncodedCSR(csrPem)
+		if err != nil {
+			t.Fatalf("[%s] failed to parse csr: %v", c.keyAlgorithm, err)
+		}
+		if err = csr.CheckSignature(); err != nil {
+			t.Errorf("[%s] csr signature is invalid: %v", c.keyAlgorithm, err)
+		}
+		pub, ok := csr.PublicKey.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != c.curve {
+			t.Errorf("[%s] unexpected csr public key %T", c.keyAlgorithm, csr.PublicKey)
+		}
+
+		priv, err := ParsePemEncodedKey(privPem)
+		if err != nil {
+			t.Fatalf("[%s] failed to parse private key: %v", c.keyAlgorithm, err)
+		}
+		if k, ok := priv.(*ecdsa.PrivateKey); !ok || k.X.Cmp(pub.X) != 0 || k.Y.Cmp(pub.Y) != 0 {
+			t.Errorf("[%s] the private key does not match the csr", c.keyAlgorithm)
+		}
+	}
+}
+
 func TestGenCSRWithInvalidOption(t *testing.T) {
 	// Options with invalid Key size.
 	csrOptions := CertOptions{
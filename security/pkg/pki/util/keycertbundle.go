@@ -20,7 +20,9 @@ package util
 
 import (
 	"crypto"
-	"crypto/tls"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
@@ -171,14 +173,34 @@ func (b *KeyCertBundleImpl) CertOptions() (*CertOptions, error) {
 	if len(ids) != 1 {
 		return nil, fmt.Errorf("expect single id from the cert, found %v", ids)
 	}
+	// The rotated key is generated with the same algorithm as the current one.
+	keyAlgorithm, rsaKeySize := keyAlgorithmOf(b.cert.PublicKey)
 	return &CertOptions{
-		Host: ids[0],
-		Org:  b.cert.Issuer.Organization[0],
-		IsCA: b.cert.IsCA,
-		TTL:  b.cert.NotAfter.Sub(b.cert.NotBefore),
+		Host:         ids[0],
+		Org:          b.cert.Issuer.Organization[0],
+		IsCA:         b.cert.IsCA,
+		TTL:          b.cert.NotAfter.Sub(b.cert.NotBefore),
+		KeyAlgorithm: keyAlgorithm,
+		RSAKeySize:   rsaKeySize,
 	}, nil
 }
 
+// keyAlgorithmOf returns the algorithm of a public key, and its size for RSA keys.
+func keyAlgorithmOf(pub crypto.PublicKey) (KeyAlgorithm, int) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return ECDSAP256, 0
+		case elliptic.P384():
+			return ECDSAP384, 0
+		}
+	case *rsa.PublicKey:
+		return RSA, k.N.BitLen()
+	}
+	return "", 0
+}
+
 // verify that the cert chain, root cert and key/cert match.
 func verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte) error {
 	// Verify the cert can be verified from the root cert through the cert chain.
@@ -204,18 +226,33 @@ func verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte) error
 	}
 
 	// Verify that the key can be correctly parsed.
-	if _, err = ParsePemEncodedKey(privKeyBytes); err != nil {
+	privKey, err := ParsePemEncodedKey(privKeyBytes)
+	if err != nil {
 		return fmt.Errorf("failed to parse private key PEM: %v", err)
 	}
 
 	// Verify the cert and key match.
-	if _, err := tls.X509KeyPair(certBytes, privKeyBytes); err != nil {
+	if !keyMatchesCert(privKey, cert) {
 		return fmt.Errorf("the cert does not match the key")
 	}
 
 	return nil
 }
 
+// keyMatchesCert returns whether the private key is the one of the certificate public key.
+func keyMatchesCert(key crypto.PrivateKey, cert *x509.Certificate) bool {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		return ok && pub.E == k.E && pub.N.Cmp(k.N) == 0
+	case *ecdsa.PrivateKey:
+		pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		return ok && pub.Curve == k.Curve && pub.X.Cmp(k.X) == 0 && pub.Y.Cmp(k.Y) == 0
+	default:
+		return false
+	}
+}
+
 func copyBytes(src []byte) []byte {
 	bs := make([]byte, len(src))
 	copy(bs, src)
@@ -15,8 +15,12 @@
 package util
 
 import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"io/ioutil"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -207,3 +211,88 @@ func TestNewVerifiedKeyCertBundleFromFile(t *testing.T) {
 		}
 	}
 }
+
+func TestNewVerifiedKeyCertBundleWithECDSAKeys(t *testing.T) {
+	rootCertPem, rootKeyPem, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "test_ca.com",
+		TTL:          time.Hour,
+		Org:          "MyOrg",
+		IsCA:         true,
+		IsSelfSigned: true,
+		KeyAlgorithm: ECDSAP384,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the root cert: %v", err)
+	}
+	rootCert, err := ParsePemEncodedCertificate(rootCertPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootKey, err := ParsePemEncodedKey(rootKeyPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPem, keyPem, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "spiffe://cluster.local/ns/default/sa/foo",
+		TTL:          time.Hour,
+		SignerCert:   rootCert,
+		SignerPriv:   rootKey,
+		IsClient:     true,
+		IsServer:     true,
+		KeyAlgorithm: ECDSAP256,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the cert: %v", err)
+	}
+
+	bundle, err := NewVerifiedKeyCertBundleFromPem(certPem, keyPem, nil, rootCertPem)
+	if err != nil {
+		t.Fatalf("failed to create the bundle: %v", err)
+	}
+	if _, key, _, _ := bundle.GetAll(); key == nil {
+		t.Errorf("the bundle private key should not be nil")
+	} else if _, ok := (*key).(*ecdsa.PrivateKey); !ok {
+		t.Errorf("unexpected bundle private key %T", *key)
+	}
+
+	// the rotated key has the same algorithm
+	opts, err := bundle.CertOptions()
+	if err != nil {
+		t.Fatalf("failed to get the cert options: %v", err)
+	}
+	if opts.KeyAlgorithm != ECDSAP256 {
+		t.Errorf("unexpected key algorithm %v, expecting %v", opts.KeyAlgorithm, ECDSAP256)
+	}
+
+	for id, key := range map[string][]byte{"ECDSA": rootKeyPem, "RSA": readFile(t, anotherKeyFile)} {
+		if _, err := NewVerifiedKeyCertBundleFromPem(certPem, key, nil, rootCertPem); err == nil ||
+			err.Error() != "the cert does not match the key" {
+			t.Errorf("%s: unexpected error for a mismatched key: %v", id, err)
+		}
+	}
+}
+
+func TestKeyCertBundleCertOptionsWithRSAKey(t *testing.T) {
+	bundle, err := NewVerifiedKeyCertBundleFromFile(int2CertFile, int2KeyFile, int2CertChainFile, rootCertFile)
+	if err != nil {
+		t.Fatalf("failed to create the bundle: %v", err)
+	}
+	cert, _, _, _ := bundle.GetAll()
+	want := cert.PublicKey.(*rsa.PublicKey).N.BitLen()
+
+	// The cert has no SPIFFE id, so the options are computed directly.
+	if alg, size := keyAlgorithmOf(cert.PublicKey); alg != RSA || size != want {
+		t.Errorf("keyAlgorithmOf() = %v, %d, want %v, %d", alg, size, RSA, want)
+	}
+}
+
+func readFile(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
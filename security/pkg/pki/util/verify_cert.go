@@ -15,7 +15,6 @@
 package util
 
 import (
-	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
 	"reflect"
@@ -79,7 +78,7 @@ func VerifyCertificate(privPem []byte, certChainPem []byte, rootCertPem []byte,
 		return err
 	}
 
-	if !reflect.DeepEqual(priv.(*rsa.PrivateKey).PublicKey, *cert.PublicKey.(*rsa.PublicKey)) {
+	if !keyMatchesCert(priv, cert) {
 		return fmt.Errorf("the generated private key and cert doesn't match")
 	}
 
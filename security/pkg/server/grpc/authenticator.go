@@ -16,6 +16,7 @@ package grpc
 
 import (
 	"fmt"
+	"math/big"
 	"strings"
 
 	oidc "github.com/coreos/go-oidc"
@@ -44,6 +45,8 @@ const (
 type caller struct {
 	authSource authSource
 	identities []string
+	// serialNumber of the client certificate, if the caller is authenticated with one.
+	serialNumber *big.Int
 }
 
 type authenticator interface {
@@ -79,8 +82,9 @@ func (cca *clientCertAuthenticator) authenticate(ctx context.Context) (*caller,
 	}
 
 	return &caller{
-		authSource: authSourceClientCertificate,
-		identities: ids,
+		authSource:   authSourceClientCertificate,
+		identities:   ids,
+		serialNumber: chains[0][0].SerialNumber,
 	}, nil
 }
 
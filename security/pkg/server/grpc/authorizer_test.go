@@ -40,7 +40,7 @@ func TestSameIDAuthroizer(t *testing.T) {
 
 	authz := &sameIDAuthorizer{}
 	for id, tc := range testCases {
-		err := authz.authorize(&caller{authSourceClientCertificate, tc.callerIDs}, tc.requestedIDs)
+		err := authz.authorize(&caller{authSource: authSourceClientCertificate, identities: tc.callerIDs}, tc.requestedIDs)
 		if len(tc.expectedErr) > 0 {
 			if err == nil {
 				t.Errorf("%s: succeeded. Error expected: %v", id, err)
@@ -70,7 +70,7 @@ func TestRegistryAuthorizerWithJWT(t *testing.T) {
 			requestor:    idRequestor,
 			requestedIDs: requestedIDs,
 			authorizor:   &registryAuthorizor{&registry.IdentityRegistry{Map: make(map[string]string)}},
-			expectedErr:  "the requestor (&{1 [id]}) is not registered",
+			expectedErr:  "the requestor (&{1 [id] <nil>}) is not registered",
 		},
 		"Authorized with one mapping": {
 			requestor:    idRequestor,
@@ -151,7 +151,7 @@ func TestRegistryAuthorizerWithClientCertificate(t *testing.T) {
 
 	for id, c := range testCases { // nolint: vet
 		authz := &registryAuthorizor{&c.registry}
-		err := authz.authorize(&caller{authSourceClientCertificate, c.callerIDs}, c.requestedIDs)
+		err := authz.authorize(&caller{authSource: authSourceClientCertificate, identities: c.callerIDs}, c.requestedIDs)
 		if c.expectedErr != "" {
 			if err == nil {
 				t.Errorf("%s: succeeded. Error expected: %v", id, err)
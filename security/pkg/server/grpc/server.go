@@ -29,6 +29,7 @@ import (
 
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/issuance"
 	"istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/registry"
 	pb "istio.io/istio/security/proto"
@@ -88,6 +89,64 @@ func (s *Server) HandleCSR(ctx context.Context, request *pb.CsrRequest) (*pb.Csr
 	return response, nil
 }
 
+// RevokeCertificate revokes a certificate issued by the CA. Only callers authenticated with a
+// client certificate can revoke certificates, and they must be authorized for all the
+// identities of the revoked certificate.
+func (s *Server) RevokeCertificate(ctx context.Context, request *pb.RevocationRequest) (*pb.RevocationResponse, error) {
+	caller := s.authenticate(ctx)
+	if caller == nil {
+		log.Warn("request authentication failure")
+		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
+	}
+	if caller.authSource != authSourceClientCertificate {
+		return nil, status.Error(codes.PermissionDenied, "revocation requires a client certificate")
+	}
+
+	issuanceLog := s.ca.GetIssuanceLog()
+	if issuanceLog == nil {
+		return nil, status.Error(codes.Unimplemented, "the CA does not record the issued certificates")
+	}
+
+	serialNumber, err := issuance.ParseSerialNumber(request.SerialNumber)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// Reason code 7 is unused in RFC 5280.
+	if request.Reason < 0 || request.Reason > 10 || request.Reason == 7 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid revocation reason %d", request.Reason)
+	}
+
+	record, err := issuanceLog.Get(serialNumber)
+	if err != nil {
+		log.Errorf("issuance log error (%v)", err)
+		return nil, status.Errorf(codes.Internal, "issuance log error (%v)", err)
+	}
+	if record == nil {
+		return nil, status.Errorf(codes.NotFound, "certificate %s is not issued by the CA", request.SerialNumber)
+	}
+
+	// A certificate without identities is not authorized for any caller.
+	if len(record.IDs) == 0 {
+		return nil, status.Errorf(codes.PermissionDenied, "certificate %s has no identity", record.SerialNumber)
+	}
+	if err = s.authorizer.authorize(caller, record.IDs); err != nil {
+		log.Warnf("revocation authorization failure (%v)", err)
+		return nil, status.Errorf(codes.PermissionDenied, "revocation authorization failure (%v)", err)
+	}
+
+	if record, err = issuanceLog.Revoke(serialNumber, int(request.Reason), time.Now()); err != nil {
+		log.Errorf("certificate revocation error (%v)", err)
+		return nil, status.Errorf(codes.Internal, "certificate revocation error (%v)", err)
+	}
+	log.Infof("Certificate %s for %v revoked by %v (reason %d)", record.SerialNumber, record.IDs,
+		caller.identities, record.RevocationReason)
+
+	return &pb.RevocationResponse{
+		IsRevoked:      true,
+		RevocationTime: record.RevokedAt.Unix(),
+	}, nil
+}
+
 // Run starts a GRPC server on the specified port.
 func (s *Server) Run() error {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
@@ -137,12 +196,7 @@ func New(ca ca.CertificateAuthority, ttl time.Duration, hostname string, port in
 }
 
 func (s *Server) createTLSServerOption() grpc.ServerOption {
-	cp := x509.NewCertPool()
-	_, _, _, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
-	cp.AppendCertsFromPEM(rootCertBytes)
-
 	config := &tls.Config{
-		ClientCAs:  cp,
 		ClientAuth: tls.VerifyClientCertIfGiven,
 		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
 			if s.certificate == nil || shouldRefresh(s.certificate) {
@@ -156,6 +210,20 @@ func (s *Server) createTLSServerOption() grpc.ServerOption {
 			return s.certificate, nil
 		},
 	}
+	// The root certificates of the CA change when its root certificate is rotated, so the client
+	// certificates are verified with the current ones.
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cp := x509.NewCertPool()
+		_, _, _, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
+		cp.AppendCertsFromPEM(rootCertBytes)
+
+		c := config.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = cp
+		// credentials.NewTLS only adds HTTP/2 to the protocols of its own copy of config.
+		c.NextProtos = []string{"h2"}
+		return c, nil
+	}
 	return grpc.Creds(credentials.NewTLS(config))
 }
 
@@ -175,7 +243,9 @@ func (s *Server) applyServerCertificate() (*tls.Certificate, error) {
 		return nil, err
 	}
 
-	cert, err := tls.X509KeyPair(certPEM, privPEM)
+	// Serve the certificate chain of the CA too, so that clients only need the root certificate.
+	_, _, certChainBytes, _ := s.ca.GetCAKeyCertBundle().GetAll()
+	cert, err := tls.X509KeyPair(append(certPEM, certChainBytes...), privPEM)
 	if err != nil {
 		return nil, err
 	}
@@ -186,12 +256,38 @@ func (s *Server) authenticate(ctx context.Context) *caller {
 	// TODO: apply different authenticators in specific order / according to configuration.
 	for _, authn := range s.authenticators {
 		if u, _ := authn.authenticate(ctx); u != nil {
+			if s.isRevoked(u) {
+				return nil
+			}
 			return u
 		}
 	}
 	return nil
 }
 
+// isRevoked indicates whether the caller is authenticated with a certificate that the issuance
+// log of the CA marks as revoked. Callers are rejected when the issuance log cannot be read, so
+// that a revoked certificate cannot be used to obtain a new one.
+func (s *Server) isRevoked(u *caller) bool {
+	if u.authSource != authSourceClientCertificate || u.serialNumber == nil {
+		return false
+	}
+	issuanceLog := s.ca.GetIssuanceLog()
+	if issuanceLog == nil {
+		return false
+	}
+	record, err := issuanceLog.Get(u.serialNumber)
+	if err != nil {
+		log.Errorf("issuance log error (%v)", err)
+		return true
+	}
+	if record != nil && record.Revoked() {
+		log.Warnf("client certificate %s for %v is revoked", record.SerialNumber, u.identities)
+		return true
+	}
+	return false
+}
+
 // shouldRefresh indicates whether the given certificate should be refreshed.
 func shouldRefresh(cert *tls.Certificate) bool {
 	// Check whether there is a valid leaf certificate.
@@ -20,15 +20,24 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"strings"
 	"testing"
 	"time"
 
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"istio.io/istio/security/pkg/pki/ca"
 	mockca "istio.io/istio/security/pkg/pki/ca/mock"
+	"istio.io/istio/security/pkg/pki/issuance"
+	"istio.io/istio/security/pkg/pki/util"
 	mockutil "istio.io/istio/security/pkg/pki/util/mock"
 	pb "istio.io/istio/security/proto"
 )
@@ -86,9 +95,10 @@ func (ca *mockCA) GetCertChain() []byte {
 }
 
 type mockAuthenticator struct {
-	authSource authSource
-	identities []string
-	errMsg     string
+	authSource   authSource
+	identities   []string
+	serialNumber *big.Int
+	errMsg       string
 }
 
 func (authn *mockAuthenticator) authenticate(ctx context.Context) (*caller, error) {
@@ -97,8 +107,9 @@ func (authn *mockAuthenticator) authenticate(ctx context.Context) (*caller, erro
 	}
 
 	return &caller{
-		authSource: authn.authSource,
-		identities: authn.identities,
+		authSource:   authn.authSource,
+		identities:   authn.identities,
+		serialNumber: authn.serialNumber,
 	}, nil
 }
 
@@ -114,6 +125,20 @@ func (authz *mockAuthorizer) authorize(requester *caller, requestedIds []string)
 }
 
 func TestSign(t *testing.T) {
+	now := time.Now()
+	issuanceLog := issuance.NewMemoryLog()
+	for _, r := range []*issuance.Record{
+		{SerialNumber: "1", IDs: []string{"spiffe://cluster.local/ns/default/sa/foo"}, NotAfter: now.Add(time.Hour)},
+		{SerialNumber: "2", IDs: []string{"spiffe://cluster.local/ns/default/sa/foo"}, NotAfter: now.Add(time.Hour)},
+	} {
+		if err := issuanceLog.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := issuanceLog.Revoke(big.NewInt(2), 1, now); err != nil {
+		t.Fatal(err)
+	}
+
 	testCases := map[string]struct {
 		authenticators []authenticator
 		authorizer     *mockAuthorizer
@@ -137,6 +162,17 @@ func TestSign(t *testing.T) {
 			authorizer: &mockAuthorizer{},
 			ca:         &mockca.FakeCA{SignErr: fmt.Errorf("cannot sign")},
 		},
+		"Revoked client certificate": {
+			authenticators: []authenticator{&mockAuthenticator{serialNumber: big.NewInt(2)}},
+			authorizer:     &mockAuthorizer{},
+			ca: &mockca.FakeCA{
+				SignedCert:    []byte("generated cert"),
+				KeyCertBundle: &mockutil.FakeKeyCertBundle{CertChainBytes: []byte("cert chain")},
+				IssuanceLog:   issuanceLog,
+			},
+			csr:  csr,
+			code: codes.Unauthenticated,
+		},
 		"Corrupted CSR": {
 			authorizer:     &mockAuthorizer{},
 			authenticators: []authenticator{&mockAuthenticator{}},
@@ -170,6 +206,19 @@ func TestSign(t *testing.T) {
 			certChain: "cert chain",
 			code:      codes.OK,
 		},
+		"Successful signing for a client certificate": {
+			authenticators: []authenticator{&mockAuthenticator{serialNumber: big.NewInt(1)}},
+			authorizer:     &mockAuthorizer{},
+			ca: &mockca.FakeCA{
+				SignedCert:    []byte("generated cert"),
+				KeyCertBundle: &mockutil.FakeKeyCertBundle{CertChainBytes: []byte("cert chain")},
+				IssuanceLog:   issuanceLog,
+			},
+			csr:       csr,
+			cert:      "generated cert",
+			certChain: "cert chain",
+			code:      codes.OK,
+		},
 	}
 
 	for id, c := range testCases {
@@ -199,6 +248,117 @@ func TestSign(t *testing.T) {
 	}
 }
 
+func TestRevokeCertificate(t *testing.T) {
+	now := time.Now()
+	issuanceLog := issuance.NewMemoryLog()
+	for _, r := range []*issuance.Record{
+		{SerialNumber: "1", IDs: []string{"spiffe://cluster.local/ns/default/sa/foo"}, NotAfter: now.Add(time.Hour)},
+		{SerialNumber: "2", IsCA: true, NotAfter: now.Add(time.Hour)},
+	} {
+		if err := issuanceLog.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := map[string]struct {
+		authenticators []authenticator
+		authorizer     *mockAuthorizer
+		ca             ca.CertificateAuthority
+		serialNumber   string
+		reason         int32
+		code           codes.Code
+	}{
+		"Unauthenticated request": {
+			authenticators: []authenticator{&mockAuthenticator{errMsg: "Not authorized"}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "1",
+			code:           codes.Unauthenticated,
+		},
+		"ID token request": {
+			authenticators: []authenticator{&mockAuthenticator{authSource: authSourceIDToken}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "1",
+			code:           codes.PermissionDenied,
+		},
+		"No issuance log": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{},
+			serialNumber:   "1",
+			code:           codes.Unimplemented,
+		},
+		"Invalid serial number": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "xyz",
+			code:           codes.InvalidArgument,
+		},
+		"Invalid reason": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "1",
+			reason:         7,
+			code:           codes.InvalidArgument,
+		},
+		"Unknown certificate": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "3",
+			code:           codes.NotFound,
+		},
+		"Certificate without identity": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "2",
+			code:           codes.PermissionDenied,
+		},
+		"Unauthorized request": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{errMsg: "not authorized"},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "1",
+			code:           codes.PermissionDenied,
+		},
+		"Successful revocation": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{IssuanceLog: issuanceLog},
+			serialNumber:   "1",
+			reason:         1,
+			code:           codes.OK,
+		},
+	}
+
+	for id, c := range testCases {
+		server := &Server{
+			ca:             c.ca,
+			hostname:       "hostname",
+			port:           8080,
+			authorizer:     c.authorizer,
+			authenticators: c.authenticators,
+		}
+		request := &pb.RevocationRequest{SerialNumber: c.serialNumber, Reason: c.reason}
+
+		response, err := server.RevokeCertificate(context.Background(), request)
+		s, _ := status.FromError(err)
+		if code := s.Code(); c.code != code {
+			t.Errorf("Case %s: expecting code to be (%d) but got (%d: %s)", id, c.code, code, s.Message())
+		} else if c.code == codes.OK {
+			record, _ := issuanceLog.Get(big.NewInt(1))
+			if !response.IsRevoked || !record.Revoked() || record.RevokedAt.Unix() != response.RevocationTime ||
+				record.RevocationReason != int(c.reason) {
+				t.Errorf("Case %s: unexpected response %v for record %+v", id, response, record)
+			}
+		}
+	}
+}
+
 func TestShouldRefresh(t *testing.T) {
 	now := time.Now()
 	testCases := map[string]struct {
@@ -304,3 +464,190 @@ func TestRun(t *testing.T) {
 		}
 	}
 }
+
+func TestIntegratedCA(t *testing.T) {
+	// The upstream CA signs with an intermediate certificate.
+	rootCert, rootKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          24 * time.Hour,
+		Org:          "upstream",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the root certificate: %v", err)
+	}
+	rootBundle, err := util.NewVerifiedKeyCertBundleFromPem(rootCert, rootKey, nil, rootCert)
+	if err != nil {
+		t.Fatalf("Failed to create the root bundle: %v", err)
+	}
+	signingCert, signingKey, _, _ := rootBundle.GetAll()
+	intermediateCert, intermediateKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:        24 * time.Hour,
+		Org:        "upstream",
+		IsCA:       true,
+		SignerCert: signingCert,
+		SignerPriv: *signingKey,
+		RSAKeySize: 2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the intermediate certificate: %v", err)
+	}
+	bundle, err := util.NewVerifiedKeyCertBundleFromPem(intermediateCert, intermediateKey, intermediateCert, rootCert)
+	if err != nil {
+		t.Fatalf("Failed to create the upstream bundle: %v", err)
+	}
+	upstreamCA, err := ca.NewIstioCA(&ca.IstioCAOptions{
+		CertTTL:       time.Hour,
+		MaxCertTTL:    24 * time.Hour,
+		KeyCertBundle: bundle,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create the upstream CA: %v", err)
+	}
+
+	upstream := New(upstreamCA, time.Hour, "localhost", 0)
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(upstream.createTLSServerOption())
+	pb.RegisterIstioCAServiceServer(grpcServer, upstream)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	defer grpcServer.Stop()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	address := net.JoinHostPort("localhost", port)
+
+	dir, err := ioutil.TempDir("", "integrated_ca_test")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	// The certificates authenticating the integrated CA to the upstream CA.
+	clientCSR, clientKey, err := util.GenCSR(util.CertOptions{
+		Host:       "spiffe://cluster.local/ns/istio-system/sa/istio-ca-service-account",
+		RSAKeySize: 2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the client CSR: %v", err)
+	}
+	clientCert, err := upstreamCA.Sign(clientCSR, time.Hour, false)
+	if err != nil {
+		t.Fatalf("Failed to sign the client certificate: %v", err)
+	}
+	untrustedCert, untrustedKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "spiffe://cluster.local/ns/istio-system/sa/istio-ca-service-account",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the untrusted client certificate: %v", err)
+	}
+	files := map[string][]byte{
+		"root-cert.pem":            rootCert,
+		"cert-chain.pem":           append(clientCert, intermediateCert...),
+		"key.pem":                  clientKey,
+		"untrusted-cert-chain.pem": untrustedCert,
+		"untrusted-key.pem":        untrustedKey,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(path.Join(dir, name), content, 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	testCases := map[string]struct {
+		certChainFile string
+		keyFile       string
+		ttl           time.Duration
+		forCA         bool
+		expectedErr   string
+	}{
+		"Workload certificate": {
+			certChainFile: "cert-chain.pem",
+			keyFile:       "key.pem",
+			ttl:           time.Hour,
+		},
+		"CA certificate": {
+			certChainFile: "cert-chain.pem",
+			keyFile:       "key.pem",
+			ttl:           time.Hour,
+			forCA:         true,
+		},
+		"TTL exceeds the max TTL": {
+			certChainFile: "cert-chain.pem",
+			keyFile:       "key.pem",
+			ttl:           3 * time.Hour,
+			expectedErr:   "requested TTL 3h0m0s is greater than the max allowed TTL 2h0m0s",
+		},
+		"Untrusted client certificate": {
+			certChainFile: "untrusted-cert-chain.pem",
+			keyFile:       "untrusted-key.pem",
+			ttl:           time.Hour,
+			expectedErr:   fmt.Sprintf("upstream CA %s failed to sign the CSR", address),
+		},
+	}
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(rootCert)
+	for id, tc := range testCases {
+		caOpts, err := ca.NewIntegratedIstioCAOptions(address, path.Join(dir, "root-cert.pem"), ca.UpstreamAuthMTLS,
+			path.Join(dir, tc.certChainFile), path.Join(dir, tc.keyFile), time.Hour, 2*time.Hour)
+		if err != nil {
+			t.Fatalf("%s: Failed to create the integrated CA options: %v", id, err)
+		}
+		integratedCA, err := ca.NewIstioCA(caOpts)
+		if err != nil {
+			t.Fatalf("%s: Failed to create the integrated CA: %v", id, err)
+		}
+
+		csrPEM, _, err := util.GenCSR(util.CertOptions{
+			Host:       "spiffe://cluster.local/ns/default/sa/default",
+			RSAKeySize: 2048,
+		})
+		if err != nil {
+			t.Fatalf("%s: Failed to generate the CSR: %v", id, err)
+		}
+
+		certChain, err := integratedCA.Sign(csrPEM, tc.ttl, tc.forCA)
+		if len(tc.expectedErr) > 0 {
+			if err == nil {
+				t.Errorf("%s: Succeeded. Error expected: %v", id, tc.expectedErr)
+			} else if !strings.HasPrefix(err.Error(), tc.expectedErr) {
+				t.Errorf("%s: incorrect error message: %s VS %s", id, err.Error(), tc.expectedErr)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: Unexpected Error: %v", id, err)
+		}
+
+		if !bytes.HasSuffix(certChain, intermediateCert) {
+			t.Errorf("%s: the certificate is not followed by the upstream certificate chain", id)
+		}
+		cert, err := util.ParsePemEncodedCertificate(certChain)
+		if err != nil {
+			t.Fatalf("%s: Failed to parse the certificate: %v", id, err)
+		}
+		intermediates := x509.NewCertPool()
+		intermediates.AppendCertsFromPEM(intermediateCert)
+		if _, err := cert.Verify(x509.VerifyOptions{
+			Intermediates: intermediates,
+			Roots:         roots,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			t.Errorf("%s: the certificate does not verify against the upstream root: %v", id, err)
+		}
+		if ttl := cert.NotAfter.Sub(cert.NotBefore); ttl != tc.ttl {
+			t.Errorf("%s: unexpected TTL %v, expected %v", id, ttl, tc.ttl)
+		}
+		if cert.IsCA != tc.forCA {
+			t.Errorf("%s: unexpected IsCA %t, expected %t", id, cert.IsCA, tc.forCA)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package revocation serves the revocation status of the certificates issued by the Istio CA
+// over HTTP, as a CRL and with a minimal OCSP responder.
+package revocation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/issuance"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	// CRLPath serves the DER-encoded CRL of the CA.
+	CRLPath = "/crl"
+	// OCSPPath serves the OCSP responder of the CA, for both GET and POST requests.
+	OCSPPath = "/ocsp"
+
+	// maxOCSPRequestSize bounds the size of the body of an OCSP request.
+	maxOCSPRequestSize = 10 * 1024
+)
+
+// oidCRLReason is the object identifier of the reason code extension of CRL entries.
+var oidCRLReason = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// Server serves the CRL and the OCSP responses of the CA, signed with the signing key of the CA.
+// The revocation status is read from the issuance log of the CA.
+type Server struct {
+	ca ca.CertificateAuthority
+	// validity is the time until the next update of the CRL and the OCSP responses.
+	validity time.Duration
+	port     int
+	mux      *http.ServeMux
+}
+
+// New creates a new revocation Server.
+func New(ca ca.CertificateAuthority, validity time.Duration, port int) *Server {
+	s := &Server{
+		ca:       ca,
+		validity: validity,
+		port:     port,
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc(CRLPath, s.handleCRL)
+	s.mux.HandleFunc(OCSPPath, s.handleOCSP)
+	s.mux.HandleFunc(OCSPPath+"/", s.handleOCSP)
+	return s
+}
+
+// Run starts an HTTP server on the specified port.
+func (s *Server) Run() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("cannot listen on port %d (error: %v)", s.port, err)
+	}
+
+	go func() {
+		log.Infof("Starting revocation server on port %d", s.port)
+
+		// http.Serve() always returns a non-nil error.
+		err := http.Serve(listener, s)
+		log.Warnf("Revocation server returns an error: %v", err)
+	}()
+
+	return nil
+}
+
+// ServeHTTP serves the CRL and the OCSP responder.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleCRL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	crl, err := s.generateCRL(time.Now())
+	if err != nil {
+		log.Errorf("failed to generate the CRL (%v)", err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pkix-crl")
+	if _, err := w.Write(crl); err != nil {
+		log.Errorf("failed to write the CRL (%v)", err)
+	}
+}
+
+// generateCRL returns the DER-encoded CRL listing the revoked certificates that have not expired.
+func (s *Server) generateCRL(now time.Time) ([]byte, error) {
+	signingCert, signingKey, _, _ := s.ca.GetCAKeyCertBundle().GetAll()
+	if signingCert == nil || signingKey == nil {
+		return nil, fmt.Errorf("the CA has no signing key")
+	}
+	issuanceLog := s.ca.GetIssuanceLog()
+	if issuanceLog == nil {
+		return nil, fmt.Errorf("the CA does not record the issued certificates")
+	}
+
+	records, err := issuanceLog.Revoked(now)
+	if err != nil {
+		return nil, err
+	}
+	revoked := make([]pkix.RevokedCertificate, 0, len(records))
+	for _, record := range records {
+		serialNumber, err := issuance.ParseSerialNumber(record.SerialNumber)
+		if err != nil {
+			return nil, err
+		}
+		entry := pkix.RevokedCertificate{
+			SerialNumber:   serialNumber,
+			RevocationTime: record.RevokedAt.UTC(),
+		}
+		if record.RevocationReason != ocsp.Unspecified {
+			reason, err := asn1.Marshal(asn1.Enumerated(record.RevocationReason))
+			if err != nil {
+				return nil, err
+			}
+			entry.Extensions = []pkix.Extension{{Id: oidCRLReason, Value: reason}}
+		}
+		revoked = append(revoked, entry)
+	}
+	sort.Slice(revoked, func(i, j int) bool {
+		return revoked[i].SerialNumber.Cmp(revoked[j].SerialNumber) < 0
+	})
+
+	return signingCert.CreateCRL(rand.Reader, *signingKey, revoked, now, now.Add(s.validity))
+}
+
+func (s *Server) handleOCSP(w http.ResponseWriter, r *http.Request) {
+	var der []byte
+	switch r.Method {
+	case http.MethodGet:
+		// The request is base64-encoded in the path, as defined in RFC 6960 appendix A.1.
+		encoded, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), OCSPPath+"/"))
+		if err == nil {
+			der, err = base64.StdEncoding.DecodeString(encoded)
+		}
+		if err != nil {
+			s.writeOCSPResponse(w, ocsp.MalformedRequestErrorResponse)
+			return
+		}
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxOCSPRequestSize))
+		if err != nil {
+			s.writeOCSPResponse(w, ocsp.MalformedRequestErrorResponse)
+			return
+		}
+		der = body
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	request, err := ocsp.ParseRequest(der)
+	if err != nil {
+		s.writeOCSPResponse(w, ocsp.MalformedRequestErrorResponse)
+		return
+	}
+	response, err := s.ocspResponse(request, time.Now())
+	if err != nil {
+		log.Errorf("failed to create the OCSP response (%v)", err)
+		s.writeOCSPResponse(w, ocsp.InternalErrorErrorResponse)
+		return
+	}
+	s.writeOCSPResponse(w, response)
+}
+
+// ocspResponse returns the OCSP response for the certificate in the request. Requests are
+// answered for the certificates issued with the signing key of the CA, and with the key of the
+// root certificate replaced by a rotation until it is retired. In integrated mode, the upstream
+// CA signs the certificates, and the requests are unauthorized as the CA doesn't hold its key.
+func (s *Server) ocspResponse(request *ocsp.Request, now time.Time) ([]byte, error) {
+	issuanceLog := s.ca.GetIssuanceLog()
+	if issuanceLog == nil {
+		return ocsp.UnauthorizedErrorResponse, nil
+	}
+	record, err := issuanceLog.Get(request.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	issuer, signer, err := s.responder(request)
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		if record != nil {
+			log.Warnf("cannot answer the OCSP request for certificate %s as the CA doesn't hold the key of its issuer",
+				record.SerialNumber)
+		}
+		return ocsp.UnauthorizedErrorResponse, nil
+	}
+	if record != nil && record.IssuerKeyHash != "" {
+		// The serial number is unique per issuer, the record may be of a certificate signed by
+		// another CA certificate.
+		keyHash, err := issuance.KeyHash(issuer)
+		if err != nil {
+			return nil, err
+		}
+		if record.IssuerKeyHash != keyHash {
+			record = nil
+		}
+	}
+
+	template := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: request.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(s.validity),
+	}
+	if record != nil {
+		if record.Revoked() {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = *record.RevokedAt
+			template.RevocationReason = record.RevocationReason
+		} else {
+			template.Status = ocsp.Good
+		}
+	}
+	return ocsp.CreateResponse(issuer, issuer, template, signer)
+}
+
+// responder returns the CA certificate that the OCSP request is for, and its key, among the
+// signing certificate of the CA and the replaced root certificate. It returns a nil certificate
+// if the CA doesn't hold the key of the issuer in the request.
+func (s *Server) responder(request *ocsp.Request) (*x509.Certificate, crypto.Signer, error) {
+	bundles := []util.KeyCertBundle{s.ca.GetCAKeyCertBundle()}
+	if previous := s.ca.GetPreviousCAKeyCertBundle(); previous != nil {
+		bundles = append(bundles, previous)
+	}
+	for _, bundle := range bundles {
+		cert, key, _, _ := bundle.GetAll()
+		if cert == nil || key == nil {
+			continue
+		}
+		if issued, err := issuedBy(request, cert); err != nil || !issued {
+			continue
+		}
+		signer, ok := (*key).(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("the key of CA certificate %s is not a crypto.Signer", cert.Subject)
+		}
+		return cert, signer, nil
+	}
+	return nil, nil, nil
+}
+
+func (s *Server) writeOCSPResponse(w http.ResponseWriter, response []byte) {
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	if _, err := w.Write(response); err != nil {
+		log.Errorf("failed to write the OCSP response (%v)", err)
+	}
+}
+
+// issuedBy returns whether the OCSP request is for a certificate issued by the given CA
+// certificate, by comparing the hashes of its name and public key.
+func issuedBy(request *ocsp.Request, issuer *x509.Certificate) (bool, error) {
+	if !request.HashAlgorithm.Available() {
+		return false, fmt.Errorf("unsupported hash algorithm %v", request.HashAlgorithm)
+	}
+
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return false, err
+	}
+
+	nameHash := request.HashAlgorithm.New()
+	nameHash.Write(issuer.RawSubject)
+	keyHash := request.HashAlgorithm.New()
+	keyHash.Write(publicKeyInfo.PublicKey.RightAlign())
+	return bytes.Equal(nameHash.Sum(nil), request.IssuerNameHash) &&
+		bytes.Equal(keyHash.Sum(nil), request.IssuerKeyHash), nil
+}
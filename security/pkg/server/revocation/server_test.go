@@ -0,0 +1,265 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"istio.io/istio/security/pkg/pki/ca"
+	mockca "istio.io/istio/security/pkg/pki/ca/mock"
+	"istio.io/istio/security/pkg/pki/issuance"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func createCA(t *testing.T) *ca.IstioCA {
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		Org:          "Root CA",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := util.NewVerifiedKeyCertBundleFromPem(certPEM, keyPEM, nil, certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	istioCA, err := ca.NewIstioCA(&ca.IstioCAOptions{
+		CertTTL:       time.Hour,
+		MaxCertTTL:    time.Hour,
+		KeyCertBundle: bundle,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return istioCA
+}
+
+func signCert(t *testing.T, istioCA *ca.IstioCA, host string) *x509.Certificate {
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: host, RSAKeySize: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := istioCA.Sign(csrPEM, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestCRL(t *testing.T) {
+	istioCA := createCA(t)
+	good := signCert(t, istioCA, "spiffe://cluster.local/ns/default/sa/good")
+	revoked := signCert(t, istioCA, "spiffe://cluster.local/ns/default/sa/revoked")
+	if _, err := istioCA.GetIssuanceLog().Revoke(revoked.SerialNumber, ocsp.KeyCompromise, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	New(istioCA, time.Hour, 0).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, CRLPath, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET %s returns status %d: %s", CRLPath, recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/pkix-crl" {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+
+	crl, err := x509.ParseCRL(recorder.Body.Bytes())
+	if err != nil {
+		t.Fatalf("failed to parse the CRL: %v", err)
+	}
+	signingCert, _, _, _ := istioCA.GetCAKeyCertBundle().GetAll()
+	if err = signingCert.CheckCRLSignature(crl); err != nil {
+		t.Errorf("the CRL is not signed by the CA: %v", err)
+	}
+	entries := crl.TBSCertList.RevokedCertificates
+	if len(entries) != 1 {
+		t.Fatalf("the CRL has %d entries, expecting 1", len(entries))
+	}
+	if entries[0].SerialNumber.Cmp(revoked.SerialNumber) != 0 || entries[0].SerialNumber.Cmp(good.SerialNumber) == 0 {
+		t.Errorf("the CRL lists serial number %v, expecting %v", entries[0].SerialNumber, revoked.SerialNumber)
+	}
+	var reason asn1.Enumerated
+	if len(entries[0].Extensions) != 1 || !entries[0].Extensions[0].Id.Equal(oidCRLReason) {
+		t.Fatalf("the CRL entry has unexpected extensions %v", entries[0].Extensions)
+	}
+	if _, err = asn1.Unmarshal(entries[0].Extensions[0].Value, &reason); err != nil || reason != ocsp.KeyCompromise {
+		t.Errorf("the CRL entry has reason %d (%v), expecting %d", reason, err, ocsp.KeyCompromise)
+	}
+
+	// The CRL is not available without a signing key, e.g. in integrated mode.
+	recorder = httptest.NewRecorder()
+	New(&mockca.FakeCA{IssuanceLog: issuance.NewMemoryLog()}, time.Hour, 0).ServeHTTP(recorder,
+		httptest.NewRequest(http.MethodGet, CRLPath, nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET %s without signing key returns status %d", CRLPath, recorder.Code)
+	}
+}
+
+func TestOCSP(t *testing.T) {
+	istioCA := createCA(t)
+	signingCert, _, _, _ := istioCA.GetCAKeyCertBundle().GetAll()
+	good := signCert(t, istioCA, "spiffe://cluster.local/ns/default/sa/good")
+	revoked := signCert(t, istioCA, "spiffe://cluster.local/ns/default/sa/revoked")
+	revokedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if _, err := istioCA.GetIssuanceLog().Revoke(revoked.SerialNumber, ocsp.KeyCompromise, revokedAt); err != nil {
+		t.Fatal(err)
+	}
+	otherCA := createCA(t)
+	otherSigningCert, _, _, _ := otherCA.GetCAKeyCertBundle().GetAll()
+
+	// After a rotation, the replaced root certificate still answers for the certificates it signed.
+	rotatedCA, err := ca.NewIstioCA(&ca.IstioCAOptions{
+		CertTTL:               time.Hour,
+		MaxCertTTL:            time.Hour,
+		KeyCertBundle:         otherCA.GetCAKeyCertBundle(),
+		PreviousKeyCertBundle: istioCA.GetCAKeyCertBundle(),
+		IssuanceLog:           istioCA.GetIssuanceLog(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := signCert(t, rotatedCA, "spiffe://cluster.local/ns/default/sa/rotated")
+
+	testCases := map[string]struct {
+		ca             ca.CertificateAuthority
+		cert           *x509.Certificate
+		issuer         *x509.Certificate
+		get            bool
+		expectedStatus int
+		expectedErr    string
+	}{
+		"Good": {
+			ca:             istioCA,
+			cert:           good,
+			issuer:         signingCert,
+			expectedStatus: ocsp.Good,
+		},
+		"Good with GET": {
+			ca:             istioCA,
+			cert:           good,
+			issuer:         signingCert,
+			get:            true,
+			expectedStatus: ocsp.Good,
+		},
+		"Revoked": {
+			ca:             istioCA,
+			cert:           revoked,
+			issuer:         signingCert,
+			expectedStatus: ocsp.Revoked,
+		},
+		"Unknown": {
+			ca:             istioCA,
+			cert:           &x509.Certificate{SerialNumber: big.NewInt(42)},
+			issuer:         signingCert,
+			expectedStatus: ocsp.Unknown,
+		},
+		"Other issuer": {
+			ca:          istioCA,
+			cert:        &x509.Certificate{SerialNumber: big.NewInt(42)},
+			issuer:      otherSigningCert,
+			expectedErr: "ocsp: error from server: unauthorized",
+		},
+		"Replaced root": {
+			ca:             rotatedCA,
+			cert:           good,
+			issuer:         signingCert,
+			expectedStatus: ocsp.Good,
+		},
+		"Revoked by replaced root": {
+			ca:             rotatedCA,
+			cert:           revoked,
+			issuer:         signingCert,
+			expectedStatus: ocsp.Revoked,
+		},
+		"New root": {
+			ca:             rotatedCA,
+			cert:           rotated,
+			issuer:         otherSigningCert,
+			expectedStatus: ocsp.Good,
+		},
+		"Serial number of another issuer": {
+			ca:             rotatedCA,
+			cert:           good,
+			issuer:         otherSigningCert,
+			expectedStatus: ocsp.Unknown,
+		},
+		"Integrated mode": {
+			// The upstream CA signs the certificates, the CA has no signing key.
+			ca:          &mockca.FakeCA{IssuanceLog: istioCA.GetIssuanceLog()},
+			cert:        good,
+			issuer:      signingCert,
+			expectedErr: "ocsp: error from server: unauthorized",
+		},
+	}
+
+	for id, tc := range testCases {
+		request, err := ocsp.CreateRequest(tc.cert, tc.issuer, nil)
+		if err != nil {
+			t.Fatalf("%s: failed to create the OCSP request: %v", id, err)
+		}
+		var httpRequest *http.Request
+		if tc.get {
+			httpRequest = httptest.NewRequest(http.MethodGet,
+				OCSPPath+"/"+base64.StdEncoding.EncodeToString(request), nil)
+		} else {
+			httpRequest = httptest.NewRequest(http.MethodPost, OCSPPath, bytes.NewReader(request))
+		}
+		recorder := httptest.NewRecorder()
+		New(tc.ca, time.Hour, 0).ServeHTTP(recorder, httpRequest)
+		body, _ := ioutil.ReadAll(recorder.Body)
+
+		response, err := ocsp.ParseResponse(body, tc.issuer)
+		if len(tc.expectedErr) > 0 {
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Errorf("%s: expecting error %q, got %v", id, tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: failed to parse the OCSP response: %v", id, err)
+			continue
+		}
+		if response.Status != tc.expectedStatus {
+			t.Errorf("%s: OCSP status is %d, expecting %d", id, response.Status, tc.expectedStatus)
+		}
+		if response.SerialNumber.Cmp(tc.cert.SerialNumber) != 0 {
+			t.Errorf("%s: OCSP response is for serial number %v, expecting %v", id, response.SerialNumber,
+				tc.cert.SerialNumber)
+		}
+		if tc.expectedStatus == ocsp.Revoked &&
+			(!response.RevokedAt.Equal(revokedAt) || response.RevocationReason != ocsp.KeyCompromise) {
+			t.Errorf("%s: unexpected revocation at %v with reason %d", id, response.RevokedAt,
+				response.RevocationReason)
+		}
+	}
+}
@@ -51,7 +51,7 @@ func NewSecretServer(cfg *Config) (SecretServer, error) {
 	case SecretFile:
 		return &SecretFileServer{cfg.SecretDirectory}, nil
 	case SecretDiscoveryServiceAPI:
-		return &SDSServer{}, nil
+		return NewSDSServer(), nil
 	default:
 		return nil, fmt.Errorf("mode: %d is not supported", cfg.Mode)
 	}
@@ -16,9 +16,12 @@ package workload
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	api "github.com/envoyproxy/go-control-plane/envoy/api/v2"
@@ -39,17 +42,27 @@ import (
 // SDSServer implements api.SecretDiscoveryServiceServer that listens on a
 // list of Unix Domain Sockets.
 type SDSServer struct {
-	// Stores the certificated chain in the memory protected by certificateChainGuard
+	// Counter of the responses sent on the StreamSecrets streams, first for
+	// the 64-bit alignment required by atomic operations
+	nonce uint64
+
+	// Stores the certificated chain in the memory protected by secretsGuard
 	certificateChain []byte
 
-	// Stores the private key in the memory protected by privateKeyGuard
+	// Stores the private key in the memory protected by secretsGuard
 	privateKey []byte
 
-	// Read/Write mutex for certificateChain
-	certificateChainGuard sync.RWMutex
+	// Stores the root certificates in the memory protected by secretsGuard
+	rootCert []byte
+
+	// current certificate chain, private key and root certificates version
+	// number, protected by secretsGuard
+	version int64
 
-	// Read/Write mutex for privateKey
-	privateKeyGuard sync.RWMutex
+	// Read/Write mutex for the secrets and their version, which are updated
+	// and read together so that the certificate chain always matches the
+	// private key
+	secretsGuard sync.RWMutex
 
 	// Specifies a map of Unix Domain Socket paths and the server listens on.
 	// Each UDS path identifies the identity for which the workload will
@@ -60,104 +73,386 @@ type SDSServer struct {
 	// Mutex for udsServerMap
 	udsServerMapGuard sync.Mutex
 
-	// current certificate chain and private key version number
-	version string
+	// Specifies a map of UDS paths and the StreamSecrets streams connected on
+	// them, to which the secrets are pushed when they are updated.
+	connections map[string]map[*sdsConnection]struct{}
+
+	// Mutex for connections
+	connectionsGuard sync.Mutex
+}
+
+// sdsConnection is the state of a StreamSecrets stream.
+type sdsConnection struct {
+	// pushChannel is signaled when the secrets are updated.
+	pushChannel chan struct{}
+
+	// closeChannel is closed when the UDS path of the stream is deregistered.
+	closeChannel chan struct{}
+
+	// requested is true once the initial request is received on the stream.
+	requested bool
+
+	// resourceNames are the names of the secrets requested on the stream.
+	resourceNames []string
+
+	// nonce and version of the last response sent on the stream.
+	lastNonce   string
+	lastVersion string
+}
+
+// sdsUdsServer serves the SDSServer on a registered UDS path.
+type sdsUdsServer struct {
+	*SDSServer
+	udsPath string
 }
 
 const (
 	// SecretTypeURL defines the type URL for Envoy secret proto.
 	SecretTypeURL = "type.googleapis.com/envoy.api.v2.auth.Secret"
 
-	// SecretName defines the type of the secrets to fetch from the SDS server.
+	// SecretName defines the name of the secret holding the workload key/cert.
 	SecretName = "SPKI"
+
+	// RootCertSecretName defines the name of the secret holding the root
+	// certificates the workload uses to validate its peers.
+	RootCertSecretName = "ROOTCA"
 )
 
 // SetServiceIdentityCert sets the service identity certificate into the memory.
+// It is not pushed to the connected streams, as it doesn't match the private
+// key until that is set too: Save updates and pushes both together.
 func (s *SDSServer) SetServiceIdentityCert(content []byte) error {
-	s.certificateChainGuard.Lock()
+	s.secretsGuard.Lock()
 	s.certificateChain = content
-	s.version = fmt.Sprintf("%v", time.Now().UnixNano()/int64(time.Millisecond))
-	s.certificateChainGuard.Unlock()
+	s.updateVersion()
+	s.secretsGuard.Unlock()
 	return nil
 }
 
-// SetServiceIdentityPrivateKey sets the service identity private key into the memory.
+// SetServiceIdentityPrivateKey sets the service identity private key into the
+// memory. It is not pushed to the connected streams, as it doesn't match the
+// certificate until that is set too: Save updates and pushes both together.
 func (s *SDSServer) SetServiceIdentityPrivateKey(content []byte) error {
-	s.privateKeyGuard.Lock()
+	s.secretsGuard.Lock()
 	s.privateKey = content
-	s.version = fmt.Sprintf("%v", time.Now().UnixNano()/int64(time.Millisecond))
-	s.privateKeyGuard.Unlock()
+	s.updateVersion()
+	s.secretsGuard.Unlock()
 	return nil
 }
 
-// Save saves the specified key cert.
+// Save saves the specified key cert and root certificates, and pushes them to
+// the connected streams.
 func (s *SDSServer) Save(b util.KeyCertBundle) error {
+	cert, privateKey, certChain, rootCert := b.GetAllPem()
+	// The certificate chain starts with the certificate when it is not empty.
+	if len(certChain) == 0 {
+		certChain = cert
+	}
+
+	s.secretsGuard.Lock()
+	s.certificateChain = certChain
+	s.privateKey = privateKey
+	s.rootCert = rootCert
+	s.updateVersion()
+	s.secretsGuard.Unlock()
+
+	s.pushSecrets()
 	return nil
 }
 
+// updateVersion sets a new version for the secrets. It must be called with
+// secretsGuard held.
+// TODO(jaebong) for now we are using timestamp in miliseconds. It needs to be updated once we have a new design
+func (s *SDSServer) updateVersion() {
+	version := time.Now().UnixNano() / int64(time.Millisecond)
+	// The secrets can be updated several times in the same millisecond, e.g.
+	// the certificate then the private key.
+	if version <= s.version {
+		version = s.version + 1
+	}
+	s.version = version
+}
+
+// getAll returns the certificate chain, private key and root certificates,
+// with their version, all read at once.
+func (s *SDSServer) getAll() (certificateChain, privateKey, rootCert []byte, version string) {
+	s.secretsGuard.RLock()
+	defer s.secretsGuard.RUnlock()
+	return s.certificateChain, s.privateKey, s.rootCert, strconv.FormatInt(s.version, 10)
+}
+
 // GetTLSCertificate generates the X.509 key/cert for the workload identity
 // derived from udsPath, which is where the FetchSecrets grpc request is
 // received.
 // SecretServer implementations could have different implementation
 func (s *SDSServer) GetTLSCertificate() (*auth.TlsCertificate, error) {
-	s.certificateChainGuard.RLock()
-	s.privateKeyGuard.RLock()
+	certificateChain, privateKey, _, _ := s.getAll()
+	return tlsCertificate(certificateChain, privateKey), nil
+}
 
-	tlsSecret := &auth.TlsCertificate{
+// GetValidationContext returns the root certificates the workload uses to
+// validate its peers, or nil if they are not set.
+func (s *SDSServer) GetValidationContext() (*auth.CertificateValidationContext, error) {
+	_, _, rootCert, _ := s.getAll()
+	return validationContext(rootCert), nil
+}
+
+func tlsCertificate(certificateChain, privateKey []byte) *auth.TlsCertificate {
+	return &auth.TlsCertificate{
 		CertificateChain: &core.DataSource{
-			Specifier: &core.DataSource_InlineBytes{s.certificateChain},
+			Specifier: &core.DataSource_InlineBytes{InlineBytes: certificateChain},
 		},
 		PrivateKey: &core.DataSource{
-			Specifier: &core.DataSource_InlineBytes{s.privateKey},
+			Specifier: &core.DataSource_InlineBytes{InlineBytes: privateKey},
 		},
 	}
+}
 
-	s.certificateChainGuard.RUnlock()
-	s.privateKeyGuard.RUnlock()
-	return tlsSecret, nil
+func validationContext(rootCert []byte) *auth.CertificateValidationContext {
+	if len(rootCert) == 0 {
+		return nil
+	}
+	return &auth.CertificateValidationContext{
+		TrustedCa: &core.DataSource{
+			Specifier: &core.DataSource_InlineBytes{InlineBytes: rootCert},
+		},
+	}
+}
+
+// getSecrets returns the secrets with the given names, or all the secrets
+// if no name is given, with their version. The secrets that are not set yet
+// are omitted.
+func (s *SDSServer) getSecrets(names []string) ([]types.Any, string, error) {
+	// The secrets are read together, so that the certificate chain matches the
+	// private key and the version.
+	certificateChain, privateKey, rootCert, version := s.getAll()
+
+	if len(names) == 0 {
+		names = []string{SecretName, RootCertSecretName}
+	}
+	resources := make([]types.Any, 0, len(names))
+	for _, name := range names {
+		secret := &auth.Secret{Name: name}
+		switch name {
+		case SecretName:
+			secret.Type = &auth.Secret_TlsCertificate{
+				TlsCertificate: tlsCertificate(certificateChain, privateKey),
+			}
+		case RootCertSecretName:
+			if len(rootCert) == 0 {
+				continue
+			}
+			secret.Type = &auth.Secret_ValidationContext{
+				ValidationContext: validationContext(rootCert),
+			}
+		default:
+			return nil, "", status.Errorf(codes.NotFound, "unknown secret %q", name)
+		}
+
+		data, err := proto.Marshal(secret)
+		if err != nil {
+			errMessage := fmt.Sprintf("Generates invalid secret (%v)", err)
+			log.Errorf(errMessage)
+			return nil, "", status.Errorf(codes.Internal, errMessage)
+		}
+		resources = append(resources, types.Any{
+			TypeUrl: SecretTypeURL,
+			Value:   data,
+		})
+	}
+
+	return resources, version, nil
 }
 
 // FetchSecrets fetches the X.509 key/cert for a given workload whose identity
 // can be derived from the UDS path where this call is received.
 func (s *SDSServer) FetchSecrets(ctx context.Context, request *api.DiscoveryRequest) (*api.DiscoveryResponse, error) {
-	tlsCertificate, err := s.GetTLSCertificate()
+	resources, version, err := s.getSecrets(request.ResourceNames)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to read TLS certificate (%v)", err)
+		return nil, err
 	}
 
-	resources := make([]types.Any, 1)
-	secret := &auth.Secret{
-		Name: SecretName,
-		Type: &auth.Secret_TlsCertificate{
-			TlsCertificate: tlsCertificate,
-		},
+	response := &api.DiscoveryResponse{
+		Resources:   resources,
+		TypeUrl:     SecretTypeURL,
+		VersionInfo: version,
 	}
-	data, err := proto.Marshal(secret)
-	if err != nil {
-		errMessage := fmt.Sprintf("Generates invalid secret (%v)", err)
-		log.Errorf(errMessage)
-		return nil, status.Errorf(codes.Internal, errMessage)
+
+	return response, nil
+}
+
+// StreamSecrets streams the X.509 key/cert to a workload, pushing the updated
+// secrets until the workload closes the stream.
+func (s *SDSServer) StreamSecrets(stream sds.SecretDiscoveryService_StreamSecretsServer) error {
+	return s.streamSecrets("", stream)
+}
+
+// StreamSecrets streams the secrets to a workload connected on the UDS path,
+// until the UDS path is deregistered.
+func (s *sdsUdsServer) StreamSecrets(stream sds.SecretDiscoveryService_StreamSecretsServer) error {
+	return s.streamSecrets(s.udsPath, stream)
+}
+
+func (s *SDSServer) streamSecrets(udsPath string, stream sds.SecretDiscoveryService_StreamSecretsServer) error {
+	conn := &sdsConnection{
+		pushChannel:  make(chan struct{}, 1),
+		closeChannel: make(chan struct{}),
 	}
-	resources[0] = types.Any{
-		TypeUrl: SecretTypeURL,
-		Value:   data,
+	if !s.addConnection(udsPath, conn) {
+		return status.Errorf(codes.Unavailable, "UDS path %s is not registered", udsPath)
 	}
+	defer s.removeConnection(udsPath, conn)
 
-	// TODO(jaebong) for now we are using timestamp in miliseconds. It needs to be updated once we have a new design
-	response := &api.DiscoveryResponse{
+	// stream.Recv() is a blocking call, so run it in a goroutine.
+	requests := make(chan *api.DiscoveryRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			request, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- request:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case request := <-requests:
+			if !conn.shouldRespond(request) {
+				continue
+			}
+			conn.requested = true
+			conn.resourceNames = request.ResourceNames
+		case <-conn.pushChannel:
+			if !conn.requested {
+				continue
+			}
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-conn.closeChannel:
+			return status.Errorf(codes.Unavailable, "UDS path %s is deregistered", udsPath)
+		}
+
+		if err := s.sendSecrets(stream, conn); err != nil {
+			log.Errorf("Failed to send secrets on UDS path %s (%v)", udsPath, err)
+			return err
+		}
+	}
+}
+
+// shouldRespond returns whether the request asks for secrets: it is the
+// initial request of the stream or changes the requested secrets, and is not
+// an ACK or NACK of the last response.
+func (c *sdsConnection) shouldRespond(request *api.DiscoveryRequest) bool {
+	if request.ErrorDetail != nil {
+		log.Warnf("Workload rejected secrets version %s (%s)", c.lastVersion, request.ErrorDetail.Message)
+		return false
+	}
+	if !c.requested || request.ResponseNonce == "" {
+		return true
+	}
+	if request.ResponseNonce != c.lastNonce {
+		// The request is for a previous response, it is superseded by the last one.
+		return false
+	}
+	return request.VersionInfo != c.lastVersion || !equalNames(request.ResourceNames, c.resourceNames)
+}
+
+func (s *SDSServer) sendSecrets(stream sds.SecretDiscoveryService_StreamSecretsServer, conn *sdsConnection) error {
+	resources, version, err := s.getSecrets(conn.resourceNames)
+	if err != nil {
+		return err
+	}
+
+	nonce := strconv.FormatUint(atomic.AddUint64(&s.nonce, 1), 10)
+	if err := stream.Send(&api.DiscoveryResponse{
 		Resources:   resources,
 		TypeUrl:     SecretTypeURL,
-		VersionInfo: s.version,
+		VersionInfo: version,
+		Nonce:       nonce,
+	}); err != nil {
+		return err
 	}
+	conn.lastNonce = nonce
+	conn.lastVersion = version
+	return nil
+}
 
-	return response, nil
+// addConnection adds the stream connected on the UDS path, or returns false if
+// the UDS path is not registered.
+func (s *SDSServer) addConnection(udsPath string, conn *sdsConnection) bool {
+	s.connectionsGuard.Lock()
+	defer s.connectionsGuard.Unlock()
+
+	conns, ok := s.connections[udsPath]
+	if !ok {
+		// The streams received outside a registered UDS path are never closed.
+		if udsPath != "" {
+			return false
+		}
+		conns = map[*sdsConnection]struct{}{}
+		s.connections[udsPath] = conns
+	}
+	conns[conn] = struct{}{}
+	return true
 }
 
-// StreamSecrets is not supported.
-func (s *SDSServer) StreamSecrets(stream sds.SecretDiscoveryService_StreamSecretsServer) error {
-	errMessage := "StreamSecrets is not implemented."
-	log.Error(errMessage)
-	return status.Errorf(codes.Unimplemented, errMessage)
+func (s *SDSServer) removeConnection(udsPath string, conn *sdsConnection) {
+	s.connectionsGuard.Lock()
+	defer s.connectionsGuard.Unlock()
+
+	if conns, ok := s.connections[udsPath]; ok {
+		delete(conns, conn)
+	}
+}
+
+// pushSecrets notifies all the connected streams that the secrets are updated.
+func (s *SDSServer) pushSecrets() {
+	s.connectionsGuard.Lock()
+	defer s.connectionsGuard.Unlock()
+
+	for _, conns := range s.connections {
+		for conn := range conns {
+			// A pending notification already pushes the latest secrets.
+			select {
+			case conn.pushChannel <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// closeConnections closes the streams connected on the UDS path, and refuses
+// the new ones.
+func (s *SDSServer) closeConnections(udsPath string) {
+	s.connectionsGuard.Lock()
+	defer s.connectionsGuard.Unlock()
+
+	for conn := range s.connections[udsPath] {
+		close(conn.closeChannel)
+	}
+	delete(s.connections, udsPath)
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // NewSDSServer creates the SDSServer that registers
@@ -165,8 +460,9 @@ func (s *SDSServer) StreamSecrets(stream sds.SecretDiscoveryService_StreamSecret
 func NewSDSServer() *SDSServer {
 	s := &SDSServer{
 		udsServerMap: map[string]*grpc.Server{},
-		version:      fmt.Sprintf("%v", time.Now().UnixNano()/int64(time.Millisecond)),
+		connections:  map[string]map[*sdsConnection]struct{}{},
 	}
+	s.updateVersion()
 
 	return s
 }
@@ -188,9 +484,13 @@ func (s *SDSServer) RegisterUdsPath(udsPath string) error {
 
 	var opts []grpc.ServerOption
 	udsServer := grpc.NewServer(opts...)
-	sds.RegisterSecretDiscoveryServiceServer(udsServer, s)
+	sds.RegisterSecretDiscoveryServiceServer(udsServer, &sdsUdsServer{s, udsPath})
 	s.udsServerMap[udsPath] = udsServer
 
+	s.connectionsGuard.Lock()
+	s.connections[udsPath] = map[*sdsConnection]struct{}{}
+	s.connectionsGuard.Unlock()
+
 	// grpcServer.Serve() is a blocking call, so run it in a goroutine.
 	go func() {
 		log.Infof("Starting GRPC server on UDS path: %s", udsPath)
@@ -212,6 +512,9 @@ func (s *SDSServer) DeregisterUdsPath(udsPath string) error {
 		return fmt.Errorf("udsPath is not registred: %s", udsPath)
 	}
 
+	// The streams never end on their own, so they are closed for the grpc
+	// server to stop.
+	s.closeConnections(udsPath)
 	udsServer.GracefulStop()
 	delete(s.udsServerMap, udsPath)
 	log.Infof("Stopped the GRPC server on UDS path: %s", udsPath)
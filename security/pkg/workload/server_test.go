@@ -28,6 +28,8 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func unixDialer(target string, timeout time.Duration) (net.Conn, error) {
@@ -150,3 +152,191 @@ func TestDuplicateUdsPaths(t *testing.T) {
 		t.Errorf("failed to deregister udsPath: %s (error: %v)", udsPath, err)
 	}
 }
+
+func StreamSecrets(t *testing.T, udsPath string) (sds.SecretDiscoveryService_StreamSecretsClient, func()) {
+	var opts []grpc.DialOption
+	opts = append(opts, grpc.WithInsecure())
+	opts = append(opts, grpc.WithDialer(unixDialer))
+	conn, err := grpc.Dial(udsPath, opts...)
+	if err != nil {
+		t.Fatalf("Failed to connect with server %v", err)
+	}
+
+	client := sds.NewSecretDiscoveryServiceClient(conn)
+	stream, err := client.StreamSecrets(context.Background())
+	if err != nil {
+		conn.Close()
+		t.Fatalf("Failed to stream secrets %v", err)
+	}
+	return stream, func() { conn.Close() }
+}
+
+func RecvSecrets(t *testing.T, stream sds.SecretDiscoveryService_StreamSecretsClient) *api.DiscoveryResponse {
+	responses := make(chan *api.DiscoveryResponse, 1)
+	errs := make(chan error, 1)
+	go func() {
+		response, err := stream.Recv()
+		if err != nil {
+			errs <- err
+			return
+		}
+		responses <- response
+	}()
+
+	select {
+	case response := <-responses:
+		return response
+	case err := <-errs:
+		t.Fatalf("Failed to receive secrets %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out receiving secrets")
+	}
+	return nil
+}
+
+func TestStreamSecrets(t *testing.T) {
+	server := NewSDSServer()
+	_ = server.SetServiceIdentityCert([]byte("certificate"))
+	_ = server.SetServiceIdentityPrivateKey([]byte("private key"))
+
+	tmpdir, _ := ioutil.TempDir("", "uds")
+	udsPath := filepath.Join(tmpdir, "test_path")
+
+	if err := server.RegisterUdsPath(udsPath); err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
+	defer func() {
+		if err := server.DeregisterUdsPath(udsPath); err != nil {
+			t.Errorf("failed to deregister udsPath: %s (error: %v)", udsPath, err)
+		}
+	}()
+
+	stream, closeStream := StreamSecrets(t, udsPath)
+	defer closeStream()
+
+	request := &api.DiscoveryRequest{TypeUrl: SecretTypeURL, ResourceNames: []string{SecretName}}
+	if err := stream.Send(request); err != nil {
+		t.Fatalf("Failed to send request %v", err)
+	}
+	response := RecvSecrets(t, stream)
+	VerifySecrets(t, response, "certificate", "private key")
+	if response.Nonce == "" || response.VersionInfo == "" {
+		t.Errorf("Response has no nonce or version: %v", response)
+	}
+
+	// ACK the response, the next response is only sent on update.
+	request.VersionInfo = response.VersionInfo
+	request.ResponseNonce = response.Nonce
+	if err := stream.Send(request); err != nil {
+		t.Fatalf("Failed to send request %v", err)
+	}
+
+	// A certificate set alone is not pushed, as it doesn't match the private
+	// key: the next push is the key and certificate saved together.
+	_ = server.SetServiceIdentityCert([]byte("new certificate"))
+	bundle, err := createBundle()
+	if err != nil {
+		t.Fatalf("failed to create KeyCertBundle %v", err)
+	}
+	if err = server.Save(bundle); err != nil {
+		t.Fatalf("failed to save KeyCertBundle %v", err)
+	}
+	cert, privateKey, _, _ := bundle.GetAllPem()
+	pushed := RecvSecrets(t, stream)
+	VerifySecrets(t, pushed, string(cert), string(privateKey))
+	if pushed.VersionInfo == response.VersionInfo || pushed.Nonce == response.Nonce {
+		t.Errorf("Pushed response has the same version or nonce as the previous one: %v", pushed)
+	}
+}
+
+func TestStreamSecretsPushOnSave(t *testing.T) {
+	server := NewSDSServer()
+
+	tmpdir, _ := ioutil.TempDir("", "uds")
+	udsPath1 := filepath.Join(tmpdir, "test_path1")
+	udsPath2 := filepath.Join(tmpdir, "test_path2")
+	for _, udsPath := range []string{udsPath1, udsPath2} {
+		if err := server.RegisterUdsPath(udsPath); err != nil {
+			t.Fatalf("Unexpected Error: %v", err)
+		}
+	}
+
+	// The root certificates are not sent before they are set.
+	stream1, closeStream1 := StreamSecrets(t, udsPath1)
+	defer closeStream1()
+	if err := stream1.Send(&api.DiscoveryRequest{TypeUrl: SecretTypeURL,
+		ResourceNames: []string{RootCertSecretName}}); err != nil {
+		t.Fatalf("Failed to send request %v", err)
+	}
+	if response := RecvSecrets(t, stream1); len(response.Resources) != 0 {
+		t.Errorf("Unexpected resources before the root certificates are set: %v", response.Resources)
+	}
+	stream2, closeStream2 := StreamSecrets(t, udsPath2)
+	defer closeStream2()
+	if err := stream2.Send(&api.DiscoveryRequest{TypeUrl: SecretTypeURL}); err != nil {
+		t.Fatalf("Failed to send request %v", err)
+	}
+	RecvSecrets(t, stream2)
+
+	bundle, err := createBundle()
+	if err != nil {
+		t.Fatalf("failed to create KeyCertBundle %v", err)
+	}
+	if err = server.Save(bundle); err != nil {
+		t.Fatalf("failed to save KeyCertBundle %v", err)
+	}
+	cert, privateKey, _, rootCert := bundle.GetAllPem()
+
+	var secret auth.Secret
+	response := RecvSecrets(t, stream1)
+	if len(response.Resources) != 1 {
+		t.Fatalf("Unexpected number of resources %d, expecting 1", len(response.Resources))
+	}
+	if err = proto.Unmarshal(response.Resources[0].Value, &secret); err != nil {
+		t.Fatalf("failed parse the response %v", err)
+	}
+	if secret.GetName() != RootCertSecretName ||
+		string(secret.GetValidationContext().GetTrustedCa().GetInlineBytes()) != string(rootCert) {
+		t.Errorf("Unexpected root certificates secret %v", secret)
+	}
+
+	// All the secrets are pushed when no name is requested.
+	response = RecvSecrets(t, stream2)
+	if len(response.Resources) != 2 {
+		t.Fatalf("Unexpected number of resources %d, expecting 2", len(response.Resources))
+	}
+	VerifySecrets(t, response, string(cert), string(privateKey))
+
+	for _, udsPath := range []string{udsPath1, udsPath2} {
+		if err := server.DeregisterUdsPath(udsPath); err != nil {
+			t.Errorf("failed to deregister udsPath: %s (error: %v)", udsPath, err)
+		}
+	}
+}
+
+func TestDeregisterUdsPathWithStream(t *testing.T) {
+	server := NewSDSServer()
+	_ = server.SetServiceIdentityCert([]byte("certificate"))
+	_ = server.SetServiceIdentityPrivateKey([]byte("private key"))
+
+	tmpdir, _ := ioutil.TempDir("", "uds")
+	udsPath := filepath.Join(tmpdir, "test_path")
+
+	if err := server.RegisterUdsPath(udsPath); err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
+	stream, closeStream := StreamSecrets(t, udsPath)
+	defer closeStream()
+	if err := stream.Send(&api.DiscoveryRequest{TypeUrl: SecretTypeURL}); err != nil {
+		t.Fatalf("Failed to send request %v", err)
+	}
+	RecvSecrets(t, stream)
+
+	// The open stream doesn't block the grpc server from stopping.
+	if err := server.DeregisterUdsPath(udsPath); err != nil {
+		t.Errorf("failed to deregister udsPath: %s (error: %v)", udsPath, err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expect Unavailable error after deregistration, Actual error: %v", err)
+	}
+}
@@ -10,6 +10,8 @@
 	It has these top-level messages:
 		CsrRequest
 		CsrResponse
+		RevocationRequest
+		RevocationResponse
 */
 package istio_v1_auth
 
@@ -72,9 +74,34 @@ func (m *CsrResponse) Reset()                    { *m = CsrResponse{} }
 func (*CsrResponse) ProtoMessage()               {}
 func (*CsrResponse) Descriptor() ([]byte, []int) { return fileDescriptorCaService, []int{1} }
 
+type RevocationRequest struct {
+	// hex-encoded serial number of the certificate to revoke
+	SerialNumber string `protobuf:"bytes,1,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	// the reason code of the revocation, as defined in RFC 5280 section 5.3.1
+	Reason int32 `protobuf:"varint,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (m *RevocationRequest) Reset()                    { *m = RevocationRequest{} }
+func (*RevocationRequest) ProtoMessage()               {}
+func (*RevocationRequest) Descriptor() ([]byte, []int) { return fileDescriptorCaService, []int{2} }
+
+type RevocationResponse struct {
+	// Whether the certificate is revoked.
+	IsRevoked bool               `protobuf:"varint,1,opt,name=is_revoked,json=isRevoked,proto3" json:"is_revoked,omitempty"`
+	Status    *google_rpc.Status `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	// The time of the revocation, in seconds since epoch.
+	RevocationTime int64 `protobuf:"varint,3,opt,name=revocation_time,json=revocationTime,proto3" json:"revocation_time,omitempty"`
+}
+
+func (m *RevocationResponse) Reset()                    { *m = RevocationResponse{} }
+func (*RevocationResponse) ProtoMessage()               {}
+func (*RevocationResponse) Descriptor() ([]byte, []int) { return fileDescriptorCaService, []int{3} }
+
 func init() {
 	proto.RegisterType((*CsrRequest)(nil), "istio.v1.auth.CsrRequest")
 	proto.RegisterType((*CsrResponse)(nil), "istio.v1.auth.CsrResponse")
+	proto.RegisterType((*RevocationRequest)(nil), "istio.v1.auth.RevocationRequest")
+	proto.RegisterType((*RevocationResponse)(nil), "istio.v1.auth.RevocationResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -93,6 +120,10 @@ type IstioCAServiceClient interface {
 	// within the request object for a server to authenticate the originating
 	// node agent.
 	HandleCSR(ctx context.Context, in *CsrRequest, opts ...grpc.CallOption) (*CsrResponse, error)
+	// Revokes a certificate issued by the CA, so that it is listed in the CRL
+	// and reported as revoked by the OCSP responder of the CA. The caller must
+	// be authorized for the identities of the certificate.
+	RevokeCertificate(ctx context.Context, in *RevocationRequest, opts ...grpc.CallOption) (*RevocationResponse, error)
 }
 
 type istioCAServiceClient struct {
@@ -112,6 +143,15 @@ func (c *istioCAServiceClient) HandleCSR(ctx context.Context, in *CsrRequest, op
 	return out, nil
 }
 
+func (c *istioCAServiceClient) RevokeCertificate(ctx context.Context, in *RevocationRequest, opts ...grpc.CallOption) (*RevocationResponse, error) {
+	out := new(RevocationResponse)
+	err := grpc.Invoke(ctx, "/istio.v1.auth.IstioCAService/RevokeCertificate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for IstioCAService service
 
 type IstioCAServiceServer interface {
@@ -120,6 +160,10 @@ type IstioCAServiceServer interface {
 	// within the request object for a server to authenticate the originating
 	// node agent.
 	HandleCSR(context.Context, *CsrRequest) (*CsrResponse, error)
+	// Revokes a certificate issued by the CA, so that it is listed in the CRL
+	// and reported as revoked by the OCSP responder of the CA. The caller must
+	// be authorized for the identities of the certificate.
+	RevokeCertificate(context.Context, *RevocationRequest) (*RevocationResponse, error)
 }
 
 func RegisterIstioCAServiceServer(s *grpc.Server, srv IstioCAServiceServer) {
@@ -144,6 +188,24 @@ func _IstioCAService_HandleCSR_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _IstioCAService_RevokeCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IstioCAServiceServer).RevokeCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/istio.v1.auth.IstioCAService/RevokeCertificate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IstioCAServiceServer).RevokeCertificate(ctx, req.(*RevocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _IstioCAService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "istio.v1.auth.IstioCAService",
 	HandlerType: (*IstioCAServiceServer)(nil),
@@ -152,6 +214,10 @@ var _IstioCAService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "HandleCSR",
 			Handler:    _IstioCAService_HandleCSR_Handler,
 		},
+		{
+			MethodName: "RevokeCertificate",
+			Handler:    _IstioCAService_RevokeCertificate_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "security/proto/ca_service.proto",
@@ -258,6 +324,78 @@ func (m *CsrResponse) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *RevocationRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevocationRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.SerialNumber) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintCaService(dAtA, i, uint64(len(m.SerialNumber)))
+		i += copy(dAtA[i:], m.SerialNumber)
+	}
+	if m.Reason != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintCaService(dAtA, i, uint64(m.Reason))
+	}
+	return i, nil
+}
+
+func (m *RevocationResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevocationResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.IsRevoked {
+		dAtA[i] = 0x8
+		i++
+		if m.IsRevoked {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.Status != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintCaService(dAtA, i, uint64(m.Status.Size()))
+		n2, err := m.Status.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n2
+	}
+	if m.RevocationTime != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintCaService(dAtA, i, uint64(m.RevocationTime))
+	}
+	return i, nil
+}
+
 func encodeVarintCaService(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -312,6 +450,35 @@ func (m *CsrResponse) Size() (n int) {
 	return n
 }
 
+func (m *RevocationRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.SerialNumber)
+	if l > 0 {
+		n += 1 + l + sovCaService(uint64(l))
+	}
+	if m.Reason != 0 {
+		n += 1 + sovCaService(uint64(m.Reason))
+	}
+	return n
+}
+
+func (m *RevocationResponse) Size() (n int) {
+	var l int
+	_ = l
+	if m.IsRevoked {
+		n += 2
+	}
+	if m.Status != nil {
+		l = m.Status.Size()
+		n += 1 + l + sovCaService(uint64(l))
+	}
+	if m.RevocationTime != 0 {
+		n += 1 + sovCaService(uint64(m.RevocationTime))
+	}
+	return n
+}
+
 func sovCaService(x uint64) (n int) {
 	for {
 		n++
@@ -352,6 +519,29 @@ func (this *CsrResponse) String() string {
 	}, "")
 	return s
 }
+func (this *RevocationRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RevocationRequest{`,
+		`SerialNumber:` + fmt.Sprintf("%v", this.SerialNumber) + `,`,
+		`Reason:` + fmt.Sprintf("%v", this.Reason) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *RevocationResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RevocationResponse{`,
+		`IsRevoked:` + fmt.Sprintf("%v", this.IsRevoked) + `,`,
+		`Status:` + strings.Replace(fmt.Sprintf("%v", this.Status), "Status", "google_rpc.Status", 1) + `,`,
+		`RevocationTime:` + fmt.Sprintf("%v", this.RevocationTime) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringCaService(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -705,6 +895,226 @@ func (m *CsrResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *RevocationRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCaService
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RevocationRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RevocationRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SerialNumber", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCaService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthCaService
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SerialNumber = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
+			}
+			m.Reason = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCaService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Reason |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCaService(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCaService
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RevocationResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCaService
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RevocationResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RevocationResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsRevoked", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCaService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsRevoked = bool(v != 0)
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCaService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCaService
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Status == nil {
+				m.Status = &google_rpc.Status{}
+			}
+			if err := m.Status.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RevocationTime", wireType)
+			}
+			m.RevocationTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCaService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RevocationTime |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCaService(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCaService
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipCaService(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -813,33 +1223,41 @@ var (
 func init() { proto.RegisterFile("security/proto/ca_service.proto", fileDescriptorCaService) }
 
 var fileDescriptorCaService = []byte{
-	// 442 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0xb1, 0x6e, 0x13, 0x31,
-	0x1c, 0xc6, 0xcf, 0x40, 0x42, 0xe3, 0x84, 0x22, 0xb9, 0x45, 0x3d, 0x22, 0xe1, 0x46, 0x5d, 0x88,
-	0x18, 0x2e, 0x6a, 0x78, 0x00, 0x94, 0xde, 0x02, 0x03, 0x12, 0x72, 0x32, 0xb1, 0x58, 0x87, 0xef,
-	0xcf, 0xd5, 0xd2, 0xe5, 0x6c, 0x6c, 0x5f, 0xa4, 0x6c, 0x3c, 0x02, 0x33, 0x4f, 0xc0, 0xa3, 0x74,
-	0x60, 0xe8, 0xc8, 0x48, 0x8e, 0x85, 0xb1, 0x8f, 0x80, 0x6c, 0x57, 0x8d, 0x90, 0xba, 0xdd, 0x7d,
-	0xbf, 0x4f, 0x9f, 0xfc, 0xf9, 0x33, 0x3e, 0xb5, 0x20, 0x5a, 0x23, 0xdd, 0x76, 0xa6, 0x8d, 0x72,
-	0x6a, 0x26, 0x0a, 0x6e, 0xc1, 0x6c, 0xa4, 0x80, 0x2c, 0x08, 0xe4, 0x89, 0xb4, 0x4e, 0xaa, 0x6c,
-	0x73, 0x9e, 0x15, 0xad, 0xbb, 0x1c, 0x9f, 0x54, 0x4a, 0x55, 0x35, 0xcc, 0x8c, 0x16, 0x33, 0xeb,
-	0x0a, 0xd7, 0xda, 0xe8, 0x1b, 0x1f, 0x57, 0xaa, 0x52, 0x31, 0xc3, 0x7f, 0x45, 0xf5, 0xec, 0x27,
-	0xc2, 0x38, 0xb7, 0x86, 0xc1, 0x97, 0x16, 0xac, 0x23, 0x27, 0xf8, 0xb1, 0xb0, 0x86, 0x6b, 0x58,
-	0xa7, 0x68, 0x82, 0xa6, 0x23, 0xd6, 0x17, 0xd6, 0x7c, 0x80, 0x35, 0x99, 0xe3, 0x67, 0x8d, 0x2a,
-	0x81, 0x17, 0x15, 0x34, 0x8e, 0x0b, 0x03, 0x25, 0x34, 0x4e, 0x16, 0x75, 0xfa, 0x20, 0xd8, 0x8e,
-	0x3c, 0x5c, 0x78, 0x96, 0xdf, 0x21, 0xf2, 0x12, 0x3f, 0xdd, 0x1b, 0xb9, 0xdb, 0x6a, 0x48, 0x1f,
-	0x4e, 0xd0, 0x74, 0xc0, 0x0e, 0xf7, 0xf2, 0x6a, 0xab, 0xc1, 0x87, 0x9b, 0x78, 0x00, 0x28, 0xb9,
-	0x73, 0x35, 0x5f, 0xcb, 0xa6, 0x75, 0x60, 0xd3, 0x47, 0x13, 0x34, 0xed, 0xb1, 0xa3, 0x3b, 0xb8,
-	0x72, 0xf5, 0xfb, 0x88, 0xc8, 0x31, 0xee, 0x7d, 0x56, 0x26, 0x5f, 0xa4, 0xbd, 0x09, 0x9a, 0x1e,
-	0xb0, 0xf8, 0x73, 0xf6, 0x1d, 0xe1, 0x61, 0xa8, 0x63, 0xb5, 0x6a, 0x2c, 0x90, 0x53, 0x3c, 0x94,
-	0x96, 0x17, 0x5a, 0x1b, 0xb5, 0x81, 0x32, 0x74, 0x3a, 0x60, 0x58, 0xda, 0xc5, 0xad, 0x42, 0x5e,
-	0xe1, 0x7e, 0xbc, 0xa5, 0x50, 0x64, 0x38, 0x27, 0x59, 0xbc, 0xbf, 0xcc, 0x68, 0x91, 0x2d, 0x03,
-	0x61, 0xb7, 0x0e, 0x1f, 0x66, 0x65, 0xd5, 0x40, 0xc9, 0x05, 0x18, 0x17, 0xba, 0x8c, 0x18, 0x8e,
-	0x52, 0x0e, 0xc6, 0x91, 0x17, 0x18, 0x7b, 0xc2, 0xc5, 0x65, 0x21, 0x9b, 0x70, 0xf8, 0x11, 0x1b,
-	0x78, 0x25, 0xf7, 0xc2, 0x7c, 0x85, 0x0f, 0xdf, 0xf9, 0xad, 0xf2, 0xc5, 0x32, 0x2e, 0x48, 0x2e,
-	0xf0, 0xe0, 0x6d, 0xd1, 0x94, 0x35, 0xe4, 0x4b, 0x46, 0x9e, 0x67, 0xff, 0x2d, 0x99, 0xed, 0x67,
-	0x19, 0x8f, 0xef, 0x43, 0xb1, 0xe2, 0xc5, 0x9b, 0xab, 0x1d, 0x4d, 0xae, 0x77, 0x34, 0xf9, 0xb5,
-	0xa3, 0xc9, 0xcd, 0x8e, 0x26, 0x5f, 0x3b, 0x8a, 0x7e, 0x74, 0x34, 0xb9, 0xea, 0x28, 0xba, 0xee,
-	0x28, 0xfa, 0xdd, 0x51, 0xf4, 0xb7, 0xa3, 0xc9, 0x4d, 0x47, 0xd1, 0xb7, 0x3f, 0x34, 0xf9, 0x18,
-	0x5f, 0x0c, 0xdf, 0x9c, 0x73, 0x1f, 0xf6, 0xa9, 0x1f, 0x5e, 0xc2, 0xeb, 0x7f, 0x01, 0x00, 0x00,
-	0xff, 0xff, 0x51, 0xa6, 0x1d, 0xd1, 0x6a, 0x02, 0x00, 0x00,
+	// 561 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x93, 0x3f, 0x6f, 0x13, 0x3f,
+	0x18, 0xc7, 0xcf, 0xbf, 0xfe, 0x12, 0x1a, 0x37, 0x6d, 0x85, 0x5b, 0x68, 0x88, 0x54, 0x37, 0x84,
+	0xa1, 0x11, 0xc3, 0x45, 0x2d, 0x2f, 0x00, 0xa5, 0xb7, 0xc0, 0x00, 0xaa, 0xdc, 0x0e, 0x88, 0xc5,
+	0xba, 0x3a, 0x4f, 0x53, 0xab, 0xc9, 0xf9, 0xb0, 0x7d, 0x27, 0x65, 0x63, 0x64, 0x64, 0xe6, 0x15,
+	0x30, 0xf1, 0x3a, 0x3a, 0x30, 0x74, 0x64, 0x24, 0xc7, 0xc2, 0xd8, 0x97, 0x80, 0x6c, 0x87, 0x84,
+	0x7f, 0x42, 0x62, 0x3b, 0x7f, 0xbe, 0xcf, 0x3d, 0xf7, 0x7c, 0xbf, 0xf7, 0x18, 0xef, 0x19, 0x10,
+	0x85, 0x96, 0x76, 0xda, 0xcf, 0xb5, 0xb2, 0xaa, 0x2f, 0x52, 0x6e, 0x40, 0x97, 0x52, 0x40, 0xec,
+	0x01, 0x59, 0x97, 0xc6, 0x4a, 0x15, 0x97, 0x07, 0x71, 0x5a, 0xd8, 0x8b, 0xf6, 0xce, 0x48, 0xa9,
+	0xd1, 0x18, 0xfa, 0x3a, 0x17, 0x7d, 0x63, 0x53, 0x5b, 0x98, 0x50, 0xd7, 0xde, 0x1e, 0xa9, 0x91,
+	0x0a, 0x3d, 0xdc, 0x53, 0xa0, 0xdd, 0x8f, 0x08, 0xe3, 0xc4, 0x68, 0x06, 0xaf, 0x0a, 0x30, 0x96,
+	0xec, 0xe0, 0x5b, 0xc2, 0x68, 0x9e, 0xc3, 0xa4, 0x85, 0x3a, 0xa8, 0xd7, 0x64, 0x75, 0x61, 0xf4,
+	0x31, 0x4c, 0xc8, 0x21, 0xbe, 0x93, 0xa9, 0x21, 0xf0, 0x74, 0x04, 0x99, 0xe5, 0x42, 0xc3, 0x10,
+	0x32, 0x2b, 0xd3, 0x71, 0xeb, 0x3f, 0x5f, 0xb6, 0xe5, 0xc4, 0x81, 0xd3, 0x92, 0x85, 0x44, 0xf6,
+	0xf1, 0xe6, 0xb2, 0x90, 0xdb, 0x69, 0x0e, 0xad, 0x95, 0x0e, 0xea, 0x35, 0xd8, 0xc6, 0x12, 0x9f,
+	0x4e, 0x73, 0x70, 0xcd, 0x75, 0x18, 0x00, 0x86, 0xdc, 0xda, 0x31, 0x9f, 0xc8, 0xac, 0xb0, 0x60,
+	0x5a, 0xff, 0x77, 0x50, 0xaf, 0xc6, 0xb6, 0x16, 0xe2, 0xa9, 0x1d, 0x3f, 0x0b, 0x12, 0xd9, 0xc6,
+	0xb5, 0x73, 0xa5, 0x93, 0x41, 0xab, 0xd6, 0x41, 0xbd, 0x55, 0x16, 0x0e, 0xdd, 0x77, 0x08, 0xaf,
+	0x79, 0x3b, 0x26, 0x57, 0x99, 0x01, 0xb2, 0x87, 0xd7, 0xa4, 0xe1, 0x69, 0x9e, 0x6b, 0x55, 0xc2,
+	0xd0, 0x7b, 0x5a, 0x65, 0x58, 0x9a, 0xc1, 0x9c, 0x90, 0x87, 0xb8, 0x1e, 0x52, 0xf2, 0x46, 0xd6,
+	0x0e, 0x49, 0x1c, 0xf2, 0x8b, 0x75, 0x2e, 0xe2, 0x13, 0xaf, 0xb0, 0x79, 0x85, 0x6b, 0x66, 0xe4,
+	0x28, 0x83, 0x21, 0x17, 0xa0, 0xad, 0xf7, 0xd2, 0x64, 0x38, 0xa0, 0x04, 0xb4, 0x25, 0xbb, 0x18,
+	0x3b, 0x85, 0x8b, 0x8b, 0x54, 0x66, 0x7e, 0xf8, 0x26, 0x6b, 0x38, 0x92, 0x38, 0xd0, 0x3d, 0xc6,
+	0xb7, 0x19, 0x94, 0x4a, 0xa4, 0x56, 0xaa, 0xec, 0x7b, 0xe2, 0x0f, 0xf0, 0xba, 0x01, 0xed, 0x02,
+	0xca, 0x8a, 0xc9, 0x19, 0x68, 0x3f, 0x63, 0x83, 0x35, 0x03, 0x7c, 0xee, 0x19, 0xb9, 0x8b, 0xeb,
+	0x1a, 0x52, 0xa3, 0x32, 0x3f, 0x65, 0x8d, 0xcd, 0x4f, 0xdd, 0x37, 0x08, 0x93, 0x1f, 0x5b, 0xce,
+	0x5d, 0xef, 0x62, 0x2c, 0x0d, 0xd7, 0x50, 0xaa, 0xcb, 0x85, 0xe9, 0x86, 0x34, 0x2c, 0x80, 0x7f,
+	0xf2, 0xbc, 0x8f, 0x37, 0xf5, 0xe2, 0x03, 0xdc, 0xca, 0x49, 0xf8, 0x87, 0x2b, 0x6c, 0x63, 0x89,
+	0x4f, 0xe5, 0x04, 0x0e, 0x3f, 0x20, 0xbc, 0xf1, 0xd4, 0x6d, 0x62, 0x32, 0x38, 0x09, 0xfb, 0x49,
+	0x8e, 0x70, 0xe3, 0x49, 0x9a, 0x0d, 0xc7, 0x90, 0x9c, 0x30, 0x72, 0x2f, 0xfe, 0x69, 0x4f, 0xe3,
+	0xe5, 0xd2, 0xb5, 0xdb, 0x7f, 0x92, 0xe6, 0x56, 0x5e, 0x84, 0xcc, 0x2e, 0xc1, 0x05, 0x2c, 0xcf,
+	0xa5, 0x48, 0x2d, 0x90, 0xce, 0x2f, 0x2f, 0xfc, 0x96, 0x6a, 0xfb, 0xfe, 0x5f, 0x2a, 0x42, 0xe7,
+	0xa3, 0xc7, 0x57, 0x33, 0x1a, 0x5d, 0xcf, 0x68, 0xf4, 0x69, 0x46, 0xa3, 0x9b, 0x19, 0x8d, 0x5e,
+	0x57, 0x14, 0xbd, 0xaf, 0x68, 0x74, 0x55, 0x51, 0x74, 0x5d, 0x51, 0xf4, 0xb9, 0xa2, 0xe8, 0x6b,
+	0x45, 0xa3, 0x9b, 0x8a, 0xa2, 0xb7, 0x5f, 0x68, 0xf4, 0x32, 0xdc, 0x34, 0x5e, 0x1e, 0x70, 0xd7,
+	0xf3, 0xac, 0xee, 0x6f, 0xd0, 0xa3, 0x6f, 0x03, 0x00, 0x4c, 0x23, 0x02, 0xb7, 0xa2, 0x03, 0x00,
+	0x00,
 }